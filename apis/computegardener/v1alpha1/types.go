@@ -0,0 +1,721 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Enforcement modes of a carbon policy
+const (
+	// EnforcementModeEnforce delays pods until their constraints are met
+	EnforcementModeEnforce = "enforce"
+	// EnforcementModeAudit records the delays that would apply but admits pods
+	EnforcementModeAudit = "audit"
+)
+
+// CarbonPolicy sets carbon-aware scheduling policy for the pods of its namespace
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName={cp,cps}
+// +kubebuilder:printcolumn:name="Threshold",JSONPath=".spec.carbonIntensityThreshold",type=number,description="Carbon intensity threshold in gCO2/kWh."
+// +kubebuilder:printcolumn:name="Mode",JSONPath=".spec.enforcementMode",type=string,description="Enforcement mode."
+// +kubebuilder:printcolumn:name="Age",JSONPath=".metadata.creationTimestamp",type=date,description="Age is the time CarbonPolicy was created."
+type CarbonPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// CarbonPolicySpec defines the policy and the pods it applies to.
+	// +optional
+	Spec CarbonPolicySpec `json:"spec,omitempty"`
+}
+
+// ClusterCarbonPolicy sets carbon-aware scheduling policy for the pods of the
+// namespaces it selects
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName={ccp,ccps}
+// +kubebuilder:printcolumn:name="Threshold",JSONPath=".spec.carbonIntensityThreshold",type=number,description="Carbon intensity threshold in gCO2/kWh."
+// +kubebuilder:printcolumn:name="Mode",JSONPath=".spec.enforcementMode",type=string,description="Enforcement mode."
+// +kubebuilder:printcolumn:name="Age",JSONPath=".metadata.creationTimestamp",type=date,description="Age is the time ClusterCarbonPolicy was created."
+type ClusterCarbonPolicy struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// CarbonPolicySpec defines the policy and the pods it applies to.
+	// +optional
+	Spec CarbonPolicySpec `json:"spec,omitempty"`
+}
+
+// CarbonPolicySpec defines carbon-aware scheduling policy and the pods it applies to.
+// Settings left unset fall back to the scheduler's configuration.
+type CarbonPolicySpec struct {
+	// NamespaceSelector selects the namespaces whose pods the policy applies to. It
+	// is only used by ClusterCarbonPolicy; an empty or unset selector selects all
+	// namespaces.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// PodSelector selects the pods the policy applies to. An empty or unset selector
+	// selects all pods.
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// CarbonIntensityThreshold is the carbon intensity in gCO2/kWh pods are delayed
+	// above.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:ExclusiveMinimum=true
+	// +optional
+	CarbonIntensityThreshold *float64 `json:"carbonIntensityThreshold,omitempty"`
+
+	// MaxSchedulingDelay is the longest pods are delayed.
+	// +optional
+	MaxSchedulingDelay *metav1.Duration `json:"maxSchedulingDelay,omitempty"`
+
+	// PeakSchedules are periods pods are delayed throughout, e.g. peak grid hours.
+	// +optional
+	PeakSchedules []PeakSchedule `json:"peakSchedules,omitempty"`
+
+	// EnforcementMode is enforce, delaying pods, or audit, only recording the delays
+	// that would apply.
+	// +kubebuilder:validation:Enum=enforce;audit
+	// +optional
+	EnforcementMode string `json:"enforcementMode,omitempty"`
+
+	// EnforcedInclusion keeps the pods the policy selects under carbon-aware scheduling
+	// regardless of the skip annotation of the pod or its namespace, the off and
+	// best-effort modes, exempting workload classes and the pod annotations loosening
+	// gating, for namespaces with mandatory sustainability commitments. Like skip
+	// restrictions, it applies if any policy selecting a pod sets it. CarbonExemptions
+	// and the exemption of DaemonSet and static pods still apply.
+	// +optional
+	EnforcedInclusion bool `json:"enforcedInclusion,omitempty"`
+
+	// SkipRestriction restricts which users may opt the pods the policy selects out of
+	// carbon-aware scheduling with the skip or price skip annotation or the off mode.
+	// Unlike other settings, restrictions of all policies selecting a pod apply, so a
+	// user must be allowed by each of them. Enforced by the admission webhook.
+	// +optional
+	SkipRestriction *SkipRestriction `json:"skipRestriction,omitempty"`
+}
+
+// SkipRestriction lists the users allowed to opt pods out. An empty restriction allows
+// no one.
+type SkipRestriction struct {
+	// Users lists allowed users, e.g. system:serviceaccount:ci:deployer.
+	// +optional
+	Users []string `json:"users,omitempty"`
+
+	// Groups lists groups whose members are allowed, e.g. system:serviceaccounts:ci
+	// for the service accounts of the ci namespace.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+}
+
+// PeakSchedule is a recurring period of the week
+type PeakSchedule struct {
+	// DayOfWeek lists the days the period applies on, 0 (Sunday) to 6, e.g. "12345".
+	// +kubebuilder:validation:Pattern=`^[0-6,]+$`
+	DayOfWeek string `json:"dayOfWeek"`
+
+	// StartTime is the start of the period, in HH:MM.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	StartTime string `json:"startTime"`
+
+	// EndTime is the end of the period, in HH:MM.
+	// +kubebuilder:validation:Pattern=`^([01][0-9]|2[0-3]):[0-5][0-9]$`
+	EndTime string `json:"endTime"`
+}
+
+// CarbonPolicyList is a collection of carbon policies.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type CarbonPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard list metadata
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is the list of CarbonPolicy
+	Items []CarbonPolicy `json:"items"`
+}
+
+// ClusterCarbonPolicyList is a collection of cluster carbon policies.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type ClusterCarbonPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard list metadata
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is the list of ClusterCarbonPolicy
+	Items []ClusterCarbonPolicy `json:"items"`
+}
+
+// Carbon budget periods
+const (
+	// BudgetPeriodDay resets budgets at midnight UTC
+	BudgetPeriodDay = "day"
+	// BudgetPeriodWeek resets budgets at midnight UTC on Monday
+	BudgetPeriodWeek = "week"
+)
+
+// Actions taken on new pods once a carbon budget is exhausted
+const (
+	// BudgetActionBlock holds new pods until the budget resets
+	BudgetActionBlock = "block"
+	// BudgetActionAudit admits new pods, only recording that the budget is exhausted
+	BudgetActionAudit = "audit"
+	// BudgetActionDemote gates new pods under a stricter carbon intensity threshold
+	BudgetActionDemote = "demote"
+)
+
+// CarbonBudget limits the emissions of the pods of a namespace over a day or a week
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName={cb,cbs}
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Limit",JSONPath=".spec.limit",type=number,description="Emissions allowed per period in gCO2e."
+// +kubebuilder:printcolumn:name="Period",JSONPath=".spec.period",type=string,description="Period the budget resets on."
+// +kubebuilder:printcolumn:name="Used",JSONPath=".status.used",type=number,description="Emissions accrued in the current period in gCO2e."
+// +kubebuilder:printcolumn:name="Exhausted",JSONPath=".status.exhausted",type=boolean,description="Whether the budget is exhausted."
+// +kubebuilder:printcolumn:name="Age",JSONPath=".metadata.creationTimestamp",type=date,description="Age is the time CarbonBudget was created."
+type CarbonBudget struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// CarbonBudgetSpec defines the emissions allowed per period.
+	// +optional
+	Spec CarbonBudgetSpec `json:"spec,omitempty"`
+
+	// CarbonBudgetStatus reports the emissions accrued in the current period.
+	// +optional
+	Status CarbonBudgetStatus `json:"status,omitempty"`
+}
+
+// CarbonBudgetSpec defines the emissions allowed per period
+type CarbonBudgetSpec struct {
+	// Limit is the emissions allowed per period, in gCO2e.
+	// +kubebuilder:validation:Minimum=0
+	Limit float64 `json:"limit"`
+
+	// Period is day or week. Periods start at midnight UTC, on Monday for weekly
+	// budgets.
+	// +kubebuilder:validation:Enum=day;week
+	Period string `json:"period"`
+
+	// Action is taken on new pods once the budget is exhausted: block holds them until
+	// the budget resets, audit admits them and only records it, and demote gates them
+	// under a stricter carbon intensity threshold. Defaults to the scheduler's
+	// configured action.
+	// +kubebuilder:validation:Enum=block;audit;demote
+	// +optional
+	Action string `json:"action,omitempty"`
+
+	// DemotedThresholdFactor multiplies the carbon intensity threshold of new pods
+	// once the budget is exhausted with the demote action. Defaults to the scheduler's
+	// configured factor.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:ExclusiveMinimum=true
+	// +kubebuilder:validation:Maximum=1
+	// +optional
+	DemotedThresholdFactor *float64 `json:"demotedThresholdFactor,omitempty"`
+}
+
+// CarbonBudgetStatus reports the emissions accrued in the current period
+type CarbonBudgetStatus struct {
+	// Used is the emissions accrued in the current period, in gCO2e.
+	// +optional
+	Used float64 `json:"used,omitempty"`
+
+	// PeriodStart is the start of the current period.
+	// +optional
+	PeriodStart *metav1.Time `json:"periodStart,omitempty"`
+
+	// Exhausted is set once Used reaches Limit.
+	// +optional
+	Exhausted bool `json:"exhausted,omitempty"`
+}
+
+// CarbonBudgetList is a collection of carbon budgets.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type CarbonBudgetList struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard list metadata
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is the list of CarbonBudget
+	Items []CarbonBudget `json:"items"`
+}
+
+// ClusterCarbonBudget limits the emissions of the pods of a team or cost center, given
+// by the scheduler's accounting label, across namespaces
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName={ccb,ccbs}
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Label Value",JSONPath=".spec.labelValue",type=string,description="Accounting label value the budget applies to."
+// +kubebuilder:printcolumn:name="Limit",JSONPath=".spec.limit",type=number,description="Emissions allowed per period in gCO2e."
+// +kubebuilder:printcolumn:name="Period",JSONPath=".spec.period",type=string,description="Period the budget resets on."
+// +kubebuilder:printcolumn:name="Used",JSONPath=".status.used",type=number,description="Emissions accrued in the current period in gCO2e."
+// +kubebuilder:printcolumn:name="Exhausted",JSONPath=".status.exhausted",type=boolean,description="Whether the budget is exhausted."
+// +kubebuilder:printcolumn:name="Age",JSONPath=".metadata.creationTimestamp",type=date,description="Age is the time ClusterCarbonBudget was created."
+type ClusterCarbonBudget struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// ClusterCarbonBudgetSpec defines the emissions allowed per period and the pods
+	// they are accrued from.
+	// +optional
+	Spec ClusterCarbonBudgetSpec `json:"spec,omitempty"`
+
+	// CarbonBudgetStatus reports the emissions accrued in the current period.
+	// +optional
+	Status CarbonBudgetStatus `json:"status,omitempty"`
+}
+
+// ClusterCarbonBudgetSpec defines the emissions allowed per period and the pods they
+// are accrued from
+type ClusterCarbonBudgetSpec struct {
+	CarbonBudgetSpec `json:",inline"`
+
+	// LabelValue is the value of the accounting label of the pods the budget applies
+	// to. Pods take the label from their namespace if they don't carry it.
+	// +kubebuilder:validation:MinLength=1
+	LabelValue string `json:"labelValue"`
+}
+
+// ClusterCarbonBudgetList is a collection of cluster carbon budgets.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type ClusterCarbonBudgetList struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard list metadata
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is the list of ClusterCarbonBudget
+	Items []ClusterCarbonBudget `json:"items"`
+}
+
+// Aging curves of a workload class
+const (
+	// AgingCurveNone keeps the threshold of waiting pods unchanged
+	AgingCurveNone = "none"
+	// AgingCurveLinear relaxes the threshold linearly towards the scheduling deadline
+	AgingCurveLinear = "linear"
+	// AgingCurveQuadratic relaxes the threshold slowly at first, then faster
+	AgingCurveQuadratic = "quadratic"
+)
+
+// WorkloadClass names a set of carbon-aware scheduling settings, e.g. flexible,
+// standard or critical, that pods reference with a single label instead of carrying
+// the individual annotations
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName={wc,wcs}
+// +kubebuilder:printcolumn:name="Threshold",JSONPath=".spec.carbonIntensityThreshold",type=number,description="Carbon intensity threshold in gCO2/kWh."
+// +kubebuilder:printcolumn:name="Max Delay",JSONPath=".spec.maxSchedulingDelay",type=string,description="Maximum scheduling delay."
+// +kubebuilder:printcolumn:name="Exempt",JSONPath=".spec.exempt",type=boolean,description="Whether pods are exempt from carbon-aware scheduling."
+// +kubebuilder:printcolumn:name="Age",JSONPath=".metadata.creationTimestamp",type=date,description="Age is the time WorkloadClass was created."
+type WorkloadClass struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// WorkloadClassSpec defines the settings of the pods of the class.
+	// +optional
+	Spec WorkloadClassSpec `json:"spec,omitempty"`
+}
+
+// WorkloadClassSpec defines the carbon-aware scheduling settings of the pods of a
+// class. Settings left unset fall back to the pod's namespace, policies and the
+// scheduler's configuration.
+type WorkloadClassSpec struct {
+	// CarbonIntensityThreshold is the carbon intensity in gCO2/kWh pods are delayed
+	// above.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:ExclusiveMinimum=true
+	// +optional
+	CarbonIntensityThreshold *float64 `json:"carbonIntensityThreshold,omitempty"`
+
+	// MaxSchedulingDelay is the longest pods are delayed.
+	// +optional
+	MaxSchedulingDelay *metav1.Duration `json:"maxSchedulingDelay,omitempty"`
+
+	// AgingCurve relaxes the threshold of waiting pods towards AgingMaxFactor times
+	// the threshold at their scheduling deadline: none, linear or quadratic.
+	// +kubebuilder:validation:Enum=none;linear;quadratic
+	// +optional
+	AgingCurve string `json:"agingCurve,omitempty"`
+
+	// AgingMaxFactor is the threshold multiplier reached at the scheduling deadline.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	AgingMaxFactor *float64 `json:"agingMaxFactor,omitempty"`
+
+	// Exempt schedules pods of the class immediately, as the skip annotation does.
+	// +optional
+	Exempt bool `json:"exempt,omitempty"`
+}
+
+// WorkloadClassList is a collection of workload classes.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type WorkloadClassList struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard list metadata
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is the list of WorkloadClass
+	Items []WorkloadClass `json:"items"`
+}
+
+// CarbonExemption exempts the pods it selects from carbon-aware scheduling for a
+// limited time, e.g. during a quarter-end batch crunch
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster,shortName={cex,cexs}
+// +kubebuilder:printcolumn:name="Start",JSONPath=".spec.start",type=date,description="Time the exemption starts."
+// +kubebuilder:printcolumn:name="Expires",JSONPath=".spec.expires",type=date,description="Time the exemption lapses."
+// +kubebuilder:printcolumn:name="Reason",JSONPath=".spec.reason",type=string,description="Why the exemption was granted."
+type CarbonExemption struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// CarbonExemptionSpec defines the pods exempted and for how long.
+	// +optional
+	Spec CarbonExemptionSpec `json:"spec,omitempty"`
+}
+
+// CarbonExemptionSpec defines the pods an exemption applies to and when
+type CarbonExemptionSpec struct {
+	// NamespaceSelector selects the namespaces whose pods are exempted. An empty or
+	// unset selector selects all namespaces.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// PodSelector selects the pods exempted. An empty or unset selector selects all
+	// pods.
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// Start is when the exemption takes effect; it does immediately if unset.
+	// +optional
+	Start *metav1.Time `json:"start,omitempty"`
+
+	// Expires is when the exemption lapses.
+	Expires metav1.Time `json:"expires"`
+
+	// Reason records why the exemption was granted.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+}
+
+// CarbonExemptionList is a collection of carbon exemptions.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type CarbonExemptionList struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard list metadata
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is the list of CarbonExemption
+	Items []CarbonExemption `json:"items"`
+}
+
+// Phases of a carbon-delayed pod
+const (
+	// DelayPhaseDelayed is the phase of pods held by carbon-aware scheduling
+	DelayPhaseDelayed = "Delayed"
+	// DelayPhaseAdmitted is the phase of previously delayed pods once bound
+	DelayPhaseAdmitted = "Admitted"
+)
+
+// CarbonDelay reports why a pod is held by carbon-aware scheduling, so users can see
+// why it is pending without access to the scheduler's logs. The scheduler creates one,
+// named after the pod and owned by it, when it first delays a pod, and keeps its status
+// up to date until the pod is bound.
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName={cd,cds}
+// +kubebuilder:printcolumn:name="Phase",JSONPath=".status.phase",type=string,description="Whether the pod is delayed or admitted."
+// +kubebuilder:printcolumn:name="Reason",JSONPath=".status.reason",type=string,description="Why the pod is delayed."
+// +kubebuilder:printcolumn:name="Intensity",JSONPath=".status.carbonIntensity",type=number,description="Carbon intensity the pod was last evaluated against in gCO2/kWh."
+// +kubebuilder:printcolumn:name="Threshold",JSONPath=".status.carbonIntensityThreshold",type=number,description="Carbon intensity threshold applied to the pod in gCO2/kWh."
+// +kubebuilder:printcolumn:name="Projected Release",JSONPath=".status.projectedRelease",type=date,description="Time the pod is expected to be admitted."
+// +kubebuilder:printcolumn:name="Waiting Since",JSONPath=".status.waitingSince",type=date,description="Time the pod was first delayed."
+type CarbonDelay struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// CarbonDelayStatus reports the delay of the pod.
+	// +optional
+	Status CarbonDelayStatus `json:"status,omitempty"`
+}
+
+// CarbonDelayStatus reports the delay of a pod
+type CarbonDelayStatus struct {
+	// PodUID is the UID of the delayed pod.
+	PodUID types.UID `json:"podUID"`
+
+	// Phase is Delayed while the pod is held, and Admitted once it is bound.
+	// +kubebuilder:validation:Enum=Delayed;Admitted
+	Phase string `json:"phase"`
+
+	// Reason is the current reason the pod is held: CarbonDelayed or PriceDelayed.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message describes the current delay.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// WaitingSince is when the scheduler first delayed the pod.
+	WaitingSince metav1.Time `json:"waitingSince"`
+
+	// Zone is the grid zone the pod was evaluated in.
+	// +optional
+	Zone string `json:"zone,omitempty"`
+
+	// CarbonIntensity is the carbon intensity, in gCO2/kWh, the pod was last evaluated
+	// against.
+	// +optional
+	CarbonIntensity *float64 `json:"carbonIntensity,omitempty"`
+
+	// CarbonIntensityThreshold is the carbon intensity threshold, in gCO2/kWh, applied
+	// to the pod.
+	// +optional
+	CarbonIntensityThreshold *float64 `json:"carbonIntensityThreshold,omitempty"`
+
+	// ElectricityRate is the electricity rate the pod was last evaluated against, if
+	// price-aware scheduling is enabled.
+	// +optional
+	ElectricityRate *float64 `json:"electricityRate,omitempty"`
+
+	// ProjectedRelease is when the pod is expected to be admitted.
+	// +optional
+	ProjectedRelease *metav1.Time `json:"projectedRelease,omitempty"`
+
+	// History lists the reasons the pod was held for over time, oldest first. Only
+	// the most recent reasons are kept.
+	// +optional
+	History []CarbonDelayRecord `json:"history,omitempty"`
+
+	// AdmittedAt is when the pod was bound.
+	// +optional
+	AdmittedAt *metav1.Time `json:"admittedAt,omitempty"`
+
+	// NodeName is the node the pod was bound to.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+}
+
+// CarbonDelayRecord records a reason a pod was held for
+type CarbonDelayRecord struct {
+	// Time is when the pod was first held for the reason.
+	Time metav1.Time `json:"time"`
+
+	// Reason is CarbonDelayed or PriceDelayed.
+	Reason string `json:"reason"`
+
+	// Message describes the delay.
+	Message string `json:"message"`
+}
+
+// CarbonDelayList is a collection of carbon delays.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type CarbonDelayList struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard list metadata
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is the list of CarbonDelay
+	Items []CarbonDelay `json:"items"`
+}
+
+// CarbonSLO is an objective for the share of the CPU-hours of the pods of a namespace
+// executed under a carbon intensity, e.g. 90% of batch CPU-hours under 200 gCO2/kWh,
+// tracked over a day or a week. The scheduler can adjust the thresholds of the pods it
+// selects to keep the objective on track.
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName={cslo,cslos}
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Target",JSONPath=".spec.target",type=number,description="Percentage of CPU-hours to execute under the intensity."
+// +kubebuilder:printcolumn:name="Intensity",JSONPath=".spec.carbonIntensity",type=number,description="Carbon intensity CPU-hours count as clean under, in gCO2/kWh."
+// +kubebuilder:printcolumn:name="Attainment",JSONPath=".status.attainment",type=number,description="Percentage of CPU-hours executed under the intensity in the current period."
+// +kubebuilder:printcolumn:name="Factor",JSONPath=".status.thresholdFactor",type=number,description="Factor the thresholds of selected pods are adjusted by."
+// +kubebuilder:printcolumn:name="Age",JSONPath=".metadata.creationTimestamp",type=date,description="Age is the time CarbonSLO was created."
+type CarbonSLO struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard object's metadata.
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// CarbonSLOSpec defines the objective.
+	// +optional
+	Spec CarbonSLOSpec `json:"spec,omitempty"`
+
+	// CarbonSLOStatus reports the attainment of the objective in the current period.
+	// +optional
+	Status CarbonSLOStatus `json:"status,omitempty"`
+}
+
+// CarbonSLOSpec defines the share of CPU-hours to execute under a carbon intensity
+type CarbonSLOSpec struct {
+	// PodSelector selects the pods of the namespace the objective applies to. An empty
+	// or unset selector selects all pods.
+	// +optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// CarbonIntensity is the carbon intensity in gCO2/kWh that CPU-hours executed at or
+	// under count as clean.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:ExclusiveMinimum=true
+	CarbonIntensity float64 `json:"carbonIntensity"`
+
+	// Target is the percentage of CPU-hours to execute under CarbonIntensity.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:ExclusiveMinimum=true
+	// +kubebuilder:validation:Maximum=100
+	Target float64 `json:"target"`
+
+	// Period is day or week. Periods start at midnight UTC, on Monday for weekly
+	// objectives.
+	// +kubebuilder:validation:Enum=day;week
+	Period string `json:"period"`
+
+	// ThresholdAdjustment adjusts the carbon intensity thresholds of the selected pods
+	// by the ratio of attainment to target, tightening them while the objective is
+	// missed and relaxing them while it is exceeded. Unset only tracks attainment.
+	// +optional
+	ThresholdAdjustment *SLOThresholdAdjustment `json:"thresholdAdjustment,omitempty"`
+}
+
+// SLOThresholdAdjustment bounds the adjustment of thresholds by a CarbonSLO
+type SLOThresholdAdjustment struct {
+	// MinFactor is the tightest factor thresholds are multiplied by. Defaults to 0.5.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:ExclusiveMinimum=true
+	// +kubebuilder:validation:Maximum=1
+	// +optional
+	MinFactor *float64 `json:"minFactor,omitempty"`
+
+	// MaxFactor is the most relaxed factor thresholds are multiplied by. Defaults to 1,
+	// never relaxing them.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxFactor *float64 `json:"maxFactor,omitempty"`
+
+	// MinCPUHours is the CPU-hours a period must accrue before thresholds are adjusted,
+	// so a few early pods don't swing them.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinCPUHours float64 `json:"minCPUHours,omitempty"`
+}
+
+// CarbonSLOStatus reports the attainment of a CarbonSLO in the current period
+type CarbonSLOStatus struct {
+	// PeriodStart is the start of the current period.
+	// +optional
+	PeriodStart *metav1.Time `json:"periodStart,omitempty"`
+
+	// CPUHours is the CPU-hours of the completed pods selected in the current period.
+	// +optional
+	CPUHours float64 `json:"cpuHours,omitempty"`
+
+	// CleanCPUHours is the part of CPUHours executed under the carbon intensity.
+	// +optional
+	CleanCPUHours float64 `json:"cleanCPUHours,omitempty"`
+
+	// Attainment is the percentage of CPUHours that is clean, unset until pods
+	// complete in the period.
+	// +optional
+	Attainment *float64 `json:"attainment,omitempty"`
+
+	// Met is set while Attainment is at or above the target.
+	// +optional
+	Met bool `json:"met,omitempty"`
+
+	// ThresholdFactor is the factor the thresholds of the selected pods are multiplied
+	// by, if thresholds are adjusted.
+	// +optional
+	ThresholdFactor *float64 `json:"thresholdFactor,omitempty"`
+}
+
+// CarbonSLOList is a collection of carbon SLOs.
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+type CarbonSLOList struct {
+	metav1.TypeMeta `json:",inline"`
+
+	// Standard list metadata
+	// +optional
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	// Items is the list of CarbonSLO
+	Items []CarbonSLO `json:"items"`
+}
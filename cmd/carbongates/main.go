@@ -0,0 +1,107 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// carbongates holds pods out of scheduling with a scheduling gate until carbon
+// conditions allow. It serves the mutating webhook adding the gate and runs the
+// controller removing it, and works with any scheduler.
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/gates"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/zones"
+)
+
+func main() {
+	addr := pflag.String("addr", ":8443", "Address the webhook listens on")
+	certFile := pflag.String("tls-cert-file", "", "TLS certificate of the webhook")
+	keyFile := pflag.String("tls-key-file", "", "TLS private key of the webhook")
+	kubeconfig := pflag.String("kubeconfig", "", "Path to a kubeconfig, in-cluster configuration is used if empty")
+	interval := pflag.Duration("interval", time.Minute, "How often gated pods are evaluated")
+	batchSize := pflag.Int("batch-size", 50, "Most gated pods released per evaluation, oldest first (0 disables)")
+	pflag.Parse()
+
+	if *certFile == "" || *keyFile == "" {
+		klog.ErrorS(nil, "--tls-cert-file and --tls-key-file are required")
+		os.Exit(1)
+	}
+
+	cfg, err := config.LoadFromEnv()
+	if err != nil {
+		klog.ErrorS(err, "Failed to load configuration")
+		os.Exit(1)
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+	if err != nil {
+		klog.ErrorS(err, "Failed to build client configuration")
+		os.Exit(1)
+	}
+	client := kubernetes.NewForConfigOrDie(restConfig)
+
+	// Stop the controller and drain the webhook on SIGTERM or SIGINT
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+	stopCh := ctx.Done()
+	factory := informers.NewSharedInformerFactory(client, 0)
+	podLister := factory.Core().V1().Pods().Lister()
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
+
+	controller := gates.NewController(client, podLister, api.NewClient(cfg.API), zones.NewMapper(cfg.API.RegionZoneMap),
+		gates.Options{
+			Region:    cfg.API.Region,
+			Threshold: cfg.Scheduling.BaseCarbonIntensityThreshold,
+			MaxDelay:  cfg.Scheduling.MaxSchedulingDelay,
+			Interval:  *interval,
+			BatchSize: *batchSize,
+		}, time.Now)
+	go controller.Run(ctx, stopCh)
+
+	mux := http.NewServeMux()
+	mux.Handle("/mutate", &gates.Webhook{})
+	server := &http.Server{Addr: *addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		klog.InfoS("Shutting down carbon scheduling gate webhook")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			klog.ErrorS(err, "Webhook server shutdown failed")
+		}
+	}()
+
+	klog.InfoS("Starting carbon scheduling gate webhook", "addr", *addr, "gate", gates.GateName)
+	if err := server.ListenAndServeTLS(*certFile, *keyFile); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		klog.ErrorS(err, "Webhook server failed")
+		os.Exit(1)
+	}
+}
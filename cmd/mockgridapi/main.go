@@ -0,0 +1,53 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// mockgridapi serves scripted carbon intensity and electricity price scenarios for
+// integration tests and demos that should not depend on real API keys.
+package main
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/mockgridapi"
+)
+
+func main() {
+	addr := pflag.String("addr", ":8080", "Address to listen on")
+	scenarioPath := pflag.String("scenario", "", "Path to the scenario YAML file")
+	pflag.Parse()
+
+	if *scenarioPath == "" {
+		klog.ErrorS(nil, "--scenario is required")
+		os.Exit(1)
+	}
+
+	scenario, err := mockgridapi.LoadScenario(*scenarioPath)
+	if err != nil {
+		klog.ErrorS(err, "Failed to load scenario")
+		os.Exit(1)
+	}
+
+	klog.InfoS("Starting mock grid API", "addr", *addr, "zones", len(scenario.Zones))
+	if err := http.ListenAndServe(*addr, mockgridapi.NewServer(scenario, time.Now)); err != nil {
+		klog.ErrorS(err, "Mock grid API server failed")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// webhook serves the validating admission webhook rejecting pods with malformed
+// carbon-aware scheduling annotations or an opt-out their user isn't allowed to set by
+// carbon policies, and optionally the mutating webhook stamping pods with their
+// submission time, carbon intensity and opt-out approval.
+package main
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/admission"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/cache"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/policy"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/zones"
+)
+
+func main() {
+	addr := pflag.String("addr", ":8443", "Address the webhook listens on")
+	certFile := pflag.String("tls-cert-file", "", "TLS certificate of the webhook")
+	keyFile := pflag.String("tls-key-file", "", "TLS private key of the webhook")
+	stamp := pflag.Bool("stamp", false, "Serve the mutating webhook stamping submission time and carbon intensity at /mutate")
+	policies := pflag.Bool("policies", false, "Enforce the skip restrictions of carbon policies")
+	kubeconfig := pflag.String("kubeconfig", "", "Path to a kubeconfig, in-cluster configuration is used if empty")
+	hubKubeconfig := pflag.String("policy-hub-kubeconfig", "", "Path to the kubeconfig of a hub cluster carbon policies are read from")
+	hubSelector := pflag.String("policy-hub-selector", "", "Label selector restricting the carbon policies read from the hub cluster")
+	pflag.Parse()
+
+	if *certFile == "" || *keyFile == "" {
+		klog.ErrorS(nil, "--tls-cert-file and --tls-key-file are required")
+		os.Exit(1)
+	}
+
+	var resolver *policy.Resolver
+	if *policies {
+		restConfig, err := clientcmd.BuildConfigFromFlags("", *kubeconfig)
+		if err != nil {
+			klog.ErrorS(err, "Failed to build client configuration")
+			os.Exit(1)
+		}
+		ctx := context.Background()
+		factory := informers.NewSharedInformerFactory(kubernetes.NewForConfigOrDie(restConfig), 0)
+		namespaces := factory.Core().V1().Namespaces().Lister()
+		factory.Start(ctx.Done())
+		factory.WaitForCacheSync(ctx.Done())
+		source := policy.LocalSource(restConfig)
+		if *hubKubeconfig != "" {
+			if source, err = policy.HubSource(*hubKubeconfig, *hubSelector); err != nil {
+				klog.ErrorS(err, "Failed to configure the policy hub")
+				os.Exit(1)
+			}
+		}
+		if resolver, err = policy.Start(ctx, source, namespaces); err != nil {
+			klog.ErrorS(err, "Failed to watch carbon policies")
+			os.Exit(1)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/validate", admission.NewWebhook(resolver))
+	if *stamp {
+		cfg, err := config.LoadFromEnv()
+		if err != nil {
+			klog.ErrorS(err, "Failed to load configuration")
+			os.Exit(1)
+		}
+		intensityCache := cache.New(cfg.API.CacheTTL, cfg.API.MaxCacheAge)
+		mux.Handle("/mutate", admission.NewStamper(api.NewClient(cfg.API), intensityCache,
+			zones.NewMapper(cfg.API.RegionZoneMap), resolver, cfg.API.Region, time.Now))
+	}
+	klog.InfoS("Starting carbon annotation webhook", "addr", *addr, "stamp", *stamp, "policies", *policies)
+	if err := http.ListenAndServeTLS(*addr, *certFile, *keyFile, mux); err != nil {
+		klog.ErrorS(err, "Webhook server failed")
+		os.Exit(1)
+	}
+}
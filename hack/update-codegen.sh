@@ -48,8 +48,8 @@ kube::codegen::gen_client \
   "${SCRIPT_ROOT}/apis"
 
 ${CONTROLLER_GEN} object:headerFile="hack/boilerplate/boilerplate.generatego.txt" \
-  paths="./apis/scheduling/..."
+  paths="./apis/scheduling/..." paths="./apis/computegardener/..."
 
 ${CONTROLLER_GEN} ${CRD_OPTIONS} rbac:roleName=work-manager webhook \
-  paths="./apis/scheduling/..." \
+  paths="./apis/scheduling/..." paths="./apis/computegardener/..." \
   output:crd:artifacts:config=config/crd/bases
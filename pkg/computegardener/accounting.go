@@ -0,0 +1,89 @@
+package computegardener
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/accounting"
+)
+
+// restoreAccounting resumes the namespace totals and the energy of running pods
+// persisted before a restart. Restored pods are reconciled against the cluster once
+// the pod informer syncs.
+func (cs *CarbonAwareScheduler) restoreAccounting(h framework.Handle) {
+	for namespace, totals := range cs.accounting.Namespaces() {
+		NamespaceEnergyUsage.WithLabelValues(namespace).Add(totals.EnergyKWh)
+		NamespaceCarbonEmissions.WithLabelValues(namespace).Add(totals.EmissionsGrams)
+		NamespaceEmbodiedEmissions.WithLabelValues(namespace).Add(totals.EmbodiedGrams)
+	}
+
+	pods := cs.accounting.Pods()
+	cs.podEnergy.restore(pods)
+
+	informer := h.SharedInformerFactory().Core().V1().Pods()
+	go func() {
+		if !cache.WaitForCacheSync(cs.stopCh, informer.Informer().HasSynced) {
+			return
+		}
+		cs.reconcileAccounting(pods, informer.Lister())
+	}()
+}
+
+// reconcileAccounting accounts restored pods that finished or were deleted while the
+// scheduler was down. Deleted pods are accounted the energy of their last flush.
+func (cs *CarbonAwareScheduler) reconcileAccounting(pods map[string]accounting.Pod, lister corelisters.PodLister) {
+	for uid, p := range pods {
+		if !cs.podEnergy.tracked(types.UID(uid)) {
+			continue
+		}
+
+		pod, err := lister.Pods(p.Namespace).Get(p.Name)
+		if err != nil || string(pod.UID) != uid {
+			deleted := &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Namespace: p.Namespace, Name: p.Name, UID: types.UID(uid)},
+				Spec:       v1.PodSpec{NodeName: p.NodeName},
+			}
+			if kWh, ok := cs.accountCompletedPod(deleted, p.NodePower, p.LastAt); ok {
+				klog.V(2).InfoS("Accounted energy of pod deleted during restart",
+					"pod", klog.KRef(p.Namespace, p.Name), "energyKWh", kWh)
+			}
+			continue
+		}
+		if isFinished(pod) {
+			cs.handlePodFinished(pod)
+		}
+	}
+}
+
+// flushAccounting persists the energy of running pods and the namespace totals
+func (cs *CarbonAwareScheduler) flushAccounting() {
+	if cs.accounting == nil {
+		return
+	}
+	cs.accounting.SetPods(cs.podEnergy.snapshot())
+	if err := cs.accounting.Flush(); err != nil {
+		klog.ErrorS(err, "Failed to flush energy accounting")
+	}
+}
+
+// accountingWorker periodically persists the energy accounting
+func (cs *CarbonAwareScheduler) accountingWorker() {
+	ticker := time.NewTicker(cs.config.Accounting.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cs.stopCh:
+			return
+		case <-ticker.C:
+			cs.flushAccounting()
+		}
+	}
+}
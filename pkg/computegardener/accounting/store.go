@@ -0,0 +1,196 @@
+package accounting
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Pod is the energy accounted to a running pod up to its last power sample
+type Pod struct {
+	Namespace     string    `json:"namespace"`
+	Name          string    `json:"name"`
+	NodeName      string    `json:"nodeName"`
+	Baseline      float64   `json:"baseline"`
+	KWh           float64   `json:"kWh"`
+	AdditionalKWh float64   `json:"additionalKWh"`
+	Power         float64   `json:"power"`
+	NodePower     float64   `json:"nodePower"`
+	Own           bool      `json:"own,omitempty"`
+	LastAt        time.Time `json:"lastAt"`
+	BoundAt       time.Time `json:"boundAt"`
+	EmbodiedRate  float64   `json:"embodiedRate,omitempty"`
+	Sidecars      []string  `json:"sidecars,omitempty"`
+	AccruedAt     time.Time `json:"accruedAt,omitempty"`
+	AccruedKWh    float64   `json:"accruedKWh,omitempty"`
+	AccruedGrams  float64   `json:"accruedGrams,omitempty"`
+}
+
+// Totals is the energy and emissions accounted to the pods of a namespace, of which
+// EmbodiedGrams is embodied carbon
+type Totals struct {
+	EnergyKWh      float64 `json:"energyKWh"`
+	EmissionsGrams float64 `json:"emissionsGrams"`
+	EmbodiedGrams  float64 `json:"embodiedGrams,omitempty"`
+}
+
+type state struct {
+	Pods       map[string]Pod    `json:"pods"` // by pod UID
+	Namespaces map[string]Totals `json:"namespaces"`
+}
+
+// FileStore keeps the accounting of running pods and namespace totals in memory and
+// persists it as JSON to a file, typically on a persistent volume so accounting
+// survives scheduler restarts
+type FileStore struct {
+	path string
+
+	mutex sync.Mutex
+	state state
+	dirty bool
+}
+
+// NewFileStore creates a store backed by path, loading any previously persisted accounting
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{
+		path: path,
+		state: state{
+			Pods:       make(map[string]Pod),
+			Namespaces: make(map[string]Totals),
+		},
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read accounting file: %v", err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("failed to parse accounting file: %v", err)
+	}
+	if s.state.Pods == nil {
+		s.state.Pods = make(map[string]Pod)
+	}
+	if s.state.Namespaces == nil {
+		s.state.Namespaces = make(map[string]Totals)
+	}
+
+	klog.V(2).InfoS("Loaded energy accounting", "path", path, "pods", len(s.state.Pods),
+		"namespaces", len(s.state.Namespaces))
+	return s, nil
+}
+
+// Pods returns the running pods as of the last flush, by UID
+func (s *FileStore) Pods() map[string]Pod {
+	if s == nil {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	pods := make(map[string]Pod, len(s.state.Pods))
+	for uid, p := range s.state.Pods {
+		pods[uid] = p
+	}
+	return pods
+}
+
+// SetPods replaces the running pods to persist on the next flush
+func (s *FileStore) SetPods(pods map[string]Pod) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if len(pods) == 0 && len(s.state.Pods) == 0 {
+		return
+	}
+	s.state.Pods = pods
+	s.dirty = true
+}
+
+// Add accrues the energy and emissions of a pod to its namespace
+func (s *FileStore) Add(namespace string, kWh, grams float64) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	totals := s.state.Namespaces[namespace]
+	totals.EnergyKWh += kWh
+	totals.EmissionsGrams += grams
+	s.state.Namespaces[namespace] = totals
+	s.dirty = true
+}
+
+// AddEmbodied records the part of the emissions accrued to a namespace that is
+// embodied carbon
+func (s *FileStore) AddEmbodied(namespace string, grams float64) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	totals := s.state.Namespaces[namespace]
+	totals.EmbodiedGrams += grams
+	s.state.Namespaces[namespace] = totals
+	s.dirty = true
+}
+
+// Namespaces returns the totals of each namespace
+func (s *FileStore) Namespaces() map[string]Totals {
+	if s == nil {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	namespaces := make(map[string]Totals, len(s.state.Namespaces))
+	for ns, totals := range s.state.Namespaces {
+		namespaces[ns] = totals
+	}
+	return namespaces
+}
+
+// Flush persists the accounting if it changed. The file is written atomically via rename.
+func (s *FileStore) Flush() error {
+	if s == nil {
+		return nil
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return fmt.Errorf("failed to encode accounting: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".accounting-*")
+	if err != nil {
+		return fmt.Errorf("failed to create accounting file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write accounting file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write accounting file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace accounting file: %v", err)
+	}
+
+	s.dirty = false
+	return nil
+}
@@ -0,0 +1,60 @@
+package accounting
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "accounting.json")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	store, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	store.Add("team-a", 0.5, 100)
+	store.Add("team-a", 0.25, 50)
+	store.Add("team-b", 1, 200)
+	store.AddEmbodied("team-b", 20)
+	store.SetPods(map[string]Pod{
+		"uid-1": {Namespace: "team-a", Name: "job", NodeName: "node-1", Baseline: 100, KWh: 0.2, LastAt: now},
+	})
+
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	reloaded, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore() reload error = %v", err)
+	}
+
+	totals := reloaded.Namespaces()
+	if got := totals["team-a"]; got.EnergyKWh != 0.75 || got.EmissionsGrams != 150 {
+		t.Errorf("Namespaces()[team-a] = %+v, want 0.75 kWh and 150 g", got)
+	}
+	if got := totals["team-b"]; got.EnergyKWh != 1 || got.EmissionsGrams != 200 || got.EmbodiedGrams != 20 {
+		t.Errorf("Namespaces()[team-b] = %+v, want 1 kWh and 200 g, 20 g embodied", got)
+	}
+
+	pods := reloaded.Pods()
+	if got, ok := pods["uid-1"]; !ok || got.KWh != 0.2 || !got.LastAt.Equal(now) {
+		t.Errorf("Pods()[uid-1] = %+v, %v, want the persisted pod", got, ok)
+	}
+
+	// Pods completed since the last flush are dropped
+	reloaded.SetPods(nil)
+	if err := reloaded.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	again, err := NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore() reload error = %v", err)
+	}
+	if got := again.Pods(); len(got) != 0 {
+		t.Errorf("Pods() = %v, want none", got)
+	}
+}
@@ -0,0 +1,116 @@
+package admission
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+)
+
+// injectContextAnnotation opts a pod into having its carbon context injected into its
+// containers when set to "true"
+const injectContextAnnotation = "carbon-aware-scheduler.kubernetes.io/inject-context"
+
+// forecastSummaryHorizon is how far ahead the injected forecast summary looks
+const forecastSummaryHorizon = 24 * time.Hour
+
+// Environment variables injected into the containers of opted-in pods. Values are a
+// snapshot taken at admission.
+const (
+	// zoneEnv is the grid zone of the pod
+	zoneEnv = "CARBON_ZONE"
+	// intensityEnv is the carbon intensity of the zone, in gCO2eq/kWh
+	intensityEnv = "CARBON_INTENSITY"
+	// contextTimeEnv is the RFC3339 time the context was taken
+	contextTimeEnv = "CARBON_CONTEXT_TIME"
+	// forecastMinEnv is the lowest forecast intensity over the next 24 hours
+	forecastMinEnv = "CARBON_INTENSITY_FORECAST_MIN"
+	// forecastMinAtEnv is the RFC3339 time the lowest forecast intensity is reached
+	forecastMinAtEnv = "CARBON_INTENSITY_FORECAST_MIN_AT"
+)
+
+// contextOps returns the JSON patch operations injecting the carbon context into the
+// containers of a pod, if it opted in. Variables the pod already sets are kept.
+func (s *Stamper) contextOps(ctx context.Context, pod *v1.Pod) []patchOp {
+	if pod.Annotations[injectContextAnnotation] != "true" {
+		return nil
+	}
+	env := s.contextEnv(ctx, s.podZone(pod))
+
+	var ops []patchOp
+	for i := range pod.Spec.InitContainers {
+		ops = append(ops, envOp(fmt.Sprintf("/spec/initContainers/%d/env", i), pod.Spec.InitContainers[i].Env, env))
+	}
+	for i := range pod.Spec.Containers {
+		ops = append(ops, envOp(fmt.Sprintf("/spec/containers/%d/env", i), pod.Spec.Containers[i].Env, env))
+	}
+	return ops
+}
+
+// contextEnv returns the carbon context of a zone as environment variables. The zone
+// and time are always set; the intensity and forecast only if available.
+func (s *Stamper) contextEnv(ctx context.Context, zone string) []v1.EnvVar {
+	now := s.now()
+	env := []v1.EnvVar{
+		{Name: zoneEnv, Value: zone},
+		{Name: contextTimeEnv, Value: now.UTC().Format(time.RFC3339)},
+	}
+	if intensity, ok := s.intensity(ctx, zone); ok {
+		env = append(env, v1.EnvVar{Name: intensityEnv, Value: strconv.FormatFloat(intensity, 'f', 2, 64)})
+	}
+	if low, ok := s.forecastMin(ctx, zone, now); ok {
+		env = append(env,
+			v1.EnvVar{Name: forecastMinEnv, Value: strconv.FormatFloat(low.CarbonIntensity, 'f', 2, 64)},
+			v1.EnvVar{Name: forecastMinAtEnv, Value: low.Timestamp.UTC().Format(time.RFC3339)})
+	}
+	return env
+}
+
+// forecastMin returns the lowest point of a zone's forecast over the summary horizon.
+// Forecasts holding only the current value carry no summary.
+func (s *Stamper) forecastMin(ctx context.Context, zone string, now time.Time) (api.Point, bool) {
+	points, ok := s.cache.GetForecast(zone, forecastSummaryHorizon)
+	if !ok {
+		ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+		defer cancel()
+		fetched, err := api.Forecast(ctx, s.provider, zone, forecastSummaryHorizon)
+		if err != nil {
+			klog.V(2).InfoS("No carbon forecast to inject at admission", "zone", zone, "err", err)
+			return api.Point{}, false
+		}
+		s.cache.SetForecast(zone, fetched)
+		points = api.TrimForecast(fetched, now, forecastSummaryHorizon)
+	}
+	if len(points) < 2 {
+		return api.Point{}, false
+	}
+
+	low := points[0]
+	for _, p := range points[1:] {
+		if p.CarbonIntensity < low.CarbonIntensity {
+			low = p
+		}
+	}
+	return low, true
+}
+
+// envOp returns the JSON patch operation adding the context variables to the
+// environment of a container, keeping the variables it already sets
+func envOp(path string, existing, vars []v1.EnvVar) patchOp {
+	set := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		set[e.Name] = true
+	}
+	env := append([]v1.EnvVar{}, existing...)
+	for _, e := range vars {
+		if !set[e.Name] {
+			env = append(env, e)
+		}
+	}
+	return patchOp{Op: "add", Path: path, Value: env}
+}
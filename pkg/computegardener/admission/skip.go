@@ -0,0 +1,134 @@
+package admission
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/policy"
+)
+
+const (
+	priceSkipAnnotation  = "price-aware-scheduler.kubernetes.io/skip"
+	strictnessAnnotation = "carbon-aware-scheduler.kubernetes.io/strictness"
+	// optOutAllowedAnnotation records that the user creating a pod may opt it out under
+	// the policies restricting opt-outs; the scheduler ignores the opt-outs of pods
+	// under a restriction without it
+	optOutAllowedAnnotation = "carbon-aware-scheduler.kubernetes.io/opt-out-allowed"
+)
+
+// optOuts maps each annotation opting a pod out of carbon-aware scheduling to the
+// value opting it out
+var optOuts = map[string]string{
+	skipAnnotation:       "true",
+	priceSkipAnnotation:  "true",
+	strictnessAnnotation: "off",
+}
+
+// trustedPrefixes are the users creating pods on behalf of others: workload
+// controllers, whose pod templates are validated when created, and kubelets
+var trustedPrefixes = []string{"system:serviceaccount:kube-system:", "system:node:"}
+
+// checkSkip returns an error if the user of a request opts a pod or pod template out
+// with any of the opt-out annotations without being allowed to by every policy
+// restricting it, or changes the opt-out approval of an existing pod. Opt-outs already
+// set are left alone, so restrictions don't block updates of existing objects.
+func (wh *Webhook) checkSkip(req *admissionv1.AdmissionRequest, meta, old *metav1.ObjectMeta) error {
+	if trusted(req.UserInfo) {
+		return nil
+	}
+	// The approval is only stamped when pods are created
+	if old != nil && meta.Annotations[optOutAllowedAnnotation] != old.Annotations[optOutAllowedAnnotation] {
+		return fmt.Errorf("%s may not set %s", req.UserInfo.Username, optOutAllowedAnnotation)
+	}
+	key, ok := newOptOut(meta, old)
+	if !ok {
+		return nil
+	}
+	if name, denied := optOutDenied(wh.policies, req, meta); denied {
+		return fmt.Errorf("%s may not set %s under carbon policy %s", req.UserInfo.Username, key, name)
+	}
+	return nil
+}
+
+// newOptOut returns the first opt-out annotation set on an object that wasn't already
+// set on its old version
+func newOptOut(meta, old *metav1.ObjectMeta) (string, bool) {
+	keys := make([]string, 0, len(optOuts))
+	for key := range optOuts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if meta.Annotations[key] != optOuts[key] {
+			continue
+		}
+		if old == nil || old.Annotations[key] != optOuts[key] {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// optOutDenied returns the first policy selecting the pod or pod template of a request
+// whose restriction doesn't allow its user to opt it out, if any
+func optOutDenied(policies *policy.Resolver, req *admissionv1.AdmissionRequest, meta *metav1.ObjectMeta) (string, bool) {
+	// Policies select pods by their namespace and labels
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: req.Namespace, Labels: meta.Labels}}
+	p, ok := policies.Resolve(pod)
+	if !ok {
+		return "", false
+	}
+	names := make([]string, 0, len(p.SkipRestrictions))
+	for name := range p.SkipRestrictions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !skipAllowed(p.SkipRestrictions[name], req.UserInfo) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// restricted reports whether any policy selecting the pod or pod template of a request
+// restricts opt-outs
+func restricted(policies *policy.Resolver, req *admissionv1.AdmissionRequest, meta *metav1.ObjectMeta) bool {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: req.Namespace, Labels: meta.Labels}}
+	p, ok := policies.Resolve(pod)
+	return ok && len(p.SkipRestrictions) > 0
+}
+
+// trusted reports whether a user creates pods on behalf of others
+func trusted(user authenticationv1.UserInfo) bool {
+	for _, prefix := range trustedPrefixes {
+		if strings.HasPrefix(user.Username, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// skipAllowed reports whether a restriction allows a user to opt pods out
+func skipAllowed(r *v1alpha1.SkipRestriction, user authenticationv1.UserInfo) bool {
+	for _, u := range r.Users {
+		if u == user.Username {
+			return true
+		}
+	}
+	for _, g := range r.Groups {
+		for _, ug := range user.Groups {
+			if g == ug {
+				return true
+			}
+		}
+	}
+	return false
+}
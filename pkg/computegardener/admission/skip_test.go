@@ -0,0 +1,275 @@
+package admission
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/policy"
+)
+
+// restrictingResolver returns a resolver restricting opt-outs in namespace team-a to
+// alice, and to the service accounts of namespace ci
+func restrictingResolver(t *testing.T) *policy.Resolver {
+	namespaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range []*v1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "team-a", Labels: map[string]string{"restricted": "true"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "team-b"}},
+	} {
+		if err := namespaces.Add(ns); err != nil {
+			t.Fatal(err)
+		}
+	}
+	policies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := policies.Add(&v1alpha1.CarbonPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "alice-only"},
+		Spec:       v1alpha1.CarbonPolicySpec{SkipRestriction: &v1alpha1.SkipRestriction{Users: []string{"alice"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	clusterPolicies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := clusterPolicies.Add(&v1alpha1.ClusterCarbonPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "restricted"},
+		Spec: v1alpha1.CarbonPolicySpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"restricted": "true"}},
+			SkipRestriction:   &v1alpha1.SkipRestriction{Groups: []string{"system:serviceaccounts:ci"}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return policy.NewResolver(policies, clusterPolicies, corelisters.NewNamespaceLister(namespaces))
+}
+
+func TestCheckSkip(t *testing.T) {
+	wh := NewWebhook(restrictingResolver(t))
+
+	skip := map[string]string{skipAnnotation: "true"}
+	ciGroups := []string{"system:serviceaccounts", "system:serviceaccounts:ci"}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		kind        string
+		user        authenticationv1.UserInfo
+		object      interface{}
+		old         interface{}
+		wantAllowed bool
+		wantMessage string
+	}{
+		{
+			name:        "no skip annotation",
+			namespace:   "team-a",
+			user:        authenticationv1.UserInfo{Username: "bob"},
+			object:      annotatedPod(nil),
+			wantAllowed: true,
+		},
+		{
+			name:        "unrestricted namespace",
+			namespace:   "team-b",
+			user:        authenticationv1.UserInfo{Username: "bob"},
+			object:      annotatedPod(skip),
+			wantAllowed: true,
+		},
+		{
+			name:        "user allowed by no policy",
+			namespace:   "team-a",
+			user:        authenticationv1.UserInfo{Username: "bob"},
+			object:      annotatedPod(skip),
+			wantAllowed: false,
+			wantMessage: "carbon policy restricted",
+		},
+		{
+			name:        "user allowed by only one policy",
+			namespace:   "team-a",
+			user:        authenticationv1.UserInfo{Username: "system:serviceaccount:ci:deployer", Groups: ciGroups},
+			object:      annotatedPod(skip),
+			wantAllowed: false,
+			wantMessage: "carbon policy team-a/alice-only",
+		},
+		{
+			name:        "user allowed by all policies",
+			namespace:   "team-a",
+			user:        authenticationv1.UserInfo{Username: "alice", Groups: ciGroups},
+			object:      annotatedPod(skip),
+			wantAllowed: true,
+		},
+		{
+			name:        "price skip annotation",
+			namespace:   "team-a",
+			user:        authenticationv1.UserInfo{Username: "bob"},
+			object:      annotatedPod(map[string]string{priceSkipAnnotation: "true"}),
+			wantAllowed: false,
+			wantMessage: priceSkipAnnotation,
+		},
+		{
+			name:        "off mode",
+			namespace:   "team-a",
+			user:        authenticationv1.UserInfo{Username: "bob"},
+			object:      annotatedPod(map[string]string{strictnessAnnotation: "off"}),
+			wantAllowed: false,
+			wantMessage: strictnessAnnotation,
+		},
+		{
+			name:        "best-effort mode",
+			namespace:   "team-a",
+			user:        authenticationv1.UserInfo{Username: "bob"},
+			object:      annotatedPod(map[string]string{strictnessAnnotation: "best-effort"}),
+			wantAllowed: true,
+		},
+		{
+			name:        "approval set on update",
+			namespace:   "team-b",
+			user:        authenticationv1.UserInfo{Username: "bob"},
+			object:      annotatedPod(map[string]string{optOutAllowedAnnotation: "true"}),
+			old:         annotatedPod(nil),
+			wantAllowed: false,
+			wantMessage: optOutAllowedAnnotation,
+		},
+		{
+			name:        "skip annotation already set",
+			namespace:   "team-a",
+			user:        authenticationv1.UserInfo{Username: "bob"},
+			object:      annotatedPod(skip),
+			old:         annotatedPod(skip),
+			wantAllowed: true,
+		},
+		{
+			name:        "workload controller",
+			namespace:   "team-a",
+			user:        authenticationv1.UserInfo{Username: "system:serviceaccount:kube-system:replicaset-controller"},
+			object:      annotatedPod(skip),
+			wantAllowed: true,
+		},
+		{
+			name:      "deployment pod template",
+			namespace: "team-a",
+			kind:      "Deployment",
+			user:      authenticationv1.UserInfo{Username: "bob"},
+			object: map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{
+				"metadata": map[string]interface{}{"annotations": skip},
+			}}},
+			wantAllowed: false,
+			wantMessage: "carbon policy restricted",
+		},
+		{
+			name:      "cronjob pod template with invalid annotation",
+			namespace: "team-b",
+			kind:      "CronJob",
+			user:      authenticationv1.UserInfo{Username: "bob"},
+			object: map[string]interface{}{"spec": map[string]interface{}{"jobTemplate": map[string]interface{}{
+				"spec": map[string]interface{}{"template": map[string]interface{}{
+					"metadata": map[string]interface{}{"annotations": map[string]string{maxDelayAnnotation: "soon"}},
+				}},
+			}}},
+			wantAllowed: false,
+			wantMessage: "spec.jobTemplate.spec.template.metadata.annotations",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind := tt.kind
+			if kind == "" {
+				kind = "Pod"
+			}
+			req := &admissionv1.AdmissionRequest{
+				UID:       "uid",
+				Kind:      metav1.GroupVersionKind{Kind: kind},
+				Namespace: tt.namespace,
+				UserInfo:  tt.user,
+				Object:    runtime.RawExtension{Raw: mustMarshal(t, tt.object)},
+			}
+			if tt.old != nil {
+				req.OldObject = runtime.RawExtension{Raw: mustMarshal(t, tt.old)}
+			}
+
+			response := wh.admit(req)
+			if response.Allowed != tt.wantAllowed {
+				t.Fatalf("allowed = %v, want %v (%+v)", response.Allowed, tt.wantAllowed, response.Result)
+			}
+			if tt.wantMessage != "" && (response.Result == nil || !strings.Contains(response.Result.Message, tt.wantMessage)) {
+				t.Errorf("result = %+v, want a message containing %q", response.Result, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func mustMarshal(t *testing.T, obj interface{}) []byte {
+	raw, err := json.Marshal(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestApproveOptOut(t *testing.T) {
+	stamper := &Stamper{policies: restrictingResolver(t)}
+	ciGroups := []string{"system:serviceaccounts", "system:serviceaccounts:ci"}
+
+	tests := []struct {
+		name         string
+		namespace    string
+		user         authenticationv1.UserInfo
+		annotations  map[string]string
+		wantChanged  bool
+		wantApproved bool
+	}{
+		{
+			name:         "user allowed by all policies",
+			namespace:    "team-a",
+			user:         authenticationv1.UserInfo{Username: "alice", Groups: ciGroups},
+			wantChanged:  true,
+			wantApproved: true,
+		},
+		{
+			name:         "workload controller",
+			namespace:    "team-a",
+			user:         authenticationv1.UserInfo{Username: "system:serviceaccount:kube-system:job-controller"},
+			wantChanged:  true,
+			wantApproved: true,
+		},
+		{
+			name:        "user not allowed, submitted approval removed",
+			namespace:   "team-a",
+			user:        authenticationv1.UserInfo{Username: "bob"},
+			annotations: map[string]string{optOutAllowedAnnotation: "true"},
+			wantChanged: true,
+		},
+		{
+			name:      "user not allowed",
+			namespace: "team-a",
+			user:      authenticationv1.UserInfo{Username: "bob"},
+		},
+		{
+			name:      "unrestricted namespace",
+			namespace: "team-b",
+			user:      authenticationv1.UserInfo{Username: "bob"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &admissionv1.AdmissionRequest{Namespace: tt.namespace, UserInfo: tt.user}
+			pod := annotatedPod(tt.annotations)
+			annotations := make(map[string]string)
+			for k, v := range tt.annotations {
+				annotations[k] = v
+			}
+			if changed := stamper.approve(req, pod, annotations); changed != tt.wantChanged {
+				t.Errorf("approve() = %v, want %v", changed, tt.wantChanged)
+			}
+			if approved := annotations[optOutAllowedAnnotation] == "true"; approved != tt.wantApproved {
+				t.Errorf("approved = %v, want %v", approved, tt.wantApproved)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/cache"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/policy"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/zones"
+)
+
+const (
+	skipAnnotation   = "carbon-aware-scheduler.kubernetes.io/skip"
+	regionAnnotation = "carbon-aware-scheduler.kubernetes.io/region"
+	// submittedAtAnnotation is the RFC3339 time a pod was admitted
+	submittedAtAnnotation = "carbon-aware-scheduler.kubernetes.io/submitted-at"
+	// initialIntensityAnnotation is the carbon intensity of a pod's zone when it was
+	// admitted, which the scheduler compares with the intensity it is bound at
+	initialIntensityAnnotation = "carbon-aware-scheduler.kubernetes.io/initial-intensity"
+)
+
+// lookupTimeout bounds how long admission waits for the intensity of a zone missing
+// from the cache
+const lookupTimeout = 2 * time.Second
+
+// Stamper is a mutating admission webhook stamping pods with their submission time and
+// the carbon intensity of their zone at submission, and whether their user may opt
+// them out, once, when they are created, and injecting the carbon context into the
+// containers of pods opting in. Pods are always admitted; the intensity is left out if
+// it isn't available.
+type Stamper struct {
+	provider api.Provider
+	cache    *cache.Cache
+	zones    *zones.Mapper
+	policies *policy.Resolver
+	region   string
+	now      func() time.Time
+}
+
+// NewStamper creates a new Stamper. Pods without a region annotation are stamped with
+// the intensity of region. Opt-outs are approved under the skip restrictions of the
+// policies resolved by policies, if set.
+func NewStamper(provider api.Provider, intensityCache *cache.Cache, zoneMapper *zones.Mapper,
+	policies *policy.Resolver, region string, now func() time.Time) *Stamper {
+	return &Stamper{
+		provider: provider,
+		cache:    intensityCache,
+		zones:    zoneMapper,
+		policies: policies,
+		region:   region,
+		now:      now,
+	}
+}
+
+// ServeHTTP handles AdmissionReview requests
+func (s *Stamper) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil || review.Request == nil {
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	response := &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	var pod v1.Pod
+	if err := json.Unmarshal(review.Request.Object.Raw, &pod); err != nil {
+		// Never block pod creation on the stamp
+		klog.ErrorS(err, "Failed to decode pod in admission review")
+	} else if patch, ok := s.patch(r.Context(), review.Request, &pod); ok {
+		patchType := admissionv1.PatchTypeJSONPatch
+		response.Patch = patch
+		response.PatchType = &patchType
+	}
+
+	review.Response = response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		klog.ErrorS(err, "Failed to write admission response")
+	}
+}
+
+// patch returns the JSON patch stamping a pod and injecting its carbon context, if
+// either applies
+func (s *Stamper) patch(ctx context.Context, req *admissionv1.AdmissionRequest, pod *v1.Pod) ([]byte, bool) {
+	if pod.Spec.NodeName != "" {
+		return nil, false
+	}
+
+	annotations := make(map[string]string, len(pod.Annotations)+3)
+	for k, v := range pod.Annotations {
+		annotations[k] = v
+	}
+	var ops []patchOp
+	stamped := s.stamp(ctx, pod, annotations)
+	if s.approve(req, pod, annotations) || stamped {
+		// Adding a member that exists replaces it
+		ops = append(ops, patchOp{Op: "add", Path: "/metadata/annotations", Value: annotations})
+	}
+	ops = append(ops, s.contextOps(ctx, pod)...)
+	if len(ops) == 0 {
+		return nil, false
+	}
+
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		klog.ErrorS(err, "Failed to encode admission patch", "pod", klog.KObj(pod))
+		return nil, false
+	}
+	return patch, true
+}
+
+// stamp stamps the annotations of a pod with its submission time and intensity, if the
+// pod should be stamped, and reports whether it did. Stamps set by the submitter are
+// overwritten.
+func (s *Stamper) stamp(ctx context.Context, pod *v1.Pod, annotations map[string]string) bool {
+	if pod.Annotations[skipAnnotation] == "true" {
+		return false
+	}
+
+	annotations[submittedAtAnnotation] = s.now().UTC().Format(time.RFC3339)
+	delete(annotations, initialIntensityAnnotation)
+	if intensity, ok := s.intensity(ctx, s.podZone(pod)); ok {
+		annotations[initialIntensityAnnotation] = fmt.Sprintf("%.2f", intensity)
+	}
+	return true
+}
+
+// approve stamps the annotations of a pod under a skip restriction with the opt-out
+// approval if the user creating it may opt it out, and reports whether they changed.
+// Approvals set by the submitter are removed.
+func (s *Stamper) approve(req *admissionv1.AdmissionRequest, pod *v1.Pod, annotations map[string]string) bool {
+	_, submitted := annotations[optOutAllowedAnnotation]
+	delete(annotations, optOutAllowedAnnotation)
+	if !restricted(s.policies, req, &pod.ObjectMeta) {
+		return submitted
+	}
+	if _, denied := optOutDenied(s.policies, req, &pod.ObjectMeta); denied && !trusted(req.UserInfo) {
+		return submitted
+	}
+	annotations[optOutAllowedAnnotation] = "true"
+	return true
+}
+
+// patchOp is a JSON patch operation
+type patchOp struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+}
+
+// intensity returns the current carbon intensity of a zone, from the cache if fresh
+func (s *Stamper) intensity(ctx context.Context, zone string) (float64, bool) {
+	if data, ok := s.cache.Get(zone); ok {
+		return data.CarbonIntensity, true
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, lookupTimeout)
+	defer cancel()
+	data, err := s.provider.GetCarbonIntensity(ctx, zone)
+	if err != nil {
+		klog.V(2).InfoS("No carbon intensity to stamp at admission", "zone", zone, "err", err)
+		return 0, false
+	}
+	s.cache.Set(zone, data)
+	return data.CarbonIntensity, true
+}
+
+// podZone returns the zone from a pod's region annotation, or the default region
+func (s *Stamper) podZone(pod *v1.Pod) string {
+	if val := pod.Annotations[regionAnnotation]; val != "" {
+		if zone, ok := s.zones.ZoneForRegion(val); ok {
+			return zone
+		}
+		return val
+	}
+	return s.region
+}
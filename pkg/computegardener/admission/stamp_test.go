@@ -0,0 +1,219 @@
+package admission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/cache"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/zones"
+)
+
+type fakeProvider map[string]float64
+
+func (p fakeProvider) GetCarbonIntensity(_ context.Context, zone string) (*api.ElectricityData, error) {
+	intensity, ok := p[zone]
+	if !ok {
+		return nil, fmt.Errorf("no data for zone %s", zone)
+	}
+	return &api.ElectricityData{CarbonIntensity: intensity}, nil
+}
+
+func TestStampPatch(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		pod         *v1.Pod
+		wantPatch   bool
+		wantStamps  map[string]string
+		wantMissing []string
+	}{
+		{
+			name:      "default region",
+			pod:       &v1.Pod{},
+			wantPatch: true,
+			wantStamps: map[string]string{
+				submittedAtAnnotation:      "2024-01-01T12:00:00Z",
+				initialIntensityAnnotation: "250.00",
+			},
+		},
+		{
+			name: "region annotation, existing annotations kept",
+			pod: &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				regionAnnotation: "other",
+				"team":           "research",
+			}}},
+			wantPatch: true,
+			wantStamps: map[string]string{
+				initialIntensityAnnotation: "100.00",
+				"team":                     "research",
+			},
+		},
+		{
+			name: "intensity unavailable, submitted stamp overwritten",
+			pod: &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+				regionAnnotation:           "unknown",
+				initialIntensityAnnotation: "1",
+			}}},
+			wantPatch:   true,
+			wantStamps:  map[string]string{submittedAtAnnotation: "2024-01-01T12:00:00Z"},
+			wantMissing: []string{initialIntensityAnnotation},
+		},
+		{
+			name:      "skip annotation",
+			pod:       &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{skipAnnotation: "true"}}},
+			wantPatch: false,
+		},
+		{
+			name:      "already bound",
+			pod:       &v1.Pod{Spec: v1.PodSpec{NodeName: "node-1"}},
+			wantPatch: false,
+		},
+	}
+
+	intensityCache := cache.New(time.Minute, time.Hour)
+	defer intensityCache.Close()
+	stamper := NewStamper(fakeProvider{"test-region": 250, "other": 100}, intensityCache,
+		zones.NewMapper(nil), nil, "test-region", func() time.Time { return now })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, ok := stamper.patch(context.Background(), &admissionv1.AdmissionRequest{}, tt.pod)
+			if ok != tt.wantPatch {
+				t.Fatalf("patch() ok = %v, want %v", ok, tt.wantPatch)
+			}
+			if !ok {
+				return
+			}
+
+			var ops []struct {
+				Op    string            `json:"op"`
+				Path  string            `json:"path"`
+				Value map[string]string `json:"value"`
+			}
+			if err := json.Unmarshal(patch, &ops); err != nil {
+				t.Fatalf("invalid patch %s: %v", patch, err)
+			}
+			if len(ops) != 1 || ops[0].Op != "add" || ops[0].Path != "/metadata/annotations" {
+				t.Fatalf("patch() = %s, want an add of the annotations", patch)
+			}
+			for key, want := range tt.wantStamps {
+				if got := ops[0].Value[key]; got != want {
+					t.Errorf("annotation %s = %q, want %q", key, got, want)
+				}
+			}
+			for _, key := range tt.wantMissing {
+				if got, ok := ops[0].Value[key]; ok {
+					t.Errorf("annotation %s = %q, want it unset", key, got)
+				}
+			}
+		})
+	}
+}
+
+type fakeForecastProvider struct {
+	fakeProvider
+	points []api.Point
+}
+
+func (p fakeForecastProvider) Forecast(_ context.Context, _ string, _ time.Duration) ([]api.Point, error) {
+	return p.points, nil
+}
+
+func TestContextOps(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	optedIn := map[string]string{injectContextAnnotation: "true"}
+
+	tests := []struct {
+		name     string
+		pod      *v1.Pod
+		points   []api.Point
+		wantOps  int
+		wantEnv  map[string]string
+		wantKept map[string]string
+	}{
+		{
+			name:    "not opted in",
+			pod:     &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{Name: "main"}}}},
+			wantOps: 0,
+		},
+		{
+			name: "intensity and forecast",
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: optedIn},
+				Spec: v1.PodSpec{
+					InitContainers: []v1.Container{{Name: "init"}},
+					Containers:     []v1.Container{{Name: "main"}},
+				},
+			},
+			points: []api.Point{
+				{Timestamp: now, CarbonIntensity: 250},
+				{Timestamp: now.Add(3 * time.Hour), CarbonIntensity: 120},
+				{Timestamp: now.Add(6 * time.Hour), CarbonIntensity: 180},
+			},
+			wantOps: 2,
+			wantEnv: map[string]string{
+				zoneEnv:          "test-region",
+				intensityEnv:     "250.00",
+				contextTimeEnv:   "2024-01-01T12:00:00Z",
+				forecastMinEnv:   "120.00",
+				forecastMinAtEnv: "2024-01-01T15:00:00Z",
+			},
+		},
+		{
+			name: "flat forecast, existing variables kept",
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Annotations: optedIn},
+				Spec: v1.PodSpec{Containers: []v1.Container{{
+					Name: "main",
+					Env:  []v1.EnvVar{{Name: "OTHER", Value: "1"}, {Name: zoneEnv, Value: "mine"}},
+				}}},
+			},
+			points:   []api.Point{{Timestamp: now, CarbonIntensity: 250}},
+			wantOps:  1,
+			wantEnv:  map[string]string{intensityEnv: "250.00", forecastMinEnv: ""},
+			wantKept: map[string]string{"OTHER": "1", zoneEnv: "mine"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			intensityCache := cache.New(time.Minute, time.Hour)
+			defer intensityCache.Close()
+			provider := fakeForecastProvider{fakeProvider{"test-region": 250}, tt.points}
+			stamper := NewStamper(provider, intensityCache, zones.NewMapper(nil), nil, "test-region", func() time.Time { return now })
+
+			ops := stamper.contextOps(context.Background(), tt.pod)
+			if len(ops) != tt.wantOps {
+				t.Fatalf("contextOps() = %d operations, want %d", len(ops), tt.wantOps)
+			}
+			for _, op := range ops {
+				env := map[string]string{}
+				for _, e := range op.Value.([]v1.EnvVar) {
+					if _, dup := env[e.Name]; dup {
+						t.Errorf("%s sets %s twice", op.Path, e.Name)
+					}
+					env[e.Name] = e.Value
+				}
+				for name, want := range tt.wantEnv {
+					if got := env[name]; got != want {
+						t.Errorf("%s %s = %q, want %q", op.Path, name, got, want)
+					}
+				}
+				for name, want := range tt.wantKept {
+					if got := env[name]; got != want {
+						t.Errorf("%s %s = %q, want the pod's value %q kept", op.Path, name, got, want)
+					}
+				}
+			}
+		})
+	}
+}
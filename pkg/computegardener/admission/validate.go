@@ -0,0 +1,110 @@
+// Package admission holds the admission webhooks of the carbon-aware scheduler. A
+// validating webhook rejects pods with malformed carbon-aware scheduling annotations
+// when they are created, so mistakes surface to the user submitting the pod rather
+// than as scheduling errors or silently ignored settings once the pod is pending. A
+// mutating webhook stamps pods with their submission time and carbon intensity, so
+// the scheduler needn't record them itself, and injects the carbon context into the
+// containers of pods opting in, so workloads can adapt to grid conditions.
+package admission
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Annotations read by the scheduler plugin
+const (
+	thresholdAnnotation         = "carbon-aware-scheduler.kubernetes.io/carbon-intensity-threshold"
+	thermalThresholdAnnotation  = "carbon-aware-scheduler.kubernetes.io/thermal-threshold"
+	priceThresholdAnnotation    = "price-aware-scheduler.kubernetes.io/price-threshold"
+	maxDelayAnnotation          = "carbon-aware-scheduler.kubernetes.io/max-delay"
+	estimatedDurationAnnotation = "carbon-aware-scheduler.kubernetes.io/estimated-duration"
+	deadlineAnnotation          = "carbon-aware-scheduler.kubernetes.io/deadline"
+)
+
+// validators maps each validated annotation to the check of its value
+var validators = map[string]func(string) error{
+	thresholdAnnotation:         nonNegativeNumber,
+	thermalThresholdAnnotation:  number,
+	priceThresholdAnnotation:    nonNegativeNumber,
+	maxDelayAnnotation:          nonNegativeDuration,
+	estimatedDurationAnnotation: nonNegativeDuration,
+	deadlineAnnotation:          timestamp,
+}
+
+// ValidatePod returns the errors in the carbon-aware scheduling annotations of a pod.
+// If old is set, only annotations whose value changed are validated, so pods created
+// before the webhook was installed can still be updated.
+func ValidatePod(pod, old *v1.Pod) field.ErrorList {
+	var oldMeta *metav1.ObjectMeta
+	if old != nil {
+		oldMeta = &old.ObjectMeta
+	}
+	return validateAnnotations(field.NewPath("metadata", "annotations"), &pod.ObjectMeta, oldMeta)
+}
+
+// validateAnnotations returns the errors in the carbon-aware scheduling annotations of
+// the metadata of a pod or pod template, ignoring those unchanged from old
+func validateAnnotations(path *field.Path, meta, old *metav1.ObjectMeta) field.ErrorList {
+	var errs field.ErrorList
+	for key, validate := range validators {
+		val, ok := meta.Annotations[key]
+		if !ok {
+			continue
+		}
+		if old != nil {
+			if oldVal, ok := old.Annotations[key]; ok && oldVal == val {
+				continue
+			}
+		}
+		if err := validate(val); err != nil {
+			errs = append(errs, field.Invalid(path.Key(key), val, err.Error()))
+		}
+	}
+	return errs
+}
+
+// number checks that a value is a number
+func number(val string) error {
+	if _, err := strconv.ParseFloat(val, 64); err != nil {
+		return fmt.Errorf("must be a number")
+	}
+	return nil
+}
+
+// nonNegativeNumber checks that a value is a number of at least 0
+func nonNegativeNumber(val string) error {
+	n, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return fmt.Errorf("must be a number")
+	}
+	if n < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+// nonNegativeDuration checks that a value is a duration, e.g. 12h or 90m, of at least 0
+func nonNegativeDuration(val string) error {
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return fmt.Errorf("must be a duration, e.g. 12h or 90m")
+	}
+	if d < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+// timestamp checks that a value is an RFC3339 time
+func timestamp(val string) error {
+	if _, err := time.Parse(time.RFC3339, val); err != nil {
+		return fmt.Errorf("must be an RFC3339 time, e.g. 2025-01-01T18:00:00Z")
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package admission
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func annotatedPod(annotations map[string]string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default", Annotations: annotations}}
+}
+
+func TestValidatePod(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		old         map[string]string
+		wantErrs    []string
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name: "valid annotations",
+			annotations: map[string]string{
+				thresholdAnnotation:         "150.5",
+				thermalThresholdAnnotation:  "-5",
+				priceThresholdAnnotation:    "0.12",
+				maxDelayAnnotation:          "12h",
+				estimatedDurationAnnotation: "90m",
+				deadlineAnnotation:          "2025-01-01T18:00:00Z",
+			},
+		},
+		{
+			name:        "malformed threshold",
+			annotations: map[string]string{thresholdAnnotation: "low"},
+			wantErrs:    []string{thresholdAnnotation},
+		},
+		{
+			name:        "negative threshold",
+			annotations: map[string]string{thresholdAnnotation: "-1"},
+			wantErrs:    []string{thresholdAnnotation},
+		},
+		{
+			name: "malformed durations and deadline",
+			annotations: map[string]string{
+				maxDelayAnnotation:          "12",
+				estimatedDurationAnnotation: "-1h",
+				deadlineAnnotation:          "tomorrow",
+			},
+			wantErrs: []string{maxDelayAnnotation, estimatedDurationAnnotation, deadlineAnnotation},
+		},
+		{
+			name:        "unchanged invalid annotation on update",
+			annotations: map[string]string{thresholdAnnotation: "low"},
+			old:         map[string]string{thresholdAnnotation: "low"},
+		},
+		{
+			name:        "changed invalid annotation on update",
+			annotations: map[string]string{thresholdAnnotation: "lower"},
+			old:         map[string]string{thresholdAnnotation: "low"},
+			wantErrs:    []string{thresholdAnnotation},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var old *v1.Pod
+			if tt.old != nil {
+				old = annotatedPod(tt.old)
+			}
+			errs := ValidatePod(annotatedPod(tt.annotations), old)
+			if len(errs) != len(tt.wantErrs) {
+				t.Fatalf("ValidatePod() = %v, want errors for %v", errs, tt.wantErrs)
+			}
+			for _, key := range tt.wantErrs {
+				if !strings.Contains(errs.ToAggregate().Error(), key) {
+					t.Errorf("ValidatePod() = %v, want an error for %s", errs, key)
+				}
+			}
+		})
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantAllowed bool
+	}{
+		{
+			name:        "valid pod",
+			annotations: map[string]string{maxDelayAnnotation: "6h"},
+			wantAllowed: true,
+		},
+		{
+			name:        "invalid pod",
+			annotations: map[string]string{maxDelayAnnotation: "six hours"},
+			wantAllowed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := json.Marshal(annotatedPod(tt.annotations))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := json.Marshal(admissionv1.AdmissionReview{
+				Request: &admissionv1.AdmissionRequest{
+					UID:    "uid",
+					Kind:   metav1.GroupVersionKind{Version: "v1", Kind: "Pod"},
+					Object: runtime.RawExtension{Raw: raw},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec := httptest.NewRecorder()
+			(&Webhook{}).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(body)))
+
+			var review admissionv1.AdmissionReview
+			if err := json.NewDecoder(rec.Body).Decode(&review); err != nil {
+				t.Fatalf("invalid response: %v", err)
+			}
+			if review.Response == nil || review.Response.UID != "uid" {
+				t.Fatalf("response = %+v, want the request UID", review.Response)
+			}
+			if review.Response.Allowed != tt.wantAllowed {
+				t.Errorf("allowed = %v, want %v", review.Response.Allowed, tt.wantAllowed)
+			}
+			if !tt.wantAllowed && (review.Response.Result == nil || !strings.Contains(review.Response.Result.Message, maxDelayAnnotation)) {
+				t.Errorf("result = %+v, want a message naming %s", review.Response.Result, maxDelayAnnotation)
+			}
+		})
+	}
+}
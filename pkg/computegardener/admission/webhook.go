@@ -0,0 +1,121 @@
+package admission
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/policy"
+)
+
+// Webhook is a validating admission webhook rejecting pods, and the pod templates of
+// workloads, with malformed carbon-aware scheduling annotations or a skip annotation
+// their user isn't allowed to set. Which objects are validated is selected in the
+// webhook configuration.
+type Webhook struct {
+	policies *policy.Resolver
+}
+
+// NewWebhook returns a webhook enforcing the skip restrictions of the policies
+// resolved by policies. Without policies, the skip annotation is not restricted.
+func NewWebhook(policies *policy.Resolver) *Webhook {
+	return &Webhook{policies: policies}
+}
+
+// ServeHTTP handles AdmissionReview requests
+func (wh *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil || review.Request == nil {
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	review.Response = wh.admit(review.Request)
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		klog.ErrorS(err, "Failed to write admission response")
+	}
+}
+
+// admit validates the pod, or pod template, of an admission request
+func (wh *Webhook) admit(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	response := &admissionv1.AdmissionResponse{UID: req.UID, Allowed: true}
+
+	meta, path, err := templateMeta(req.Kind.Kind, req.Object.Raw)
+	if err != nil {
+		// Malformed objects are rejected by the API server itself
+		klog.ErrorS(err, "Failed to decode object in admission review", "kind", req.Kind.Kind)
+		return response
+	}
+	var old *metav1.ObjectMeta
+	if len(req.OldObject.Raw) > 0 {
+		if old, _, err = templateMeta(req.Kind.Kind, req.OldObject.Raw); err != nil {
+			klog.ErrorS(err, "Failed to decode old object in admission review", "kind", req.Kind.Kind)
+			return response
+		}
+	}
+
+	if errs := validateAnnotations(path.Child("annotations"), meta, old); len(errs) > 0 {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonInvalid,
+			Code:    http.StatusUnprocessableEntity,
+			Message: errs.ToAggregate().Error(),
+		}
+		klog.V(2).InfoS("Rejected object with invalid carbon-aware annotations", "kind", req.Kind.Kind,
+			"object", klog.KRef(req.Namespace, req.Name), "err", response.Result.Message)
+		return response
+	}
+
+	if err := wh.checkSkip(req, meta, old); err != nil {
+		response.Allowed = false
+		response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonForbidden,
+			Code:    http.StatusForbidden,
+			Message: err.Error(),
+		}
+		klog.V(2).InfoS("Rejected object with restricted skip annotation", "kind", req.Kind.Kind,
+			"object", klog.KRef(req.Namespace, req.Name), "user", req.UserInfo.Username, "err", err)
+	}
+	return response
+}
+
+// templatePaths locates the pod template of workload kinds, by default spec.template
+var templatePaths = map[string][]string{
+	"Pod":     {"metadata"},
+	"CronJob": {"spec", "jobTemplate", "spec", "template", "metadata"},
+}
+
+// templateMeta returns the metadata of a pod, or of the pod template of a workload,
+// and its path in the object
+func templateMeta(kind string, raw []byte) (*metav1.ObjectMeta, *field.Path, error) {
+	path, ok := templatePaths[kind]
+	if !ok {
+		path = []string{"spec", "template", "metadata"}
+	}
+
+	var obj map[string]interface{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, nil, err
+	}
+	meta := &metav1.ObjectMeta{}
+	m, found, err := unstructured.NestedMap(obj, path...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if found {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, meta); err != nil {
+			return nil, nil, fmt.Errorf("failed to convert %s: %v", kind, err)
+		}
+	}
+	return meta, field.NewPath(path[0], path[1:]...), nil
+}
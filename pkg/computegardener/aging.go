@@ -0,0 +1,31 @@
+package computegardener
+
+import (
+	v1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+)
+
+// agingFactor returns the multiplier that relaxes a pod's carbon threshold as its
+// wait time approaches its scheduling deadline, from 1 when submitted up to the
+// maximum of its class or the configuration at the deadline
+func (cs *CarbonAwareScheduler) agingFactor(pod *v1.Pod) float64 {
+	curve, maxFactor := cs.agingSettings(pod)
+	created := submittedAt(pod)
+	if curve == config.AgingCurveNone || created.IsZero() {
+		return 1
+	}
+
+	total := cs.schedulingDeadline(pod).Sub(created)
+	if total <= 0 {
+		return maxFactor
+	}
+
+	progress := float64(cs.clock.Since(created)) / float64(total)
+	progress = min(max(progress, 0), 1)
+	if curve == config.AgingCurveQuadratic {
+		progress *= progress
+	}
+
+	return 1 + (maxFactor-1)*progress
+}
@@ -5,12 +5,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
 )
 
+var (
+	_ Provider         = &Client{}
+	_ ForecastProvider = &Client{}
+)
+
 // Client handles interactions with the electricity data API
 type Client struct {
 	config      config.APIConfig
@@ -22,6 +28,33 @@ type Client struct {
 type ElectricityData struct {
 	CarbonIntensity float64   `json:"carbonIntensity"`
 	Timestamp       time.Time `json:"timestamp"`
+	// SignalType records whether CarbonIntensity is an average or marginal value
+	SignalType string `json:"signalType,omitempty"`
+}
+
+// Point is a single forecasted carbon intensity value
+type Point struct {
+	Timestamp       time.Time `json:"datetime"`
+	CarbonIntensity float64   `json:"carbonIntensity"`
+}
+
+// forecastResponse is the payload returned by the forecast endpoint
+type forecastResponse struct {
+	Forecast []Point `json:"forecast"`
+}
+
+// marginalResponse is the payload returned by marginal emissions endpoints
+type marginalResponse struct {
+	MarginalCarbonIntensity *float64  `json:"marginalCarbonIntensity"`
+	Timestamp               time.Time `json:"timestamp"`
+}
+
+// Capabilities describes the optional signals supported by the data provider
+type Capabilities struct {
+	// Marginal is true if the provider serves marginal operating emissions rates
+	Marginal bool
+	// Forecast is true if the provider serves carbon intensity forecasts
+	Forecast bool
 }
 
 // NewClient creates a new API client
@@ -35,17 +68,68 @@ func NewClient(cfg config.APIConfig) *Client {
 	}
 }
 
+// Capabilities reports which optional signals the configured provider supports
+func (c *Client) Capabilities() Capabilities {
+	return Capabilities{
+		Marginal: c.config.MarginalURL != "",
+		Forecast: c.config.ForecastEnabled && c.config.ForecastURL != "",
+	}
+}
+
 // GetCarbonIntensity fetches carbon intensity data with retries and circuit breaking
 func (c *Client) GetCarbonIntensity(ctx context.Context, region string) (*ElectricityData, error) {
+	if c.config.SignalType == config.SignalTypeMarginal && !c.Capabilities().Marginal {
+		return nil, ErrMarginalUnsupported
+	}
+	var data *ElectricityData
+	err := c.withRetries(ctx, func() error {
+		var err error
+		data, err = c.doRequest(ctx, region)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetForecast fetches the carbon intensity forecast for a region with retries
+func (c *Client) GetForecast(ctx context.Context, region string) ([]Point, error) {
+	var points []Point
+	err := c.withRetries(ctx, func() error {
+		var err error
+		points, err = c.doForecastRequest(ctx, region)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+// Forecast implements ForecastProvider, returning forecast points up to the given horizon
+func (c *Client) Forecast(ctx context.Context, zone string, horizon time.Duration) ([]Point, error) {
+	if !c.Capabilities().Forecast {
+		return nil, ErrForecastUnsupported
+	}
+	points, err := c.GetForecast(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return TrimForecast(points, time.Now(), horizon), nil
+}
+
+// withRetries calls fn until it succeeds, applying rate limiting and exponential backoff
+func (c *Client) withRetries(ctx context.Context, fn func() error) error {
 	var lastErr error
 	for attempt := 0; attempt <= c.config.MaxRetries; attempt++ {
 		select {
 		case <-ctx.Done():
-			return nil, fmt.Errorf("context cancelled: %v", ctx.Err())
+			return fmt.Errorf("context cancelled: %v", ctx.Err())
 		case <-c.rateLimiter.C:
-			data, err := c.doRequest(ctx, region)
+			err := fn()
 			if err == nil {
-				return data, nil
+				return nil
 			}
 			lastErr = err
 			klog.V(2).InfoS("API request failed, retrying",
@@ -61,23 +145,136 @@ func (c *Client) GetCarbonIntensity(ctx context.Context, region string) (*Electr
 			select {
 			case <-ctx.Done():
 				timer.Stop()
-				return nil, fmt.Errorf("context cancelled during backoff: %v", ctx.Err())
+				return fmt.Errorf("context cancelled during backoff: %v", ctx.Err())
 			case <-timer.C:
 				continue
 			}
 		}
 	}
-	return nil, fmt.Errorf("all retries failed: %v", lastErr)
+	return fmt.Errorf("all retries failed: %v", lastErr)
+}
+
+// GetCarbonIntensities fetches carbon intensity data for several regions in parallel
+// using a bounded pool of workers. Results and errors are keyed by region.
+func (c *Client) GetCarbonIntensities(ctx context.Context, regions []string) (map[string]*ElectricityData, map[string]error) {
+	workers := c.config.FetchWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(regions) {
+		workers = len(regions)
+	}
+
+	results := make(map[string]*ElectricityData, len(regions))
+	errs := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	work := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for region := range work {
+				data, err := c.GetCarbonIntensity(ctx, region)
+				mu.Lock()
+				if err != nil {
+					errs[region] = err
+				} else {
+					results[region] = data
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, region := range regions {
+		work <- region
+	}
+	close(work)
+	wg.Wait()
+
+	return results, errs
 }
 
 func (c *Client) doRequest(ctx context.Context, region string) (*ElectricityData, error) {
+	marginal := c.config.SignalType == config.SignalTypeMarginal
+	url := c.config.URL
+	if marginal {
+		url = c.config.MarginalURL
+	}
+
+	resp, err := c.get(ctx, url, region)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Decode response
+	var data ElectricityData
+	if marginal {
+		var mr marginalResponse
+		if err := json.NewDecoder(resp.Body).Decode(&mr); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %v", err)
+		}
+		// The average value isn't a substitute for a missing marginal one
+		if mr.MarginalCarbonIntensity == nil {
+			return nil, fmt.Errorf("response for region %s has no marginal carbon intensity", region)
+		}
+		data.CarbonIntensity = *mr.MarginalCarbonIntensity
+		data.Timestamp = mr.Timestamp
+		data.SignalType = config.SignalTypeMarginal
+	} else {
+		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %v", err)
+		}
+		data.SignalType = config.SignalTypeAverage
+	}
+
+	// Validate response data
+	if data.CarbonIntensity < 0 {
+		return nil, fmt.Errorf("invalid carbon intensity value: %f", data.CarbonIntensity)
+	}
+
+	// Set timestamp if not provided by API
+	if data.Timestamp.IsZero() {
+		data.Timestamp = time.Now()
+	}
+
+	return &data, nil
+}
+
+func (c *Client) doForecastRequest(ctx context.Context, region string) ([]Point, error) {
+	resp, err := c.get(ctx, c.config.ForecastURL, region)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var fr forecastResponse
+	if err := json.NewDecoder(resp.Body).Decode(&fr); err != nil {
+		return nil, fmt.Errorf("failed to decode forecast response: %v", err)
+	}
+
+	for _, p := range fr.Forecast {
+		if p.CarbonIntensity < 0 {
+			return nil, fmt.Errorf("invalid forecast carbon intensity value: %f", p.CarbonIntensity)
+		}
+	}
+
+	return fr.Forecast, nil
+}
+
+// get issues an authenticated GET request for a region and checks the response status.
+// The caller is responsible for closing the response body.
+func (c *Client) get(ctx context.Context, url, region string) (*http.Response, error) {
 	// Validate inputs
 	if region == "" {
 		return nil, fmt.Errorf("region cannot be empty")
 	}
 
 	// Create request with context
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.config.URL+region, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url+region, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %v", err)
 	}
@@ -92,39 +289,22 @@ func (c *Client) doRequest(ctx context.Context, region string) (*ElectricityData
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %v", err)
 	}
-	defer resp.Body.Close()
 
 	// Handle response status
 	switch resp.StatusCode {
 	case http.StatusOK:
-		// Continue processing
+		return resp, nil
 	case http.StatusTooManyRequests:
-		return nil, fmt.Errorf("rate limit exceeded")
+		err = fmt.Errorf("rate limit exceeded")
 	case http.StatusUnauthorized:
-		return nil, fmt.Errorf("invalid API key")
+		err = fmt.Errorf("invalid API key")
 	case http.StatusNotFound:
-		return nil, fmt.Errorf("region not found: %s", region)
+		err = fmt.Errorf("region not found: %s", region)
 	default:
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-	}
-
-	// Decode response
-	var data ElectricityData
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %v", err)
+		err = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
-
-	// Validate response data
-	if data.CarbonIntensity < 0 {
-		return nil, fmt.Errorf("invalid carbon intensity value: %f", data.CarbonIntensity)
-	}
-
-	// Set timestamp if not provided by API
-	if data.Timestamp.IsZero() {
-		data.Timestamp = time.Now()
-	}
-
-	return &data, nil
+	resp.Body.Close()
+	return nil, err
 }
 
 func (c *Client) getBackoffDuration(attempt int) time.Duration {
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrForecastUnsupported is returned by providers that cannot serve forecasts
+var ErrForecastUnsupported = errors.New("forecast not supported by provider")
+
+// ErrMarginalUnsupported is returned when marginal signals are requested from a
+// provider that cannot serve them
+var ErrMarginalUnsupported = errors.New("marginal signal not supported by provider")
+
+// Provider supplies the latest carbon intensity for a zone
+type Provider interface {
+	GetCarbonIntensity(ctx context.Context, zone string) (*ElectricityData, error)
+}
+
+// ForecastProvider is implemented by providers that can forecast carbon intensity
+type ForecastProvider interface {
+	Forecast(ctx context.Context, zone string, horizon time.Duration) ([]Point, error)
+}
+
+// Forecast returns the forecast for a zone over the given horizon. Providers that do
+// not implement ForecastProvider (or report ErrForecastUnsupported) degrade to a flat
+// forecast holding the latest value, so callers can be written once against forecasts.
+func Forecast(ctx context.Context, p Provider, zone string, horizon time.Duration) ([]Point, error) {
+	if fp, ok := p.(ForecastProvider); ok {
+		points, err := fp.Forecast(ctx, zone, horizon)
+		if !errors.Is(err, ErrForecastUnsupported) {
+			return points, err
+		}
+	}
+
+	data, err := p.GetCarbonIntensity(ctx, zone)
+	if err != nil {
+		return nil, err
+	}
+	return []Point{{
+		Timestamp:       data.Timestamp,
+		CarbonIntensity: data.CarbonIntensity,
+	}}, nil
+}
+
+// TrimForecast returns the points covering the interval from now until now+horizon
+func TrimForecast(points []Point, now time.Time, horizon time.Duration) []Point {
+	end := now.Add(horizon)
+	var trimmed []Point
+	for i, p := range points {
+		if p.Timestamp.After(end) {
+			break
+		}
+		// Skip points superseded by a later point at or before now, keeping
+		// the one covering the current interval
+		if i+1 < len(points) && !points[i+1].Timestamp.After(now) {
+			continue
+		}
+		trimmed = append(trimmed, p)
+	}
+	return trimmed
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/clock"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+)
+
+var (
+	_ Provider         = &Synthetic{}
+	_ ForecastProvider = &Synthetic{}
+)
+
+// Synthetic is a provider that models carbon intensity as a sinusoidal daily curve,
+// lowest at solar noon and highest twelve hours later. It is used as a fallback when
+// the real API has been unreachable for too long.
+type Synthetic struct {
+	config config.FallbackConfig
+	clock  clock.Clock
+}
+
+// NewSynthetic creates a new synthetic provider
+func NewSynthetic(cfg config.FallbackConfig, clk clock.Clock) *Synthetic {
+	return &Synthetic{
+		config: cfg,
+		clock:  clk,
+	}
+}
+
+// At returns the synthetic carbon intensity at the given time
+func (s *Synthetic) At(t time.Time) float64 {
+	mid := (s.config.MinIntensity + s.config.MaxIntensity) / 2
+	amplitude := (s.config.MaxIntensity - s.config.MinIntensity) / 2
+
+	// Phase within the day relative to solar noon, in radians
+	sinceMidnight := t.UTC().Sub(t.UTC().Truncate(24 * time.Hour))
+	phase := 2 * math.Pi * (sinceMidnight - s.config.SolarNoonOffset).Hours() / 24
+
+	return mid - amplitude*math.Cos(phase)
+}
+
+// GetCarbonIntensity implements Provider
+func (s *Synthetic) GetCarbonIntensity(ctx context.Context, zone string) (*ElectricityData, error) {
+	now := s.clock.Now()
+	return &ElectricityData{
+		CarbonIntensity: s.At(now),
+		Timestamp:       now,
+		SignalType:      "synthetic",
+	}, nil
+}
+
+// Forecast implements ForecastProvider with hourly points up to the given horizon
+func (s *Synthetic) Forecast(ctx context.Context, zone string, horizon time.Duration) ([]Point, error) {
+	start := s.clock.Now().Truncate(time.Hour)
+	var points []Point
+	for t := start; !t.After(start.Add(horizon)); t = t.Add(time.Hour) {
+		points = append(points, Point{
+			Timestamp:       t,
+			CarbonIntensity: s.At(t),
+		})
+	}
+	return points, nil
+}
@@ -0,0 +1,82 @@
+package computegardener
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+)
+
+// backoffEntry is the last carbon or price verdict for a delayed pod, reused until
+// the data it was based on changes
+type backoffEntry struct {
+	until   time.Time
+	data    *api.ElectricityData
+	message string
+}
+
+// backOff records the verdict for a pod delayed on carbon intensity or price until
+// its projected start or at most RequeueBackoff from now
+func (cs *CarbonAwareScheduler) backOff(pod *v1.Pod, start time.Time, status *framework.Status) {
+	if cs.config.Scheduling.RequeueBackoff <= 0 {
+		return
+	}
+
+	until := cs.clock.Now().Add(cs.config.Scheduling.RequeueBackoff)
+	if start.Before(until) {
+		until = start
+	}
+	data, _ := cs.cache.Get(cs.podZone(pod))
+	cs.backoff.Store(pod.UID, &backoffEntry{until: until, data: data, message: status.Message()})
+}
+
+// checkBackoff returns the recorded verdict for a pod while it is backing off. The
+// backoff ends early when the cached data for the pod's zone is refreshed.
+func (cs *CarbonAwareScheduler) checkBackoff(pod *v1.Pod) *framework.Status {
+	val, ok := cs.backoff.Load(pod.UID)
+	if !ok {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	entry := val.(*backoffEntry)
+	data, _ := cs.cache.Get(cs.podZone(pod))
+	if !cs.clock.Now().Before(entry.until) || data != entry.data {
+		cs.backoff.Delete(pod.UID)
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	cs.countAttempt("backoff")
+	return framework.NewStatus(framework.Unschedulable, entry.message)
+}
+
+// PreEnqueue implements the PreEnqueue interface. Pods backing off stay in the
+// unschedulable pool instead of running a scheduling cycle only to be rejected
+// in PreFilter. The queue re-runs PreEnqueue on cluster events and periodically.
+func (cs *CarbonAwareScheduler) PreEnqueue(ctx context.Context, pod *v1.Pod) *framework.Status {
+	if cs.enforcementMode(pod) == config.EnforcementModeAudit || isNominated(pod) || cs.gangReleased(pod) {
+		return framework.NewStatus(framework.Success, "")
+	}
+	return cs.checkBackoff(pod)
+}
+
+// annotationsChanged reports whether a pod's annotations changed other than the
+// projected start written by the scheduler itself
+func annotationsChanged(oldPod, newPod *v1.Pod) bool {
+	for k, v := range newPod.Annotations {
+		if k == projectedStartAnnotation {
+			continue
+		}
+		if old, ok := oldPod.Annotations[k]; !ok || old != v {
+			return true
+		}
+	}
+	for k := range oldPod.Annotations {
+		if _, ok := newPod.Annotations[k]; !ok && k != projectedStartAnnotation {
+			return true
+		}
+	}
+	return false
+}
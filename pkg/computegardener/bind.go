@@ -0,0 +1,72 @@
+package computegardener
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// deferBindAnnotation opts a pod into deferred binding
+const deferBindAnnotation = "carbon-aware-scheduler.kubernetes.io/defer-bind"
+
+// defersBind reports whether a delayed pod is placed now and has its bind deferred
+func (cs *CarbonAwareScheduler) defersBind(pod *v1.Pod) bool {
+	return cs.config.Scheduling.DeferBindMaxDelay > 0 && pod.Annotations[deferBindAnnotation] == "true"
+}
+
+// Bind implements the Bind interface. Pods opting into deferred binding are placed
+// on a node as soon as they are scheduled, with volumes bound in its topology, but
+// their bind is deferred until their constraints clear and they are paced within
+// the admission budget, their scheduling deadline, or the deferred bind cap. The
+// bind itself is left to the next bind plugin, so the plugin must be enabled before
+// DefaultBinder.
+func (cs *CarbonAwareScheduler) Bind(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) *framework.Status {
+	s, ok := readAdmissionState(state)
+	if !ok || !s.deferBind {
+		return framework.NewStatus(framework.Skip)
+	}
+
+	deadline := cs.clock.Now().Add(cs.config.Scheduling.DeferBindMaxDelay)
+	if scheduling := cs.schedulingDeadline(pod); scheduling.Before(deadline) {
+		deadline = scheduling
+	}
+
+	cs.countAttempt("deferred_bind")
+	klog.V(2).InfoS("Deferring bind", "pod", klog.KObj(pod), "node", nodeName, "deadline", deadline)
+	if err := cs.waitForWindow(ctx, pod, nodeName, deadline, s); err != nil {
+		return framework.AsStatus(err)
+	}
+	klog.V(2).InfoS("Binding deferred pod", "pod", klog.KObj(pod), "node", nodeName)
+	cs.releaseGang(pod, podsToActivate(state))
+	return framework.NewStatus(framework.Skip)
+}
+
+// waitForWindow blocks until the constraints of a pod placed on a node clear and
+// its admission budget is available, or the deadline passes. The budget is taken
+// into s, and returned if the pod is unreserved.
+func (cs *CarbonAwareScheduler) waitForWindow(ctx context.Context, pod *v1.Pod, nodeName string, deadline time.Time, s *admissionState) error {
+	ticker := time.NewTicker(permitPollInterval)
+	defer ticker.Stop()
+
+	for cs.clock.Now().Before(deadline) {
+		// Greener zones elsewhere don't clear the constraints of the pod's own node
+		result, status := cs.checkAdmission(ctx, pod)
+		if status.IsSuccess() && (result == nil || result.NodeNames.Has(nodeName)) && cs.takeWaitingBudget(pod, s, false) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-cs.stopCh:
+			return errors.New("scheduler stopped while deferring bind")
+		case <-ticker.C:
+		}
+	}
+	cs.takeWaitingBudget(pod, s, true)
+	return nil
+}
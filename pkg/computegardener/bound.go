@@ -0,0 +1,107 @@
+package computegardener
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const (
+	// initialIntensityAnnotation is the carbon intensity when a pod was submitted, as
+	// stamped by the admission webhook, or else when it was first delayed
+	initialIntensityAnnotation = "carbon-aware-scheduler.kubernetes.io/initial-intensity"
+	// boundIntensityAnnotation is the carbon intensity of a pod's zone when it was bound
+	boundIntensityAnnotation = "carbon-aware-scheduler.kubernetes.io/bound-intensity"
+	// submittedAtAnnotation is the RFC3339 time a pod was submitted, as stamped by the
+	// admission webhook
+	submittedAtAnnotation = "carbon-aware-scheduler.kubernetes.io/submitted-at"
+)
+
+// recordInitialIntensity remembers the intensity a pod was first delayed at, to be
+// compared with the intensity it is eventually bound at
+func (cs *CarbonAwareScheduler) recordInitialIntensity(pod *v1.Pod, intensity float64) {
+	cs.initialIntensity.LoadOrStore(pod.UID, intensity)
+}
+
+// recordBoundIntensity annotates a bound pod with the carbon intensity of its node's
+// zone, and the intensity it was first delayed at, and emits an Event. Pods stamped
+// by the admission webhook are compared with the intensity they were submitted at
+// instead. The savings from the delay are accounted for here, once per pod.
+func (cs *CarbonAwareScheduler) recordBoundIntensity(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
+	zone := cs.nodeZone(nodeName)
+	var intensity float64
+	if data, ok := cs.cache.Get(zone); ok {
+		intensity = cs.effectiveIntensity(zone, data)
+	} else if s, ok := readAdmissionState(state); ok && s.zone == zone {
+		// Fall back to the intensity the pod was reserved under
+		intensity = s.intensity
+	} else {
+		klog.V(2).InfoS("No carbon intensity to record for bound pod", "pod", klog.KObj(pod), "zone", zone)
+		return
+	}
+
+	annotations := map[string]string{boundIntensityAnnotation: fmt.Sprintf("%.2f", intensity)}
+	msg := fmt.Sprintf("Bound to %s at carbon intensity %.2f in zone %s", nodeName, intensity, zone)
+	value, delayed := cs.initialIntensity.LoadAndDelete(pod.UID)
+	initial, ok := cs.submittedIntensity(pod)
+	switch {
+	case ok:
+		msg += fmt.Sprintf(", submitted at %.2f", initial)
+	case delayed:
+		initial, ok = value.(float64), true
+		annotations[initialIntensityAnnotation] = fmt.Sprintf("%.2f", initial)
+		msg += fmt.Sprintf(", delayed at %.2f", initial)
+	}
+
+	if ok {
+		delta := intensity - initial
+		// The efficiency gauge has one series per pod, so is only set in debug mode
+		if cs.config.Observability.PodMetricsEnabled {
+			SchedulingEfficiencyMetrics.WithLabelValues("carbon_intensity_delta", pod.Name).Set(delta)
+		}
+		if delta < 0 { // negative delta means improvement
+			EstimatedSavings.WithLabelValues("carbon", "grams_co2").Add(-delta)
+		}
+	}
+
+	cs.handle.EventRecorder().Eventf(pod, nil, v1.EventTypeNormal, "CarbonAwareBound", "Binding", "%s", msg)
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"annotations": annotations},
+	})
+	if err != nil {
+		klog.ErrorS(err, "Failed to encode bound intensity annotations", "pod", klog.KObj(pod))
+		return
+	}
+	_, err = cs.handle.ClientSet().CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name,
+		types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		klog.ErrorS(err, "Failed to annotate bound carbon intensity", "pod", klog.KObj(pod))
+	}
+}
+
+// submittedIntensity returns the intensity a pod was stamped with at admission. The
+// stamp is only trusted if configured, since submitters can set it themselves when the
+// stamp webhook isn't running and inflate the estimated savings.
+func (cs *CarbonAwareScheduler) submittedIntensity(pod *v1.Pod) (float64, bool) {
+	if !cs.config.Observability.StampsTrusted {
+		return 0, false
+	}
+	val, ok := pod.Annotations[initialIntensityAnnotation]
+	if !ok {
+		return 0, false
+	}
+	intensity, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		klog.V(2).InfoS("Ignoring invalid initial intensity annotation", "pod", klog.KObj(pod), "value", val)
+		return 0, false
+	}
+	return intensity, true
+}
@@ -0,0 +1,101 @@
+package computegardener
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+)
+
+// checkBudget holds new pods of a namespace whose carbon budget is exhausted. Pods
+// opted out of carbon-aware scheduling or released by an operator are exempt. Budgets
+// with the audit action only record that they are exhausted, and those with the
+// demote action gate pods under a stricter threshold instead, see budgetFactor.
+func (cs *CarbonAwareScheduler) checkBudget(pod *v1.Pod) *framework.Status {
+	if cs.budgets == nil || cs.isOptedOut(pod) || cs.isReleased(pod) {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	exhausted, ok := cs.budgets.Exhausted(pod.Namespace, cs.accountingValue(pod), cs.clock.Now())
+	if !ok {
+		return framework.NewStatus(framework.Success, "")
+	}
+	msg := fmt.Sprintf("Carbon budget %s exhausted (%.0f of %.0f gCO2e)", exhausted.Name, exhausted.Used, exhausted.Limit)
+	switch exhausted.Action {
+	case v1alpha1.BudgetActionAudit:
+		cs.countAttempt("budget_audit")
+		klog.V(2).InfoS("Admitting pod over carbon budget in audit mode", "pod", klog.KObj(pod), "reason", msg)
+		return framework.NewStatus(framework.Success, "")
+	case v1alpha1.BudgetActionDemote:
+		klog.V(4).InfoS("Demoting pod over carbon budget", "pod", klog.KObj(pod), "reason", msg,
+			"thresholdFactor", exhausted.ThresholdFactor)
+		return framework.NewStatus(framework.Success, "")
+	}
+	cs.countAttempt("budget_exhausted")
+	return framework.NewStatus(framework.Unschedulable, msg)
+}
+
+// budgetFactor returns the factor the carbon intensity threshold of a pod is tightened
+// by while its namespace is over a demoting budget, or 1
+func (cs *CarbonAwareScheduler) budgetFactor(pod *v1.Pod) float64 {
+	if cs.budgets == nil || cs.isOptedOut(pod) || cs.isReleased(pod) {
+		return 1
+	}
+	exhausted, ok := cs.budgets.Exhausted(pod.Namespace, cs.accountingValue(pod), cs.clock.Now())
+	if !ok || exhausted.Action != v1alpha1.BudgetActionDemote {
+		return 1
+	}
+	return exhausted.ThresholdFactor
+}
+
+// budgetWorker reports the emissions accrued against carbon budgets in their status
+func (cs *CarbonAwareScheduler) budgetWorker(ctx context.Context) {
+	ticker := time.NewTicker(cs.config.Policy.BudgetSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cs.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cs.budgets.SyncStatus(ctx, cs.clock.Now())
+		}
+	}
+}
+
+// accountingValue returns the value of the accounting label of a pod, taken from its
+// namespace if the pod doesn't carry it
+func (cs *CarbonAwareScheduler) accountingValue(pod *v1.Pod) string {
+	key := cs.config.Policy.AccountingLabel
+	if key == "" {
+		return ""
+	}
+	if val, ok := pod.Labels[key]; ok {
+		return val
+	}
+	if cs.namespaceLister == nil {
+		return ""
+	}
+	ns, err := cs.namespaceLister.Get(pod.Namespace)
+	if err != nil {
+		return ""
+	}
+	return ns.Labels[key]
+}
+
+// accrueEmissions attributes the emissions of a completed pod, in gCO2e, to its
+// namespace and accounting label value
+func (cs *CarbonAwareScheduler) accrueEmissions(pod *v1.Pod, grams float64) {
+	value := cs.accountingValue(pod)
+	if value != "" {
+		LabelCarbonEmissions.WithLabelValues(cs.config.Policy.AccountingLabel, value).Add(grams)
+	}
+	cs.budgets.Accrue(pod.Namespace, value, grams, cs.clock.Now())
+}
@@ -10,12 +10,18 @@ import (
 
 // Cache provides thread-safe caching of electricity data with TTL
 type Cache struct {
-	data    map[string]*cacheEntry
-	mutex   sync.RWMutex
-	ttl     time.Duration
-	maxAge  time.Duration
-	stopCh  chan struct{}
-	metrics *metrics
+	data      map[string]*cacheEntry
+	forecasts map[string]*forecastEntry
+	mutex     sync.RWMutex
+	ttl       time.Duration
+	maxAge    time.Duration
+	stopCh    chan struct{}
+	metrics   *metrics
+}
+
+type forecastEntry struct {
+	points    []api.Point
+	timestamp time.Time
 }
 
 type cacheEntry struct {
@@ -33,7 +39,8 @@ type metrics struct {
 // New creates a new cache instance
 func New(ttl time.Duration, maxAge time.Duration) *Cache {
 	c := &Cache{
-		data: make(map[string]*cacheEntry),
+		data:      make(map[string]*cacheEntry),
+		forecasts: make(map[string]*forecastEntry),
 		// For cache freshness purposes at get time.
 		ttl: ttl,
 		// Age to clean-up unaccessed items.
@@ -91,6 +98,65 @@ func (c *Cache) Set(region string, data *api.ElectricityData) {
 		"timestamp", data.Timestamp)
 }
 
+// SetForecast stores the forecast for a region, replacing any previous forecast
+func (c *Cache) SetForecast(region string, points []api.Point) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.forecasts[region] = &forecastEntry{
+		points:    points,
+		timestamp: time.Now(),
+	}
+
+	klog.V(4).InfoS("Cached forecast data",
+		"region", region,
+		"points", len(points))
+}
+
+// GetForecast returns the forecast points for a region from now up to the given horizon
+func (c *Cache) GetForecast(region string, horizon time.Duration) ([]api.Point, bool) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	entry, exists := c.forecasts[region]
+	if !exists || time.Since(entry.timestamp) > c.maxAge {
+		return nil, false
+	}
+
+	points := api.TrimForecast(entry.points, time.Now(), horizon)
+	return points, len(points) > 0
+}
+
+// StaleForecasts returns the subset of regions that have no fresh forecast in the cache
+func (c *Cache) StaleForecasts(regions []string) []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var stale []string
+	for _, region := range regions {
+		entry, exists := c.forecasts[region]
+		if !exists || time.Since(entry.timestamp) > c.ttl {
+			stale = append(stale, region)
+		}
+	}
+	return stale
+}
+
+// Stale returns the subset of regions that have no fresh entry in the cache
+func (c *Cache) Stale(regions []string) []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	var stale []string
+	for _, region := range regions {
+		entry, exists := c.data[region]
+		if !exists || time.Since(entry.timestamp) > c.ttl {
+			stale = append(stale, region)
+		}
+	}
+	return stale
+}
+
 // GetMetrics returns cache performance metrics
 func (c *Cache) GetMetrics() (hits, misses int64) {
 	c.metrics.mutex.RLock()
@@ -140,6 +206,11 @@ func (c *Cache) removeExpired() {
 				"hits", entry.hits)
 		}
 	}
+	for region, entry := range c.forecasts {
+		if now.Sub(entry.timestamp) > c.maxAge {
+			delete(c.forecasts, region)
+		}
+	}
 }
 
 // Close stops the cleanup goroutine
@@ -153,6 +224,7 @@ func (c *Cache) Clear() {
 	defer c.mutex.Unlock()
 
 	c.data = make(map[string]*cacheEntry)
+	c.forecasts = make(map[string]*forecastEntry)
 	klog.V(4).Info("Cleared cache")
 }
 
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+)
+
+func TestGetForecast(t *testing.T) {
+	c := New(time.Minute, time.Hour)
+	defer c.Close()
+
+	now := time.Now().Truncate(time.Hour)
+	var points []api.Point
+	for i := -2; i < 24; i++ {
+		points = append(points, api.Point{
+			Timestamp:       now.Add(time.Duration(i) * time.Hour),
+			CarbonIntensity: float64(100 + i),
+		})
+	}
+	c.SetForecast("test-region", points)
+
+	got, ok := c.GetForecast("test-region", 3*time.Hour)
+	if !ok {
+		t.Fatalf("GetForecast() found = false, want true")
+	}
+	// Current interval plus the next three hours
+	if len(got) != 4 {
+		t.Fatalf("GetForecast() returned %d points, want 4", len(got))
+	}
+	if !got[0].Timestamp.Equal(now) {
+		t.Errorf("GetForecast() first point = %v, want %v", got[0].Timestamp, now)
+	}
+
+	if _, ok := c.GetForecast("unknown-region", time.Hour); ok {
+		t.Errorf("GetForecast() found = true for unknown region, want false")
+	}
+
+	if stale := c.StaleForecasts([]string{"test-region", "unknown-region"}); len(stale) != 1 || stale[0] != "unknown-region" {
+		t.Errorf("StaleForecasts() = %v, want [unknown-region]", stale)
+	}
+}
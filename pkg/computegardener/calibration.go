@@ -0,0 +1,51 @@
+package computegardener
+
+// calibrationWeight is the weight of each new wall power reading in the smoothed
+// ratio of measured to modeled power
+const calibrationWeight = 0.2
+
+// Ratios outside these bounds point at a misconfigured model or meter, and are clamped
+const (
+	minCalibration = 0.25
+	maxCalibration = 4.0
+)
+
+// calibrateNodePower reconciles a wall power reading of a node, from its BMC or PDU
+// outlets, with its modeled power, smoothing the ratio between them to calibrate
+// estimates once readings are unavailable
+func (cs *CarbonAwareScheduler) calibrateNodePower(nodeName string, measured float64) {
+	if cs.nodeCalibration == nil {
+		return
+	}
+	modeled := cs.modelNodePower(nodeName)
+	if modeled <= 0 || measured <= 0 {
+		return
+	}
+	ratio := min(max(measured/modeled, minCalibration), maxCalibration)
+	if prev, ok := cs.nodeCalibration.Load(nodeName); ok {
+		ratio = prev.(float64) + calibrationWeight*(ratio-prev.(float64))
+	}
+	cs.nodeCalibration.Store(nodeName, ratio)
+	NodePowerCalibration.WithLabelValues(nodeName).Set(ratio)
+}
+
+// calibration returns the ratio of measured to modeled power of a node, or 1 if its
+// power has never been measured at the wall
+func (cs *CarbonAwareScheduler) calibration(nodeName string) float64 {
+	if cs.nodeCalibration == nil {
+		return 1
+	}
+	if ratio, ok := cs.nodeCalibration.Load(nodeName); ok {
+		return ratio.(float64)
+	}
+	return 1
+}
+
+// forgetCalibration drops the calibration of a deleted node
+func (cs *CarbonAwareScheduler) forgetCalibration(nodeName string) {
+	if cs.nodeCalibration == nil {
+		return
+	}
+	cs.nodeCalibration.Delete(nodeName)
+	NodePowerCalibration.DeleteLabelValues(nodeName)
+}
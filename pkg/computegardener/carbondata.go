@@ -0,0 +1,79 @@
+package computegardener
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// CarbonDataStateKey is the CycleState key the carbon data a pod is scheduled under
+// is published at, so other plugins can use it without calling the carbon APIs
+const CarbonDataStateKey framework.StateKey = Name + "/carbon-data"
+
+// ZoneData is the carbon data of a grid zone
+type ZoneData struct {
+	// Intensity is the carbon intensity compared with thresholds, smoothed if
+	// smoothing is enabled
+	Intensity float64
+	// Threshold is the base carbon intensity threshold of the zone
+	Threshold float64
+	// Timestamp is when the intensity was measured
+	Timestamp time.Time
+}
+
+// CarbonData is the carbon data published for a scheduling cycle. It is shared
+// between plugins and must not be modified.
+type CarbonData struct {
+	// Zone is the grid zone of the pod
+	Zone string
+	// Threshold is the carbon intensity threshold that applies to the pod, 0 if the
+	// pod's threshold annotation is invalid
+	Threshold float64
+	// Zones holds the cached data of the pod's zone and the zones of cluster nodes
+	Zones map[string]ZoneData
+}
+
+// Clone implements framework.StateData. The data is never modified once written.
+func (d *CarbonData) Clone() framework.StateData {
+	return d
+}
+
+// ReadCarbonData returns the carbon data published for a scheduling cycle
+func ReadCarbonData(state *framework.CycleState) (*CarbonData, bool) {
+	if state == nil {
+		return nil, false
+	}
+	data, err := state.Read(CarbonDataStateKey)
+	if err != nil {
+		return nil, false
+	}
+	d, ok := data.(*CarbonData)
+	return d, ok
+}
+
+// writeCarbonData publishes the cached carbon data of the zones a pod may be placed
+// in. Only cached data is published, so it costs no API calls.
+func (cs *CarbonAwareScheduler) writeCarbonData(state *framework.CycleState, pod *v1.Pod) {
+	if state == nil {
+		return
+	}
+
+	d := &CarbonData{Zone: cs.podZone(pod), Zones: make(map[string]ZoneData)}
+	if threshold, err := cs.carbonThreshold(pod); err == nil {
+		d.Threshold = threshold
+	}
+	for _, zone := range append(cs.trackedZones(), d.Zone) {
+		if _, ok := d.Zones[zone]; ok {
+			continue
+		}
+		if data, ok := cs.cache.Get(zone); ok {
+			d.Zones[zone] = ZoneData{
+				Intensity: cs.effectiveIntensity(zone, data),
+				Threshold: cs.baseThreshold(zone),
+				Timestamp: data.Timestamp,
+			}
+		}
+	}
+	state.Write(CarbonDataStateKey, d)
+}
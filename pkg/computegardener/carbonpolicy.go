@@ -0,0 +1,153 @@
+package computegardener
+
+import (
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/policy"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/pricing/tou"
+)
+
+// policyStateKey is the CycleState key the policy resolved for a pod is stored under
+const policyStateKey framework.StateKey = Name + "/policy"
+
+// policyState is the policy resolved for a pod in a scheduling cycle
+type policyState struct {
+	policy policy.Policy
+	ok     bool
+}
+
+// Clone implements framework.StateData. The state is never modified once written.
+func (s *policyState) Clone() framework.StateData {
+	return s
+}
+
+// writePolicyState resolves the policy of a pod once for its scheduling cycle. The
+// policy settings of the pod are read from it until the pod's next cycle.
+//
+// Settings of a pod are taken from the first of these that sets them:
+//
+//  1. the pod's annotations
+//  2. the WorkloadClass named by its label, for the threshold, delay, aging and exemption
+//  3. the annotations of its namespace, or its label for the enforcement mode
+//  4. the CarbonPolicies of its namespace selecting it
+//  5. the ClusterCarbonPolicies selecting it
+//  6. the plugin's configuration
+//
+// A pod naming a policy with the compute-gardener.dev/policy annotation takes steps 4
+// and 5 from that policy alone, unless a policy selecting it enforces its inclusion,
+// in which case the named policy is ignored.
+//
+// Each setting is resolved independently, so a partially specified policy only
+// overrides the settings it sets. Among several policies of the same kind, the oldest
+// takes precedence.
+func (cs *CarbonAwareScheduler) writePolicyState(state *framework.CycleState, pod *v1.Pod) {
+	if cs.policies == nil {
+		return
+	}
+	p, ok := cs.policies.Resolve(pod)
+	s := &policyState{policy: p, ok: ok}
+	if state != nil {
+		state.Write(policyStateKey, s)
+	}
+	cs.resolvedPolicies.Store(pod.UID, s)
+}
+
+// podPolicy returns the policy resolved for a pod in its last scheduling cycle, or
+// resolves it for pods that haven't had one
+func (cs *CarbonAwareScheduler) podPolicy(pod *v1.Pod) (policy.Policy, bool) {
+	if s, ok := cs.resolvedPolicies.Load(pod.UID); ok {
+		s := s.(*policyState)
+		return s.policy, s.ok
+	}
+	return cs.policies.Resolve(pod)
+}
+
+// policyThreshold returns the carbon intensity threshold set by the policies of a pod
+func (cs *CarbonAwareScheduler) policyThreshold(pod *v1.Pod) (float64, bool) {
+	p, ok := cs.podPolicy(pod)
+	if !ok || p.Spec.CarbonIntensityThreshold == nil {
+		return 0, false
+	}
+	return *p.Spec.CarbonIntensityThreshold, true
+}
+
+// policyMaxDelay returns the maximum scheduling delay set by the policies of a pod
+func (cs *CarbonAwareScheduler) policyMaxDelay(pod *v1.Pod) (time.Duration, bool) {
+	p, ok := cs.podPolicy(pod)
+	if !ok || p.Spec.MaxSchedulingDelay == nil {
+		return 0, false
+	}
+	return p.Spec.MaxSchedulingDelay.Duration, true
+}
+
+// policyEnforcementMode returns the enforcement mode set by the policies of a pod
+func (cs *CarbonAwareScheduler) policyEnforcementMode(pod *v1.Pod) (string, bool) {
+	p, ok := cs.podPolicy(pod)
+	if !ok {
+		return "", false
+	}
+	switch p.Spec.EnforcementMode {
+	case config.EnforcementModeEnforce, config.EnforcementModeAudit:
+		return p.Spec.EnforcementMode, true
+	}
+	return "", false
+}
+
+// inclusionEnforced reports whether a policy of a pod keeps it under carbon-aware
+// scheduling regardless of its opt-outs
+func (cs *CarbonAwareScheduler) inclusionEnforced(pod *v1.Pod) bool {
+	p, ok := cs.podPolicy(pod)
+	return ok && p.EnforcedBy != ""
+}
+
+// optOutAllowedAnnotation is stamped by the admission webhook on pods created by a user
+// allowed to opt them out under the skip restrictions of their policies
+const optOutAllowedAnnotation = "carbon-aware-scheduler.kubernetes.io/opt-out-allowed"
+
+// podOptedOut reports whether a pod opts out of carbon-aware scheduling with the skip
+// or price skip annotation or the off mode. Under a policy restricting opt-outs, they
+// only apply to pods whose creator the admission webhook approved.
+func (cs *CarbonAwareScheduler) podOptedOut(pod *v1.Pod) bool {
+	if pod.Annotations[skipAnnotation] != "true" && podStrictness(pod) != strictnessOff &&
+		pod.Annotations["price-aware-scheduler.kubernetes.io/skip"] != "true" {
+		return false
+	}
+	if p, ok := cs.podPolicy(pod); ok && len(p.SkipRestrictions) > 0 {
+		return pod.Annotations[optOutAllowedAnnotation] == "true"
+	}
+	return true
+}
+
+// podOverride returns an annotation a pod overrides its gating with, which is ignored
+// for pods whose inclusion is enforced
+func (cs *CarbonAwareScheduler) podOverride(pod *v1.Pod, key string) (string, bool) {
+	if cs.inclusionEnforced(pod) {
+		return "", false
+	}
+	val, ok := pod.Annotations[key]
+	return val, ok
+}
+
+// checkPolicyPeak delays pods during the peak schedules of their policies
+func (cs *CarbonAwareScheduler) checkPolicyPeak(pod *v1.Pod) *framework.Status {
+	p, ok := cs.podPolicy(pod)
+	if !ok {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	now := cs.clock.Now()
+	for _, peak := range p.Spec.PeakSchedules {
+		schedule := config.Schedule{DayOfWeek: peak.DayOfWeek, StartTime: peak.StartTime, EndTime: peak.EndTime}
+		if tou.InSchedule(schedule, now) {
+			cs.countAttempt("policy_peak")
+			return framework.NewStatus(framework.Unschedulable,
+				fmt.Sprintf("Peak hours of carbon policy %s", p.Sources["peakSchedules"]))
+		}
+	}
+	return framework.NewStatus(framework.Success, "")
+}
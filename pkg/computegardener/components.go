@@ -0,0 +1,45 @@
+package computegardener
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// bytesPerGB converts memory usage to the GB that memory power is configured per
+const bytesPerGB = 1e9
+
+// memoryPower estimates the power of the memory in use on a node
+func (cs *CarbonAwareScheduler) memoryPower(memoryGB float64) float64 {
+	return cs.config.Power.MemoryWattsPerGB * memoryGB
+}
+
+// devicePower estimates the power of the disks and NICs of a node, which draw a
+// constant power regardless of CPU usage
+func (cs *CarbonAwareScheduler) devicePower(nodeName string) float64 {
+	disks, nics := cs.config.Power.DefaultDisks, cs.config.Power.DefaultNICs
+	if nodePower, ok := cs.nodeProfile(nodeName); ok {
+		if nodePower.Disks > 0 {
+			disks = nodePower.Disks
+		}
+		if nodePower.NICs > 0 {
+			nics = nodePower.NICs
+		}
+	}
+	return float64(disks)*cs.config.Power.DiskWatts + float64(nics)*cs.config.Power.NICWatts
+}
+
+// getNodeMemoryGB returns the memory in use on a node in GB, or 0 when memory power
+// is disabled
+func (cs *CarbonAwareScheduler) getNodeMemoryGB(nodeName string) float64 {
+	if cs.config.Power.MemoryWattsPerGB <= 0 {
+		return 0
+	}
+	metrics, err := cs.metricsClient.NodeMetricses().Get(context.Background(), nodeName, metav1.GetOptions{})
+	if err != nil {
+		klog.ErrorS(err, "Failed to get node metrics", "node", nodeName)
+		return 0
+	}
+	return metrics.Usage.Memory().AsApproximateFloat64() / bytesPerGB
+}
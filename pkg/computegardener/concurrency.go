@@ -0,0 +1,98 @@
+package computegardener
+
+import (
+	"fmt"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// concurrencySlots tracks the admitted pods running at once. A slot is taken when
+// a pod is reserved, and returned when the pod is unreserved, finishes or is
+// deleted. Slots are tracked in memory, so pods already running when the scheduler
+// starts don't take one.
+type concurrencySlots struct {
+	mu   sync.Mutex
+	pods map[types.UID]struct{}
+}
+
+func newConcurrencySlots() *concurrencySlots {
+	return &concurrencySlots{
+		pods: make(map[types.UID]struct{}),
+	}
+}
+
+// available reports whether fewer than limit slots are taken
+func (s *concurrencySlots) available(limit int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.pods) < limit
+}
+
+// take assigns a slot to a pod. Pods hold at most one slot.
+func (s *concurrencySlots) take(uid types.UID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pods[uid] = struct{}{}
+	ConcurrentPods.Set(float64(len(s.pods)))
+}
+
+// release returns the slot of a pod, if it holds one
+func (s *concurrencySlots) release(uid types.UID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pods, uid)
+	ConcurrentPods.Set(float64(len(s.pods)))
+}
+
+// checkConcurrency admits pods only while a concurrency slot is free. The slot is
+// taken when the pod is reserved.
+func (cs *CarbonAwareScheduler) checkConcurrency(pod *v1.Pod) *framework.Status {
+	if cs.slots == nil {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	if limit := cs.concurrencyLimit(); limit > 0 && !cs.slots.available(limit) {
+		cs.countAttempt("concurrency_limited")
+		return framework.NewStatus(framework.Unschedulable,
+			fmt.Sprintf("Maximum of %d concurrent pods reached", limit))
+	}
+	return framework.NewStatus(framework.Success, "")
+}
+
+// concurrencyLimit returns the concurrency limit in effect, 0 if unlimited. The
+// weekend limit replaces the base limit on weekends if set, and the conservation
+// mode limit applies while a grid alert is active, if it is lower.
+func (cs *CarbonAwareScheduler) concurrencyLimit() int {
+	limit := cs.config.Scheduling.MaxConcurrentPods
+	if cs.config.Scheduling.WeekendMaxConcurrentPods > 0 && isWeekend(cs.clock.Now()) {
+		limit = cs.config.Scheduling.WeekendMaxConcurrentPods
+	}
+	if conservation := cs.config.GridAlert.MaxConcurrentPods; conservation > 0 {
+		if _, ok := cs.conservationMode(); ok && (limit == 0 || conservation < limit) {
+			limit = conservation
+		}
+	}
+	return limit
+}
+
+// releaseSlot returns the concurrency slot of a pod that finished or was deleted
+func (cs *CarbonAwareScheduler) releaseSlot(pod *v1.Pod) {
+	if cs.slots != nil {
+		cs.slots.release(pod.UID)
+	}
+}
+
+// isNominated reports whether preemption nominated a node for a pod. The pod was
+// admitted when its victims were chosen and they are already being evicted, so it
+// is not delayed again, and is not counted against the concurrency limit.
+func isNominated(pod *v1.Pod) bool {
+	return pod.Status.NominatedNodeName != ""
+}
+
+// isFinished reports whether a pod has terminated
+func isFinished(pod *v1.Pod) bool {
+	return pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed
+}
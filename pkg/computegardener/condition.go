@@ -0,0 +1,104 @@
+package computegardener
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// delayedCondition is the pod condition reporting whether a pod is held by
+	// carbon-aware scheduling, so delays can be told apart from capacity problems
+	delayedCondition v1.PodConditionType = "CarbonAwareDelayed"
+
+	// reasonCarbonDelayed is the reason of pods held on carbon intensity or other
+	// grid and facility constraints
+	reasonCarbonDelayed = "CarbonDelayed"
+	// reasonPriceDelayed is the reason of pods held on the electricity price
+	reasonPriceDelayed = "PriceDelayed"
+	// reasonAdmitted is the reason once a previously delayed pod has been bound
+	reasonAdmitted = "Admitted"
+)
+
+// delayConditionMessages are the messages of the delayed condition by reason. They
+// carry no live values, so the condition only changes with the pod's state; the
+// details are in the pod's status message and Events.
+var delayConditionMessages = map[string]string{
+	reasonCarbonDelayed: "Held until carbon intensity and grid constraints allow scheduling",
+	reasonPriceDelayed:  "Held until the electricity price drops below its threshold",
+}
+
+// delayConditionReason returns the condition reason for a PreFilter delay message
+func delayConditionReason(message string) string {
+	if strings.HasPrefix(message, "Current electricity rate") {
+		return reasonPriceDelayed
+	}
+	return reasonCarbonDelayed
+}
+
+// delayedConditionUpdate returns the delayed condition a pod should have, and
+// whether it differs from the pod's current one. Pods that were never delayed do
+// not get the condition when they are admitted.
+func delayedConditionUpdate(pod *v1.Pod, status v1.ConditionStatus, reason, message string, now metav1.Time) (v1.PodCondition, bool) {
+	cond := v1.PodCondition{
+		Type:               delayedCondition,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type != delayedCondition {
+			continue
+		}
+		if c.Status == status && c.Reason == reason && c.Message == message {
+			return c, false
+		}
+		if c.Status == status {
+			cond.LastTransitionTime = c.LastTransitionTime
+		}
+		return cond, true
+	}
+	return cond, status == v1.ConditionTrue
+}
+
+// setDelayedCondition queues an update of the delayed condition of a pod if it
+// changed. The delay status worker patches it, so the scheduling cycle makes no API
+// calls.
+func (cs *CarbonAwareScheduler) setDelayedCondition(pod *v1.Pod, status v1.ConditionStatus, reason, message string) {
+	cond, changed := delayedConditionUpdate(pod, status, reason, message, metav1.NewTime(cs.clock.Now()))
+	if !changed {
+		return
+	}
+	cs.delayStatus.setCondition(pod, cond)
+}
+
+// conditionUpdate is a pending update of the delayed condition of a pod
+type conditionUpdate struct {
+	namespace string
+	name      string
+	condition v1.PodCondition
+}
+
+// writeCondition patches the delayed condition of a pod
+func (r *delayReporter) writeCondition(ctx context.Context, u *conditionUpdate) error {
+	// Pod conditions are merged by type, leaving the scheduler's PodScheduled alone
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{"conditions": []v1.PodCondition{u.condition}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.pods.CoreV1().Pods(u.namespace).Patch(ctx, u.name,
+		types.StrategicMergePatchType, patch, metav1.PatchOptions{}, "status")
+	if err != nil && !apierrors.IsNotFound(err) {
+		klog.ErrorS(err, "Failed to set delayed condition", "pod", klog.KRef(u.namespace, u.name))
+	}
+	return err
+}
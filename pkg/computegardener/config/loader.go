@@ -10,32 +10,80 @@ import (
 	"gopkg.in/yaml.v2"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/klog/v2"
+	sigsyaml "sigs.k8s.io/yaml"
 )
 
 // LoadFromEnv loads configuration from environment variables
 func LoadFromEnv() (*Config, error) {
 	cfg := &Config{
 		API: APIConfig{
-			Key:         os.Getenv("ELECTRICITY_MAP_API_KEY"),
-			URL:         getEnvOrDefault("ELECTRICITY_MAP_API_URL", "https://api.electricitymap.org/v3/carbon-intensity/latest?zone="),
-			Region:      getEnvOrDefault("ELECTRICITY_MAP_API_REGION", "US-CAL-CISO"),
-			Timeout:     getDurationOrDefault("API_TIMEOUT", 10*time.Second),
-			MaxRetries:  getIntOrDefault("API_MAX_RETRIES", 3),
-			RetryDelay:  getDurationOrDefault("API_RETRY_DELAY", 1*time.Second),
-			RateLimit:   getIntOrDefault("API_RATE_LIMIT", 10),
-			CacheTTL:    getDurationOrDefault("CACHE_TTL", 5*time.Minute),
-			MaxCacheAge: getDurationOrDefault("MAX_CACHE_AGE", 1*time.Hour),
+			Key:             os.Getenv("ELECTRICITY_MAP_API_KEY"),
+			URL:             getEnvOrDefault("ELECTRICITY_MAP_API_URL", "https://api.electricitymap.org/v3/carbon-intensity/latest?zone="),
+			MarginalURL:     os.Getenv("ELECTRICITY_MAP_MARGINAL_API_URL"),
+			SignalType:      getEnvOrDefault("CARBON_SIGNAL_TYPE", SignalTypeAverage),
+			ForecastURL:     getEnvOrDefault("ELECTRICITY_MAP_FORECAST_API_URL", "https://api.electricitymap.org/v3/carbon-intensity/forecast?zone="),
+			ForecastEnabled: getBoolOrDefault("FORECAST_ENABLED", false),
+			Region:          getEnvOrDefault("ELECTRICITY_MAP_API_REGION", "US-CAL-CISO"),
+			Zones:           getStringSliceOrDefault("ELECTRICITY_MAP_API_ZONES", nil),
+			FetchWorkers:    getIntOrDefault("API_FETCH_WORKERS", 4),
+			RegionZoneMap:   getStringMapOrDefault("REGION_ZONE_MAP", nil),
+			Timeout:         getDurationOrDefault("API_TIMEOUT", 10*time.Second),
+			MaxRetries:      getIntOrDefault("API_MAX_RETRIES", 3),
+			RetryDelay:      getDurationOrDefault("API_RETRY_DELAY", 1*time.Second),
+			RateLimit:       getIntOrDefault("API_RATE_LIMIT", 10),
+			CacheTTL:        getDurationOrDefault("CACHE_TTL", 5*time.Minute),
+			MaxCacheAge:     getDurationOrDefault("MAX_CACHE_AGE", 1*time.Hour),
 		},
 		Scheduling: SchedulingConfig{
-			BaseCarbonIntensityThreshold: getFloatOrDefault("CARBON_INTENSITY_THRESHOLD", 150.0),
-			MaxSchedulingDelay:           getDurationOrDefault("MAX_SCHEDULING_DELAY", 24*time.Hour),
-			DefaultRegion:                getEnvOrDefault("DEFAULT_REGION", "US-CAL-CISO"),
-			EnablePodPriorities:          getBoolOrDefault("ENABLE_POD_PRIORITIES", false),
+			BaseCarbonIntensityThreshold:    getFloatOrDefault("CARBON_INTENSITY_THRESHOLD", 150.0),
+			WeekendCarbonIntensityThreshold: getFloatOrDefault("WEEKEND_CARBON_INTENSITY_THRESHOLD", 0),
+			ReleaseCarbonIntensityThreshold: getFloatOrDefault("CARBON_INTENSITY_RELEASE_THRESHOLD", 0),
+			AbortCarbonIntensityThreshold:   getFloatOrDefault("CARBON_INTENSITY_ABORT_THRESHOLD", 0),
+			MaxSchedulingDelay:              getDurationOrDefault("MAX_SCHEDULING_DELAY", 24*time.Hour),
+			DefaultRegion:                   getEnvOrDefault("DEFAULT_REGION", "US-CAL-CISO"),
+			EnablePodPriorities:             getBoolOrDefault("ENABLE_POD_PRIORITIES", false),
+			EnforcementMode:                 getEnvOrDefault("ENFORCEMENT_MODE", EnforcementModeEnforce),
+			WaitMode:                        getEnvOrDefault("WAIT_MODE", WaitModeRequeue),
+			DecisionMode:                    getEnvOrDefault("DECISION_MODE", DecisionModeGates),
+			Weights: DecisionWeights{
+				Carbon: getFloatOrDefault("WEIGHT_CARBON", 1.0),
+				Price:  getFloatOrDefault("WEIGHT_PRICE", 0),
+				Load:   getFloatOrDefault("WEIGHT_LOAD", 0),
+			},
+			WeightedCutoff:           getFloatOrDefault("WEIGHTED_CUTOFF", 0.5),
+			OptimalWindowEnabled:     getBoolOrDefault("OPTIMAL_WINDOW_ENABLED", false),
+			ThresholdPercentile:      getFloatOrDefault("CARBON_INTENSITY_PERCENTILE", 0),
+			PercentileWindow:         getDurationOrDefault("CARBON_INTENSITY_PERCENTILE_WINDOW", 7*24*time.Hour),
+			PercentileMinSamples:     getIntOrDefault("CARBON_INTENSITY_PERCENTILE_MIN_SAMPLES", 24),
+			AgingCurve:               getEnvOrDefault("AGING_CURVE", AgingCurveNone),
+			AgingMaxFactor:           getFloatOrDefault("AGING_MAX_FACTOR", 2.0),
+			ReleaseBatchSize:         getIntOrDefault("RELEASE_BATCH_SIZE", 0),
+			ReleaseBatchInterval:     getDurationOrDefault("RELEASE_BATCH_INTERVAL", 30*time.Second),
+			WeekendReleaseBatchSize:  getIntOrDefault("WEEKEND_RELEASE_BATCH_SIZE", 0),
+			AdmissionRate:            getFloatOrDefault("ADMISSION_RATE_PER_MINUTE", 0),
+			AdmissionBurst:           getFloatOrDefault("ADMISSION_BURST", 10),
+			AdmissionWeightByCPU:     getBoolOrDefault("ADMISSION_WEIGHT_BY_CPU", false),
+			MaxConcurrentPods:        getIntOrDefault("MAX_CONCURRENT_PODS", 0),
+			WeekendMaxConcurrentPods: getIntOrDefault("WEEKEND_MAX_CONCURRENT_PODS", 0),
+			BestEffortHorizon:        getDurationOrDefault("BEST_EFFORT_HORIZON", 2*time.Hour),
+			TrendHorizon:             getDurationOrDefault("TREND_HORIZON", 0),
+			RequeueBackoff:           getDurationOrDefault("REQUEUE_BACKOFF", 5*time.Minute),
+			GangReleaseTTL:           getDurationOrDefault("GANG_RELEASE_TTL", 10*time.Minute),
+			DeferBindMaxDelay:        getDurationOrDefault("DEFER_BIND_MAX_DELAY", 0),
+			SmoothingWindow:          getIntOrDefault("SMOOTHING_WINDOW", 0),
+			SmoothingAlpha:           getFloatOrDefault("SMOOTHING_ALPHA", 0.3),
+			LearnDurations:           getBoolOrDefault("LEARN_JOB_DURATIONS", false),
+			DurationSamples:          getIntOrDefault("JOB_DURATION_SAMPLES", 20),
+			EnergyLightKWh:           getFloatOrDefault("ENERGY_LIGHT_KWH", 0),
+			EnergyHeavyKWh:           getFloatOrDefault("ENERGY_HEAVY_KWH", 0),
+			EnergyHeavyFactor:        getFloatOrDefault("ENERGY_HEAVY_THRESHOLD_FACTOR", 0.8),
+			ExemptStatefulSets:       getBoolOrDefault("EXEMPT_STATEFULSETS", false),
 		},
 		Pricing: PricingConfig{
-			Enabled:  getBoolOrDefault("PRICING_ENABLED", false),
-			Provider: getEnvOrDefault("PRICING_PROVIDER", "tou"),
-			MaxDelay: getEnvOrDefault("PRICING_MAX_DELAY", "24h"),
+			Enabled:    getBoolOrDefault("PRICING_ENABLED", false),
+			Provider:   getEnvOrDefault("PRICING_PROVIDER", "tou"),
+			MaxDelay:   getEnvOrDefault("PRICING_MAX_DELAY", "24h"),
+			PeakSource: getEnvOrDefault("PRICING_PEAK_SOURCE", PeakSourceSchedule),
 		},
 		Observability: ObservabilityConfig{
 			MetricsEnabled:     getBoolOrDefault("METRICS_ENABLED", true),
@@ -44,11 +92,187 @@ func LoadFromEnv() (*Config, error) {
 			HealthCheckPort:    getIntOrDefault("HEALTH_CHECK_PORT", 8080),
 			LogLevel:           getEnvOrDefault("LOG_LEVEL", "info"),
 			EnableTracing:      getBoolOrDefault("ENABLE_TRACING", false),
+			DelayStatusEnabled: getBoolOrDefault("CARBON_DELAY_STATUS_ENABLED", false),
+			EvaluateEnabled:    getBoolOrDefault("EVALUATE_ENABLED", false),
+			EvaluatePath:       getEnvOrDefault("EVALUATE_PATH", "/evaluate"),
+			EvaluateToken:      os.Getenv("EVALUATE_TOKEN"),
+			PodMetricsEnabled:  getBoolOrDefault("POD_METRICS_ENABLED", false),
+			StampsTrusted:      getBoolOrDefault("ADMISSION_STAMPS_TRUSTED", false),
 		},
 		Power: PowerConfig{
-			DefaultIdlePower: getFloatOrDefault("NODE_DEFAULT_IDLE_POWER", 100.0),
-			DefaultMaxPower:  getFloatOrDefault("NODE_DEFAULT_MAX_POWER", 400.0),
-			NodePowerConfig:  loadNodePowerConfig(),
+			DefaultIdlePower:      getFloatOrDefault("NODE_DEFAULT_IDLE_POWER", 100.0),
+			DefaultMaxPower:       getFloatOrDefault("NODE_DEFAULT_MAX_POWER", 400.0),
+			DefaultCurve:          parsePowerCurve("NODE_DEFAULT_POWER_CURVE", os.Getenv("NODE_DEFAULT_POWER_CURVE")),
+			DefaultGPUIdlePower:   getFloatOrDefault("NODE_DEFAULT_GPU_IDLE_POWER", 50.0),
+			DefaultGPUMaxPower:    getFloatOrDefault("NODE_DEFAULT_GPU_MAX_POWER", 300.0),
+			MemoryWattsPerGB:      getFloatOrDefault("NODE_MEMORY_WATTS_PER_GB", 0),
+			DiskWatts:             getFloatOrDefault("NODE_DISK_WATTS", 0),
+			NICWatts:              getFloatOrDefault("NODE_NIC_WATTS", 0),
+			DefaultDisks:          getIntOrDefault("NODE_DEFAULT_DISKS", 1),
+			DefaultNICs:           getIntOrDefault("NODE_DEFAULT_NICS", 1),
+			PUE:                   getFloatOrDefault("PUE", 1.0),
+			ZonePUE:               getFloatMapOrDefault("ZONE_PUE", nil),
+			EmbodiedCarbonPerHour: getFloatOrDefault("NODE_EMBODIED_CARBON_PER_HOUR", 0),
+			WattsPerCore:          getFloatOrDefault("NODE_WATTS_PER_CORE", 10.0),
+			NodePowerConfig:       loadNodePowerConfig(),
+			ReferencePerfPerWatt:  getFloatOrDefault("NODE_REFERENCE_PERF_PER_WATT", 1.0),
+			PackingEnabled:        getBoolOrDefault("PACKING_SCORE_ENABLED", false),
+			RackBudgetsEnabled:    getBoolOrDefault("RACK_POWER_BUDGETS_ENABLED", false),
+			PodAttributionEnabled: getBoolOrDefault("POD_ENERGY_ATTRIBUTION_ENABLED", false),
+			ExcludeSidecars:       getBoolOrDefault("POD_ENERGY_EXCLUDE_SIDECARS", false),
+			SidecarContainers:     getStringSliceOrDefault("POD_ENERGY_SIDECAR_CONTAINERS", []string{"istio-proxy", "linkerd-proxy", "envoy"}),
+			PodSampleInterval:     getDurationOrDefault("POD_ENERGY_SAMPLE_INTERVAL", 30*time.Second),
+			AccrualInterval:       getDurationOrDefault("POD_ENERGY_ACCRUAL_INTERVAL", 0),
+			MetricsTTL:            getDurationOrDefault("POWER_METRICS_TTL", 24*time.Hour),
+			MetricsMaxEntries:     getIntOrDefault("POWER_METRICS_MAX_ENTRIES", 10000),
+			InstanceTypesEnabled:  getBoolOrDefault("INSTANCE_TYPE_POWER_ENABLED", true),
+			InstanceTypesPath:     os.Getenv("INSTANCE_TYPE_POWER_PATH"),
+			InstanceTypesRefreshInterval: getDurationOrDefault("INSTANCE_TYPE_POWER_REFRESH_INTERVAL",
+				5*time.Minute),
+			NFD: NFDConfig{
+				Enabled:         getBoolOrDefault("NFD_POWER_PROFILES_ENABLED", false),
+				TDPLabel:        getEnvOrDefault("NFD_TDP_LABEL", "feature.node.kubernetes.io/cpu-tdp"),
+				TDPIdleFraction: getFloatOrDefault("NFD_TDP_IDLE_FRACTION", 0.3),
+			},
+		},
+		Scoring: ScoringConfig{
+			Weights: ScoreWeights{
+				Zone:       getFloatOrDefault("SCORE_WEIGHT_ZONE", 1.0),
+				Efficiency: getFloatOrDefault("SCORE_WEIGHT_EFFICIENCY", 1.0),
+				Packing:    getFloatOrDefault("SCORE_WEIGHT_PACKING", 1.0),
+				Spot:       getFloatOrDefault("SCORE_WEIGHT_SPOT", 1.0),
+			},
+		},
+		DemandResponse: DemandResponseConfig{
+			Enabled:         getBoolOrDefault("DEMAND_RESPONSE_ENABLED", false),
+			Path:            getEnvOrDefault("DEMAND_RESPONSE_PATH", "/demand-response/events"),
+			Token:           os.Getenv("DEMAND_RESPONSE_TOKEN"),
+			ThresholdFactor: getFloatOrDefault("DEMAND_RESPONSE_THRESHOLD_FACTOR", 0.75),
+			PauseAdmissions: getBoolOrDefault("DEMAND_RESPONSE_PAUSE_ADMISSIONS", false),
+		},
+		GridAlert: GridAlertConfig{
+			Enabled:           getBoolOrDefault("GRID_ALERT_ENABLED", false),
+			URL:               os.Getenv("GRID_ALERT_URL"),
+			PollInterval:      getDurationOrDefault("GRID_ALERT_POLL_INTERVAL", 5*time.Minute),
+			ThresholdFactor:   getFloatOrDefault("GRID_ALERT_THRESHOLD_FACTOR", 0.5),
+			PauseAdmissions:   getBoolOrDefault("GRID_ALERT_PAUSE_ADMISSIONS", false),
+			MaxConcurrentPods: getIntOrDefault("GRID_ALERT_MAX_CONCURRENT_PODS", 0),
+		},
+		OnSite: OnSiteConfig{
+			Enabled:          getBoolOrDefault("ONSITE_ENABLED", false),
+			URL:              os.Getenv("ONSITE_TELEMETRY_URL"),
+			PollInterval:     getDurationOrDefault("ONSITE_POLL_INTERVAL", 1*time.Minute),
+			MaxAge:           getDurationOrDefault("ONSITE_MAX_AGE", 5*time.Minute),
+			MinProductionKW:  getFloatOrDefault("ONSITE_MIN_PRODUCTION_KW", 0),
+			MinStateOfCharge: getFloatOrDefault("ONSITE_MIN_STATE_OF_CHARGE", 0),
+		},
+		Thermal: ThermalConfig{
+			Enabled:       getBoolOrDefault("THERMAL_ENABLED", false),
+			PrometheusURL: os.Getenv("THERMAL_PROMETHEUS_URL"),
+			Query:         os.Getenv("THERMAL_QUERY"),
+			Threshold:     getFloatOrDefault("THERMAL_THRESHOLD", 0),
+			PollInterval:  getDurationOrDefault("THERMAL_POLL_INTERVAL", 1*time.Minute),
+			MaxAge:        getDurationOrDefault("THERMAL_MAX_AGE", 5*time.Minute),
+		},
+		PowerCap: PowerCapConfig{
+			Enabled:       getBoolOrDefault("POWER_CAP_ENABLED", false),
+			PrometheusURL: os.Getenv("POWER_CAP_PROMETHEUS_URL"),
+			Query: getEnvOrDefault("POWER_CAP_QUERY",
+				"sum by (node) (rate(node_rapl_package_joules_total[1m]))"),
+			NodeLabel:    getEnvOrDefault("POWER_CAP_NODE_LABEL", "node"),
+			Threshold:    getFloatOrDefault("POWER_CAP_THRESHOLD", 0.9),
+			PollInterval: getDurationOrDefault("POWER_CAP_POLL_INTERVAL", 30*time.Second),
+			MaxAge:       getDurationOrDefault("POWER_CAP_MAX_AGE", 2*time.Minute),
+		},
+		GPU: GPUConfig{
+			Enabled:       getBoolOrDefault("GPU_POWER_ENABLED", false),
+			ResourceName:  getEnvOrDefault("GPU_RESOURCE_NAME", "nvidia.com/gpu"),
+			PrometheusURL: os.Getenv("GPU_PROMETHEUS_URL"),
+			Query: getEnvOrDefault("GPU_UTILIZATION_QUERY",
+				"sum by (Hostname) (DCGM_FI_DEV_GPU_UTIL) / 100"),
+			NodeLabel:    getEnvOrDefault("GPU_NODE_LABEL", "Hostname"),
+			PollInterval: getDurationOrDefault("GPU_POLL_INTERVAL", 30*time.Second),
+			MaxAge:       getDurationOrDefault("GPU_MAX_AGE", 2*time.Minute),
+		},
+		CPU: CPUConfig{
+			FrequencyEnabled: getBoolOrDefault("CPU_FREQUENCY_ENABLED", false),
+			PrometheusURL:    os.Getenv("CPU_PROMETHEUS_URL"),
+			FrequencyQuery: getEnvOrDefault("CPU_FREQUENCY_QUERY",
+				"avg by (node) (node_cpu_scaling_frequency_hertz) / avg by (node) (node_cpu_scaling_frequency_max_hertz)"),
+			NodeLabel:         getEnvOrDefault("CPU_NODE_LABEL", "node"),
+			PollInterval:      getDurationOrDefault("CPU_POLL_INTERVAL", 30*time.Second),
+			MaxAge:            getDurationOrDefault("CPU_MAX_AGE", 2*time.Minute),
+			FrequencyExponent: getFloatOrDefault("CPU_FREQUENCY_EXPONENT", 2.0),
+			SMTEnabled:        getBoolOrDefault("CPU_SMT_AWARE_ENABLED", false),
+			SMTLabel:          getEnvOrDefault("CPU_SMT_LABEL", "feature.node.kubernetes.io/cpu-hardware_multithreading"),
+			SMTSiblingPower:   getFloatOrDefault("CPU_SMT_SIBLING_POWER", 0.2),
+		},
+		EnergySource: EnergySourceConfig{
+			Default:       getEnvOrDefault("ENERGY_SOURCE", EnergySourceModel),
+			PrometheusURL: os.Getenv("ENERGY_SOURCE_PROMETHEUS_URL"),
+			NodePoolLabel: os.Getenv("ENERGY_SOURCE_NODE_POOL_LABEL"),
+			NodePools:     getStringMapOrDefault("ENERGY_SOURCE_NODE_POOLS", nil),
+			NodeLabel:     getEnvOrDefault("ENERGY_SOURCE_NODE_LABEL", "node"),
+			ScaphandreNodeQuery: getEnvOrDefault("SCAPHANDRE_NODE_QUERY",
+				"sum by (node) (scaph_host_power_microwatts) / 1e6"),
+			ScaphandrePodQuery: getEnvOrDefault("SCAPHANDRE_POD_QUERY",
+				"sum by (kubernetes_pod_namespace, kubernetes_pod_name) (scaph_process_power_consumption_microwatts) / 1e6"),
+			PodNamespaceLabel: getEnvOrDefault("SCAPHANDRE_POD_NAMESPACE_LABEL", "kubernetes_pod_namespace"),
+			PodNameLabel:      getEnvOrDefault("SCAPHANDRE_POD_NAME_LABEL", "kubernetes_pod_name"),
+			RAPLNodeQuery: getEnvOrDefault("RAPL_NODE_QUERY",
+				"sum by (node) (rate(node_rapl_package_joules_total[1m]))"),
+			RedfishAddresses:          getStringMapOrDefault("REDFISH_ADDRESSES", nil),
+			RedfishPowerPath:          getEnvOrDefault("REDFISH_POWER_PATH", "/redfish/v1/Chassis/1/Power"),
+			RedfishUsername:           os.Getenv("REDFISH_USERNAME"),
+			RedfishPassword:           os.Getenv("REDFISH_PASSWORD"),
+			RedfishInsecureSkipVerify: getBoolOrDefault("REDFISH_INSECURE_SKIP_VERIFY", false),
+			PollInterval:              getDurationOrDefault("ENERGY_SOURCE_POLL_INTERVAL", 30*time.Second),
+			MaxAge:                    getDurationOrDefault("ENERGY_SOURCE_MAX_AGE", 2*time.Minute),
+			PDU: PDUConfig{
+				OutletsAnnotation: getEnvOrDefault("PDU_OUTLETS_ANNOTATION",
+					"carbon-aware-scheduler.kubernetes.io/pdu-outlets"),
+				Addresses:          getStringMapOrDefault("PDU_ADDRESSES", nil),
+				OutletPath:         getEnvOrDefault("PDU_OUTLET_PATH", "/redfish/v1/PowerEquipment/RackPDUs/1/Outlets/%s"),
+				Username:           os.Getenv("PDU_USERNAME"),
+				Password:           os.Getenv("PDU_PASSWORD"),
+				InsecureSkipVerify: getBoolOrDefault("PDU_INSECURE_SKIP_VERIFY", false),
+				Query:              os.Getenv("PDU_QUERY"),
+				PDULabel:           getEnvOrDefault("PDU_LABEL", "pdu"),
+				OutletLabel:        getEnvOrDefault("PDU_OUTLET_LABEL", "outlet"),
+			},
+		},
+		History: HistoryConfig{
+			Enabled:       getBoolOrDefault("HISTORY_ENABLED", false),
+			Path:          os.Getenv("HISTORY_PATH"),
+			Retention:     getDurationOrDefault("HISTORY_RETENTION", 7*24*time.Hour),
+			FlushInterval: getDurationOrDefault("HISTORY_FLUSH_INTERVAL", 5*time.Minute),
+		},
+		Accounting: AccountingConfig{
+			Enabled:       getBoolOrDefault("ACCOUNTING_ENABLED", false),
+			Path:          os.Getenv("ACCOUNTING_PATH"),
+			FlushInterval: getDurationOrDefault("ACCOUNTING_FLUSH_INTERVAL", time.Minute),
+		},
+		Policy: PolicyConfig{
+			Enabled:                    getBoolOrDefault("CARBON_POLICIES_ENABLED", false),
+			BudgetsEnabled:             getBoolOrDefault("CARBON_BUDGETS_ENABLED", false),
+			BudgetSyncInterval:         getDurationOrDefault("CARBON_BUDGET_SYNC_INTERVAL", time.Minute),
+			BudgetAction:               getEnvOrDefault("CARBON_BUDGET_ACTION", BudgetActionBlock),
+			BudgetDemotionFactor:       getFloatOrDefault("CARBON_BUDGET_DEMOTION_FACTOR", 0.5),
+			AccountingLabel:            os.Getenv("CARBON_ACCOUNTING_LABEL"),
+			ClassesEnabled:             getBoolOrDefault("WORKLOAD_CLASSES_ENABLED", false),
+			ExemptionsEnabled:          getBoolOrDefault("CARBON_EXEMPTIONS_ENABLED", false),
+			HubKubeconfig:              os.Getenv("CARBON_POLICY_HUB_KUBECONFIG"),
+			HubSelector:                os.Getenv("CARBON_POLICY_HUB_SELECTOR"),
+			QuotaBorrowingMaxIntensity: getFloatOrDefault("QUOTA_BORROWING_MAX_INTENSITY", 0),
+			SLOsEnabled:                getBoolOrDefault("CARBON_SLOS_ENABLED", false),
+			SLOSyncInterval:            getDurationOrDefault("CARBON_SLO_SYNC_INTERVAL", time.Minute),
+		},
+		Fallback: FallbackConfig{
+			Enabled:         getBoolOrDefault("FALLBACK_ENABLED", false),
+			After:           getDurationOrDefault("FALLBACK_AFTER", 15*time.Minute),
+			MinIntensity:    getFloatOrDefault("FALLBACK_MIN_INTENSITY", 100.0),
+			MaxIntensity:    getFloatOrDefault("FALLBACK_MAX_INTENSITY", 400.0),
+			SolarNoonOffset: getDurationOrDefault("FALLBACK_SOLAR_NOON_OFFSET", 12*time.Hour),
 		},
 	}
 
@@ -61,6 +285,19 @@ func LoadFromEnv() (*Config, error) {
 		}
 	}
 
+	// Load maintenance windows if path provided
+	if windowsPath := os.Getenv("MAINTENANCE_WINDOWS_PATH"); windowsPath != "" {
+		if err := loadMaintenanceWindows(cfg, windowsPath); err != nil {
+			return nil, fmt.Errorf("failed to load maintenance windows: %v", err)
+		}
+	}
+
+	if profilesPath := os.Getenv("NFD_POWER_PROFILES_PATH"); profilesPath != "" {
+		if err := loadNFDProfiles(cfg, profilesPath); err != nil {
+			return nil, fmt.Errorf("failed to load NFD power profiles: %v", err)
+		}
+	}
+
 	// Validate the configuration
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %v", err)
@@ -69,18 +306,21 @@ func LoadFromEnv() (*Config, error) {
 	return cfg, nil
 }
 
-// Load creates a new Config from the provided runtime.Object
+// Load creates a new Config from the environment, overridden by the plugin args
+// in the provided runtime.Object
 func Load(obj runtime.Object) (*Config, error) {
-	// For now, we only support environment variable configuration
-	// In the future, this could be extended to support configuration
-	// from the runtime.Object parameter
 	cfg, err := LoadFromEnv()
 	if err != nil {
 		return nil, err
 	}
 
+	if err := applyArgs(cfg, obj); err != nil {
+		return nil, fmt.Errorf("invalid plugin args: %v", err)
+	}
+
 	klog.V(2).InfoS("Loaded configuration",
 		"region", cfg.API.Region,
+		"signalType", cfg.API.SignalType,
 		"baseThreshold", cfg.Scheduling.BaseCarbonIntensityThreshold,
 		"pricingEnabled", cfg.Pricing.Enabled,
 		"defaultIdlePower", cfg.Power.DefaultIdlePower,
@@ -89,6 +329,26 @@ func Load(obj runtime.Object) (*Config, error) {
 	return cfg, nil
 }
 
+// applyArgs overrides the configuration with the plugin args. Args of plugins
+// without a registered type reach the plugin undecoded, as runtime.Unknown.
+func applyArgs(cfg *Config, obj runtime.Object) error {
+	raw, ok := obj.(*runtime.Unknown)
+	if !ok || len(raw.Raw) == 0 {
+		return nil
+	}
+
+	// Decoding into the loaded settings keeps the fields the args leave out
+	args := PluginArgs{
+		ScoreWeights:             &cfg.Scoring.Weights,
+		EnforcementMode:          &cfg.Scheduling.EnforcementMode,
+		CarbonIntensityThreshold: &cfg.Scheduling.BaseCarbonIntensityThreshold,
+	}
+	if err := sigsyaml.Unmarshal(raw.Raw, &args); err != nil {
+		return err
+	}
+	return cfg.Validate()
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -96,6 +356,60 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getStringSliceOrDefault(key string, defaultValue []string) []string {
+	strValue := os.Getenv(key)
+	if strValue == "" {
+		return defaultValue
+	}
+	var values []string
+	for _, v := range strings.Split(strValue, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// getStringMapOrDefault parses a comma-separated list of key=value pairs,
+// e.g. REGION_ZONE_MAP=us-west-2=US-NW-PACW,eu-west-1=IE
+func getStringMapOrDefault(key string, defaultValue map[string]string) map[string]string {
+	strValue := os.Getenv(key)
+	if strValue == "" {
+		return defaultValue
+	}
+	values := make(map[string]string)
+	for _, pair := range strings.Split(strValue, ",") {
+		k, v, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || k == "" || v == "" {
+			klog.V(2).InfoS("Invalid key=value pair, ignoring",
+				"key", key,
+				"value", pair)
+			continue
+		}
+		values[k] = v
+	}
+	return values
+}
+
+func getFloatMapOrDefault(key string, defaultValue map[string]float64) map[string]float64 {
+	strValues := getStringMapOrDefault(key, nil)
+	if strValues == nil {
+		return defaultValue
+	}
+	values := make(map[string]float64)
+	for k, v := range strValues {
+		value, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			klog.V(2).InfoS("Invalid float value, ignoring",
+				"key", key,
+				"value", k+"="+v)
+			continue
+		}
+		values[k] = value
+	}
+	return values
+}
+
 func getIntOrDefault(key string, defaultValue int) int {
 	if strValue := os.Getenv(key); strValue != "" {
 		if value, err := strconv.Atoi(strValue); err == nil {
@@ -154,7 +468,8 @@ func loadNodePowerConfig() map[string]NodePower {
 	config := make(map[string]NodePower)
 
 	// Look for NODE_POWER_CONFIG_[NAME] environment variables
-	// Format: NODE_POWER_CONFIG_worker1=idle:100,max:400
+	// Format: NODE_POWER_CONFIG_worker1=idle:100,max:400[,ppw:1.5][,curve:0=60;0.5=180;1=320]
+	// [,gpuIdle:50,gpuMax:400][,disks:4,nics:2][,embodied:25]
 	for _, env := range os.Environ() {
 		if name, value, found := strings.Cut(env, "="); found && strings.HasPrefix(name, "NODE_POWER_CONFIG_") {
 			nodeName := strings.TrimPrefix(name, "NODE_POWER_CONFIG_")
@@ -172,12 +487,38 @@ func loadNodePowerConfig() map[string]NodePower {
 						if p, err := strconv.ParseFloat(val, 64); err == nil {
 							power.MaxPower = p
 						}
+					case "ppw":
+						if p, err := strconv.ParseFloat(val, 64); err == nil {
+							power.PerfPerWatt = p
+						}
+					case "curve":
+						power.Curve = parsePowerCurve(name, val)
+					case "gpuIdle":
+						if p, err := strconv.ParseFloat(val, 64); err == nil {
+							power.GPUIdlePower = p
+						}
+					case "gpuMax":
+						if p, err := strconv.ParseFloat(val, 64); err == nil {
+							power.GPUMaxPower = p
+						}
+					case "disks":
+						if n, err := strconv.Atoi(val); err == nil {
+							power.Disks = n
+						}
+					case "nics":
+						if n, err := strconv.Atoi(val); err == nil {
+							power.NICs = n
+						}
+					case "embodied":
+						if g, err := strconv.ParseFloat(val, 64); err == nil {
+							power.EmbodiedCarbonPerHour = g
+						}
 					}
 				}
 			}
 
-			// Only add if both values were parsed successfully
-			if power.IdlePower > 0 && power.MaxPower > power.IdlePower {
+			// Only add if a curve or both limits were parsed successfully
+			if len(power.Curve) > 0 || (power.IdlePower > 0 && power.MaxPower > power.IdlePower) {
 				config[nodeName] = power
 			}
 		}
@@ -186,6 +527,28 @@ func loadNodePowerConfig() map[string]NodePower {
 	return config
 }
 
+// parsePowerCurve parses a semicolon-separated list of utilization=watts points,
+// e.g. 0=60;0.1=95;0.5=180;1=320
+func parsePowerCurve(key, value string) []PowerPoint {
+	if value == "" {
+		return nil
+	}
+	var curve []PowerPoint
+	for _, pair := range strings.Split(value, ";") {
+		u, w, found := strings.Cut(strings.TrimSpace(pair), "=")
+		utilization, uErr := strconv.ParseFloat(u, 64)
+		watts, wErr := strconv.ParseFloat(w, 64)
+		if !found || uErr != nil || wErr != nil {
+			klog.V(2).InfoS("Invalid power curve point, ignoring curve",
+				"key", key,
+				"value", pair)
+			return nil
+		}
+		curve = append(curve, PowerPoint{Utilization: utilization, Watts: watts})
+	}
+	return curve
+}
+
 func loadPricingSchedules(cfg *Config, path string) error {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -210,3 +573,33 @@ func loadPricingSchedules(cfg *Config, path string) error {
 	cfg.Pricing.Schedules = schedules.Schedules
 	return nil
 }
+
+func loadMaintenanceWindows(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read maintenance windows file: %v", err)
+	}
+
+	maintenance := &MaintenanceConfig{}
+	if err := yaml.Unmarshal(data, maintenance); err != nil {
+		return fmt.Errorf("failed to parse maintenance windows: %v", err)
+	}
+
+	cfg.Maintenance = *maintenance
+	return nil
+}
+
+func loadNFDProfiles(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read NFD power profiles file: %v", err)
+	}
+
+	nfd := &NFDConfig{}
+	if err := yaml.Unmarshal(data, nfd); err != nil {
+		return fmt.Errorf("failed to parse NFD power profiles: %v", err)
+	}
+
+	cfg.Power.NFD.Profiles = nfd.Profiles
+	return nil
+}
@@ -2,50 +2,605 @@ package config
 
 import (
 	"fmt"
+	"strings"
 	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 // PowerConfig holds power consumption settings for nodes
 type PowerConfig struct {
-	DefaultIdlePower float64              `yaml:"defaultIdlePower"` // Default idle power in watts
-	DefaultMaxPower  float64              `yaml:"defaultMaxPower"`  // Default max power in watts
-	NodePowerConfig  map[string]NodePower `yaml:"nodePowerConfig"`  // Per-node power settings
+	DefaultIdlePower float64 `yaml:"defaultIdlePower"` // Default idle power in watts
+	DefaultMaxPower  float64 `yaml:"defaultMaxPower"`  // Default max power in watts
+	// DefaultCurve is the default power curve of nodes, overriding idle and max power
+	DefaultCurve    []PowerPoint         `yaml:"defaultCurve"`
+	WattsPerCore    float64              `yaml:"wattsPerCore"`    // Power per requested CPU core in watts
+	NodePowerConfig map[string]NodePower `yaml:"nodePowerConfig"` // Per-node power settings
+	// ReferencePerfPerWatt is the performance per watt rating that scores half the
+	// maximum efficiency score
+	ReferencePerfPerWatt float64 `yaml:"referencePerfPerWatt"`
+	// PackingEnabled scores nodes by the marginal power of placing a pod there,
+	// favoring already busy nodes
+	PackingEnabled bool `yaml:"packingEnabled"`
+	// RackBudgetsEnabled rejects nodes whose rack would exceed the power budget
+	// declared in its node labels
+	RackBudgetsEnabled bool `yaml:"rackBudgetsEnabled"`
+	// PodAttributionEnabled attributes energy to pods from samples of their own CPU
+	// usage, rather than the power of their node while they ran
+	PodAttributionEnabled bool          `yaml:"podAttributionEnabled"`
+	PodSampleInterval     time.Duration `yaml:"podSampleInterval"` // How often the power of bound pods is sampled
+	// AccrualInterval is how often the energy of running pods is accounted, rather
+	// than only on completion; zero disables accrual
+	AccrualInterval time.Duration `yaml:"accrualInterval"`
+	// ExcludeSidecars leaves the usage of sidecar containers, such as service mesh
+	// proxies, out of the energy attributed to pods. Init containers always count.
+	ExcludeSidecars bool `yaml:"excludeSidecars"`
+	// SidecarContainers names the containers treated as sidecars, besides native
+	// sidecars: init containers that keep running alongside the pod
+	SidecarContainers []string `yaml:"sidecarContainers"`
+	// MetricsTTL and MetricsMaxEntries bound the power recorded at the binding and
+	// completion of pods whose deletion is missed
+	MetricsTTL        time.Duration `yaml:"metricsTTL"`
+	MetricsMaxEntries int           `yaml:"metricsMaxEntries"`
+	// InstanceTypesEnabled estimates the power of nodes without a power profile from
+	// their instance type label
+	InstanceTypesEnabled bool `yaml:"instanceTypesEnabled"`
+	// InstanceTypesPath is a file of instance type power estimates overriding the
+	// built-in ones, reloaded every InstanceTypesRefreshInterval
+	InstanceTypesPath            string        `yaml:"instanceTypesPath"`
+	InstanceTypesRefreshInterval time.Duration `yaml:"instanceTypesRefreshInterval"`
+	// NFD picks power profiles from Node Feature Discovery labels
+	NFD NFDConfig `yaml:"nfd"`
+	// DefaultGPUIdlePower and DefaultGPUMaxPower are the default power of each GPU
+	// of a node in watts
+	DefaultGPUIdlePower float64 `yaml:"defaultGPUIdlePower"`
+	DefaultGPUMaxPower  float64 `yaml:"defaultGPUMaxPower"`
+	// MemoryWattsPerGB, DiskWatts and NICWatts add the power of the memory in use,
+	// and of each disk and NIC, to modeled node power. Zero leaves them out.
+	MemoryWattsPerGB float64 `yaml:"memoryWattsPerGB"`
+	DiskWatts        float64 `yaml:"diskWatts"`
+	NICWatts         float64 `yaml:"nicWatts"`
+	DefaultDisks     int     `yaml:"defaultDisks"` // Disks of nodes whose profile doesn't set them
+	DefaultNICs      int     `yaml:"defaultNICs"`  // NICs of nodes whose profile doesn't set them
+	// PUE is the power usage effectiveness of the facility, multiplying the IT energy
+	// of pods into the facility energy their emissions are calculated from.
+	// ZonePUE overrides it for the nodes of a grid zone.
+	PUE     float64            `yaml:"pue"`
+	ZonePUE map[string]float64 `yaml:"zonePUE"`
+	// EmbodiedCarbonPerHour is the embodied carbon of a node's hardware amortized
+	// over its lifetime, in gCO2e per node-hour, added to the emissions of pods by
+	// their share of the node. Zero leaves it out.
+	EmbodiedCarbonPerHour float64 `yaml:"embodiedCarbonPerHour"`
+}
+
+// NFDConfig holds settings for picking the power profile of nodes without an
+// explicit one from the hardware features published by Node Feature Discovery
+type NFDConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Profiles are picked for nodes matching their features, the first match winning
+	Profiles []NFDProfile `yaml:"profiles"`
+	// TDPLabel is a label with the TDP of a node's CPUs in watts, estimating the
+	// max power of nodes no profile matches
+	TDPLabel string `yaml:"tdpLabel"`
+	// TDPIdleFraction is the fraction of the TDP drawn at idle
+	TDPIdleFraction float64 `yaml:"tdpIdleFraction"`
+}
+
+// NFDProfile is a power profile for nodes with the given NFD features
+type NFDProfile struct {
+	Name string `yaml:"name"`
+	// Features are NFD feature labels without their prefix, e.g.
+	// cpu-model.vendor_id: AMD or cpu-hardware_multithreading: "true"
+	Features  map[string]string `yaml:"features"`
+	NodePower `yaml:",inline"`
 }
 
 // NodePower holds power settings for a specific node
 type NodePower struct {
 	IdlePower float64 `yaml:"idlePower"` // Idle power in watts
 	MaxPower  float64 `yaml:"maxPower"`  // Max power in watts
+	// Curve is the power of the node by CPU utilization, overriding idle and max power
+	Curve []PowerPoint `yaml:"curve"`
+	// GPUIdlePower and GPUMaxPower are the power of each GPU of the node in watts,
+	// overriding the defaults
+	GPUIdlePower float64 `yaml:"gpuIdlePower"`
+	GPUMaxPower  float64 `yaml:"gpuMaxPower"`
+	// Disks and NICs are the number of each on the node, overriding the defaults
+	Disks int `yaml:"disks"`
+	NICs  int `yaml:"nics"`
+	// EmbodiedCarbonPerHour overrides the default embodied carbon per node-hour
+	EmbodiedCarbonPerHour float64 `yaml:"embodiedCarbonPerHour"`
+	// PerfPerWatt rates the node's performance per watt, overriding its label
+	PerfPerWatt float64 `yaml:"perfPerWatt"`
+}
+
+// PowerPoint is the power of a node at a CPU utilization, as in SPECpower results.
+// Power between points is interpolated linearly.
+type PowerPoint struct {
+	Utilization float64 `yaml:"utilization"` // CPU utilization from 0 to 1
+	Watts       float64 `yaml:"watts"`
 }
 
+// Enforcement modes
+const (
+	// EnforcementModeEnforce holds pods that don't meet admission constraints
+	EnforcementModeEnforce = "enforce"
+	// EnforcementModeAudit admits all pods, recording the delays that would have applied
+	EnforcementModeAudit = "audit"
+)
+
+// Actions taken on new pods once a carbon budget is exhausted
+const (
+	// BudgetActionBlock holds new pods until the budget resets
+	BudgetActionBlock = "block"
+	// BudgetActionAudit admits new pods, only recording that the budget is exhausted
+	BudgetActionAudit = "audit"
+	// BudgetActionDemote gates new pods under a stricter carbon intensity threshold
+	BudgetActionDemote = "demote"
+)
+
+// Modes of holding delayed pods
+const (
+	// WaitModeRequeue rejects delayed pods in PreFilter so they are retried later
+	WaitModeRequeue = "requeue"
+	// WaitModePermit holds delayed pods at Permit until their constraints clear
+	WaitModePermit = "permit"
+)
+
+// Decision modes
+const (
+	// DecisionModeGates checks price and carbon intensity as independent gates
+	DecisionModeGates = "gates"
+	// DecisionModeWeighted admits pods on a weighted score of carbon, price and load
+	DecisionModeWeighted = "weighted"
+)
+
+// DecisionWeights holds the weights of the weighted decision mode
+type DecisionWeights struct {
+	Carbon float64 `yaml:"carbon"`
+	Price  float64 `yaml:"price"`
+	Load   float64 `yaml:"load"`
+}
+
+// ScoreWeights holds the weights of the scores combined into a node's score
+type ScoreWeights struct {
+	Zone       float64 `yaml:"zone" json:"zone"`
+	Efficiency float64 `yaml:"efficiency" json:"efficiency"`
+	Packing    float64 `yaml:"packing" json:"packing"`
+	Spot       float64 `yaml:"spot" json:"spot"`
+}
+
+// ScoringConfig holds settings for scoring nodes
+type ScoringConfig struct {
+	Weights ScoreWeights `yaml:"weights" json:"weights"`
+}
+
+// PluginArgs holds the settings accepted as args in the plugin's pluginConfig.
+// Args override the environment, so profiles enabling the plugin can apply
+// different policies.
+type PluginArgs struct {
+	ScoreWeights             *ScoreWeights `json:"scoreWeights,omitempty"`
+	EnforcementMode          *string       `json:"enforcementMode,omitempty"`
+	CarbonIntensityThreshold *float64      `json:"carbonIntensityThreshold,omitempty"`
+}
+
+// Aging curves relaxing thresholds with wait time
+const (
+	AgingCurveNone      = ""
+	AgingCurveLinear    = "linear"
+	AgingCurveQuadratic = "quadratic"
+)
+
+// Carbon intensity signal types
+const (
+	// SignalTypeAverage uses the average carbon intensity of the grid mix
+	SignalTypeAverage = "average"
+	// SignalTypeMarginal uses the marginal operating emissions rate, i.e. the
+	// emissions of the generator that responds to additional load
+	SignalTypeMarginal = "marginal"
+)
+
 // Config holds all configuration for the carbon-aware scheduler
 type Config struct {
-	API           APIConfig           `yaml:"api"`
-	Scheduling    SchedulingConfig    `yaml:"scheduling"`
-	Pricing       PricingConfig       `yaml:"pricing"`
-	Observability ObservabilityConfig `yaml:"observability"`
-	Power         PowerConfig         `yaml:"power"`
+	API            APIConfig            `yaml:"api"`
+	Scheduling     SchedulingConfig     `yaml:"scheduling"`
+	Pricing        PricingConfig        `yaml:"pricing"`
+	Observability  ObservabilityConfig  `yaml:"observability"`
+	Power          PowerConfig          `yaml:"power"`
+	Scoring        ScoringConfig        `yaml:"scoring"`
+	Fallback       FallbackConfig       `yaml:"fallback"`
+	History        HistoryConfig        `yaml:"history"`
+	Accounting     AccountingConfig     `yaml:"accounting"`
+	DemandResponse DemandResponseConfig `yaml:"demandResponse"`
+	GridAlert      GridAlertConfig      `yaml:"gridAlert"`
+	OnSite         OnSiteConfig         `yaml:"onSite"`
+	Thermal        ThermalConfig        `yaml:"thermal"`
+	PowerCap       PowerCapConfig       `yaml:"powerCap"`
+	EnergySource   EnergySourceConfig   `yaml:"energySource"`
+	GPU            GPUConfig            `yaml:"gpu"`
+	CPU            CPUConfig            `yaml:"cpu"`
+	Maintenance    MaintenanceConfig    `yaml:"maintenance"`
+	Policy         PolicyConfig         `yaml:"policy"`
+}
+
+// OnSiteConfig holds settings for gating on local solar/battery telemetry. When
+// enabled, pods are only admitted while on-site generation or battery charge is
+// above the configured minimums.
+type OnSiteConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	URL          string        `yaml:"url"`          // Inverter/battery endpoint returning {"productionWatts", "stateOfCharge"} JSON
+	PollInterval time.Duration `yaml:"pollInterval"` // How often the endpoint is polled
+	MaxAge       time.Duration `yaml:"maxAge"`       // Readings older than this are ignored
+	// MinProductionKW admits pods when on-site generation exceeds this value, 0 disables
+	MinProductionKW float64 `yaml:"minProductionKW"`
+	// MinStateOfCharge admits pods when battery charge exceeds this percentage, 0 disables
+	MinStateOfCharge float64 `yaml:"minStateOfCharge"`
+}
+
+// ThermalConfig holds settings for gating on data-center temperature or cooling load,
+// read from a Prometheus query
+type ThermalConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	PrometheusURL string        `yaml:"prometheusURL"`
+	Query         string        `yaml:"query"`        // Query whose maximum sample is compared to Threshold
+	Threshold     float64       `yaml:"threshold"`    // Pods are delayed while the query value exceeds this
+	PollInterval  time.Duration `yaml:"pollInterval"` // How often the query is evaluated
+	MaxAge        time.Duration `yaml:"maxAge"`       // Values older than this are ignored
+}
+
+// PowerCapConfig holds settings for avoiding nodes running close to their power cap,
+// from node power readings in Prometheus
+type PowerCapConfig struct {
+	Enabled       bool   `yaml:"enabled"`
+	PrometheusURL string `yaml:"prometheusURL"`
+	Query         string `yaml:"query"`     // Query returning the power draw of each node in watts
+	NodeLabel     string `yaml:"nodeLabel"` // Label of the query samples holding the node name
+	// Threshold is the fraction of its power cap above which a node is avoided
+	Threshold    float64       `yaml:"threshold"`
+	PollInterval time.Duration `yaml:"pollInterval"` // How often the query is evaluated
+	MaxAge       time.Duration `yaml:"maxAge"`       // Readings older than this are ignored
+}
+
+// GPUConfig holds settings for including the power of GPUs in node power estimates
+type GPUConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// ResourceName is the extended resource counting the GPUs of a node
+	ResourceName  string `yaml:"resourceName"`
+	PrometheusURL string `yaml:"prometheusURL"`
+	// Query returns the summed utilization of the GPUs of each node, each from 0 to 1
+	Query        string        `yaml:"query"`
+	NodeLabel    string        `yaml:"nodeLabel"`    // Label of the query samples holding the node name
+	PollInterval time.Duration `yaml:"pollInterval"` // How often the query is evaluated
+	MaxAge       time.Duration `yaml:"maxAge"`       // Readings older than this are ignored
+}
+
+// CPUConfig holds settings for adjusting modeled node power for the frequency and
+// SMT of node CPUs, rather than assuming power scales linearly with utilization at a
+// fixed frequency
+type CPUConfig struct {
+	FrequencyEnabled bool   `yaml:"frequencyEnabled"`
+	PrometheusURL    string `yaml:"prometheusURL"`
+	// FrequencyQuery returns the average frequency of the CPUs of each node relative
+	// to their max frequency, including turbo, from 0 to 1
+	FrequencyQuery string        `yaml:"frequencyQuery"`
+	NodeLabel      string        `yaml:"nodeLabel"`    // Label of the query samples holding the node name
+	PollInterval   time.Duration `yaml:"pollInterval"` // How often the query is evaluated
+	MaxAge         time.Duration `yaml:"maxAge"`       // Readings older than this are ignored
+	// FrequencyExponent scales dynamic power by the relative frequency raised to it,
+	// as voltage drops along with frequency
+	FrequencyExponent float64 `yaml:"frequencyExponent"`
+	// SMTEnabled maps the utilization of the logical CPUs of nodes with SMT to the
+	// busy physical cores drawing power, as the second thread of a core draws little
+	SMTEnabled bool   `yaml:"smtEnabled"`
+	SMTLabel   string `yaml:"smtLabel"` // Label set to "true" on nodes with SMT
+	// SMTSiblingPower is the power of the second thread of a core relative to the first
+	SMTSiblingPower float64 `yaml:"smtSiblingPower"`
+}
+
+// Energy sources of node power
+const (
+	// EnergySourceModel estimates node power from CPU usage between idle and max power
+	EnergySourceModel = "model"
+	// EnergySourceScaphandre reads node and pod power measured by Scaphandre
+	EnergySourceScaphandre = "scaphandre"
+	// EnergySourceRAPL reads node power from the RAPL counters exported by node-exporter
+	EnergySourceRAPL = "rapl"
+	// EnergySourceRedfish polls the power readings of node BMCs over Redfish, the
+	// whole node's power at the wall, and calibrates the model against them
+	EnergySourceRedfish = "redfish"
+	// EnergySourcePDU reads the power of the smart PDU outlets feeding each node
+	EnergySourcePDU = "pdu"
+)
+
+// EnergySourceConfig holds settings for reading measured power from Prometheus in
+// place of the CPU-linear power model, which is poor on bare metal. Each node pool
+// can use its own source; nodes without a fresh reading fall back to the model.
+type EnergySourceConfig struct {
+	Default       string `yaml:"default"` // Source of nodes not in a configured pool
+	PrometheusURL string `yaml:"prometheusURL"`
+	// NodePoolLabel is the node label naming the pool of a node
+	NodePoolLabel string `yaml:"nodePoolLabel"`
+	// NodePools maps node pools to their source
+	NodePools map[string]string `yaml:"nodePools"`
+	NodeLabel string            `yaml:"nodeLabel"` // Label of the node query samples holding the node name
+	// ScaphandreNodeQuery returns the power draw of each node in watts
+	ScaphandreNodeQuery string `yaml:"scaphandreNodeQuery"`
+	// ScaphandrePodQuery returns the power draw of each pod in watts, with namespace
+	// and pod labels
+	ScaphandrePodQuery string `yaml:"scaphandrePodQuery"`
+	PodNamespaceLabel  string `yaml:"podNamespaceLabel"` // Label of the pod query samples holding the namespace
+	PodNameLabel       string `yaml:"podNameLabel"`      // Label of the pod query samples holding the pod name
+	// RAPLNodeQuery returns the power draw of each node in watts
+	RAPLNodeQuery string `yaml:"raplNodeQuery"`
+	// RedfishAddresses maps node names to the base URL of their BMC. They are
+	// configured here rather than on nodes, which could otherwise redirect the BMC
+	// credentials to any host.
+	RedfishAddresses map[string]string `yaml:"redfishAddresses"`
+	// RedfishPowerPath is the Redfish resource with the power readings of a node
+	RedfishPowerPath          string        `yaml:"redfishPowerPath"`
+	RedfishUsername           string        `yaml:"redfishUsername"`
+	RedfishPassword           string        `yaml:"redfishPassword"`
+	RedfishInsecureSkipVerify bool          `yaml:"redfishInsecureSkipVerify"` // BMCs often serve self-signed certificates
+	PollInterval              time.Duration `yaml:"pollInterval"`              // How often the sources are polled
+	MaxAge                    time.Duration `yaml:"maxAge"`                    // Readings older than this are ignored
+	PDU                       PDUConfig     `yaml:"pdu"`
+}
+
+// PDUConfig holds settings for reading the power of the smart PDU outlets feeding
+// nodes, over Redfish or from Prometheus, e.g. scraped by the SNMP exporter
+type PDUConfig struct {
+	// OutletsAnnotation is the node annotation listing the outlets feeding it as
+	// pdu/outlet pairs, e.g. pdu-a/3,pdu-b/3 for a dual-fed node
+	OutletsAnnotation string `yaml:"outletsAnnotation"`
+	// Addresses maps PDUs to the base URL of their Redfish service
+	Addresses map[string]string `yaml:"addresses"`
+	// OutletPath is the Redfish resource of an outlet, with %s standing for its id
+	OutletPath         string `yaml:"outletPath"`
+	Username           string `yaml:"username"`
+	Password           string `yaml:"password"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+	// Query returns the power of PDU outlets in watts with PDU and outlet labels,
+	// read from the energy source Prometheus in place of Redfish when set
+	Query       string `yaml:"query"`
+	PDULabel    string `yaml:"pduLabel"`
+	OutletLabel string `yaml:"outletLabel"`
+}
+
+// Uses reports whether the default or any node pool uses a source
+func (c EnergySourceConfig) Uses(source string) bool {
+	if c.Default == source {
+		return true
+	}
+	for _, poolSource := range c.NodePools {
+		if poolSource == source {
+			return true
+		}
+	}
+	return false
+}
+
+// Measured reports whether any node reads its power from a measured source
+func (c EnergySourceConfig) Measured() bool {
+	if c.Default != "" && c.Default != EnergySourceModel {
+		return true
+	}
+	for _, source := range c.NodePools {
+		if source != EnergySourceModel {
+			return true
+		}
+	}
+	return false
+}
+
+// GridAlertConfig holds settings for grid emergency alert integration. While an
+// alert is active the scheduler runs in conservation mode.
+type GridAlertConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	URL          string        `yaml:"url"`          // Alert feed returning {"id", "active", "until"} JSON
+	PollInterval time.Duration `yaml:"pollInterval"` // How often the feed is polled
+	// ThresholdFactor multiplies carbon intensity thresholds in conservation mode
+	ThresholdFactor float64 `yaml:"thresholdFactor"`
+	// PauseAdmissions blocks all new gated admissions in conservation mode
+	PauseAdmissions bool `yaml:"pauseAdmissions"`
+	// MaxConcurrentPods limits the number of admitted pods running at once in
+	// conservation mode, if lower than Scheduling.MaxConcurrentPods, 0 disables
+	MaxConcurrentPods int `yaml:"maxConcurrentPods"`
+}
+
+// DemandResponseConfig holds settings for demand-response event handling
+type DemandResponseConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"` // Webhook path served on the metrics port
+	// Token is the bearer token webhook requests must carry
+	Token string `yaml:"token"`
+	// ThresholdFactor multiplies carbon intensity thresholds during events that
+	// don't specify their own factor
+	ThresholdFactor float64 `yaml:"thresholdFactor"`
+	// PauseAdmissions blocks all new gated admissions during every event
+	PauseAdmissions bool `yaml:"pauseAdmissions"`
+}
+
+// PolicyConfig holds settings for the compute-gardener.dev policy resources. When
+// enabled, the CarbonPolicy or ClusterCarbonPolicy selecting a pod sets its threshold,
+// maximum delay, peak schedules and enforcement mode in place of the scheduler's
+// configuration.
+type PolicyConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// BudgetsEnabled enforces the CarbonBudgets of namespaces against the emissions
+	// of their completed pods
+	BudgetsEnabled     bool          `yaml:"budgetsEnabled"`
+	BudgetSyncInterval time.Duration `yaml:"budgetSyncInterval"` // How often budget status is reported
+	// BudgetAction is taken on new pods of budgets that don't set their own action
+	BudgetAction string `yaml:"budgetAction"`
+	// BudgetDemotionFactor multiplies the thresholds of pods demoted by budgets that
+	// don't set their own factor
+	BudgetDemotionFactor float64 `yaml:"budgetDemotionFactor"`
+	// AccountingLabel is the label key, e.g. team, emissions are also attributed by,
+	// taken from the pod or else its namespace. ClusterCarbonBudgets apply to its values.
+	AccountingLabel string `yaml:"accountingLabel"`
+	// ClassesEnabled applies the WorkloadClass named by the workload-class label of pods
+	ClassesEnabled bool `yaml:"classesEnabled"`
+	// ExemptionsEnabled exempts the pods selected by CarbonExemptions in effect
+	ExemptionsEnabled bool `yaml:"exemptionsEnabled"`
+	// HubKubeconfig is the kubeconfig of a hub cluster CarbonPolicies,
+	// CarbonBudgets, WorkloadClasses, CarbonExemptions and CarbonSLOs are read from
+	// in place of this cluster's, so a fleet shares one set of them. ElasticQuotas
+	// are always read from this cluster. Empty reads this cluster's.
+	HubKubeconfig string `yaml:"hubKubeconfig"`
+	// HubSelector restricts the hub objects read to those whose labels it matches
+	HubSelector string `yaml:"hubSelector"`
+	// QuotaBorrowingMaxIntensity is the carbon intensity above which pods may not
+	// borrow beyond the min of their namespace's ElasticQuota, 0 to always allow it
+	QuotaBorrowingMaxIntensity float64 `yaml:"quotaBorrowingMaxIntensity"`
+	// SLOsEnabled tracks the CarbonSLOs of namespaces against the CPU-hours of their
+	// completed pods, adjusting thresholds to keep them on track
+	SLOsEnabled     bool          `yaml:"slosEnabled"`
+	SLOSyncInterval time.Duration `yaml:"sloSyncInterval"` // How often SLO status is reported
+}
+
+// MaintenanceConfig holds time windows during which carbon and price gating is suspended
+type MaintenanceConfig struct {
+	Windows []Schedule `yaml:"windows"` // Windows in the pricing schedule syntax, rates are ignored
+}
+
+// HistoryConfig holds settings for persisting sampled carbon intensity values
+type HistoryConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Path          string        `yaml:"path"`          // File to persist samples to, empty for in-memory only
+	Retention     time.Duration `yaml:"retention"`     // How long samples are kept
+	FlushInterval time.Duration `yaml:"flushInterval"` // How often samples are written to Path
+}
+
+// AccountingConfig holds settings for persisting the energy accounting of pods, so
+// a restart doesn't lose the energy of running pods or reset namespace totals
+type AccountingConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	Path          string        `yaml:"path"`          // File to persist accounting to
+	FlushInterval time.Duration `yaml:"flushInterval"` // How often accounting is written to Path
+}
+
+// FallbackConfig holds settings for the synthetic intensity curve used when the
+// carbon data API is unreachable
+type FallbackConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	After           time.Duration `yaml:"after"`           // How long the API must be unreachable before falling back
+	MinIntensity    float64       `yaml:"minIntensity"`    // Intensity at solar noon (gCO2eq/kWh)
+	MaxIntensity    float64       `yaml:"maxIntensity"`    // Intensity twelve hours after solar noon (gCO2eq/kWh)
+	SolarNoonOffset time.Duration `yaml:"solarNoonOffset"` // Time of local solar noon as an offset from UTC midnight
 }
 
 // APIConfig holds configuration for external API interactions
 type APIConfig struct {
-	Key         string        `yaml:"key"`
-	URL         string        `yaml:"url"`
-	Region      string        `yaml:"region"`
-	Timeout     time.Duration `yaml:"timeout"`
-	MaxRetries  int           `yaml:"maxRetries"`
-	RetryDelay  time.Duration `yaml:"retryDelay"`
-	RateLimit   int           `yaml:"rateLimit"`
-	CacheTTL    time.Duration `yaml:"cacheTTL"`
-	MaxCacheAge time.Duration `yaml:"maxCacheAge"`
+	Key         string `yaml:"key"`
+	URL         string `yaml:"url"`
+	MarginalURL string `yaml:"marginalURL"` // Endpoint serving marginal emissions rates, empty if unsupported
+	SignalType  string `yaml:"signalType"`  // "average" or "marginal"
+	ForecastURL string `yaml:"forecastURL"`
+	// ForecastEnabled fetches the intensity forecast alongside the latest value
+	ForecastEnabled bool     `yaml:"forecastEnabled"`
+	Region          string   `yaml:"region"`
+	Zones           []string `yaml:"zones"`        // Additional zones tracked alongside Region
+	FetchWorkers    int      `yaml:"fetchWorkers"` // Concurrent requests when refreshing zones
+	// RegionZoneMap overrides the built-in cloud region to grid zone mapping
+	RegionZoneMap map[string]string `yaml:"regionZoneMap"`
+	Timeout       time.Duration     `yaml:"timeout"`
+	MaxRetries    int               `yaml:"maxRetries"`
+	RetryDelay    time.Duration     `yaml:"retryDelay"`
+	RateLimit     int               `yaml:"rateLimit"`
+	CacheTTL      time.Duration     `yaml:"cacheTTL"`
+	MaxCacheAge   time.Duration     `yaml:"maxCacheAge"`
 }
 
 // SchedulingConfig holds configuration for scheduling behavior
 type SchedulingConfig struct {
-	BaseCarbonIntensityThreshold float64       `yaml:"baseCarbonIntensityThreshold"`
-	MaxSchedulingDelay           time.Duration `yaml:"maxSchedulingDelay"`
-	DefaultRegion                string        `yaml:"defaultRegion"`
-	EnablePodPriorities          bool          `yaml:"enablePodPriorities"`
+	BaseCarbonIntensityThreshold float64 `yaml:"baseCarbonIntensityThreshold"`
+	// ReleaseCarbonIntensityThreshold enables hysteresis: once blocked above the base
+	// threshold, pods are only released when intensity falls below this value. 0 disables.
+	ReleaseCarbonIntensityThreshold float64 `yaml:"releaseCarbonIntensityThreshold"`
+	// WeekendCarbonIntensityThreshold replaces the base threshold on Saturdays and
+	// Sundays, 0 uses the base threshold every day
+	WeekendCarbonIntensityThreshold float64 `yaml:"weekendCarbonIntensityThreshold"`
+	// AbortCarbonIntensityThreshold aborts binding pods whose zone's intensity has
+	// risen above it since they were admitted, 0 disables
+	AbortCarbonIntensityThreshold float64       `yaml:"abortCarbonIntensityThreshold"`
+	MaxSchedulingDelay            time.Duration `yaml:"maxSchedulingDelay"`
+	DefaultRegion                 string        `yaml:"defaultRegion"`
+	EnablePodPriorities           bool          `yaml:"enablePodPriorities"`
+	// EnforcementMode is the default enforcement mode, overridable per namespace
+	EnforcementMode string `yaml:"enforcementMode"`
+	// WaitMode selects whether delayed pods are requeued or held at Permit
+	WaitMode string `yaml:"waitMode"`
+	// DecisionMode selects between independent price and carbon gates and a weighted
+	// score with a single cut-off
+	DecisionMode   string          `yaml:"decisionMode"`
+	Weights        DecisionWeights `yaml:"weights"`
+	WeightedCutoff float64         `yaml:"weightedCutoff"`
+	// OptimalWindowEnabled schedules pods immediately when the forecast shows no lower
+	// intensity window before their scheduling deadline
+	OptimalWindowEnabled bool `yaml:"optimalWindowEnabled"`
+	// ThresholdPercentile replaces the base threshold with this percentile of the
+	// zone's recorded intensity over PercentileWindow, 0 disables
+	ThresholdPercentile float64       `yaml:"thresholdPercentile"`
+	PercentileWindow    time.Duration `yaml:"percentileWindow"`
+	// PercentileMinSamples is the number of samples required before the percentile
+	// is used instead of the base threshold
+	PercentileMinSamples int `yaml:"percentileMinSamples"`
+	// AgingCurve relaxes the threshold of waiting pods towards AgingMaxFactor times
+	// the threshold at their scheduling deadline, "linear" or "quadratic"
+	AgingCurve     string  `yaml:"agingCurve"`
+	AgingMaxFactor float64 `yaml:"agingMaxFactor"`
+	// ReleaseBatchSize limits how many held pods are released per ReleaseBatchInterval
+	// once constraints clear, 0 disables
+	ReleaseBatchSize     int           `yaml:"releaseBatchSize"`
+	ReleaseBatchInterval time.Duration `yaml:"releaseBatchInterval"`
+	// WeekendReleaseBatchSize replaces ReleaseBatchSize on Saturdays and Sundays, 0
+	// uses ReleaseBatchSize every day
+	WeekendReleaseBatchSize int `yaml:"weekendReleaseBatchSize"`
+	// AdmissionRate paces admissions to this many pods per minute, or CPU cores per
+	// minute if AdmissionWeightByCPU is set, 0 disables
+	AdmissionRate        float64 `yaml:"admissionRate"`
+	AdmissionBurst       float64 `yaml:"admissionBurst"`
+	AdmissionWeightByCPU bool    `yaml:"admissionWeightByCPU"`
+	// MaxConcurrentPods limits the number of admitted pods running at once, 0 disables
+	MaxConcurrentPods int `yaml:"maxConcurrentPods"`
+	// WeekendMaxConcurrentPods replaces MaxConcurrentPods on Saturdays and Sundays, 0
+	// uses MaxConcurrentPods every day
+	WeekendMaxConcurrentPods int `yaml:"weekendMaxConcurrentPods"`
+	// BestEffortHorizon is how far ahead best-effort pods look for a window under
+	// their threshold before being admitted
+	BestEffortHorizon time.Duration `yaml:"bestEffortHorizon"`
+	// TrendHorizon schedules pods immediately when intensity is expected to keep rising
+	// over this horizon, 0 disables
+	TrendHorizon time.Duration `yaml:"trendHorizon"`
+	// RequeueBackoff is the longest a pod delayed on carbon intensity or price skips
+	// re-evaluation while its zone's data is unchanged, 0 disables
+	RequeueBackoff time.Duration `yaml:"requeueBackoff"`
+	// GangReleaseTTL is how long the other members of a coscheduling PodGroup are
+	// admitted once one member is, 0 gates members independently
+	GangReleaseTTL time.Duration `yaml:"gangReleaseTTL"`
+	// DeferBindMaxDelay enables deferred binding for pods opting in, and caps how long
+	// their bind is deferred, 0 disables
+	DeferBindMaxDelay time.Duration `yaml:"deferBindMaxDelay"`
+	// SmoothingWindow is the number of recent samples smoothed before threshold
+	// comparison, 0 disables smoothing
+	SmoothingWindow int `yaml:"smoothingWindow"`
+	// SmoothingAlpha is the EWMA weight of the newest sample, in (0, 1]
+	SmoothingAlpha float64 `yaml:"smoothingAlpha"`
+	// LearnDurations estimates run durations of Job pods without an
+	// estimated-duration annotation from the median of previous runs
+	LearnDurations bool `yaml:"learnDurations"`
+	// DurationSamples is the number of recent runs kept per Job template
+	DurationSamples int `yaml:"durationSamples"`
+	// EnergyLightKWh admits pods with a lower estimated energy regardless of carbon
+	// intensity, 0 disables
+	EnergyLightKWh float64 `yaml:"energyLightKWh"`
+	// EnergyHeavyKWh applies EnergyHeavyFactor to the carbon threshold of pods with
+	// at least this estimated energy, 0 disables
+	EnergyHeavyKWh    float64 `yaml:"energyHeavyKWh"`
+	EnergyHeavyFactor float64 `yaml:"energyHeavyFactor"`
+	// ExemptStatefulSets exempts StatefulSet pods from carbon-aware scheduling, like
+	// DaemonSet and static pods
+	ExemptStatefulSets bool `yaml:"exemptStatefulSets"`
 }
 
 // Schedule defines a time range with its peak and off-peak rates
@@ -57,12 +612,23 @@ type Schedule struct {
 	OffPeakRate float64 `yaml:"offPeakRate"` // Rate in $/kWh outside this time period
 }
 
+// Sources deciding whether peak hours are in effect
+const (
+	// PeakSourceSchedule follows the configured pricing schedules
+	PeakSourceSchedule = "schedule"
+	// PeakSourceForecast admits pods during peak hours when live carbon data and
+	// the forecast show a clean grid
+	PeakSourceForecast = "forecast"
+)
+
 // PricingConfig holds configuration for price-aware scheduling
 type PricingConfig struct {
 	Enabled   bool       `yaml:"enabled"`
 	Provider  string     `yaml:"provider"` // e.g. "tou" for time-of-use pricing
 	MaxDelay  string     `yaml:"maxDelay"`
 	Schedules []Schedule `yaml:"schedules"` // Time-based pricing periods with their rates
+	// PeakSource decides which source wins when peak hours and carbon data disagree
+	PeakSource string `yaml:"peakSource"`
 }
 
 // ObservabilityConfig holds configuration for monitoring and debugging
@@ -73,6 +639,36 @@ type ObservabilityConfig struct {
 	HealthCheckPort    int    `yaml:"healthCheckPort"`
 	LogLevel           string `yaml:"logLevel"`
 	EnableTracing      bool   `yaml:"enableTracing"`
+	// DelayStatusEnabled reports the delay of each held pod in a CarbonDelay resource
+	DelayStatusEnabled bool `yaml:"delayStatusEnabled"`
+	// EvaluateEnabled serves dry-run evaluations of submitted pods on the metrics port,
+	// to requests carrying EvaluateToken as a bearer token
+	EvaluateEnabled bool   `yaml:"evaluateEnabled"`
+	EvaluatePath    string `yaml:"evaluatePath"` // Path evaluations are served on
+	EvaluateToken   string `yaml:"evaluateToken"`
+	// PodMetricsEnabled labels the per-workload job metrics with the name of each
+	// pod and records the per-pod scheduling efficiency, for debugging at the cost
+	// of one series per pod
+	PodMetricsEnabled bool `yaml:"podMetricsEnabled"`
+	// StampsTrusted compares the intensity pods are bound at with the intensity
+	// stamped by the admission webhook. Only set it if the stamp webhook runs with
+	// failurePolicy Fail, as submitters can otherwise set the stamp themselves.
+	StampsTrusted bool `yaml:"stampsTrusted"`
+}
+
+// TrackedZones returns the deduplicated list of zones the scheduler keeps data for,
+// starting with the primary region
+func (c APIConfig) TrackedZones() []string {
+	zones := make([]string, 0, len(c.Zones)+1)
+	seen := make(map[string]bool, len(c.Zones)+1)
+	for _, zone := range append([]string{c.Region}, c.Zones...) {
+		if zone == "" || seen[zone] {
+			continue
+		}
+		seen[zone] = true
+		zones = append(zones, zone)
+	}
+	return zones
 }
 
 // Validate performs validation of the configuration
@@ -81,36 +677,430 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("API key is required")
 	}
 
+	switch c.API.SignalType {
+	case "", SignalTypeAverage:
+	case SignalTypeMarginal:
+		if c.API.MarginalURL == "" {
+			return fmt.Errorf("marginal signal type requires a marginal API URL")
+		}
+	default:
+		return fmt.Errorf("invalid signal type: %s (must be %q or %q)", c.API.SignalType, SignalTypeAverage, SignalTypeMarginal)
+	}
+
+	if c.API.ForecastEnabled && c.API.ForecastURL == "" {
+		return fmt.Errorf("forecast URL is required when forecasts are enabled")
+	}
+
+	if c.API.FetchWorkers < 0 {
+		return fmt.Errorf("fetch workers must not be negative")
+	}
+
 	if c.Scheduling.BaseCarbonIntensityThreshold <= 0 {
 		return fmt.Errorf("base carbon intensity threshold must be positive")
 	}
 
+	if c.Scheduling.ReleaseCarbonIntensityThreshold < 0 ||
+		c.Scheduling.ReleaseCarbonIntensityThreshold > c.Scheduling.BaseCarbonIntensityThreshold {
+		return fmt.Errorf("release carbon intensity threshold must be between 0 and the base threshold")
+	}
+
+	if c.Scheduling.AbortCarbonIntensityThreshold != 0 &&
+		c.Scheduling.AbortCarbonIntensityThreshold < c.Scheduling.BaseCarbonIntensityThreshold {
+		return fmt.Errorf("abort carbon intensity threshold must be 0 or at least the base threshold")
+	}
+
+	if c.Scheduling.WeekendCarbonIntensityThreshold < 0 {
+		return fmt.Errorf("weekend carbon intensity threshold must not be negative")
+	}
+	if c.Scheduling.WeekendCarbonIntensityThreshold > 0 && c.Scheduling.ReleaseCarbonIntensityThreshold > 0 &&
+		c.Scheduling.WeekendCarbonIntensityThreshold <= c.Scheduling.BaseCarbonIntensityThreshold-c.Scheduling.ReleaseCarbonIntensityThreshold {
+		return fmt.Errorf("weekend carbon intensity threshold must exceed the hysteresis band")
+	}
+
+	if c.Scheduling.ThresholdPercentile != 0 {
+		if c.Scheduling.ThresholdPercentile < 0 || c.Scheduling.ThresholdPercentile > 100 {
+			return fmt.Errorf("threshold percentile must be in (0, 100]")
+		}
+		if !c.History.Enabled {
+			return fmt.Errorf("threshold percentile requires history to be enabled")
+		}
+		if c.Scheduling.PercentileWindow <= 0 {
+			return fmt.Errorf("percentile window must be positive")
+		}
+	}
+	switch c.Scheduling.AgingCurve {
+	case AgingCurveNone:
+	case AgingCurveLinear, AgingCurveQuadratic:
+		if c.Scheduling.AgingMaxFactor < 1 {
+			return fmt.Errorf("aging max factor must be at least 1")
+		}
+	default:
+		return fmt.Errorf("unknown aging curve: %s", c.Scheduling.AgingCurve)
+	}
+	if c.Scheduling.ReleaseBatchSize < 0 || c.Scheduling.WeekendReleaseBatchSize < 0 {
+		return fmt.Errorf("release batch size must not be negative")
+	}
+	if (c.Scheduling.ReleaseBatchSize > 0 || c.Scheduling.WeekendReleaseBatchSize > 0) && c.Scheduling.ReleaseBatchInterval <= 0 {
+		return fmt.Errorf("release batch interval must be positive")
+	}
+	if c.Scheduling.AdmissionRate < 0 {
+		return fmt.Errorf("admission rate must not be negative")
+	}
+	if c.Scheduling.AdmissionRate > 0 && c.Scheduling.AdmissionBurst <= 0 {
+		return fmt.Errorf("admission burst must be positive")
+	}
+	if c.Scheduling.MaxConcurrentPods < 0 || c.Scheduling.WeekendMaxConcurrentPods < 0 {
+		return fmt.Errorf("max concurrent pods must not be negative")
+	}
+	if c.Scheduling.EnforcementMode != EnforcementModeEnforce && c.Scheduling.EnforcementMode != EnforcementModeAudit {
+		return fmt.Errorf("unknown enforcement mode: %s", c.Scheduling.EnforcementMode)
+	}
+	if c.Scheduling.BestEffortHorizon < 0 {
+		return fmt.Errorf("best-effort horizon must not be negative")
+	}
+	switch c.Scheduling.WaitMode {
+	case WaitModeRequeue, WaitModePermit:
+	default:
+		return fmt.Errorf("unknown wait mode: %s", c.Scheduling.WaitMode)
+	}
+	if w := c.Scoring.Weights; w.Zone <= 0 || w.Efficiency < 0 || w.Packing < 0 || w.Spot < 0 {
+		return fmt.Errorf("zone score weight must be positive and other score weights must not be negative")
+	}
+	switch c.Scheduling.DecisionMode {
+	case DecisionModeGates:
+	case DecisionModeWeighted:
+		w := c.Scheduling.Weights
+		if w.Carbon < 0 || w.Price < 0 || w.Load < 0 || w.Carbon+w.Price+w.Load == 0 {
+			return fmt.Errorf("decision weights must not be negative and at least one must be positive")
+		}
+		if c.Scheduling.WeightedCutoff <= 0 || c.Scheduling.WeightedCutoff > 1 {
+			return fmt.Errorf("weighted cut-off must be in (0, 1]")
+		}
+	default:
+		return fmt.Errorf("unknown decision mode: %s", c.Scheduling.DecisionMode)
+	}
+	if c.Scheduling.TrendHorizon < 0 {
+		return fmt.Errorf("trend horizon must not be negative")
+	}
+	if c.Scheduling.RequeueBackoff < 0 {
+		return fmt.Errorf("requeue backoff must not be negative")
+	}
+	if c.Scheduling.GangReleaseTTL < 0 {
+		return fmt.Errorf("gang release TTL must not be negative")
+	}
+	if c.Scheduling.DeferBindMaxDelay < 0 {
+		return fmt.Errorf("deferred bind max delay must not be negative")
+	}
+	if c.Scheduling.SmoothingWindow < 0 {
+		return fmt.Errorf("smoothing window must not be negative")
+	}
+	if c.Scheduling.SmoothingWindow > 0 && (c.Scheduling.SmoothingAlpha <= 0 || c.Scheduling.SmoothingAlpha > 1) {
+		return fmt.Errorf("smoothing alpha must be in (0, 1]")
+	}
+	if c.Scheduling.LearnDurations && c.Scheduling.DurationSamples <= 0 {
+		return fmt.Errorf("duration samples must be positive")
+	}
+	if c.Scheduling.EnergyLightKWh < 0 || c.Scheduling.EnergyHeavyKWh < 0 {
+		return fmt.Errorf("energy thresholds must not be negative")
+	}
+	if c.Scheduling.EnergyHeavyKWh > 0 {
+		if c.Scheduling.EnergyHeavyKWh <= c.Scheduling.EnergyLightKWh {
+			return fmt.Errorf("heavy energy threshold must be greater than light energy threshold")
+		}
+		if c.Scheduling.EnergyHeavyFactor <= 0 || c.Scheduling.EnergyHeavyFactor > 1 {
+			return fmt.Errorf("heavy energy threshold factor must be in (0, 1]")
+		}
+	}
+
 	if c.Pricing.Enabled {
 		if err := c.validatePricing(); err != nil {
 			return fmt.Errorf("invalid pricing config: %v", err)
 		}
 	}
 
+	if c.Fallback.Enabled {
+		if c.Fallback.MinIntensity < 0 {
+			return fmt.Errorf("fallback min intensity must not be negative")
+		}
+		if c.Fallback.MaxIntensity < c.Fallback.MinIntensity {
+			return fmt.Errorf("fallback max intensity must not be less than min intensity")
+		}
+	}
+
+	for i, window := range c.Maintenance.Windows {
+		if err := validateSchedule(window); err != nil {
+			return fmt.Errorf("invalid maintenance window at index %d: %v", i, err)
+		}
+	}
+
+	if c.History.Enabled {
+		if c.History.Retention <= 0 {
+			return fmt.Errorf("history retention must be positive")
+		}
+		if c.History.FlushInterval <= 0 {
+			return fmt.Errorf("history flush interval must be positive")
+		}
+	}
+
+	if c.Accounting.Enabled {
+		if c.Accounting.Path == "" {
+			return fmt.Errorf("energy accounting requires a path")
+		}
+		if c.Accounting.FlushInterval <= 0 {
+			return fmt.Errorf("energy accounting flush interval must be positive")
+		}
+	}
+
+	if c.DemandResponse.Enabled {
+		if c.DemandResponse.ThresholdFactor <= 0 || c.DemandResponse.ThresholdFactor > 1 {
+			return fmt.Errorf("demand response threshold factor must be in (0, 1]")
+		}
+		if !strings.HasPrefix(c.DemandResponse.Path, "/") {
+			return fmt.Errorf("demand response path must start with /")
+		}
+		if c.DemandResponse.Token == "" {
+			return fmt.Errorf("demand response token is required when demand response is enabled")
+		}
+	}
+
+	if c.Observability.EvaluateEnabled && c.Observability.EvaluateToken == "" {
+		return fmt.Errorf("evaluation token is required when evaluations are enabled")
+	}
+
+	if c.Policy.BudgetsEnabled {
+		if c.Policy.BudgetSyncInterval <= 0 {
+			return fmt.Errorf("carbon budget sync interval must be positive")
+		}
+		switch c.Policy.BudgetAction {
+		case BudgetActionBlock, BudgetActionAudit, BudgetActionDemote:
+		default:
+			return fmt.Errorf("unknown carbon budget action: %s", c.Policy.BudgetAction)
+		}
+		if c.Policy.BudgetDemotionFactor <= 0 || c.Policy.BudgetDemotionFactor > 1 {
+			return fmt.Errorf("carbon budget demotion factor must be in (0, 1]")
+		}
+	}
+
+	if c.Policy.QuotaBorrowingMaxIntensity < 0 {
+		return fmt.Errorf("quota borrowing max intensity must be non-negative")
+	}
+
+	if c.Policy.SLOsEnabled && c.Policy.SLOSyncInterval <= 0 {
+		return fmt.Errorf("carbon SLO sync interval must be positive")
+	}
+
+	if c.Policy.HubSelector != "" {
+		if c.Policy.HubKubeconfig == "" {
+			return fmt.Errorf("hub kubeconfig is required with a hub selector")
+		}
+		if _, err := labels.Parse(c.Policy.HubSelector); err != nil {
+			return fmt.Errorf("invalid hub selector: %v", err)
+		}
+	}
+
+	if c.GridAlert.Enabled {
+		if c.GridAlert.URL == "" {
+			return fmt.Errorf("grid alert URL is required when grid alerts are enabled")
+		}
+		if c.GridAlert.PollInterval <= 0 {
+			return fmt.Errorf("grid alert poll interval must be positive")
+		}
+		if c.GridAlert.ThresholdFactor <= 0 || c.GridAlert.ThresholdFactor > 1 {
+			return fmt.Errorf("grid alert threshold factor must be in (0, 1]")
+		}
+		if c.GridAlert.MaxConcurrentPods < 0 {
+			return fmt.Errorf("grid alert max concurrent pods must not be negative")
+		}
+	}
+
+	if c.OnSite.Enabled {
+		if c.OnSite.URL == "" {
+			return fmt.Errorf("on-site telemetry URL is required when on-site gating is enabled")
+		}
+		if c.OnSite.PollInterval <= 0 || c.OnSite.MaxAge <= 0 {
+			return fmt.Errorf("on-site poll interval and max age must be positive")
+		}
+		if c.OnSite.MinProductionKW <= 0 && c.OnSite.MinStateOfCharge <= 0 {
+			return fmt.Errorf("on-site gating requires a minimum production or state of charge")
+		}
+		if c.OnSite.MinStateOfCharge > 100 {
+			return fmt.Errorf("on-site minimum state of charge must not exceed 100")
+		}
+	}
+
+	if c.Thermal.Enabled {
+		if c.Thermal.PrometheusURL == "" || c.Thermal.Query == "" {
+			return fmt.Errorf("thermal gating requires a Prometheus URL and query")
+		}
+		if c.Thermal.Threshold <= 0 {
+			return fmt.Errorf("thermal threshold must be positive")
+		}
+		if c.Thermal.PollInterval <= 0 || c.Thermal.MaxAge <= 0 {
+			return fmt.Errorf("thermal poll interval and max age must be positive")
+		}
+	}
+
+	if c.PowerCap.Enabled {
+		if c.PowerCap.PrometheusURL == "" || c.PowerCap.Query == "" || c.PowerCap.NodeLabel == "" {
+			return fmt.Errorf("power cap filtering requires a Prometheus URL, query and node label")
+		}
+		if c.PowerCap.Threshold <= 0 || c.PowerCap.Threshold > 1 {
+			return fmt.Errorf("power cap threshold must be in (0, 1]")
+		}
+		if c.PowerCap.PollInterval <= 0 || c.PowerCap.MaxAge <= 0 {
+			return fmt.Errorf("power cap poll interval and max age must be positive")
+		}
+	}
+
+	if c.GPU.Enabled {
+		if c.GPU.ResourceName == "" {
+			return fmt.Errorf("GPU power estimation requires a GPU resource name")
+		}
+		if c.GPU.PrometheusURL == "" || c.GPU.Query == "" || c.GPU.NodeLabel == "" {
+			return fmt.Errorf("GPU power estimation requires a Prometheus URL, query and node label")
+		}
+		if c.GPU.PollInterval <= 0 || c.GPU.MaxAge <= 0 {
+			return fmt.Errorf("GPU poll interval and max age must be positive")
+		}
+		if c.Power.DefaultGPUIdlePower < 0 || c.Power.DefaultGPUMaxPower <= c.Power.DefaultGPUIdlePower {
+			return fmt.Errorf("default GPU max power must be greater than GPU idle power")
+		}
+	}
+
+	if c.CPU.FrequencyEnabled {
+		if c.CPU.PrometheusURL == "" || c.CPU.FrequencyQuery == "" || c.CPU.NodeLabel == "" {
+			return fmt.Errorf("CPU frequency scaling requires a Prometheus URL, query and node label")
+		}
+		if c.CPU.PollInterval <= 0 || c.CPU.MaxAge <= 0 {
+			return fmt.Errorf("CPU frequency poll interval and max age must be positive")
+		}
+		if c.CPU.FrequencyExponent <= 0 {
+			return fmt.Errorf("CPU frequency exponent must be positive")
+		}
+	}
+	if c.CPU.SMTEnabled {
+		if c.CPU.SMTLabel == "" {
+			return fmt.Errorf("SMT-aware power requires an SMT label")
+		}
+		if c.CPU.SMTSiblingPower < 0 || c.CPU.SMTSiblingPower > 1 {
+			return fmt.Errorf("SMT sibling power must be between 0 and 1")
+		}
+	}
+
+	for pool, source := range c.EnergySource.NodePools {
+		if err := validateEnergySource(source); err != nil {
+			return fmt.Errorf("node pool %s: %v", pool, err)
+		}
+	}
+	if c.EnergySource.Default != "" {
+		if err := validateEnergySource(c.EnergySource.Default); err != nil {
+			return err
+		}
+	}
+	if len(c.EnergySource.NodePools) > 0 && c.EnergySource.NodePoolLabel == "" {
+		return fmt.Errorf("energy source node pools require a node pool label")
+	}
+	if c.EnergySource.Uses(EnergySourceScaphandre) || c.EnergySource.Uses(EnergySourceRAPL) {
+		if c.EnergySource.PrometheusURL == "" || c.EnergySource.NodeLabel == "" {
+			return fmt.Errorf("energy sources read from Prometheus require a Prometheus URL and node label")
+		}
+	}
+	if c.EnergySource.Uses(EnergySourceRedfish) {
+		if len(c.EnergySource.RedfishAddresses) == 0 || c.EnergySource.RedfishPowerPath == "" {
+			return fmt.Errorf("the Redfish energy source requires BMC addresses and a power path")
+		}
+	}
+	if c.EnergySource.Uses(EnergySourcePDU) {
+		pdu := c.EnergySource.PDU
+		if pdu.OutletsAnnotation == "" {
+			return fmt.Errorf("the PDU energy source requires an outlets annotation")
+		}
+		if pdu.Query != "" {
+			if c.EnergySource.PrometheusURL == "" || pdu.PDULabel == "" || pdu.OutletLabel == "" {
+				return fmt.Errorf("reading PDUs from Prometheus requires a Prometheus URL, PDU label and outlet label")
+			}
+		} else if len(pdu.Addresses) == 0 || !strings.Contains(pdu.OutletPath, "%s") {
+			return fmt.Errorf("reading PDUs over Redfish requires their addresses and an outlet path containing %%s")
+		}
+	}
+	if c.EnergySource.Measured() {
+		if c.EnergySource.PollInterval <= 0 || c.EnergySource.MaxAge <= 0 {
+			return fmt.Errorf("energy source poll interval and max age must be positive")
+		}
+	}
+
 	// Validate power settings
+	if c.Power.PodSampleInterval <= 0 {
+		return fmt.Errorf("pod energy sample interval must be positive")
+	}
+	if c.Power.AccrualInterval < 0 {
+		return fmt.Errorf("pod energy accrual interval must be non-negative")
+	}
+	if c.Power.MetricsTTL <= 0 || c.Power.MetricsMaxEntries <= 0 {
+		return fmt.Errorf("power metrics TTL and max entries must be positive")
+	}
+	if c.Power.MemoryWattsPerGB < 0 || c.Power.DiskWatts < 0 || c.Power.NICWatts < 0 {
+		return fmt.Errorf("memory, disk and NIC power must not be negative")
+	}
+	if c.Power.DefaultDisks < 0 || c.Power.DefaultNICs < 0 {
+		return fmt.Errorf("default disks and NICs must not be negative")
+	}
+	if c.Power.EmbodiedCarbonPerHour < 0 {
+		return fmt.Errorf("embodied carbon per node-hour must not be negative")
+	}
+	if c.Power.PUE < 1 {
+		return fmt.Errorf("PUE must be at least 1")
+	}
+	for zone, pue := range c.Power.ZonePUE {
+		if pue < 1 {
+			return fmt.Errorf("PUE of zone %s must be at least 1", zone)
+		}
+	}
+	if c.Power.InstanceTypesPath != "" && c.Power.InstanceTypesRefreshInterval <= 0 {
+		return fmt.Errorf("instance type power refresh interval must be positive")
+	}
+	if c.Power.NFD.Enabled {
+		for _, profile := range c.Power.NFD.Profiles {
+			if len(profile.Features) == 0 {
+				return fmt.Errorf("NFD power profile %s must match features", profile.Name)
+			}
+			if err := validateNodePower(profile.NodePower); err != nil {
+				return fmt.Errorf("invalid NFD power profile %s: %v", profile.Name, err)
+			}
+		}
+		if c.Power.NFD.TDPIdleFraction <= 0 || c.Power.NFD.TDPIdleFraction >= 1 {
+			return fmt.Errorf("NFD TDP idle fraction must be between 0 and 1")
+		}
+	}
 	if c.Power.DefaultIdlePower <= 0 {
 		return fmt.Errorf("default idle power must be positive")
 	}
 	if c.Power.DefaultMaxPower <= c.Power.DefaultIdlePower {
 		return fmt.Errorf("default max power must be greater than idle power")
 	}
-	for node, power := range c.Power.NodePowerConfig {
-		if power.IdlePower <= 0 {
-			return fmt.Errorf("idle power for node %s must be positive", node)
+	if len(c.Power.DefaultCurve) > 0 {
+		if err := validatePowerCurve(c.Power.DefaultCurve); err != nil {
+			return fmt.Errorf("invalid default power curve: %v", err)
 		}
-		if power.MaxPower <= power.IdlePower {
-			return fmt.Errorf("max power must be greater than idle power for node %s", node)
+	}
+	for node, power := range c.Power.NodePowerConfig {
+		if err := validateNodePower(power); err != nil {
+			return fmt.Errorf("invalid power for node %s: %v", node, err)
 		}
 	}
+	if c.Power.ReferencePerfPerWatt <= 0 {
+		return fmt.Errorf("reference perf per watt must be positive")
+	}
 
 	return nil
 }
 
 func (c *Config) validatePricing() error {
+	switch c.Pricing.PeakSource {
+	case PeakSourceSchedule, PeakSourceForecast:
+	default:
+		return fmt.Errorf("unknown peak source: %s", c.Pricing.PeakSource)
+	}
 	for i, schedule := range c.Pricing.Schedules {
 		if err := validateSchedule(schedule); err != nil {
 			return fmt.Errorf("invalid schedule at index %d: %v", i, err)
@@ -145,3 +1135,63 @@ func validateSchedule(schedule Schedule) error {
 
 	return nil
 }
+
+// validateNodePower checks a power profile has a valid curve, or idle and max power
+func validateNodePower(power NodePower) error {
+	if len(power.Curve) > 0 {
+		if err := validatePowerCurve(power.Curve); err != nil {
+			return fmt.Errorf("invalid power curve: %v", err)
+		}
+	} else if power.IdlePower <= 0 {
+		return fmt.Errorf("idle power must be positive")
+	} else if power.MaxPower <= power.IdlePower {
+		return fmt.Errorf("max power must be greater than idle power")
+	}
+	if power.PerfPerWatt < 0 {
+		return fmt.Errorf("perf per watt must not be negative")
+	}
+	if power.GPUIdlePower < 0 || power.GPUMaxPower < power.GPUIdlePower {
+		return fmt.Errorf("GPU max power must not be less than GPU idle power")
+	}
+	if power.Disks < 0 || power.NICs < 0 {
+		return fmt.Errorf("disks and NICs must not be negative")
+	}
+	if power.EmbodiedCarbonPerHour < 0 {
+		return fmt.Errorf("embodied carbon must not be negative")
+	}
+	return nil
+}
+
+// validatePowerCurve checks that a power curve spans utilizations from 0 to 1 in
+// increasing order, with positive power that doesn't fall as utilization rises
+func validatePowerCurve(curve []PowerPoint) error {
+	if len(curve) < 2 {
+		return fmt.Errorf("at least two points are required")
+	}
+	if curve[0].Utilization != 0 || curve[len(curve)-1].Utilization != 1 {
+		return fmt.Errorf("points must span utilizations from 0 to 1")
+	}
+	for i, point := range curve {
+		if point.Watts <= 0 {
+			return fmt.Errorf("power at utilization %v must be positive", point.Utilization)
+		}
+		if i == 0 {
+			continue
+		}
+		if point.Utilization <= curve[i-1].Utilization {
+			return fmt.Errorf("utilizations must be increasing")
+		}
+		if point.Watts < curve[i-1].Watts {
+			return fmt.Errorf("power must not fall as utilization rises")
+		}
+	}
+	return nil
+}
+
+func validateEnergySource(source string) error {
+	switch source {
+	case EnergySourceModel, EnergySourceScaphandre, EnergySourceRAPL, EnergySourceRedfish, EnergySourcePDU:
+		return nil
+	}
+	return fmt.Errorf("unknown energy source: %s", source)
+}
@@ -0,0 +1,52 @@
+package computegardener
+
+import (
+	"math"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// trackNodeSMT records whether a node has SMT from its label
+func (cs *CarbonAwareScheduler) trackNodeSMT(node *v1.Node) {
+	if cs.smtNodes == nil {
+		return
+	}
+	if node.Labels[cs.config.CPU.SMTLabel] == "true" {
+		cs.smtNodes.Store(node.Name, true)
+	} else {
+		cs.smtNodes.Delete(node.Name)
+	}
+}
+
+// cpuPowerAt estimates the power of a node at a CPU utilization from its power
+// profile, adjusted for SMT and the current frequency of its CPUs
+func (cs *CarbonAwareScheduler) cpuPowerAt(nodeName string, utilization float64) float64 {
+	if cs.smtNodes != nil {
+		if _, ok := cs.smtNodes.Load(nodeName); ok {
+			utilization = smtUtilization(utilization, cs.config.CPU.SMTSiblingPower)
+		}
+	}
+	power := cs.nodePowerAt(nodeName, utilization)
+	if cs.cpuFrequency == nil {
+		return power
+	}
+
+	// Dynamic power drops with frequency, and the voltage it runs at
+	frequency, ok := cs.cpuFrequency.Value(nodeName)
+	if !ok {
+		return power
+	}
+	idlePower := cs.nodePowerAt(nodeName, 0)
+	return idlePower + (power-idlePower)*math.Pow(min(max(frequency, 0), 1), cs.config.CPU.FrequencyExponent)
+}
+
+// smtUtilization maps the utilization of the logical CPUs of a node with two threads
+// per core to the fraction of its dynamic power drawn. Threads are spread across
+// physical cores first, and the second thread of a core draws siblingPower relative
+// to the first.
+func smtUtilization(utilization, siblingPower float64) float64 {
+	utilization = min(max(utilization, 0), 1)
+	cores := min(2*utilization, 1)
+	siblings := max(2*utilization-1, 0)
+	return (cores + siblingPower*siblings) / (1 + siblingPower)
+}
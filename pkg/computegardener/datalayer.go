@@ -0,0 +1,441 @@
+package computegardener
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/dynamic"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	metricsv1beta1 "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/accounting"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+	schedulercache "sigs.k8s.io/scheduler-plugins/pkg/computegardener/cache"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/demandresponse"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/durations"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/energysource"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/gridalert"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/history"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/instancetypes"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/onsite"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/policy"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/pricing"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/promquery"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/zones"
+)
+
+// sharedData is the plugin instance owning the data layer: the API client, cache,
+// pollers, background workers and metrics server. The instances of the plugin in
+// other profiles share it rather than each starting their own. The data layer is
+// configured from the environment, so it is the same for every profile; plugin args
+// only change per-profile policy.
+var (
+	sharedDataMu sync.Mutex
+	sharedData   *CarbonAwareScheduler
+)
+
+// dataLayer is the state the instances of the plugin in every profile share: the
+// API client, cache, pollers and the stores fed by background workers
+type dataLayer struct {
+	apiClient     *api.Client
+	cache         *schedulercache.Cache
+	pricingImpl   pricing.Implementation
+	metricsClient metricsv1beta1.MetricsV1beta1Interface
+	zoneMapper    *zones.Mapper
+	fallback      *api.Synthetic          // nil if fallback is disabled
+	history       history.Store           // nil if history is disabled
+	accounting    *accounting.FileStore   // nil if energy accounting isn't persisted
+	instanceTypes *instancetypes.Table    // nil if instance type power estimates are disabled
+	nfdProfiles   *nfdProfiles            // nil if power profiles aren't picked from node features
+	smoother      *smoother               // nil if smoothing is disabled
+	demandResp    *demandresponse.Manager // nil if demand response is disabled
+	gridAlerts    *gridalert.Poller       // nil if grid alerts are disabled
+	onSite        *onsite.Provider        // nil if on-site gating is disabled
+	thermal       *promquery.Gauge        // nil if thermal gating is disabled
+	nodePower     *promquery.Vector       // nil if power cap filtering is disabled
+	energySource  *energysource.Meter     // nil if all nodes use the power model
+	podEnergy     *podEnergyTracker       // nil if per-pod energy attribution is disabled
+	durations     *durations.Estimator    // nil if duration learning is disabled
+	jobLister     batchlisters.JobLister  // resolves the CronJob of job pods
+	policies      *policy.Resolver        // nil if carbon policies are disabled
+	budgets       *policy.Budgets         // nil if carbon budgets are disabled
+	slos          *policy.SLOs            // nil if carbon SLOs are disabled
+	classes       *policy.Classes         // nil if workload classes are disabled
+	exemptions    *policy.Exemptions      // nil if carbon exemptions are disabled
+	quotas        *policy.Quotas          // nil if the quota borrowing limit is disabled
+	podLister     corelisters.PodLister   // nil if the quota borrowing limit is disabled
+	delayStatus   *delayReporter
+
+	// Unix nanoseconds of the last successful API fetch
+	lastAPISuccess atomic.Int64
+
+	// Grid zones discovered from node region labels
+	nodeZones sync.Map // map[string]string - node name to zone
+	// GPUs of nodes, and their summed utilization, nil if GPU power is disabled
+	nodeGPUs       *sync.Map // map[string]int64 - node name to GPU count
+	gpuUtilization *promquery.Vector
+	// Relative frequency of node CPUs, nil if frequency scaling is disabled, and the
+	// nodes with SMT, nil if SMT-aware power is disabled
+	cpuFrequency *promquery.Vector
+	smtNodes     *sync.Map // map[string]bool - node name to SMT
+	// Ratio of the wall power readings of nodes to their modeled power, nil unless
+	// the Redfish or PDU energy source is used
+	nodeCalibration *sync.Map // map[string]float64 - node name to ratio
+
+	// Power of nodes at the binding and completion of pods
+	powerMetrics *powerMetricsStore
+}
+
+// shareDataLayer uses the data layer of the instance owning it
+func (cs *CarbonAwareScheduler) shareDataLayer(owner *CarbonAwareScheduler) {
+	cs.dataLayer = owner.dataLayer
+	cs.sharesData = true
+}
+
+// profileName returns the name of the profile a plugin instance was created in
+func profileName(h framework.Handle) string {
+	if p, ok := h.(interface{ ProfileName() string }); ok {
+		return p.ProfileName()
+	}
+	return ""
+}
+
+// startDataLayer creates the data layer and starts its background workers
+func (cs *CarbonAwareScheduler) startDataLayer(ctx context.Context, h framework.Handle) error {
+	cfg := cs.config
+
+	// Initialize components
+	cs.dataLayer = &dataLayer{
+		apiClient:  api.NewClient(cfg.API),
+		cache:      schedulercache.New(cfg.API.CacheTTL, cfg.API.MaxCacheAge),
+		zoneMapper: zones.NewMapper(cfg.API.RegionZoneMap),
+	}
+
+	// Initialize pricing implementation if enabled
+	pricingImpl, err := pricing.Factory(cfg.Pricing)
+	if err != nil {
+		return fmt.Errorf("failed to initialize pricing implementation: %v", err)
+	}
+	cs.pricingImpl = pricingImpl
+
+	// Initialize metrics client
+	metricsClient, err := metricsv1beta1.NewForConfig(h.KubeConfig())
+	if err != nil {
+		return fmt.Errorf("failed to create metrics client: %v", err)
+	}
+	cs.metricsClient = metricsClient
+
+	if cfg.Fallback.Enabled {
+		cs.fallback = api.NewSynthetic(cfg.Fallback, cs.clock)
+	}
+	cs.markAPISuccess()
+
+	if cfg.DemandResponse.Enabled {
+		cs.demandResp = demandresponse.NewManager(cs.clock.Now)
+	}
+
+	if cfg.GridAlert.Enabled {
+		cs.gridAlerts = gridalert.NewPoller(cfg.GridAlert.URL, cfg.GridAlert.PollInterval, cfg.API.Timeout, cs.clock.Now)
+		go cs.gridAlerts.Run(ctx, cs.stopCh)
+	}
+
+	if cfg.OnSite.Enabled {
+		cs.onSite = onsite.NewProvider(cfg.OnSite.URL, cfg.OnSite.PollInterval, cfg.OnSite.MaxAge, cfg.API.Timeout, cs.clock.Now)
+		go cs.onSite.Run(ctx, cs.stopCh)
+	}
+
+	if cfg.Thermal.Enabled {
+		promClient := promquery.NewClient(cfg.Thermal.PrometheusURL, cfg.API.Timeout)
+		cs.thermal = promquery.NewGauge(promClient, cfg.Thermal.Query, cfg.Thermal.PollInterval, cfg.Thermal.MaxAge, cs.clock.Now)
+		go cs.thermal.Run(ctx, cs.stopCh)
+	}
+
+	if cfg.PowerCap.Enabled {
+		promClient := promquery.NewClient(cfg.PowerCap.PrometheusURL, cfg.API.Timeout)
+		cs.nodePower = promquery.NewVector(promClient, cfg.PowerCap.Query, cfg.PowerCap.NodeLabel,
+			cfg.PowerCap.PollInterval, cfg.PowerCap.MaxAge, cs.clock.Now)
+		go cs.nodePower.Run(ctx, cs.stopCh)
+	}
+
+	if cfg.GPU.Enabled {
+		cs.nodeGPUs = new(sync.Map)
+		cs.gpuUtilization = promquery.NewVector(promquery.NewClient(cfg.GPU.PrometheusURL, cfg.API.Timeout),
+			cfg.GPU.Query, cfg.GPU.NodeLabel, cfg.GPU.PollInterval, cfg.GPU.MaxAge, cs.clock.Now)
+		go cs.gpuUtilization.Run(ctx, cs.stopCh)
+	}
+
+	if cfg.CPU.FrequencyEnabled {
+		cs.cpuFrequency = promquery.NewVector(promquery.NewClient(cfg.CPU.PrometheusURL, cfg.API.Timeout),
+			cfg.CPU.FrequencyQuery, cfg.CPU.NodeLabel, cfg.CPU.PollInterval, cfg.CPU.MaxAge, cs.clock.Now)
+		go cs.cpuFrequency.Run(ctx, cs.stopCh)
+	}
+	if cfg.CPU.SMTEnabled {
+		cs.smtNodes = new(sync.Map)
+	}
+
+	if cfg.EnergySource.Measured() {
+		cs.energySource = energysource.New(cfg.EnergySource, cfg.API.Timeout, cs.clock.Now)
+		cs.energySource.Run(ctx, cs.stopCh)
+	}
+	if cfg.EnergySource.Uses(config.EnergySourceRedfish) || cfg.EnergySource.Uses(config.EnergySourcePDU) {
+		cs.nodeCalibration = new(sync.Map)
+	}
+
+	if cfg.Power.InstanceTypesEnabled {
+		table, err := instancetypes.New(cfg.Power.InstanceTypesPath)
+		if err != nil {
+			return fmt.Errorf("failed to load instance type power: %v", err)
+		}
+		cs.instanceTypes = table
+		go table.Run(ctx, cs.stopCh, cfg.Power.InstanceTypesRefreshInterval)
+	}
+
+	if cfg.Power.NFD.Enabled {
+		cs.nfdProfiles = newNFDProfiles(cfg.Power.NFD)
+	}
+
+	cs.podEnergy = newPodEnergyTracker()
+	cs.powerMetrics = newPowerMetricsStore(cfg.Power.MetricsTTL, cfg.Power.MetricsMaxEntries)
+	go cs.podEnergyWorker(ctx)
+
+	if cfg.Scheduling.SmoothingWindow > 0 {
+		cs.smoother = newSmoother(cfg.Scheduling.SmoothingWindow, cfg.Scheduling.SmoothingAlpha)
+	}
+
+	if cfg.Scheduling.LearnDurations {
+		cs.durations = durations.NewEstimator(cfg.Scheduling.DurationSamples, maxDurationTemplates)
+	}
+	// Jobs resolve the CronJob of job pods, for duration templates and workload metrics
+	cs.jobLister = h.SharedInformerFactory().Batch().V1().Jobs().Lister()
+
+	// Policies, budgets, classes, exemptions and SLOs are shared by a fleet through the
+	// hub. ElasticQuotas are always read from this cluster, since they partition its
+	// own capacity.
+	policySource := policy.LocalSource(h.KubeConfig())
+	if cfg.Policy.HubKubeconfig != "" && (cfg.Policy.Enabled || cfg.Policy.BudgetsEnabled ||
+		cfg.Policy.ClassesEnabled || cfg.Policy.ExemptionsEnabled || cfg.Policy.SLOsEnabled) {
+		src, err := policy.HubSource(cfg.Policy.HubKubeconfig, cfg.Policy.HubSelector)
+		if err != nil {
+			return err
+		}
+		policySource = src
+		klog.V(2).InfoS("Reading carbon policies from hub cluster", "host", src.Config.Host, "selector", src.Selector)
+	}
+
+	if cfg.Policy.Enabled {
+		resolver, err := policy.Start(ctx, policySource, h.SharedInformerFactory().Core().V1().Namespaces().Lister())
+		if err != nil {
+			return fmt.Errorf("failed to start carbon policy resolver: %v", err)
+		}
+		cs.policies = resolver
+	}
+
+	if cfg.Policy.ClassesEnabled {
+		classes, err := policy.StartClasses(ctx, policySource)
+		if err != nil {
+			return fmt.Errorf("failed to start workload classes: %v", err)
+		}
+		cs.classes = classes
+	}
+
+	if cfg.Policy.ExemptionsEnabled {
+		exemptions, err := policy.StartExemptions(ctx, policySource, h.SharedInformerFactory().Core().V1().Namespaces().Lister())
+		if err != nil {
+			return fmt.Errorf("failed to start carbon exemptions: %v", err)
+		}
+		cs.exemptions = exemptions
+		go cs.exemptionWorker(ctx)
+	}
+
+	if cfg.Policy.QuotaBorrowingMaxIntensity > 0 {
+		quotas, err := policy.StartQuotas(ctx, h.KubeConfig())
+		if err != nil {
+			return fmt.Errorf("failed to start elastic quotas: %v", err)
+		}
+		cs.quotas = quotas
+		cs.podLister = h.SharedInformerFactory().Core().V1().Pods().Lister()
+	}
+
+	var delayClient dynamic.Interface
+	if cfg.Observability.DelayStatusEnabled {
+		client, err := dynamic.NewForConfig(h.KubeConfig())
+		if err != nil {
+			return fmt.Errorf("failed to create dynamic client: %v", err)
+		}
+		delayClient = client
+	}
+	cs.delayStatus = newDelayReporter(h.ClientSet(), delayClient)
+	go cs.delayStatusWorker(ctx)
+
+	if cfg.Policy.BudgetsEnabled {
+		budgets, err := policy.StartBudgets(ctx, policySource, policy.BudgetDefaults{
+			Action:          cfg.Policy.BudgetAction,
+			ThresholdFactor: cfg.Policy.BudgetDemotionFactor,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start carbon budgets: %v", err)
+		}
+		cs.budgets = budgets
+		go cs.budgetWorker(ctx)
+	}
+
+	if cfg.Policy.SLOsEnabled {
+		slos, err := policy.StartSLOs(ctx, policySource)
+		if err != nil {
+			return fmt.Errorf("failed to start carbon SLOs: %v", err)
+		}
+		cs.slos = slos
+		go cs.sloWorker(ctx)
+	}
+
+	if cfg.History.Enabled {
+		store, err := history.NewFileStore(cfg.History.Path, cfg.History.Retention)
+		if err != nil {
+			return fmt.Errorf("failed to initialize history store: %v", err)
+		}
+		cs.history = store
+		go cs.historyWorker()
+	}
+
+	if cfg.Accounting.Enabled {
+		store, err := accounting.NewFileStore(cfg.Accounting.Path)
+		if err != nil {
+			return fmt.Errorf("failed to initialize energy accounting: %v", err)
+		}
+		cs.accounting = store
+		cs.restoreAccounting(h)
+		go cs.accountingWorker()
+	}
+
+	// Start health check worker
+	go cs.healthCheckWorker(ctx)
+
+	// Register pod informer to track completion
+	h.SharedInformerFactory().Core().V1().Pods().Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				oldPod := oldObj.(*v1.Pod)
+				newPod := newObj.(*v1.Pod)
+
+				// Check if pod has completed, or failed, as when evicted by the kubelet
+				if !isFinished(oldPod) && isFinished(newPod) {
+					cs.handlePodFinished(newPod)
+				}
+			},
+			DeleteFunc: func(obj interface{}) {
+				pod, ok := deletedPod(obj)
+				if !ok {
+					return
+				}
+
+				// Account pods deleted before they finished, as when cancelled or
+				// evicted through the API, then stop tracking them
+				if !isFinished(pod) {
+					cs.handlePodCompletion(pod)
+					cs.accrueSLO(pod)
+				}
+				cs.podEnergy.forget(pod.UID)
+				cs.forgetPodPower(pod)
+			},
+		},
+	)
+
+	// Track grid zones of cluster nodes from their region labels, energy sources from
+	// their pool labels, power profiles from their features and instance types, and
+	// their GPUs and SMT
+	h.SharedInformerFactory().Core().V1().Nodes().Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				cs.trackNodeZone(obj.(*v1.Node))
+				cs.energySource.TrackNode(obj.(*v1.Node))
+				cs.instanceTypes.TrackNode(obj.(*v1.Node))
+				cs.nfdProfiles.track(obj.(*v1.Node))
+				cs.trackNodeGPUs(obj.(*v1.Node))
+				cs.trackNodeSMT(obj.(*v1.Node))
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				cs.trackNodeZone(newObj.(*v1.Node))
+				cs.energySource.TrackNode(newObj.(*v1.Node))
+				cs.instanceTypes.TrackNode(newObj.(*v1.Node))
+				cs.nfdProfiles.track(newObj.(*v1.Node))
+				cs.trackNodeGPUs(newObj.(*v1.Node))
+				cs.trackNodeSMT(newObj.(*v1.Node))
+			},
+			DeleteFunc: func(obj interface{}) {
+				if node, ok := obj.(*v1.Node); ok {
+					cs.nodeZones.Delete(node.Name)
+					cs.energySource.ForgetNode(node.Name)
+					cs.instanceTypes.ForgetNode(node.Name)
+					cs.nfdProfiles.forget(node.Name)
+					if cs.nodeGPUs != nil {
+						cs.nodeGPUs.Delete(node.Name)
+					}
+					if cs.smtNodes != nil {
+						cs.smtNodes.Delete(node.Name)
+					}
+					cs.forgetCalibration(node.Name)
+				}
+			},
+		},
+	)
+
+	// Start metrics server (insecure) on a separate mux
+	go func() {
+		metricsPort := fmt.Sprint(":", cs.config.Observability.MetricsPort)
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", legacyregistry.Handler())
+		if cs.demandResp != nil {
+			events := requireToken(cs.config.DemandResponse.Token, cs.demandResp)
+			metricsMux.Handle(cs.config.DemandResponse.Path, events)
+			metricsMux.Handle(cs.config.DemandResponse.Path+"/", events)
+		}
+		if cs.config.Observability.EvaluateEnabled {
+			metricsMux.Handle(cs.config.Observability.EvaluatePath, &evaluationHandler{
+				owner: cs,
+				token: cs.config.Observability.EvaluateToken,
+			})
+		}
+
+		metricsServer := &http.Server{
+			Addr:    metricsPort,
+			Handler: metricsMux,
+		}
+
+		klog.InfoS("Starting metrics server", "addr", metricsPort)
+		if err := metricsServer.ListenAndServe(); err != nil {
+			klog.ErrorS(err, "Failed to start metrics server")
+		}
+	}()
+
+	return nil
+}
+
+// deletedPod returns the pod of a delete event, including one whose final state
+// was missed by the informer
+func deletedPod(obj interface{}) (*v1.Pod, bool) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	pod, ok := obj.(*v1.Pod)
+	return pod, ok
+}
+
+// handlePodFinished accounts a pod that completed or failed
+func (cs *CarbonAwareScheduler) handlePodFinished(pod *v1.Pod) {
+	cs.handlePodCompletion(pod)
+	cs.accrueSLO(pod)
+	// Only successful runs tell how long a job takes
+	if pod.Status.Phase == v1.PodSucceeded {
+		cs.observeDuration(pod)
+	}
+}
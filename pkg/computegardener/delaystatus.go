@@ -0,0 +1,272 @@
+package computegardener
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+)
+
+// carbonDelayResource is the resource the delays of pods are reported in
+var carbonDelayResource = v1alpha1.SchemeGroupVersion.WithResource("carbondelays")
+
+const (
+	// delayStatusSyncInterval is how often changed delays are written to CarbonDelays
+	delayStatusSyncInterval = 15 * time.Second
+	// maxDelayRecords bounds the reasons kept in the history of a CarbonDelay
+	maxDelayRecords = 10
+)
+
+// delayReporter keeps the delayed conditions of pods, and the delays of held pods if
+// CarbonDelays are enabled, which the delay status worker writes to the pods and their
+// CarbonDelay, so the scheduling cycle makes no API calls and a pod whose delay
+// changes several times between syncs is written once
+type delayReporter struct {
+	pods   kubernetes.Interface
+	client dynamic.Interface // nil if CarbonDelays are disabled
+
+	mu         sync.Mutex
+	delays     map[types.UID]*podDelay
+	conditions map[types.UID]*conditionUpdate
+}
+
+// podDelay is the delay of a pod, and whether it changed since it was last written
+type podDelay struct {
+	namespace string
+	name      string
+	status    v1alpha1.CarbonDelayStatus
+	dirty     bool
+}
+
+// newDelayReporter returns a reporter patching pod conditions with pods, and writing
+// CarbonDelays with client if set
+func newDelayReporter(pods kubernetes.Interface, client dynamic.Interface) *delayReporter {
+	return &delayReporter{
+		pods:       pods,
+		client:     client,
+		delays:     make(map[types.UID]*podDelay),
+		conditions: make(map[types.UID]*conditionUpdate),
+	}
+}
+
+// delayed records that a pod was held for reason, in the state given by diagnosis.
+// A reason is added to the history when it differs from the last one.
+func (r *delayReporter) delayed(pod *v1.Pod, reason string, diagnosis delayDiagnosis, now time.Time) {
+	if r == nil || r.client == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.delays[pod.UID]
+	if !ok {
+		entry = &podDelay{
+			namespace: pod.Namespace,
+			name:      pod.Name,
+			status:    v1alpha1.CarbonDelayStatus{PodUID: pod.UID, WaitingSince: metav1.NewTime(now)},
+		}
+		r.delays[pod.UID] = entry
+	}
+
+	status := entry.status.DeepCopy()
+	status.Phase = v1alpha1.DelayPhaseDelayed
+	status.Reason = delayConditionReason(reason)
+	status.Message = reason
+	status.Zone = diagnosis.zone
+	status.CarbonIntensity = rounded(diagnosis.intensity)
+	status.CarbonIntensityThreshold = rounded(diagnosis.threshold)
+	status.ElectricityRate = diagnosis.rate
+	release := metav1.NewTime(diagnosis.nextTransition)
+	status.ProjectedRelease = &release
+	if n := len(status.History); n == 0 || status.History[n-1].Message != reason {
+		status.History = append(status.History, v1alpha1.CarbonDelayRecord{
+			Time:    metav1.NewTime(now),
+			Reason:  status.Reason,
+			Message: reason,
+		})
+		if len(status.History) > maxDelayRecords {
+			status.History = status.History[len(status.History)-maxDelayRecords:]
+		}
+	}
+	r.update(entry, status)
+}
+
+// admitted records that a previously delayed pod was bound to a node. Pods that were
+// never delayed have no CarbonDelay.
+func (r *delayReporter) admitted(pod *v1.Pod, nodeName string, now time.Time) {
+	if r == nil || r.client == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.delays[pod.UID]
+	if !ok {
+		return
+	}
+	status := entry.status.DeepCopy()
+	status.Phase = v1alpha1.DelayPhaseAdmitted
+	status.Reason = reasonAdmitted
+	status.Message = "Bound to " + nodeName
+	status.ProjectedRelease = nil
+	admittedAt := metav1.NewTime(now)
+	status.AdmittedAt = &admittedAt
+	status.NodeName = nodeName
+	r.update(entry, status)
+}
+
+// setCondition queues an update of the delayed condition of a pod, replacing any
+// update of it still pending
+func (r *delayReporter) setCondition(pod *v1.Pod, cond v1.PodCondition) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conditions[pod.UID] = &conditionUpdate{namespace: pod.Namespace, name: pod.Name, condition: cond}
+}
+
+// forget drops the delay and pending condition of a deleted pod. Its CarbonDelay is
+// garbage collected along with the pod.
+func (r *delayReporter) forget(uid types.UID) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.delays, uid)
+	delete(r.conditions, uid)
+}
+
+// update sets the status of a delay, marking it for writing if it changed
+func (r *delayReporter) update(entry *podDelay, status *v1alpha1.CarbonDelayStatus) {
+	if equality.Semantic.DeepEqual(&entry.status, status) {
+		return
+	}
+	entry.status = *status
+	entry.dirty = true
+}
+
+// sync writes the conditions and delays that changed since the last sync. Those
+// failing to be written are retried on the next sync, unless the pod is gone or has
+// changed again; admitted pods are forgotten once written.
+func (r *delayReporter) sync(ctx context.Context) {
+	if r == nil {
+		return
+	}
+	type pending struct {
+		uid             types.UID
+		namespace, name string
+		status          *v1alpha1.CarbonDelayStatus
+	}
+	var writes []pending
+	r.mu.Lock()
+	conditions := r.conditions
+	r.conditions = make(map[types.UID]*conditionUpdate)
+	for uid, entry := range r.delays {
+		if entry.dirty {
+			entry.dirty = false
+			writes = append(writes, pending{uid, entry.namespace, entry.name, entry.status.DeepCopy()})
+		}
+	}
+	r.mu.Unlock()
+
+	for uid, u := range conditions {
+		if err := r.writeCondition(ctx, u); err != nil && !apierrors.IsNotFound(err) {
+			r.mu.Lock()
+			if _, ok := r.conditions[uid]; !ok {
+				r.conditions[uid] = u
+			}
+			r.mu.Unlock()
+		}
+	}
+
+	for _, w := range writes {
+		err := r.write(ctx, w.namespace, w.name, w.status)
+
+		r.mu.Lock()
+		entry, ok := r.delays[w.uid]
+		unchanged := ok && !entry.dirty
+		switch {
+		case err != nil && unchanged:
+			entry.dirty = true
+		case err == nil && unchanged && w.status.Phase == v1alpha1.DelayPhaseAdmitted:
+			delete(r.delays, w.uid)
+		}
+		r.mu.Unlock()
+	}
+}
+
+// write creates or updates the CarbonDelay of a pod. The CarbonDelay is owned by the
+// pod, so it is deleted along with it.
+func (r *delayReporter) write(ctx context.Context, namespace, name string, status *v1alpha1.CarbonDelayStatus) error {
+	owners := []metav1.OwnerReference{{APIVersion: "v1", Kind: "Pod", Name: name, UID: status.PodUID}}
+	client := r.client.Resource(carbonDelayResource).Namespace(namespace)
+
+	// Adding a member that exists replaces it, clearing the fields the status dropped
+	patch, err := json.Marshal([]map[string]interface{}{
+		{"op": "add", "path": "/metadata/ownerReferences", "value": owners},
+		{"op": "add", "path": "/status", "value": status},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = client.Patch(ctx, name, types.JSONPatchType, patch, metav1.PatchOptions{})
+	if apierrors.IsNotFound(err) {
+		delay := &v1alpha1.CarbonDelay{
+			TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "CarbonDelay"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, OwnerReferences: owners},
+			Status:     *status,
+		}
+		var obj map[string]interface{}
+		if obj, err = runtime.DefaultUnstructuredConverter.ToUnstructured(delay); err != nil {
+			return err
+		}
+		_, err = client.Create(ctx, &unstructured.Unstructured{Object: obj}, metav1.CreateOptions{})
+	}
+	if err != nil {
+		klog.ErrorS(err, "Failed to update carbon delay", "pod", klog.KRef(namespace, name))
+	}
+	return err
+}
+
+// rounded rounds a value to hundredths, so delays aren't rewritten for negligible
+// changes
+func rounded(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	r := math.Round(*v*100) / 100
+	return &r
+}
+
+// delayStatusWorker writes changed conditions and delays
+func (cs *CarbonAwareScheduler) delayStatusWorker(ctx context.Context) {
+	ticker := time.NewTicker(delayStatusSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cs.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cs.delayStatus.sync(ctx)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package demandresponse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Event is a demand-response event during which the scheduler tightens thresholds or
+// pauses new admissions. Fields loosely follow the OpenADR event model.
+type Event struct {
+	ID    string    `json:"eventID"`
+	Start time.Time `json:"dtstart"`
+	// Duration of the event, e.g. "2h"
+	Duration Duration `json:"duration"`
+	// ThresholdFactor multiplies carbon intensity thresholds while the event is active,
+	// 0 uses the configured default
+	ThresholdFactor float64 `json:"thresholdFactor,omitempty"`
+	// Pause blocks all new gated admissions while the event is active
+	Pause bool `json:"pause,omitempty"`
+}
+
+// End returns the time at which the event ends
+func (e Event) End() time.Time {
+	return e.Start.Add(e.Duration.Duration)
+}
+
+// Active reports whether the event is active at the given time
+func (e Event) Active(now time.Time) bool {
+	return !now.Before(e.Start) && now.Before(e.End())
+}
+
+// Duration wraps time.Duration to marshal as a duration string
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalJSON parses a duration string such as "90m"
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = parsed
+	return nil
+}
+
+// MarshalJSON formats the duration as a string
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Duration.String())
+}
+
+// Manager tracks scheduled and active demand-response events
+type Manager struct {
+	mutex  sync.RWMutex
+	events map[string]Event
+	now    func() time.Time
+}
+
+// NewManager creates a new event manager
+func NewManager(now func() time.Time) *Manager {
+	return &Manager{
+		events: make(map[string]Event),
+		now:    now,
+	}
+}
+
+// Add schedules an event, replacing any event with the same ID
+func (m *Manager) Add(e Event) error {
+	if e.ID == "" {
+		return fmt.Errorf("event ID is required")
+	}
+	if e.Duration.Duration <= 0 {
+		return fmt.Errorf("event duration must be positive")
+	}
+	if e.ThresholdFactor < 0 {
+		return fmt.Errorf("threshold factor must not be negative")
+	}
+	if e.Start.IsZero() {
+		e.Start = m.now()
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.events[e.ID] = e
+	m.pruneLocked()
+
+	klog.V(2).InfoS("Scheduled demand response event",
+		"event", e.ID,
+		"start", e.Start,
+		"end", e.End(),
+		"pause", e.Pause)
+	return nil
+}
+
+// Cancel removes an event
+func (m *Manager) Cancel(id string) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, ok := m.events[id]
+	delete(m.events, id)
+	return ok
+}
+
+// Active returns the currently active events ordered by start time
+func (m *Manager) Active() []Event {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	now := m.now()
+	var active []Event
+	for _, e := range m.events {
+		if e.Active(now) {
+			active = append(active, e)
+		}
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].Start.Before(active[j].Start)
+	})
+	return active
+}
+
+// pruneLocked drops events that have ended. Callers must hold the lock.
+func (m *Manager) pruneLocked() {
+	now := m.now()
+	for id, e := range m.events {
+		if !now.Before(e.End()) {
+			delete(m.events, id)
+		}
+	}
+}
+
+// ServeHTTP implements a simple webhook: POST an Event to schedule it, DELETE
+// <path>/<eventID> to cancel it, GET to list active events
+func (m *Manager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var e Event
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			http.Error(w, fmt.Sprintf("invalid event: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := m.Add(e); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	case http.MethodDelete:
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		if !m.Cancel(id) {
+			http.Error(w, "event not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(m.Active()); err != nil {
+			klog.ErrorS(err, "Failed to encode demand response events")
+		}
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,82 @@
+// Package durations learns run durations of recurring workloads so that pods
+// without an explicit estimate can still be placed in low-carbon windows.
+package durations
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Estimator records observed run durations per workload template and estimates
+// new runs from the median of recent observations
+type Estimator struct {
+	mu         sync.Mutex
+	maxSamples int
+	maxKeys    int
+	samples    map[string][]time.Duration
+	// order tracks keys from least to most recently observed
+	order []string
+}
+
+// NewEstimator creates an estimator keeping up to maxSamples observations for
+// each of up to maxKeys templates
+func NewEstimator(maxSamples, maxKeys int) *Estimator {
+	return &Estimator{
+		maxSamples: maxSamples,
+		maxKeys:    maxKeys,
+		samples:    make(map[string][]time.Duration),
+	}
+}
+
+// Observe records a completed run of the template identified by key
+func (e *Estimator) Observe(key string, d time.Duration) {
+	if key == "" || d <= 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	samples := append(e.samples[key], d)
+	if len(samples) > e.maxSamples {
+		samples = samples[len(samples)-e.maxSamples:]
+	}
+	e.samples[key] = samples
+	e.touch(key)
+
+	// Evict the least recently observed templates
+	for len(e.order) > e.maxKeys {
+		delete(e.samples, e.order[0])
+		e.order = e.order[1:]
+	}
+}
+
+// Estimate returns the median observed duration of the template identified by key
+func (e *Estimator) Estimate(key string) (time.Duration, bool) {
+	e.mu.Lock()
+	samples := append([]time.Duration(nil), e.samples[key]...)
+	e.mu.Unlock()
+
+	if len(samples) == 0 {
+		return 0, false
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	mid := len(samples) / 2
+	if len(samples)%2 == 0 {
+		return (samples[mid-1] + samples[mid]) / 2, true
+	}
+	return samples[mid], true
+}
+
+// touch moves key to the most recently observed position
+func (e *Estimator) touch(key string) {
+	for i, k := range e.order {
+		if k == key {
+			e.order = append(e.order[:i], e.order[i+1:]...)
+			break
+		}
+	}
+	e.order = append(e.order, key)
+}
@@ -0,0 +1,69 @@
+package durations
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name     string
+		observed []time.Duration
+		want     time.Duration
+		wantOK   bool
+	}{
+		{
+			name:   "no observations",
+			wantOK: false,
+		},
+		{
+			name:     "odd number of observations",
+			observed: []time.Duration{3 * time.Hour, time.Hour, 2 * time.Hour},
+			want:     2 * time.Hour,
+			wantOK:   true,
+		},
+		{
+			name:     "even number of observations",
+			observed: []time.Duration{time.Hour, 2 * time.Hour},
+			want:     90 * time.Minute,
+			wantOK:   true,
+		},
+		{
+			name:     "oldest observations dropped",
+			observed: []time.Duration{10 * time.Hour, 10 * time.Hour, time.Hour, time.Hour, time.Hour},
+			want:     time.Hour,
+			wantOK:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewEstimator(3, 10)
+			for _, d := range tt.observed {
+				e.Observe("job", d)
+			}
+
+			got, ok := e.Estimate("job")
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Estimate() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEviction(t *testing.T) {
+	e := NewEstimator(3, 2)
+	e.Observe("a", time.Hour)
+	e.Observe("b", time.Hour)
+	e.Observe("a", time.Hour)
+	e.Observe("c", time.Hour)
+
+	if _, ok := e.Estimate("b"); ok {
+		t.Error("expected least recently observed template to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := e.Estimate(key); !ok {
+			t.Errorf("expected template %q to be kept", key)
+		}
+	}
+}
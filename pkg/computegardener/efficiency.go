@@ -0,0 +1,55 @@
+package computegardener
+
+import (
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const (
+	// preferEfficientAnnotation opts a pod into preferring energy-efficient nodes
+	preferEfficientAnnotation = "carbon-aware-scheduler.kubernetes.io/prefer-efficient-nodes"
+	// perfPerWattLabel rates a node's performance per watt relative to the reference
+	perfPerWattLabel = "carbon-aware-scheduler.kubernetes.io/perf-per-watt"
+)
+
+// prefersEfficientNodes reports whether a pod opted into efficiency scoring
+func prefersEfficientNodes(pod *v1.Pod) bool {
+	return pod.Annotations[preferEfficientAnnotation] == "true"
+}
+
+// efficiencyScore scores a node by its performance per watt. A node rated at the
+// reference scores half the maximum, unrated nodes are treated as the reference.
+func (cs *CarbonAwareScheduler) efficiencyScore(nodeName string) int64 {
+	reference := cs.config.Power.ReferencePerfPerWatt
+	rating, ok := cs.perfPerWatt(nodeName)
+	if !ok {
+		rating = reference
+	}
+	return int64(float64(framework.MaxNodeScore) * rating / (rating + reference))
+}
+
+// perfPerWatt returns a node's performance per watt from its power profile, or
+// from its node label
+func (cs *CarbonAwareScheduler) perfPerWatt(nodeName string) (float64, bool) {
+	if nodePower, ok := cs.nodeProfile(nodeName); ok && nodePower.PerfPerWatt > 0 {
+		return nodePower.PerfPerWatt, true
+	}
+
+	nodeInfo, err := cs.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil || nodeInfo.Node() == nil {
+		return 0, false
+	}
+	val, ok := nodeInfo.Node().Labels[perfPerWattLabel]
+	if !ok {
+		return 0, false
+	}
+	rating, err := strconv.ParseFloat(val, 64)
+	if err != nil || rating <= 0 {
+		klog.V(2).InfoS("Ignoring invalid perf-per-watt label", "node", nodeName, "value", val)
+		return 0, false
+	}
+	return rating, true
+}
@@ -0,0 +1,48 @@
+package computegardener
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/api/v1/resource"
+)
+
+// embodiedCarbonPerHour returns the amortized embodied carbon of a node in gCO2e per
+// node-hour, from its power profile or the default
+func (cs *CarbonAwareScheduler) embodiedCarbonPerHour(nodeName string) float64 {
+	if nodePower, ok := cs.nodeProfile(nodeName); ok && nodePower.EmbodiedCarbonPerHour > 0 {
+		return nodePower.EmbodiedCarbonPerHour
+	}
+	return cs.config.Power.EmbodiedCarbonPerHour
+}
+
+// embodiedRate returns the embodied carbon a pod accrues on a node in gCO2e per hour,
+// from its share of the node: the larger of its CPU and memory requests relative to
+// the node's allocatable resources, as in the Software Carbon Intensity spec
+func (cs *CarbonAwareScheduler) embodiedRate(pod *v1.Pod, nodeName string) float64 {
+	perHour := cs.embodiedCarbonPerHour(nodeName)
+	if perHour <= 0 {
+		return 0
+	}
+	nodeInfo, err := cs.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil || nodeInfo.Node() == nil {
+		klog.V(2).InfoS("Failed to get node for embodied carbon", "node", nodeName, "err", err)
+		return 0
+	}
+	return perHour * resourceShare(pod, nodeInfo.Node())
+}
+
+// resourceShare returns the larger of the shares of a node's allocatable CPU and
+// memory requested by a pod, from 0 to 1
+func resourceShare(pod *v1.Pod, node *v1.Node) float64 {
+	requests := resource.PodRequests(pod, resource.PodResourcesOptions{})
+	var share float64
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		allocatable := node.Status.Allocatable[name]
+		if allocatable.IsZero() {
+			continue
+		}
+		requested := requests[name]
+		share = max(share, requested.AsApproximateFloat64()/allocatable.AsApproximateFloat64())
+	}
+	return min(share, 1)
+}
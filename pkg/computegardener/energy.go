@@ -0,0 +1,59 @@
+package computegardener
+
+import (
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// estimatedEnergyAnnotation is the expected energy use of a pod in kWh
+const estimatedEnergyAnnotation = "carbon-aware-scheduler.kubernetes.io/estimated-energy-kwh"
+
+// estimatedEnergy returns the estimated-energy-kwh annotation of a pod, or derives
+// the energy from its CPU requests and estimated duration
+func (cs *CarbonAwareScheduler) estimatedEnergy(pod *v1.Pod) (float64, bool) {
+	if val, ok := pod.Annotations[estimatedEnergyAnnotation]; ok {
+		energy, err := strconv.ParseFloat(val, 64)
+		if err == nil && energy >= 0 {
+			return energy, true
+		}
+		klog.V(2).InfoS("Ignoring invalid estimated energy annotation", "pod", klog.KObj(pod), "value", val)
+	}
+
+	duration, ok := cs.estimatedDuration(pod)
+	if !ok {
+		return 0, false
+	}
+
+	var cores float64
+	for _, c := range pod.Spec.Containers {
+		cores += float64(c.Resources.Requests.Cpu().MilliValue()) / 1000
+	}
+	if cores == 0 {
+		return 0, false
+	}
+
+	return cores * cs.config.Power.WattsPerCore * duration.Hours() / 1000, true
+}
+
+// isLightPod reports whether a pod's estimated energy is too small to be worth delaying
+func (cs *CarbonAwareScheduler) isLightPod(pod *v1.Pod) bool {
+	if cs.config.Scheduling.EnergyLightKWh <= 0 {
+		return false
+	}
+	energy, ok := cs.estimatedEnergy(pod)
+	return ok && energy < cs.config.Scheduling.EnergyLightKWh
+}
+
+// energyFactor returns the multiplier applied to the carbon threshold of a pod
+// based on its estimated energy
+func (cs *CarbonAwareScheduler) energyFactor(pod *v1.Pod) float64 {
+	if cs.config.Scheduling.EnergyHeavyKWh <= 0 {
+		return 1
+	}
+	if energy, ok := cs.estimatedEnergy(pod); ok && energy >= cs.config.Scheduling.EnergyHeavyKWh {
+		return cs.config.Scheduling.EnergyHeavyFactor
+	}
+	return 1
+}
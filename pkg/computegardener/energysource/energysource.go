@@ -0,0 +1,165 @@
+package energysource
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/promquery"
+)
+
+// Meter reads the measured power of nodes, and of pods where the source measures
+// them, from the energy source of each node's pool
+type Meter struct {
+	cfg config.EnergySourceConfig
+
+	nodeReadings map[string]*promquery.Vector // source to node power
+	podReadings  *promquery.Vector            // pod power, nil unless Scaphandre is used
+	redfish      *Redfish                     // BMC power, nil unless Redfish is used
+	pdu          *PDU                         // PDU outlet power, nil unless PDUs are used
+
+	mutex sync.RWMutex
+	nodes map[string]string // node name to source
+}
+
+// New creates a meter for the sources in use. Sources are only queried if a node pool,
+// or the default, uses them.
+func New(cfg config.EnergySourceConfig, timeout time.Duration, now func() time.Time) *Meter {
+	m := &Meter{
+		cfg:          cfg,
+		nodeReadings: make(map[string]*promquery.Vector),
+		nodes:        make(map[string]string),
+	}
+	client := promquery.NewClient(cfg.PrometheusURL, timeout)
+	sources := map[string]bool{cfg.Default: true}
+	for _, source := range cfg.NodePools {
+		sources[source] = true
+	}
+	if sources[config.EnergySourceScaphandre] {
+		m.nodeReadings[config.EnergySourceScaphandre] = promquery.NewVector(client, cfg.ScaphandreNodeQuery,
+			cfg.NodeLabel, cfg.PollInterval, cfg.MaxAge, now)
+		if cfg.ScaphandrePodQuery != "" {
+			m.podReadings = promquery.NewVectorByLabels(client, cfg.ScaphandrePodQuery,
+				[]string{cfg.PodNamespaceLabel, cfg.PodNameLabel}, cfg.PollInterval, cfg.MaxAge, now)
+		}
+	}
+	if sources[config.EnergySourceRAPL] {
+		m.nodeReadings[config.EnergySourceRAPL] = promquery.NewVector(client, cfg.RAPLNodeQuery,
+			cfg.NodeLabel, cfg.PollInterval, cfg.MaxAge, now)
+	}
+	if sources[config.EnergySourceRedfish] {
+		m.redfish = NewRedfish(cfg, timeout, now)
+	}
+	if sources[config.EnergySourcePDU] {
+		m.pdu = NewPDU(cfg, timeout, now)
+	}
+	return m
+}
+
+// NewWithReadings creates a meter over existing readings, keyed by source
+func NewWithReadings(cfg config.EnergySourceConfig, nodeReadings map[string]*promquery.Vector, podReadings *promquery.Vector) *Meter {
+	return &Meter{
+		cfg:          cfg,
+		nodeReadings: nodeReadings,
+		podReadings:  podReadings,
+		nodes:        make(map[string]string),
+	}
+}
+
+// Run evaluates the queries of the sources in use until stopCh is closed
+func (m *Meter) Run(ctx context.Context, stopCh <-chan struct{}) {
+	for _, readings := range m.nodeReadings {
+		go readings.Run(ctx, stopCh)
+	}
+	if m.podReadings != nil {
+		go m.podReadings.Run(ctx, stopCh)
+	}
+	if m.redfish != nil {
+		go m.redfish.Run(ctx, stopCh)
+	}
+	if m.pdu != nil {
+		go m.pdu.Run(ctx, stopCh)
+	}
+}
+
+// TrackNode records the source of a node from the label naming its pool
+func (m *Meter) TrackNode(node *v1.Node) {
+	if m == nil {
+		return
+	}
+	source := m.cfg.Default
+	if pool, ok := node.Labels[m.cfg.NodePoolLabel]; ok && m.cfg.NodePoolLabel != "" {
+		if poolSource, ok := m.cfg.NodePools[pool]; ok {
+			source = poolSource
+		}
+	}
+	m.redfish.TrackNode(node)
+	m.pdu.TrackNode(node)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.nodes[node.Name] = source
+}
+
+// ForgetNode drops a deleted node
+func (m *Meter) ForgetNode(name string) {
+	if m == nil {
+		return
+	}
+	m.redfish.ForgetNode(name)
+	m.pdu.ForgetNode(name)
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.nodes, name)
+}
+
+// source returns the source of a tracked node
+func (m *Meter) source(nodeName string) string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	if source, ok := m.nodes[nodeName]; ok {
+		return source
+	}
+	return m.cfg.Default
+}
+
+// NodePower returns the measured power of a node in watts, if its source measures
+// it and the reading is fresh
+func (m *Meter) NodePower(nodeName string) (float64, bool) {
+	if m == nil {
+		return 0, false
+	}
+	source := m.source(nodeName)
+	switch source {
+	case config.EnergySourceRedfish:
+		return m.redfish.Value(nodeName)
+	case config.EnergySourcePDU:
+		return m.pdu.Value(nodeName)
+	}
+	readings, ok := m.nodeReadings[source]
+	if !ok {
+		return 0, false
+	}
+	return readings.Value(nodeName)
+}
+
+// WallPower reports whether the source of a node measures its whole power at the
+// wall, as BMCs and PDUs do, rather than that of its CPUs
+func (m *Meter) WallPower(nodeName string) bool {
+	if m == nil {
+		return false
+	}
+	source := m.source(nodeName)
+	return source == config.EnergySourceRedfish || source == config.EnergySourcePDU
+}
+
+// PodPower returns the measured power of a pod in watts, if the source of its node
+// measures pods and the reading is fresh
+func (m *Meter) PodPower(pod *v1.Pod) (float64, bool) {
+	if m == nil || m.podReadings == nil || m.source(pod.Spec.NodeName) != config.EnergySourceScaphandre {
+		return 0, false
+	}
+	return m.podReadings.Value(pod.Namespace + "/" + pod.Name)
+}
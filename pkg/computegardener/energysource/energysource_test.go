@@ -0,0 +1,218 @@
+package energysource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/promquery"
+)
+
+func TestMeter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	readings := func(values map[string]float64) *promquery.Vector {
+		v := promquery.NewVector(nil, "", "node", time.Minute, 5*time.Minute, clock)
+		v.Set(values)
+		return v
+	}
+	scaphandre := readings(map[string]float64{"metal-1": 250, "vm-1": 90})
+	rapl := readings(map[string]float64{"metal-2": 180, "vm-1": 95})
+	pods := promquery.NewVectorByLabels(nil, "", []string{"namespace", "pod"}, time.Minute, 5*time.Minute, clock)
+	pods.Set(map[string]float64{"team-a/job": 35})
+
+	m := NewWithReadings(config.EnergySourceConfig{
+		Default:       config.EnergySourceModel,
+		NodePoolLabel: "pool",
+		NodePools: map[string]string{
+			"metal":  config.EnergySourceScaphandre,
+			"metal2": config.EnergySourceRAPL,
+		},
+	}, map[string]*promquery.Vector{
+		config.EnergySourceScaphandre: scaphandre,
+		config.EnergySourceRAPL:       rapl,
+	}, pods)
+
+	node := func(name, pool string) *v1.Node {
+		return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"pool": pool}}}
+	}
+	m.TrackNode(node("metal-1", "metal"))
+	m.TrackNode(node("metal-2", "metal2"))
+	m.TrackNode(node("vm-1", "general"))
+
+	tests := []struct {
+		node   string
+		want   float64
+		wantOK bool
+	}{
+		{node: "metal-1", want: 250, wantOK: true},
+		{node: "metal-2", want: 180, wantOK: true},
+		// The pool of vm-1 uses the model, though sources report it
+		{node: "vm-1", wantOK: false},
+		{node: "untracked", wantOK: false},
+	}
+	for _, tt := range tests {
+		if got, ok := m.NodePower(tt.node); ok != tt.wantOK || got != tt.want {
+			t.Errorf("NodePower(%q) = %v, %v, want %v, %v", tt.node, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	pod := func(nodeName string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "job"},
+			Spec:       v1.PodSpec{NodeName: nodeName},
+		}
+	}
+	if got, ok := m.PodPower(pod("metal-1")); !ok || got != 35 {
+		t.Errorf("PodPower() on Scaphandre node = %v, %v, want 35, true", got, ok)
+	}
+	if _, ok := m.PodPower(pod("metal-2")); ok {
+		t.Error("PodPower() on RAPL node should be unavailable")
+	}
+
+	m.ForgetNode("metal-1")
+	if _, ok := m.NodePower("metal-1"); ok {
+		t.Error("NodePower() of forgotten node should fall back to the default source")
+	}
+
+	now = now.Add(10 * time.Minute)
+	if _, ok := m.NodePower("metal-2"); ok {
+		t.Error("NodePower() of stale readings should be unavailable")
+	}
+
+	var unset *Meter
+	if _, ok := unset.NodePower("metal-1"); ok {
+		t.Error("NodePower() of nil meter should be unavailable")
+	}
+}
+
+func TestRedfish(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	bmc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/metal-1/redfish/v1/Chassis/1/Power":
+			fmt.Fprint(w, `{"PowerControl": [{"PowerConsumedWatts": 412}]}`)
+		case "/metal-2/redfish/v1/Chassis/1/Power":
+			fmt.Fprint(w, `{"PowerControl": [{"PowerConsumedWatts": null}]}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer bmc.Close()
+
+	m := New(config.EnergySourceConfig{
+		Default: config.EnergySourceRedfish,
+		RedfishAddresses: map[string]string{
+			"metal-1": bmc.URL + "/metal-1/",
+			"metal-2": bmc.URL + "/metal-2",
+		},
+		RedfishPowerPath: "/redfish/v1/Chassis/1/Power",
+		RedfishUsername:  "admin",
+		RedfishPassword:  "secret",
+		PollInterval:     time.Minute,
+		MaxAge:           5 * time.Minute,
+	}, time.Second, func() time.Time { return now })
+
+	// BMC addresses annotated on nodes are ignored
+	node := func(name, address string) *v1.Node {
+		return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{"bmc": address}}}
+	}
+	m.TrackNode(node("metal-1", "https://attacker.example"))
+	m.TrackNode(node("metal-2", ""))
+	m.TrackNode(node("no-bmc", bmc.URL+"/metal-1/"))
+	m.redfish.poll(context.Background())
+
+	if got, ok := m.NodePower("metal-1"); !ok || got != 412 {
+		t.Errorf("NodePower(metal-1) = %v, %v, want 412, true", got, ok)
+	}
+	for _, name := range []string{"metal-2", "no-bmc"} {
+		if _, ok := m.NodePower(name); ok {
+			t.Errorf("NodePower(%s) without a BMC reading should be unavailable", name)
+		}
+	}
+	if !m.WallPower("metal-1") {
+		t.Error("WallPower() of a Redfish node should be true")
+	}
+
+	now = now.Add(10 * time.Minute)
+	if _, ok := m.NodePower("metal-1"); ok {
+		t.Error("NodePower() of a stale BMC reading should be unavailable")
+	}
+}
+
+func TestPDU(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redfish/v1/PowerEquipment/RackPDUs/1/Outlets/3":
+			fmt.Fprint(w, `{"PowerWatts": {"Reading": 180.5}}`)
+		case "/redfish/v1/PowerEquipment/RackPDUs/1/Outlets/4":
+			fmt.Fprint(w, `{"PowerWatts": {"Reading": 175}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	cfg := config.EnergySourceConfig{
+		Default:      config.EnergySourcePDU,
+		PollInterval: time.Minute,
+		MaxAge:       5 * time.Minute,
+		PDU: config.PDUConfig{
+			OutletsAnnotation: "outlets",
+			Addresses:         map[string]string{"pdu-a": server.URL, "pdu-b": server.URL + "/"},
+			OutletPath:        "/redfish/v1/PowerEquipment/RackPDUs/1/Outlets/%s",
+		},
+	}
+	node := func(name, outlets string) *v1.Node {
+		return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: map[string]string{"outlets": outlets}}}
+	}
+
+	m := New(cfg, time.Second, clock)
+	m.TrackNode(node("dual-fed", "pdu-a/3, pdu-b/4"))
+	m.TrackNode(node("missing-outlet", "pdu-a/3,pdu-a/9"))
+	m.TrackNode(node("unknown-pdu", "pdu-c/1"))
+	m.TrackNode(node("invalid", "pdu-a"))
+	m.pdu.poll(context.Background())
+
+	if got, ok := m.NodePower("dual-fed"); !ok || got != 355.5 {
+		t.Errorf("NodePower(dual-fed) = %v, %v, want 355.5, true", got, ok)
+	}
+	// Nodes are only measured if every outlet feeding them is
+	for _, name := range []string{"missing-outlet", "unknown-pdu", "invalid"} {
+		if _, ok := m.NodePower(name); ok {
+			t.Errorf("NodePower(%s) should be unavailable", name)
+		}
+	}
+	if !m.WallPower("dual-fed") {
+		t.Error("WallPower() of a PDU node should be true")
+	}
+
+	// Outlets can instead be read from Prometheus, e.g. scraped by the SNMP exporter
+	cfg.PDU.Query = "pdu_outlet_power_watts"
+	cfg.PDU.PDULabel, cfg.PDU.OutletLabel = "pdu", "outlet"
+	m = New(cfg, time.Second, clock)
+	m.pdu.query.Set(map[string]float64{"pdu-a/3": 90, "pdu-b/4": 95})
+	m.TrackNode(node("dual-fed", "pdu-a/3,pdu-b/4"))
+	if got, ok := m.NodePower("dual-fed"); !ok || got != 185 {
+		t.Errorf("NodePower(dual-fed) from Prometheus = %v, %v, want 185, true", got, ok)
+	}
+
+	now = now.Add(10 * time.Minute)
+	if _, ok := m.NodePower("dual-fed"); ok {
+		t.Error("NodePower() of stale outlet readings should be unavailable")
+	}
+}
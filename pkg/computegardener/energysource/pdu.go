@@ -0,0 +1,207 @@
+package energysource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/promquery"
+)
+
+// PDU reads the power of the smart PDU outlets feeding nodes, annotated on each node
+// as pdu/outlet pairs. Outlets are polled over Redfish, or read from Prometheus, e.g.
+// scraped over SNMP by the SNMP exporter.
+type PDU struct {
+	cfg      config.PDUConfig
+	interval time.Duration
+	maxAge   time.Duration
+	client   *http.Client
+	query    *promquery.Vector // outlet power, nil if polled over Redfish
+	now      func() time.Time
+
+	mutex    sync.RWMutex
+	outlets  map[string][]string // node name to its pdu/outlet pairs
+	readings map[string]reading  // by pdu/outlet
+}
+
+// outletResponse is the part of a Redfish Outlet resource holding its power
+type outletResponse struct {
+	PowerWatts *struct {
+		Reading *float64 `json:"Reading"`
+	} `json:"PowerWatts"`
+}
+
+// NewPDU creates a PDU reader, querying the energy source Prometheus if a PDU query
+// is set
+func NewPDU(cfg config.EnergySourceConfig, timeout time.Duration, now func() time.Time) *PDU {
+	p := &PDU{
+		cfg:      cfg.PDU,
+		interval: cfg.PollInterval,
+		maxAge:   cfg.MaxAge,
+		client:   newHTTPClient(timeout, cfg.PDU.InsecureSkipVerify),
+		now:      now,
+		outlets:  make(map[string][]string),
+		readings: make(map[string]reading),
+	}
+	if cfg.PDU.Query != "" {
+		p.query = promquery.NewVectorByLabels(promquery.NewClient(cfg.PrometheusURL, timeout), cfg.PDU.Query,
+			[]string{cfg.PDU.PDULabel, cfg.PDU.OutletLabel}, cfg.PollInterval, cfg.MaxAge, now)
+	}
+	return p
+}
+
+// TrackNode records the outlets feeding a node from its annotation
+func (p *PDU) TrackNode(node *v1.Node) {
+	if p == nil {
+		return
+	}
+	var outlets []string
+	for _, outlet := range strings.Split(node.Annotations[p.cfg.OutletsAnnotation], ",") {
+		outlet = strings.TrimSpace(outlet)
+		if pdu, id, ok := strings.Cut(outlet, "/"); ok && pdu != "" && id != "" {
+			outlets = append(outlets, outlet)
+		} else if outlet != "" {
+			klog.V(2).InfoS("Ignoring invalid PDU outlet", "node", node.Name, "outlet", outlet)
+		}
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(outlets) > 0 {
+		p.outlets[node.Name] = outlets
+	} else {
+		delete(p.outlets, node.Name)
+	}
+}
+
+// ForgetNode drops a deleted node
+func (p *PDU) ForgetNode(name string) {
+	if p == nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.outlets, name)
+}
+
+// Run reads the outlets feeding tracked nodes every poll interval until stopCh is
+// closed
+func (p *PDU) Run(ctx context.Context, stopCh <-chan struct{}) {
+	if p.query != nil {
+		p.query.Run(ctx, stopCh)
+		return
+	}
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.poll(ctx)
+		select {
+		case <-stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll reads every outlet feeding a tracked node over Redfish concurrently, keeping
+// the previous reading of outlets that can't be read
+func (p *PDU) poll(ctx context.Context) {
+	p.mutex.RLock()
+	outlets := make(map[string]bool)
+	for _, nodeOutlets := range p.outlets {
+		for _, outlet := range nodeOutlets {
+			outlets[outlet] = true
+		}
+	}
+	p.mutex.RUnlock()
+
+	var wg sync.WaitGroup
+	for outlet := range outlets {
+		wg.Add(1)
+		go func(outlet string) {
+			defer wg.Done()
+			watts, err := p.read(ctx, outlet)
+			if err != nil {
+				klog.V(2).InfoS("Failed to read PDU outlet power", "outlet", outlet, "err", err)
+				return
+			}
+			p.mutex.Lock()
+			defer p.mutex.Unlock()
+			p.readings[outlet] = reading{watts: watts, at: p.now()}
+		}(outlet)
+	}
+	wg.Wait()
+
+	// Drop the readings of outlets no longer feeding a tracked node
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for outlet := range p.readings {
+		if !outlets[outlet] {
+			delete(p.readings, outlet)
+		}
+	}
+}
+
+// read returns the power of a pdu/outlet from the PDU's Redfish Outlet resource
+func (p *PDU) read(ctx context.Context, outlet string) (float64, error) {
+	pdu, id, _ := strings.Cut(outlet, "/")
+	address, ok := p.cfg.Addresses[pdu]
+	if !ok {
+		return 0, fmt.Errorf("no address for PDU %s", pdu)
+	}
+	url := strings.TrimSuffix(address, "/") + fmt.Sprintf(p.cfg.OutletPath, id)
+
+	var resp outletResponse
+	if err := getJSON(ctx, p.client, url, p.cfg.Username, p.cfg.Password, &resp); err != nil {
+		return 0, err
+	}
+	if resp.PowerWatts == nil || resp.PowerWatts.Reading == nil {
+		return 0, fmt.Errorf("no power reading")
+	}
+	return *resp.PowerWatts.Reading, nil
+}
+
+// outletPower returns the latest power reading of a pdu/outlet if it is fresh
+func (p *PDU) outletPower(outlet string) (float64, bool) {
+	if p.query != nil {
+		return p.query.Value(outlet)
+	}
+	reading, ok := p.readings[outlet]
+	if !ok || p.now().Sub(reading.at) > p.maxAge {
+		return 0, false
+	}
+	return reading.watts, true
+}
+
+// Value returns the summed power of the outlets feeding a node in watts, if all of
+// them have a fresh reading
+func (p *PDU) Value(nodeName string) (float64, bool) {
+	if p == nil {
+		return 0, false
+	}
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	outlets, ok := p.outlets[nodeName]
+	if !ok {
+		return 0, false
+	}
+	var watts float64
+	for _, outlet := range outlets {
+		power, ok := p.outletPower(outlet)
+		if !ok {
+			return 0, false
+		}
+		watts += power
+	}
+	return watts, true
+}
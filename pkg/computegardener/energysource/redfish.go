@@ -0,0 +1,189 @@
+package energysource
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+)
+
+// Redfish polls the power readings of node BMCs over Redfish, for bare-metal nodes
+// whose BMC address is configured
+type Redfish struct {
+	cfg    config.EnergySourceConfig
+	client *http.Client
+	now    func() time.Time
+
+	mutex     sync.RWMutex
+	addresses map[string]string // node name to BMC base URL
+	readings  map[string]reading
+}
+
+type reading struct {
+	watts float64
+	at    time.Time
+}
+
+// powerResponse is the part of a Redfish Power resource holding the power consumed
+type powerResponse struct {
+	PowerControl []struct {
+		PowerConsumedWatts *float64 `json:"PowerConsumedWatts"`
+	} `json:"PowerControl"`
+}
+
+// NewRedfish creates a Redfish poller with the credentials and power path of cfg
+func NewRedfish(cfg config.EnergySourceConfig, timeout time.Duration, now func() time.Time) *Redfish {
+	return &Redfish{
+		cfg:       cfg,
+		client:    newHTTPClient(timeout, cfg.RedfishInsecureSkipVerify),
+		now:       now,
+		addresses: make(map[string]string),
+		readings:  make(map[string]reading),
+	}
+}
+
+// TrackNode starts polling the BMC configured for a node, if any
+func (r *Redfish) TrackNode(node *v1.Node) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if address := r.cfg.RedfishAddresses[node.Name]; address != "" {
+		r.addresses[node.Name] = strings.TrimSuffix(address, "/")
+	} else {
+		delete(r.addresses, node.Name)
+		delete(r.readings, node.Name)
+	}
+}
+
+// ForgetNode drops a deleted node
+func (r *Redfish) ForgetNode(name string) {
+	if r == nil {
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	delete(r.addresses, name)
+	delete(r.readings, name)
+}
+
+// Run polls the BMCs of tracked nodes every poll interval until stopCh is closed
+func (r *Redfish) Run(ctx context.Context, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		r.poll(ctx)
+		select {
+		case <-stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll reads the power of every tracked node concurrently, keeping the previous
+// reading of nodes whose BMC can't be read
+func (r *Redfish) poll(ctx context.Context) {
+	r.mutex.RLock()
+	addresses := make(map[string]string, len(r.addresses))
+	for name, address := range r.addresses {
+		addresses[name] = address
+	}
+	r.mutex.RUnlock()
+
+	var wg sync.WaitGroup
+	for name, address := range addresses {
+		wg.Add(1)
+		go func(name, address string) {
+			defer wg.Done()
+			watts, err := r.read(ctx, address)
+			if err != nil {
+				klog.V(2).InfoS("Failed to read BMC power", "node", name, "address", address, "err", err)
+				return
+			}
+			r.mutex.Lock()
+			defer r.mutex.Unlock()
+			if _, tracked := r.addresses[name]; tracked {
+				r.readings[name] = reading{watts: watts, at: r.now()}
+			}
+		}(name, address)
+	}
+	wg.Wait()
+}
+
+// read returns the power consumed by a node from its BMC's Power resource
+func (r *Redfish) read(ctx context.Context, address string) (float64, error) {
+	var power powerResponse
+	err := getJSON(ctx, r.client, address+r.cfg.RedfishPowerPath, r.cfg.RedfishUsername, r.cfg.RedfishPassword, &power)
+	if err != nil {
+		return 0, err
+	}
+	for _, control := range power.PowerControl {
+		if control.PowerConsumedWatts != nil {
+			return *control.PowerConsumedWatts, nil
+		}
+	}
+	return 0, fmt.Errorf("no power consumed reading")
+}
+
+// Value returns the latest power reading of a node in watts if it is fresh
+func (r *Redfish) Value(nodeName string) (float64, bool) {
+	if r == nil {
+		return 0, false
+	}
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	reading, ok := r.readings[nodeName]
+	if !ok || r.now().Sub(reading.at) > r.cfg.MaxAge {
+		return 0, false
+	}
+	return reading.watts, true
+}
+
+// newHTTPClient creates a client for Redfish services, which often serve self-signed
+// certificates
+func newHTTPClient(timeout time.Duration, insecureSkipVerify bool) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if insecureSkipVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// getJSON decodes the JSON resource at url into v, authenticating if username is set
+func getJSON(ctx context.Context, client *http.Client, url, username, password string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package computegardener
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+)
+
+// enforcementModeLabel sets the enforcement mode of pods in a namespace
+const enforcementModeLabel = "carbon-aware-scheduler.kubernetes.io/mode"
+
+// enforcementMode returns the enforcement mode of a pod from its namespace label, its
+// carbon policies, or the configured default, in that order of precedence
+func (cs *CarbonAwareScheduler) enforcementMode(pod *v1.Pod) string {
+	if mode, ok := cs.namespaceEnforcementMode(pod); ok {
+		return mode
+	}
+	if mode, ok := cs.policyEnforcementMode(pod); ok {
+		return mode
+	}
+	return cs.config.Scheduling.EnforcementMode
+}
+
+// namespaceEnforcementMode returns the enforcement mode set by the label of a pod's
+// namespace
+func (cs *CarbonAwareScheduler) namespaceEnforcementMode(pod *v1.Pod) (string, bool) {
+	if cs.namespaceLister == nil {
+		return "", false
+	}
+	ns, err := cs.namespaceLister.Get(pod.Namespace)
+	if err != nil {
+		return "", false
+	}
+	switch label := ns.Labels[enforcementModeLabel]; label {
+	case "":
+	case config.EnforcementModeEnforce, config.EnforcementModeAudit:
+		return label, true
+	default:
+		klog.V(2).InfoS("Ignoring invalid enforcement mode label", "namespace", ns.Name, "value", label)
+	}
+	return "", false
+}
@@ -0,0 +1,203 @@
+package computegardener
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// Decisions of an evaluation
+const (
+	decisionAdmit = "admit"
+	decisionDelay = "delay"
+	decisionError = "error"
+)
+
+// maxEvaluationBytes bounds the size of the pods submitted for evaluation
+const maxEvaluationBytes = 1 << 20
+
+// profiles maps the profile names of plugin instances to the instance, so submitted
+// pods are evaluated under the plugin args of the profile their schedulerName selects
+var profiles sync.Map // map[string]*CarbonAwareScheduler
+
+// Evaluation is the decision the scheduler would make for a pod right now, along with
+// the settings resolved for the pod and the data the decision is based on
+type Evaluation struct {
+	// Profile is the scheduler profile the pod was evaluated in
+	Profile string `json:"profile"`
+	// Policy is the CarbonPolicy, as namespace/name, or ClusterCarbonPolicy applying
+	Policy string `json:"policy,omitempty"`
+	// PolicySources maps each setting taken from policies to the policy setting it
+	PolicySources map[string]string `json:"policySources,omitempty"`
+	// WorkloadClass is the WorkloadClass applying
+	WorkloadClass string `json:"workloadClass,omitempty"`
+	// Exemption is the CarbonExemption in effect for the pod
+	Exemption string `json:"exemption,omitempty"`
+	// EnforcementMode is enforce or audit
+	EnforcementMode string `json:"enforcementMode"`
+	// MaxSchedulingDelay is the longest the pod would be delayed
+	MaxSchedulingDelay string `json:"maxSchedulingDelay"`
+	// Zone is the grid zone the pod is evaluated in
+	Zone string `json:"zone"`
+	// CarbonIntensity is the current carbon intensity of the zone, in gCO2/kWh
+	CarbonIntensity *float64 `json:"carbonIntensity,omitempty"`
+	// CarbonIntensityThreshold is the effective threshold of the pod, in gCO2/kWh
+	CarbonIntensityThreshold *float64 `json:"carbonIntensityThreshold,omitempty"`
+	// ElectricityRate is the current electricity rate, if pricing is enabled
+	ElectricityRate *float64 `json:"electricityRate,omitempty"`
+	// PriceThreshold is the electricity rate threshold of the pod, if pricing is enabled
+	PriceThreshold *float64 `json:"priceThreshold,omitempty"`
+	// Decision is admit, delay or error
+	Decision string `json:"decision"`
+	// Reason explains the decision, if it has a reason
+	Reason string `json:"reason,omitempty"`
+	// Nodes restricts the nodes an admitted pod would be scheduled on, such as the
+	// nodes of greener zones
+	Nodes []string `json:"nodes,omitempty"`
+	// ProjectedStart is when a delayed pod is expected to be admitted
+	ProjectedStart *time.Time `json:"projectedStart,omitempty"`
+}
+
+// evaluationHandler evaluates pods POSTed as JSON or YAML, in the profile their
+// schedulerName selects, or else in the profile of owner. Requests must carry the
+// token as a bearer token.
+type evaluationHandler struct {
+	owner *CarbonAwareScheduler
+	token string
+}
+
+// ServeHTTP handles evaluation requests
+func (h *evaluationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !authorized(r, h.token) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var pod v1.Pod
+	if err := utilyaml.NewYAMLOrJSONDecoder(io.LimitReader(r.Body, maxEvaluationBytes), 4096).Decode(&pod); err != nil {
+		http.Error(w, "invalid pod: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	schedulerName := pod.Spec.SchedulerName
+	if schedulerName == "" {
+		schedulerName = v1.DefaultSchedulerName
+	}
+	cs := h.owner
+	if p, ok := profiles.Load(schedulerName); ok {
+		cs = p.(*CarbonAwareScheduler)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(cs.evaluate(r.Context(), &pod)); err != nil {
+		klog.ErrorS(err, "Failed to write pod evaluation")
+	}
+}
+
+// authorized reports whether a request carries token as its bearer token. Requests
+// are never authorized without a token.
+func authorized(r *http.Request, token string) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// requireToken serves only the requests carrying token as their bearer token with h
+func requireToken(token string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r, token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// evaluate returns the decision PreFilter would make for a pod submitted now, without
+// recording anything about it or fetching data. Pods without a namespace are evaluated
+// in the default namespace, and pods in zones without cached data are not evaluated.
+// Release batching, admission pacing and concurrency limits, which depend on the pods
+// being scheduled, are not evaluated.
+func (cs *CarbonAwareScheduler) evaluate(ctx context.Context, pod *v1.Pod) Evaluation {
+	pod = pod.DeepCopy()
+	now := cs.clock.Now()
+	if pod.Namespace == "" {
+		pod.Namespace = metav1.NamespaceDefault
+	}
+	if pod.CreationTimestamp.IsZero() {
+		pod.CreationTimestamp = metav1.NewTime(now)
+	}
+
+	dry := cs.dryRunInstance()
+	e := Evaluation{
+		Profile:            profileName(cs.handle),
+		EnforcementMode:    dry.enforcementMode(pod),
+		MaxSchedulingDelay: dry.maxSchedulingDelay(pod).String(),
+	}
+	if p, ok := dry.policies.Resolve(pod); ok {
+		e.Policy = p.Name
+		e.PolicySources = p.Sources
+	}
+	if class, ok := dry.workloadClass(pod); ok {
+		e.WorkloadClass = class.Name
+	}
+	if ex, ok := dry.exemptions.Active(pod, now); ok {
+		e.Exemption = ex.Name
+	}
+	if cs.config.Pricing.Enabled {
+		if threshold, err := dry.priceThreshold(pod); err == nil {
+			e.PriceThreshold = &threshold
+		}
+	}
+
+	result, status := dry.preFilter(ctx, pod)
+	diagnosis := dry.diagnoseDelay(ctx, pod)
+	e.Zone = diagnosis.zone
+	e.CarbonIntensity = diagnosis.intensity
+	e.CarbonIntensityThreshold = diagnosis.threshold
+	e.ElectricityRate = diagnosis.rate
+	e.Reason = status.Message()
+
+	switch {
+	case status.IsSuccess():
+		e.Decision = decisionAdmit
+		if result != nil && result.NodeNames != nil {
+			e.Nodes = sets.List(result.NodeNames)
+		}
+	case status.Code() == framework.Unschedulable || status.Code() == framework.Wait:
+		e.Decision = decisionDelay
+		e.ProjectedStart = &diagnosis.nextTransition
+	default:
+		e.Decision = decisionError
+	}
+	return e
+}
+
+// dryRunInstance returns a plugin instance sharing this instance's configuration and
+// data layer, but none of its scheduling state, that records nothing. It starts from a
+// copy of the hysteresis latches, so evaluations neither change nor race with them.
+func (cs *CarbonAwareScheduler) dryRunInstance() *CarbonAwareScheduler {
+	dry := &CarbonAwareScheduler{
+		handle:          cs.handle,
+		config:          cs.config,
+		clock:           cs.clock,
+		hysteresis:      cs.hysteresis.clone(),
+		namespaceLister: cs.namespaceLister,
+		dryRun:          true,
+	}
+	dry.shareDataLayer(cs)
+	return dry
+}
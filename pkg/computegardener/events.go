@@ -0,0 +1,63 @@
+package computegardener
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/util"
+)
+
+// EventsToRegister implements the EnqueueExtensions interface. Carbon and price
+// delays don't depend on cluster state, so unrelated Node and Pod events don't
+// requeue delayed pods; only changes to their own annotations do. Intensity drops
+// and the end of peak windows are picked up when the queue periodically flushes
+// unschedulable pods, which PreEnqueue keeps gated while they back off. With rack
+// power budgets, deleted pods may free up power on their rack.
+func (cs *CarbonAwareScheduler) EventsToRegister(_ context.Context) ([]framework.ClusterEventWithHint, error) {
+	events := []framework.ClusterEventWithHint{
+		{
+			Event:          framework.ClusterEvent{Resource: framework.Pod, ActionType: framework.Update},
+			QueueingHintFn: cs.isSchedulableAfterPodChange,
+		},
+	}
+	if cs.config.Power.RackBudgetsEnabled {
+		events = append(events, framework.ClusterEventWithHint{
+			Event:          framework.ClusterEvent{Resource: framework.Pod, ActionType: framework.Delete},
+			QueueingHintFn: isSchedulableAfterPodDelete,
+		})
+	}
+	return events, nil
+}
+
+// isSchedulableAfterPodDelete requeues a pod when a pod assigned to a node is
+// deleted, which lowers the power draw of the node's rack
+func isSchedulableAfterPodDelete(logger klog.Logger, pod *v1.Pod, oldObj, newObj interface{}) (framework.QueueingHint, error) {
+	deleted, _, err := util.As[*v1.Pod](oldObj, newObj)
+	if err != nil {
+		return framework.Queue, err
+	}
+	if deleted.Spec.NodeName == "" {
+		return framework.QueueSkip, nil
+	}
+
+	logger.V(5).Info("Assigned pod deleted, requeuing", "pod", klog.KObj(pod), "deletedPod", klog.KObj(deleted))
+	return framework.Queue, nil
+}
+
+// isSchedulableAfterPodChange requeues a delayed pod when its annotations change,
+// such as a release, skip or threshold annotation being added
+func (cs *CarbonAwareScheduler) isSchedulableAfterPodChange(logger klog.Logger, pod *v1.Pod, oldObj, newObj interface{}) (framework.QueueingHint, error) {
+	oldPod, newPod, err := util.As[*v1.Pod](oldObj, newObj)
+	if err != nil {
+		return framework.Queue, err
+	}
+
+	if newPod.UID != pod.UID || !annotationsChanged(oldPod, newPod) {
+		return framework.QueueSkip, nil
+	}
+
+	logger.V(5).Info("Annotations of delayed pod changed, requeuing", "pod", klog.KObj(pod))
+	return framework.Queue, nil
+}
@@ -0,0 +1,77 @@
+package computegardener
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/policy"
+)
+
+// exemptionCheckInterval is how often exemptions are checked for coming into and
+// out of effect
+const exemptionCheckInterval = time.Minute
+
+// isExempted reports whether a CarbonExemption in effect covers a pod
+func (cs *CarbonAwareScheduler) isExempted(pod *v1.Pod) bool {
+	_, ok := cs.exemptions.Active(pod, cs.clock.Now())
+	return ok
+}
+
+// exemptionWorker reports exemptions coming into and out of effect
+func (cs *CarbonAwareScheduler) exemptionWorker(ctx context.Context) {
+	ticker := time.NewTicker(exemptionCheckInterval)
+	defer ticker.Stop()
+
+	active := make(map[types.UID]bool)
+	for {
+		select {
+		case <-cs.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cs.checkExemptions(active)
+		}
+	}
+}
+
+// checkExemptions records an Event and counts each exemption that came into or out
+// of effect since the last check, tracked in active
+func (cs *CarbonAwareScheduler) checkExemptions(active map[types.UID]bool) {
+	now := cs.clock.Now()
+	seen := make(map[types.UID]bool)
+	inEffect := 0
+	for _, ex := range cs.exemptions.List() {
+		seen[ex.UID] = true
+		effective := policy.InEffect(ex, now)
+		if effective {
+			inEffect++
+		}
+		if effective == active[ex.UID] {
+			continue
+		}
+		active[ex.UID] = effective
+
+		if effective {
+			ExemptionTransitions.WithLabelValues("activated").Inc()
+			klog.InfoS("Carbon exemption activated", "exemption", ex.Name, "expires", ex.Spec.Expires.Time, "reason", ex.Spec.Reason)
+			cs.handle.EventRecorder().Eventf(ex, nil, v1.EventTypeNormal, "CarbonExemptionActive", "Exempting",
+				"Exempting selected pods from carbon-aware scheduling until %s", ex.Spec.Expires.UTC().Format(time.RFC3339))
+		} else {
+			ExemptionTransitions.WithLabelValues("lapsed").Inc()
+			klog.InfoS("Carbon exemption lapsed", "exemption", ex.Name)
+			cs.handle.EventRecorder().Eventf(ex, nil, v1.EventTypeNormal, "CarbonExemptionLapsed", "Exempting",
+				"Exemption expired at %s", ex.Spec.Expires.UTC().Format(time.RFC3339))
+		}
+	}
+	for uid := range active {
+		if !seen[uid] {
+			delete(active, uid)
+		}
+	}
+	ActiveExemptions.Set(float64(inEffect))
+}
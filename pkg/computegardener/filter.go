@@ -0,0 +1,20 @@
+package computegardener
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// Filter implements the Filter interface. Nodes are rejected if their rack would
+// exceed its power budget with the pod, or if they run close to their power cap.
+func (cs *CarbonAwareScheduler) Filter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	if nodeInfo.Node() == nil {
+		return nil
+	}
+	if status := cs.checkRackBudget(state, pod, nodeInfo); !status.IsSuccess() {
+		return status
+	}
+	return cs.checkPowerCap(nodeInfo.Node())
+}
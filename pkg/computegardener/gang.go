@@ -0,0 +1,127 @@
+package computegardener
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	schedulingv1alpha1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+// podGroupKey returns the coscheduling PodGroup a pod belongs to
+func podGroupKey(pod *v1.Pod) (string, bool) {
+	name := pod.Labels[schedulingv1alpha1.PodGroupLabel]
+	if name == "" {
+		return "", false
+	}
+	return pod.Namespace + "/" + name, true
+}
+
+// gangReleased reports whether another member of a pod's PodGroup has been admitted
+// recently. Members of a released group are admitted regardless of carbon, price,
+// pacing and concurrency constraints, so a gang is held or released as a whole
+// rather than partially scheduled while its siblings wait for a carbon window.
+func (cs *CarbonAwareScheduler) gangReleased(pod *v1.Pod) bool {
+	if cs.config.Scheduling.GangReleaseTTL <= 0 {
+		return false
+	}
+	key, ok := podGroupKey(pod)
+	if !ok {
+		return false
+	}
+	value, ok := cs.gangReleases.Load(key)
+	if !ok {
+		return false
+	}
+	if cs.clock.Since(value.(time.Time)) > cs.config.Scheduling.GangReleaseTTL {
+		cs.gangReleases.CompareAndDelete(key, value)
+		return false
+	}
+	return true
+}
+
+// releaseGang releases the PodGroup of a pod once it is allowed past Permit, or its
+// deferred bind proceeds, so members admitted in PreFilter that fail to be placed
+// release nothing. The pending members of the group are added to activate, to be
+// moved to the active queue at the end of the pod's cycle rather than waiting out
+// their backoff. Opted-out pods don't release their group.
+func (cs *CarbonAwareScheduler) releaseGang(pod *v1.Pod, activate *framework.PodsToActivate) {
+	if cs.config.Scheduling.GangReleaseTTL <= 0 || cs.isOptedOut(pod) {
+		return
+	}
+	key, ok := podGroupKey(pod)
+	if !ok {
+		return
+	}
+	if _, loaded := cs.gangReleases.LoadOrStore(key, cs.clock.Now()); !loaded {
+		klog.V(2).InfoS("Releasing pod group", "podGroup", key, "pod", klog.KObj(pod))
+	}
+	cs.activateGang(pod, activate)
+}
+
+// activateGang adds the members of a pod's PodGroup that are not yet placed to
+// activate
+func (cs *CarbonAwareScheduler) activateGang(pod *v1.Pod, activate *framework.PodsToActivate) {
+	if activate == nil || cs.gangPods == nil {
+		return
+	}
+	selector := labels.SelectorFromSet(labels.Set{
+		schedulingv1alpha1.PodGroupLabel: pod.Labels[schedulingv1alpha1.PodGroupLabel],
+	})
+	members, err := cs.gangPods.Pods(pod.Namespace).List(selector)
+	if err != nil {
+		klog.ErrorS(err, "Failed to list pod group members", "pod", klog.KObj(pod))
+		return
+	}
+
+	activate.Lock()
+	defer activate.Unlock()
+	for _, member := range members {
+		if member.UID == pod.UID || member.Spec.NodeName != "" || member.DeletionTimestamp != nil {
+			continue
+		}
+		activate.Map[member.Namespace+"/"+member.Name] = member
+	}
+}
+
+// podsToActivate returns the pods the framework activates at the end of a cycle
+func podsToActivate(state *framework.CycleState) *framework.PodsToActivate {
+	if state == nil {
+		return nil
+	}
+	data, err := state.Read(framework.PodsToActivateKey)
+	if err != nil {
+		return nil
+	}
+	activate, _ := data.(*framework.PodsToActivate)
+	return activate
+}
+
+// gangWorker prunes expired pod group releases, which are otherwise only dropped
+// when another member of the group is looked up
+func (cs *CarbonAwareScheduler) gangWorker() {
+	ticker := time.NewTicker(cs.config.Scheduling.GangReleaseTTL)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cs.stopCh:
+			return
+		case <-ticker.C:
+			cs.pruneGangReleases()
+		}
+	}
+}
+
+// pruneGangReleases drops the releases of pod groups older than the release TTL
+func (cs *CarbonAwareScheduler) pruneGangReleases() {
+	cs.gangReleases.Range(func(key, value any) bool {
+		if cs.clock.Since(value.(time.Time)) > cs.config.Scheduling.GangReleaseTTL {
+			cs.gangReleases.CompareAndDelete(key, value)
+		}
+		return true
+	})
+}
@@ -0,0 +1,217 @@
+// Package gates holds pods out of scheduling with a scheduling gate until carbon
+// conditions allow, as an alternative to gating in the scheduler plugin that works
+// with any scheduler. A mutating webhook adds the gate when pods are created, and a
+// controller removes it once the intensity of the pod's zone is under its threshold
+// or the pod has waited for the maximum scheduling delay.
+//
+// Only a subset of the plugin's policy inputs is supported: the base threshold, the
+// maximum scheduling delay, and the skip, carbon-intensity-threshold and region
+// annotations. CarbonPolicy and ClusterCarbonPolicy resources, namespace thresholds,
+// carbon budgets, pricing, hysteresis and the other scheduling policies are ignored.
+package gates
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/zones"
+)
+
+// GateName is the scheduling gate holding pods until carbon conditions allow
+const GateName = "compute-gardener.dev/carbon"
+
+const (
+	skipAnnotation      = "carbon-aware-scheduler.kubernetes.io/skip"
+	thresholdAnnotation = "carbon-aware-scheduler.kubernetes.io/carbon-intensity-threshold"
+	regionAnnotation    = "carbon-aware-scheduler.kubernetes.io/region"
+)
+
+// Options holds the settings gated pods are released under
+type Options struct {
+	// Region is the zone of pods without a region annotation
+	Region string
+	// Threshold is the carbon intensity pods are released under, unless overridden
+	// by their threshold annotation
+	Threshold float64
+	// MaxDelay releases pods that have been gated this long regardless of intensity.
+	// Without a maximum delay, pods are released when intensity data is unavailable
+	// rather than held indefinitely.
+	MaxDelay time.Duration
+	// Interval is how often gated pods are evaluated
+	Interval time.Duration
+	// BatchSize is the most pods released per sync, oldest first, so that a zone
+	// dropping under its threshold doesn't release every pod at once (0 disables)
+	BatchSize int
+}
+
+// Controller removes the carbon scheduling gate from pods once conditions allow
+type Controller struct {
+	client   kubernetes.Interface
+	lister   corelisters.PodLister
+	provider api.Provider
+	zones    *zones.Mapper
+	opts     Options
+	now      func() time.Time
+}
+
+// NewController creates a new Controller
+func NewController(client kubernetes.Interface, lister corelisters.PodLister, provider api.Provider,
+	zoneMapper *zones.Mapper, opts Options, now func() time.Time) *Controller {
+	return &Controller{
+		client:   client,
+		lister:   lister,
+		provider: provider,
+		zones:    zoneMapper,
+		opts:     opts,
+		now:      now,
+	}
+}
+
+// Run evaluates gated pods until stopCh is closed
+func (c *Controller) Run(ctx context.Context, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(c.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		c.sync(ctx)
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sync releases the gated pods whose conditions allow, oldest first and at most
+// BatchSize of them. Each zone's intensity is fetched at most once per sync.
+func (c *Controller) sync(ctx context.Context) {
+	pods, err := c.lister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list pods")
+		return
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+	})
+
+	intensities := make(map[string]zoneIntensity)
+	released := 0
+	for _, pod := range pods {
+		if c.opts.BatchSize > 0 && released >= c.opts.BatchSize {
+			klog.V(2).InfoS("Release batch size reached, leaving remaining pods for the next sync", "batchSize", c.opts.BatchSize)
+			return
+		}
+		index := gateIndex(pod)
+		if index < 0 {
+			continue
+		}
+
+		reason, ok := c.releaseReason(ctx, pod, intensities)
+		if !ok {
+			continue
+		}
+		if err := c.ungate(ctx, pod, index); err != nil {
+			klog.ErrorS(err, "Failed to remove carbon scheduling gate", "pod", klog.KObj(pod))
+			continue
+		}
+		released++
+		klog.InfoS("Removed carbon scheduling gate", "pod", klog.KObj(pod), "reason", reason)
+	}
+}
+
+// zoneIntensity is the carbon intensity of a zone, or the error getting it, fetched
+// once per sync
+type zoneIntensity struct {
+	intensity float64
+	err       error
+}
+
+// releaseReason reports whether a gated pod can be released, and why
+func (c *Controller) releaseReason(ctx context.Context, pod *v1.Pod, intensities map[string]zoneIntensity) (string, bool) {
+	if pod.Annotations[skipAnnotation] == "true" {
+		return "skip annotation", true
+	}
+	if c.opts.MaxDelay > 0 && c.now().Sub(pod.CreationTimestamp.Time) > c.opts.MaxDelay {
+		return "maximum scheduling delay exceeded", true
+	}
+
+	threshold := c.opts.Threshold
+	if val, ok := pod.Annotations[thresholdAnnotation]; ok {
+		t, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			klog.V(2).InfoS("Ignoring invalid threshold annotation", "pod", klog.KObj(pod), "value", val)
+		} else {
+			threshold = t
+		}
+	}
+
+	zone := c.podZone(pod)
+	cached, ok := intensities[zone]
+	if !ok {
+		// Failures are cached too, so a zone without data is only requested once
+		data, err := c.provider.GetCarbonIntensity(ctx, zone)
+		if err != nil {
+			klog.ErrorS(err, "Failed to get carbon intensity", "zone", zone)
+			cached.err = err
+		} else {
+			cached.intensity = data.CarbonIntensity
+		}
+		intensities[zone] = cached
+	}
+	if cached.err != nil {
+		// Without a maximum delay pods would stay gated for as long as data is
+		// unavailable, so release them instead
+		if c.opts.MaxDelay <= 0 {
+			return "carbon intensity unavailable", true
+		}
+		// Keep pods gated until data is available or their maximum delay passes
+		return "", false
+	}
+
+	if cached.intensity > threshold {
+		return "", false
+	}
+	return fmt.Sprintf("carbon intensity %.2f under threshold %.2f", cached.intensity, threshold), true
+}
+
+// podZone returns the zone from a pod's region annotation, or the default region
+func (c *Controller) podZone(pod *v1.Pod) string {
+	if val := pod.Annotations[regionAnnotation]; val != "" {
+		if zone, ok := c.zones.ZoneForRegion(val); ok {
+			return zone
+		}
+		return val
+	}
+	return c.opts.Region
+}
+
+// ungate removes the carbon scheduling gate from a pod. The patch fails if the
+// gates changed since the pod was listed, leaving it for the next sync.
+func (c *Controller) ungate(ctx context.Context, pod *v1.Pod, index int) error {
+	patch := fmt.Sprintf(`[{"op":"test","path":"/spec/schedulingGates/%d/name","value":%q},`+
+		`{"op":"remove","path":"/spec/schedulingGates/%d"}]`, index, GateName, index)
+	_, err := c.client.CoreV1().Pods(pod.Namespace).Patch(ctx, pod.Name, types.JSONPatchType, []byte(patch), metav1.PatchOptions{})
+	return err
+}
+
+// gateIndex returns the position of the carbon scheduling gate of a pod, or -1
+func gateIndex(pod *v1.Pod) int {
+	for i, gate := range pod.Spec.SchedulingGates {
+		if gate.Name == GateName {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,278 @@
+package gates
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/ptr"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/zones"
+)
+
+type fakeProvider map[string]float64
+
+// countingProvider counts the intensity requests of each zone
+type countingProvider struct {
+	fakeProvider
+	calls map[string]int
+}
+
+func (p *countingProvider) GetCarbonIntensity(ctx context.Context, zone string) (*api.ElectricityData, error) {
+	p.calls[zone]++
+	return p.fakeProvider.GetCarbonIntensity(ctx, zone)
+}
+
+func (p fakeProvider) GetCarbonIntensity(_ context.Context, zone string) (*api.ElectricityData, error) {
+	intensity, ok := p[zone]
+	if !ok {
+		return nil, fmt.Errorf("no data for zone %s", zone)
+	}
+	return &api.ElectricityData{CarbonIntensity: intensity}, nil
+}
+
+func gatedPod(name string, created time.Time, annotations map[string]string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			Annotations:       annotations,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1.PodSpec{SchedulingGates: []v1.PodSchedulingGate{{Name: "other"}, {Name: GateName}}},
+	}
+}
+
+func TestSync(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		pod       *v1.Pod
+		wantGated bool
+	}{
+		{
+			name:      "intensity above threshold",
+			pod:       gatedPod("dirty", now, nil),
+			wantGated: true,
+		},
+		{
+			name:      "threshold annotation",
+			pod:       gatedPod("lenient", now, map[string]string{thresholdAnnotation: "300"}),
+			wantGated: false,
+		},
+		{
+			name:      "greener region",
+			pod:       gatedPod("green", now, map[string]string{regionAnnotation: "us-west-2"}),
+			wantGated: false,
+		},
+		{
+			name:      "maximum delay exceeded",
+			pod:       gatedPod("late", now.Add(-25*time.Hour), nil),
+			wantGated: false,
+		},
+		{
+			name:      "skip annotation",
+			pod:       gatedPod("skipped", now, map[string]string{skipAnnotation: "true"}),
+			wantGated: false,
+		},
+		{
+			name:      "no data for zone",
+			pod:       gatedPod("unknown", now, map[string]string{regionAnnotation: "nowhere"}),
+			wantGated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(tt.pod)
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+			if err := indexer.Add(tt.pod); err != nil {
+				t.Fatal(err)
+			}
+
+			c := NewController(client, corelisters.NewPodLister(indexer),
+				fakeProvider{"US-CAL-CISO": 250, "US-NW-PACW": 50},
+				zones.NewMapper(map[string]string{"us-west-2": "US-NW-PACW"}),
+				Options{Region: "US-CAL-CISO", Threshold: 200, MaxDelay: 24 * time.Hour, Interval: time.Minute},
+				func() time.Time { return now })
+			c.sync(context.Background())
+
+			got, err := client.CoreV1().Pods("default").Get(context.Background(), tt.pod.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if gated := gateIndex(got) >= 0; gated != tt.wantGated {
+				t.Errorf("gated = %v, want %v", gated, tt.wantGated)
+			}
+			if len(got.Spec.SchedulingGates) == 0 || got.Spec.SchedulingGates[0].Name != "other" {
+				t.Errorf("scheduling gates = %v, want other gates kept", got.Spec.SchedulingGates)
+			}
+		})
+	}
+}
+
+func TestSyncBatchSize(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pods := []*v1.Pod{
+		gatedPod("newest", now, nil),
+		gatedPod("oldest", now.Add(-2*time.Hour), nil),
+		gatedPod("older", now.Add(-time.Hour), nil),
+	}
+
+	client := fake.NewSimpleClientset(pods[0], pods[1], pods[2])
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, pod := range pods {
+		if err := indexer.Add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := NewController(client, corelisters.NewPodLister(indexer), fakeProvider{"US-CAL-CISO": 150}, zones.NewMapper(nil),
+		Options{Region: "US-CAL-CISO", Threshold: 200, MaxDelay: 24 * time.Hour, Interval: time.Minute, BatchSize: 2},
+		func() time.Time { return now })
+	c.sync(context.Background())
+
+	for name, wantGated := range map[string]bool{"oldest": false, "older": false, "newest": true} {
+		got, err := client.CoreV1().Pods("default").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if gated := gateIndex(got) >= 0; gated != wantGated {
+			t.Errorf("%s: gated = %v, want %v", name, gated, wantGated)
+		}
+	}
+}
+
+func TestSyncWithoutMaxDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pod := gatedPod("unknown", now.Add(-48*time.Hour), map[string]string{regionAnnotation: "nowhere"})
+
+	client := fake.NewSimpleClientset(pod)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(pod); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a maximum delay pods are released when intensity data is unavailable
+	c := NewController(client, corelisters.NewPodLister(indexer), fakeProvider{}, zones.NewMapper(nil),
+		Options{Region: "US-CAL-CISO", Threshold: 200, Interval: time.Minute},
+		func() time.Time { return now })
+	c.sync(context.Background())
+
+	got, err := client.CoreV1().Pods("default").Get(context.Background(), pod.Name, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gateIndex(got) >= 0 {
+		t.Error("pod stayed gated without intensity data or a maximum delay")
+	}
+}
+
+func TestSyncCachesFailures(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	pods := []*v1.Pod{
+		gatedPod("unknown-0", now, map[string]string{regionAnnotation: "nowhere"}),
+		gatedPod("unknown-1", now, map[string]string{regionAnnotation: "nowhere"}),
+		gatedPod("unknown-2", now, map[string]string{regionAnnotation: "nowhere"}),
+	}
+
+	client := fake.NewSimpleClientset(pods[0], pods[1], pods[2])
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, pod := range pods {
+		if err := indexer.Add(pod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	provider := &countingProvider{fakeProvider: fakeProvider{}, calls: make(map[string]int)}
+	c := NewController(client, corelisters.NewPodLister(indexer), provider, zones.NewMapper(nil),
+		Options{Region: "US-CAL-CISO", Threshold: 200, MaxDelay: 24 * time.Hour, Interval: time.Minute},
+		func() time.Time { return now })
+	c.sync(context.Background())
+
+	if calls := provider.calls["nowhere"]; calls != 1 {
+		t.Errorf("requests for zone without data = %d, want 1", calls)
+	}
+}
+
+func TestGatePatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		pod       *v1.Pod
+		wantPatch bool
+		wantPath  string
+	}{
+		{
+			name:      "no gates",
+			pod:       &v1.Pod{},
+			wantPatch: true,
+			wantPath:  "/spec/schedulingGates",
+		},
+		{
+			name:      "other gates",
+			pod:       &v1.Pod{Spec: v1.PodSpec{SchedulingGates: []v1.PodSchedulingGate{{Name: "other"}}}},
+			wantPatch: true,
+			wantPath:  "/spec/schedulingGates/-",
+		},
+		{
+			name:      "already gated",
+			pod:       &v1.Pod{Spec: v1.PodSpec{SchedulingGates: []v1.PodSchedulingGate{{Name: GateName}}}},
+			wantPatch: false,
+		},
+		{
+			name:      "skip annotation",
+			pod:       &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{skipAnnotation: "true"}}},
+			wantPatch: false,
+		},
+		{
+			name: "daemonset pod",
+			pod: &v1.Pod{ObjectMeta: metav1.ObjectMeta{OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "agent", Controller: ptr.To(true)},
+			}}},
+			wantPatch: false,
+		},
+		{
+			name: "daemonset pod opted in",
+			pod: &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{skipAnnotation: "false"},
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "agent", Controller: ptr.To(true)},
+				},
+			}},
+			wantPatch: true,
+			wantPath:  "/spec/schedulingGates",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, ok := gatePatch(tt.pod)
+			if ok != tt.wantPatch {
+				t.Fatalf("gatePatch() ok = %v, want %v", ok, tt.wantPatch)
+			}
+			if !ok {
+				return
+			}
+
+			var ops []struct {
+				Op   string `json:"op"`
+				Path string `json:"path"`
+			}
+			if err := json.Unmarshal(patch, &ops); err != nil {
+				t.Fatalf("invalid patch %s: %v", patch, err)
+			}
+			if len(ops) != 1 || ops[0].Op != "add" || ops[0].Path != tt.wantPath {
+				t.Errorf("gatePatch() = %s, want an add to %s", patch, tt.wantPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package gates
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+// Webhook is a mutating admission webhook adding the carbon scheduling gate to pods
+// as they are created. Which pods are gated is selected in the webhook
+// configuration; pods annotated to skip carbon-aware scheduling are left alone.
+type Webhook struct{}
+
+// ServeHTTP handles AdmissionReview requests
+func (wh *Webhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var review admissionv1.AdmissionReview
+	if err := json.NewDecoder(r.Body).Decode(&review); err != nil || review.Request == nil {
+		http.Error(w, "invalid admission review", http.StatusBadRequest)
+		return
+	}
+
+	response := &admissionv1.AdmissionResponse{UID: review.Request.UID, Allowed: true}
+	var pod v1.Pod
+	if err := json.Unmarshal(review.Request.Object.Raw, &pod); err != nil {
+		// Never block pod creation on the gate
+		klog.ErrorS(err, "Failed to decode pod in admission review")
+	} else if patch, ok := gatePatch(&pod); ok {
+		patchType := admissionv1.PatchTypeJSONPatch
+		response.Patch = patch
+		response.PatchType = &patchType
+	}
+
+	review.Response = response
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(review); err != nil {
+		klog.ErrorS(err, "Failed to write admission response")
+	}
+}
+
+// gatePatch returns the JSON patch adding the carbon scheduling gate to a pod, if
+// the pod should be gated. DaemonSet pods are not gated unless skip is set to "false".
+func gatePatch(pod *v1.Pod) ([]byte, bool) {
+	if pod.Annotations[skipAnnotation] == "true" || pod.Spec.NodeName != "" || gateIndex(pod) >= 0 {
+		return nil, false
+	}
+	if _, ok := pod.Annotations[skipAnnotation]; !ok && ownedByDaemonSet(pod) {
+		return nil, false
+	}
+
+	gate := fmt.Sprintf(`{"name":%q}`, GateName)
+	if len(pod.Spec.SchedulingGates) == 0 {
+		return []byte(fmt.Sprintf(`[{"op":"add","path":"/spec/schedulingGates","value":[%s]}]`, gate)), true
+	}
+	return []byte(fmt.Sprintf(`[{"op":"add","path":"/spec/schedulingGates/-","value":%s}]`, gate)), true
+}
+
+// ownedByDaemonSet reports whether a pod is controlled by a DaemonSet
+func ownedByDaemonSet(pod *v1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == "DaemonSet" && strings.HasPrefix(owner.APIVersion, "apps/")
+}
@@ -0,0 +1,46 @@
+package computegardener
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// trackNodeGPUs records the number of GPUs of a node from its allocatable resources
+func (cs *CarbonAwareScheduler) trackNodeGPUs(node *v1.Node) {
+	if cs.nodeGPUs == nil {
+		return
+	}
+	gpus := node.Status.Allocatable[v1.ResourceName(cs.config.GPU.ResourceName)]
+	if count := gpus.Value(); count > 0 {
+		cs.nodeGPUs.Store(node.Name, count)
+	} else {
+		cs.nodeGPUs.Delete(node.Name)
+	}
+}
+
+// gpuPowerLimits returns the idle and max power of each GPU of a node from its power
+// profile, or the defaults
+func (cs *CarbonAwareScheduler) gpuPowerLimits(nodeName string) (idlePower, maxPower float64) {
+	if nodePower, ok := cs.nodeProfile(nodeName); ok && nodePower.GPUMaxPower > 0 {
+		return nodePower.GPUIdlePower, nodePower.GPUMaxPower
+	}
+	return cs.config.Power.DefaultGPUIdlePower, cs.config.Power.DefaultGPUMaxPower
+}
+
+// gpuPower estimates the power draw of the GPUs of a node from their summed
+// utilization, each drawing between its idle and max power. GPUs without a fresh
+// utilization reading are counted as idle.
+func (cs *CarbonAwareScheduler) gpuPower(nodeName string) float64 {
+	if cs.nodeGPUs == nil {
+		return 0
+	}
+	val, ok := cs.nodeGPUs.Load(nodeName)
+	if !ok {
+		return 0
+	}
+	gpus := float64(val.(int64))
+
+	idlePower, maxPower := cs.gpuPowerLimits(nodeName)
+	utilization, _ := cs.gpuUtilization.Value(nodeName)
+	utilization = min(max(utilization, 0), gpus)
+	return gpus*idlePower + (maxPower-idlePower)*utilization
+}
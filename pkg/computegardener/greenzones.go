@@ -0,0 +1,50 @@
+package computegardener
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+)
+
+// regionAnnotation pins a pod to a grid zone or cloud region
+const regionAnnotation = "carbon-aware-scheduler.kubernetes.io/region"
+
+// greenZoneNodes returns the nodes in zones whose carbon intensity doesn't exceed
+// the pod's threshold, so that a pod delayed in its own zone can still be scheduled
+// somewhere greener. It returns an empty set for pods pinned to a region, or when
+// the cluster's nodes span a single zone.
+func (cs *CarbonAwareScheduler) greenZoneNodes(ctx context.Context, pod *v1.Pod) sets.Set[string] {
+	if _, pinned := pod.Annotations[regionAnnotation]; pinned {
+		return nil
+	}
+
+	zoneNodes := make(map[string][]string)
+	cs.nodeZones.Range(func(key, value interface{}) bool {
+		zone := value.(string)
+		zoneNodes[zone] = append(zoneNodes[zone], key.(string))
+		return true
+	})
+	if len(zoneNodes) < 2 {
+		return nil
+	}
+
+	threshold, err := cs.carbonThreshold(pod)
+	if err != nil {
+		return nil
+	}
+
+	nodes := sets.New[string]()
+	for zone, names := range zoneNodes {
+		data, err := cs.getZoneCarbonIntensityData(ctx, zone)
+		if err != nil {
+			klog.V(2).InfoS("Skipping zone without carbon intensity data", "zone", zone, "err", err)
+			continue
+		}
+		if !cs.exceedsCarbonThreshold(zone, cs.effectiveIntensity(zone, data), threshold) {
+			nodes.Insert(names...)
+		}
+	}
+	return nodes
+}
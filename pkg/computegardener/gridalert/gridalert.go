@@ -0,0 +1,108 @@
+package gridalert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Alert is a grid emergency alert, e.g. a CAISO Flex Alert
+type Alert struct {
+	ID     string    `json:"id"`
+	Active bool      `json:"active"`
+	Until  time.Time `json:"until,omitempty"` // Optional end of the alert
+}
+
+// Poller periodically polls a grid alert feed and caches the current alert.
+// The feed is expected to return an Alert as JSON.
+type Poller struct {
+	url        string
+	interval   time.Duration
+	httpClient *http.Client
+	now        func() time.Time
+
+	mutex   sync.RWMutex
+	current Alert
+}
+
+// NewPoller creates a new grid alert poller
+func NewPoller(url string, interval, timeout time.Duration, now func() time.Time) *Poller {
+	return &Poller{
+		url:        url,
+		interval:   interval,
+		httpClient: &http.Client{Timeout: timeout},
+		now:        now,
+	}
+}
+
+// Run polls the feed until stopCh is closed
+func (p *Poller) Run(ctx context.Context, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.poll(ctx); err != nil {
+			klog.V(2).InfoS("Failed to poll grid alert feed", "url", p.url, "error", err)
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Active returns the current alert if one is active
+func (p *Poller) Active() (Alert, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if !p.current.Active {
+		return Alert{}, false
+	}
+	if !p.current.Until.IsZero() && !p.now().Before(p.current.Until) {
+		return Alert{}, false
+	}
+	return p.current, true
+}
+
+// Set overrides the current alert, used when alerts are pushed rather than polled
+func (p *Poller) Set(alert Alert) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if alert.Active != p.current.Active {
+		klog.InfoS("Grid alert state changed", "alert", alert.ID, "active", alert.Active, "until", alert.Until)
+	}
+	p.current = alert
+}
+
+func (p *Poller) poll(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var alert Alert
+	if err := json.NewDecoder(resp.Body).Decode(&alert); err != nil {
+		return fmt.Errorf("failed to decode alert: %v", err)
+	}
+	p.Set(alert)
+	return nil
+}
@@ -0,0 +1,164 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Sample is a single recorded carbon intensity value
+type Sample struct {
+	Timestamp       time.Time `json:"t"`
+	CarbonIntensity float64   `json:"v"`
+}
+
+// Store records carbon intensity samples per zone
+type Store interface {
+	// Add records a sample for a zone. Samples not newer than the last recorded
+	// sample for the zone are ignored.
+	Add(zone string, sample Sample)
+	// Samples returns the samples for a zone recorded at or after since, oldest first
+	Samples(zone string, since time.Time) []Sample
+	// Flush persists the recorded samples, pruning those outside the retention window
+	Flush() error
+}
+
+var _ Store = &FileStore{}
+
+// FileStore keeps samples in memory and persists them as compact JSON to a file,
+// typically on a persistent volume so history survives scheduler restarts. With an
+// empty path samples are kept in memory only.
+type FileStore struct {
+	path      string
+	retention time.Duration
+	now       func() time.Time
+
+	mutex   sync.RWMutex
+	samples map[string][]Sample
+	dirty   bool
+}
+
+// NewFileStore creates a store backed by path, loading any previously persisted samples
+func NewFileStore(path string, retention time.Duration) (*FileStore, error) {
+	s := &FileStore{
+		path:      path,
+		retention: retention,
+		now:       time.Now,
+		samples:   make(map[string][]Sample),
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history file: %v", err)
+	}
+	if err := json.Unmarshal(data, &s.samples); err != nil {
+		return nil, fmt.Errorf("failed to parse history file: %v", err)
+	}
+	for zone := range s.samples {
+		sort.Slice(s.samples[zone], func(i, j int) bool {
+			return s.samples[zone][i].Timestamp.Before(s.samples[zone][j].Timestamp)
+		})
+	}
+	s.prune()
+
+	klog.V(2).InfoS("Loaded carbon intensity history", "path", path, "zones", len(s.samples))
+	return s, nil
+}
+
+// Add implements Store
+func (s *FileStore) Add(zone string, sample Sample) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	existing := s.samples[zone]
+	if n := len(existing); n > 0 && !sample.Timestamp.After(existing[n-1].Timestamp) {
+		return
+	}
+	s.samples[zone] = append(existing, sample)
+	s.dirty = true
+}
+
+// Samples implements Store
+func (s *FileStore) Samples(zone string, since time.Time) []Sample {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	existing := s.samples[zone]
+	i := sort.Search(len(existing), func(i int) bool {
+		return !existing[i].Timestamp.Before(since)
+	})
+	result := make([]Sample, len(existing)-i)
+	copy(result, existing[i:])
+	return result
+}
+
+// Flush implements Store. The file is written atomically via rename.
+func (s *FileStore) Flush() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.prune()
+	if s.path == "" || !s.dirty {
+		return nil
+	}
+
+	data, err := json.Marshal(s.samples)
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".history-*")
+	if err != nil {
+		return fmt.Errorf("failed to create history file: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write history file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write history file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to replace history file: %v", err)
+	}
+
+	s.dirty = false
+	return nil
+}
+
+// prune drops samples older than the retention window. Callers must hold the lock.
+func (s *FileStore) prune() {
+	if s.retention <= 0 {
+		return
+	}
+	cutoff := s.now().Add(-s.retention)
+	for zone, samples := range s.samples {
+		i := sort.Search(len(samples), func(i int) bool {
+			return !samples[i].Timestamp.Before(cutoff)
+		})
+		if i == 0 {
+			continue
+		}
+		s.dirty = true
+		if i == len(samples) {
+			delete(s.samples, zone)
+			continue
+		}
+		s.samples[zone] = append([]Sample(nil), samples[i:]...)
+	}
+}
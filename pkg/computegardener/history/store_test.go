@@ -0,0 +1,44 @@
+package history
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.json")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	store, err := NewFileStore(path, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+
+	store.Add("test-region", Sample{Timestamp: now.Add(-8 * 24 * time.Hour), CarbonIntensity: 300})
+	store.Add("test-region", Sample{Timestamp: now.Add(-2 * time.Hour), CarbonIntensity: 200})
+	store.Add("test-region", Sample{Timestamp: now.Add(-time.Hour), CarbonIntensity: 150})
+	// Not newer than the last sample, ignored
+	store.Add("test-region", Sample{Timestamp: now.Add(-time.Hour), CarbonIntensity: 999})
+
+	if err := store.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	reloaded, err := NewFileStore(path, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("NewFileStore() reload error = %v", err)
+	}
+
+	got := reloaded.Samples("test-region", time.Time{})
+	if len(got) != 2 {
+		t.Fatalf("Samples() returned %d samples, want 2 (expired sample pruned)", len(got))
+	}
+	if got[0].CarbonIntensity != 200 || got[1].CarbonIntensity != 150 {
+		t.Errorf("Samples() = %v, want intensities [200 150]", got)
+	}
+
+	if got := reloaded.Samples("test-region", now.Add(-90*time.Minute)); len(got) != 1 {
+		t.Errorf("Samples() since 90m ago returned %d samples, want 1", len(got))
+	}
+}
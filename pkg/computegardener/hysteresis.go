@@ -0,0 +1,60 @@
+package computegardener
+
+import (
+	"sync"
+	"time"
+)
+
+// latchTTL is how long a latch is kept without being evaluated before it is evicted
+const latchTTL = time.Hour
+
+// hysteresis latches the blocked state per zone so that scheduling is blocked once
+// intensity rises above the block threshold and only released once it falls below
+// the lower release threshold, preventing flapping around a single threshold
+type hysteresis struct {
+	mutex sync.Mutex
+	// blocked maps latched zones to the time they were last evaluated
+	blocked map[string]time.Time
+}
+
+func newHysteresis() *hysteresis {
+	return &hysteresis{
+		blocked: make(map[string]time.Time),
+	}
+}
+
+// clone returns a copy of the latched state, for evaluating pods without changing it
+func (h *hysteresis) clone() *hysteresis {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	c := newHysteresis()
+	for k, seen := range h.blocked {
+		c.blocked[k] = seen
+	}
+	return c
+}
+
+// isBlocked updates and returns the latched state for key given the current intensity.
+// Latches not evaluated within latchTTL are evicted.
+func (h *hysteresis) isBlocked(key string, intensity, block, release float64, now time.Time) bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for k, seen := range h.blocked {
+		if now.Sub(seen) > latchTTL {
+			delete(h.blocked, k)
+		}
+	}
+
+	_, latched := h.blocked[key]
+	if latched && intensity < release {
+		delete(h.blocked, key)
+		return false
+	}
+	if latched || intensity > block {
+		h.blocked[key] = now
+		return true
+	}
+	return false
+}
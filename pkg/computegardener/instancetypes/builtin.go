@@ -0,0 +1,122 @@
+package instancetypes
+
+// Built-in estimates follow the Cloud Carbon Footprint methodology: the power of an
+// instance is its vCPUs times the average per-vCPU power of its provider's
+// processors, at idle and at full load, plus a constant power per GiB of memory.
+const wattsPerGiB = 0.392
+
+// coefficients is the average power of a vCPU in watts at idle and at full load
+type coefficients struct {
+	idle float64
+	max  float64
+}
+
+var (
+	aws   = coefficients{idle: 0.74, max: 3.5}
+	gcp   = coefficients{idle: 0.71, max: 4.26}
+	azure = coefficients{idle: 0.78, max: 3.76}
+	// arm covers AWS Graviton and the Ampere Altra processors of GCP and Azure
+	arm = coefficients{idle: 0.47, max: 1.69}
+)
+
+// shape is the processors, vCPUs and memory of an instance type
+type shape struct {
+	cpu       coefficients
+	vCPUs     float64
+	memoryGiB float64
+}
+
+var builtin = map[string]shape{
+	// AWS
+	"t3.medium":   {aws, 2, 4},
+	"t3.large":    {aws, 2, 8},
+	"t3.xlarge":   {aws, 4, 16},
+	"t3.2xlarge":  {aws, 8, 32},
+	"m5.large":    {aws, 2, 8},
+	"m5.xlarge":   {aws, 4, 16},
+	"m5.2xlarge":  {aws, 8, 32},
+	"m5.4xlarge":  {aws, 16, 64},
+	"m5.8xlarge":  {aws, 32, 128},
+	"m6i.large":   {aws, 2, 8},
+	"m6i.xlarge":  {aws, 4, 16},
+	"m6i.2xlarge": {aws, 8, 32},
+	"m6i.4xlarge": {aws, 16, 64},
+	"m6i.8xlarge": {aws, 32, 128},
+	"c5.large":    {aws, 2, 4},
+	"c5.xlarge":   {aws, 4, 8},
+	"c5.2xlarge":  {aws, 8, 16},
+	"c5.4xlarge":  {aws, 16, 32},
+	"c6i.large":   {aws, 2, 4},
+	"c6i.xlarge":  {aws, 4, 8},
+	"c6i.2xlarge": {aws, 8, 16},
+	"c6i.4xlarge": {aws, 16, 32},
+	"r5.large":    {aws, 2, 16},
+	"r5.xlarge":   {aws, 4, 32},
+	"r5.2xlarge":  {aws, 8, 64},
+	"r5.4xlarge":  {aws, 16, 128},
+	"m6g.large":   {arm, 2, 8},
+	"m6g.xlarge":  {arm, 4, 16},
+	"m6g.2xlarge": {arm, 8, 32},
+	"m6g.4xlarge": {arm, 16, 64},
+	"m7g.large":   {arm, 2, 8},
+	"m7g.xlarge":  {arm, 4, 16},
+	"m7g.2xlarge": {arm, 8, 32},
+	"c6g.large":   {arm, 2, 4},
+	"c6g.xlarge":  {arm, 4, 8},
+	"c6g.2xlarge": {arm, 8, 16},
+
+	// GCP
+	"e2-standard-2":  {gcp, 2, 8},
+	"e2-standard-4":  {gcp, 4, 16},
+	"e2-standard-8":  {gcp, 8, 32},
+	"e2-standard-16": {gcp, 16, 64},
+	"n2-standard-2":  {gcp, 2, 8},
+	"n2-standard-4":  {gcp, 4, 16},
+	"n2-standard-8":  {gcp, 8, 32},
+	"n2-standard-16": {gcp, 16, 64},
+	"n2-standard-32": {gcp, 32, 128},
+	"n2-highmem-2":   {gcp, 2, 16},
+	"n2-highmem-4":   {gcp, 4, 32},
+	"n2-highmem-8":   {gcp, 8, 64},
+	"n2-highcpu-4":   {gcp, 4, 4},
+	"n2-highcpu-8":   {gcp, 8, 8},
+	"n2-highcpu-16":  {gcp, 16, 16},
+	"c2-standard-4":  {gcp, 4, 16},
+	"c2-standard-8":  {gcp, 8, 32},
+	"c2-standard-16": {gcp, 16, 64},
+	"t2a-standard-2": {arm, 2, 8},
+	"t2a-standard-4": {arm, 4, 16},
+	"t2a-standard-8": {arm, 8, 32},
+
+	// Azure
+	"Standard_D2s_v3":  {azure, 2, 8},
+	"Standard_D4s_v3":  {azure, 4, 16},
+	"Standard_D8s_v3":  {azure, 8, 32},
+	"Standard_D16s_v3": {azure, 16, 64},
+	"Standard_D2s_v5":  {azure, 2, 8},
+	"Standard_D4s_v5":  {azure, 4, 16},
+	"Standard_D8s_v5":  {azure, 8, 32},
+	"Standard_D16s_v5": {azure, 16, 64},
+	"Standard_E2s_v3":  {azure, 2, 16},
+	"Standard_E4s_v3":  {azure, 4, 32},
+	"Standard_E8s_v3":  {azure, 8, 64},
+	"Standard_F2s_v2":  {azure, 2, 4},
+	"Standard_F4s_v2":  {azure, 4, 8},
+	"Standard_F8s_v2":  {azure, 8, 16},
+	"Standard_D2ps_v5": {arm, 2, 8},
+	"Standard_D4ps_v5": {arm, 4, 16},
+	"Standard_D8ps_v5": {arm, 8, 32},
+}
+
+// Builtin returns the built-in power estimate of an instance type, if known
+func Builtin(instanceType string) (Power, bool) {
+	s, ok := builtin[instanceType]
+	if !ok {
+		return Power{}, false
+	}
+	memory := s.memoryGiB * wattsPerGiB
+	return Power{
+		IdlePower: s.vCPUs*s.cpu.idle + memory,
+		MaxPower:  s.vCPUs*s.cpu.max + memory,
+	}, true
+}
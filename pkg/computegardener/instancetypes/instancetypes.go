@@ -0,0 +1,130 @@
+package instancetypes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// Power is the estimated idle and max power of an instance in watts
+type Power struct {
+	IdlePower float64 `yaml:"idlePower"`
+	MaxPower  float64 `yaml:"maxPower"`
+}
+
+// Table estimates the power of nodes from their instance type label, using built-in
+// estimates for common cloud instance types and overrides read from a file, such as
+// a mounted ConfigMap
+type Table struct {
+	path string
+
+	mutex     sync.RWMutex
+	overrides map[string]Power
+	nodes     map[string]string // node name to instance type
+}
+
+// New creates a table with the built-in estimates, and the overrides in path if set
+func New(path string) (*Table, error) {
+	t := &Table{
+		path:  path,
+		nodes: make(map[string]string),
+	}
+	if path != "" {
+		if err := t.Reload(); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Reload reads the overrides file, a YAML map of instance type to idle and max power
+func (t *Table) Reload() error {
+	data, err := os.ReadFile(t.path)
+	if err != nil {
+		return fmt.Errorf("failed to read instance type power file: %v", err)
+	}
+	overrides := make(map[string]Power)
+	if err := yaml.Unmarshal(data, &overrides); err != nil {
+		return fmt.Errorf("failed to parse instance type power file: %v", err)
+	}
+	for instanceType, power := range overrides {
+		if power.IdlePower <= 0 || power.MaxPower <= power.IdlePower {
+			return fmt.Errorf("invalid power for instance type %s: max power must be greater than positive idle power", instanceType)
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.overrides = overrides
+	return nil
+}
+
+// Run reloads the overrides file every interval until stopCh is closed, keeping the
+// previous overrides if it can't be read
+func (t *Table) Run(ctx context.Context, stopCh <-chan struct{}, interval time.Duration) {
+	if t.path == "" {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.Reload(); err != nil {
+				klog.ErrorS(err, "Failed to reload instance type power", "path", t.path)
+			}
+		}
+	}
+}
+
+// TrackNode records the instance type of a node from its label
+func (t *Table) TrackNode(node *v1.Node) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if instanceType, ok := node.Labels[v1.LabelInstanceTypeStable]; ok {
+		t.nodes[node.Name] = instanceType
+	} else {
+		delete(t.nodes, node.Name)
+	}
+}
+
+// ForgetNode drops a deleted node
+func (t *Table) ForgetNode(name string) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	delete(t.nodes, name)
+}
+
+// NodePower returns the estimated power of a node from its instance type, if known
+func (t *Table) NodePower(nodeName string) (Power, bool) {
+	if t == nil {
+		return Power{}, false
+	}
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	instanceType, ok := t.nodes[nodeName]
+	if !ok {
+		return Power{}, false
+	}
+	if power, ok := t.overrides[instanceType]; ok {
+		return power, true
+	}
+	return Builtin(instanceType)
+}
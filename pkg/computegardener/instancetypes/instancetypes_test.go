@@ -0,0 +1,73 @@
+package instancetypes
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instance-types.yaml")
+	if err := os.WriteFile(path, []byte("m5.large:\n  idlePower: 20\n  maxPower: 60\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	table, err := New(path)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	node := func(name, instanceType string) *v1.Node {
+		labels := map[string]string{}
+		if instanceType != "" {
+			labels[v1.LabelInstanceTypeStable] = instanceType
+		}
+		return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	table.TrackNode(node("aws-1", "m5.large"))
+	table.TrackNode(node("gcp-1", "n2-standard-4"))
+	table.TrackNode(node("custom-1", "custom-type"))
+	table.TrackNode(node("bare-1", ""))
+
+	// Overrides take precedence over the built-in estimates
+	if got, ok := table.NodePower("aws-1"); !ok || got.IdlePower != 20 || got.MaxPower != 60 {
+		t.Errorf("NodePower(aws-1) = %+v, %v, want the override", got, ok)
+	}
+
+	// 4 vCPUs at 0.71-4.26W and 16GiB at 0.392W
+	got, ok := table.NodePower("gcp-1")
+	if !ok || math.Abs(got.IdlePower-9.112) > 1e-9 || math.Abs(got.MaxPower-23.312) > 1e-9 {
+		t.Errorf("NodePower(gcp-1) = %+v, %v, want 9.112 and 23.312", got, ok)
+	}
+
+	for _, name := range []string{"custom-1", "bare-1", "untracked"} {
+		if _, ok := table.NodePower(name); ok {
+			t.Errorf("NodePower(%s) should be unknown", name)
+		}
+	}
+
+	// Invalid overrides are rejected, keeping the previous ones
+	if err := os.WriteFile(path, []byte("m5.large:\n  idlePower: 60\n  maxPower: 20\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Reload(); err == nil {
+		t.Error("Reload() of invalid overrides should fail")
+	}
+	if got, _ := table.NodePower("aws-1"); got.IdlePower != 20 {
+		t.Errorf("NodePower(aws-1) after failed reload = %+v, want the previous override", got)
+	}
+
+	table.ForgetNode("aws-1")
+	if _, ok := table.NodePower("aws-1"); ok {
+		t.Error("NodePower() of forgotten node should be unknown")
+	}
+
+	var disabled *Table
+	if _, ok := disabled.NodePower("aws-1"); ok {
+		t.Error("NodePower() of nil table should be unknown")
+	}
+}
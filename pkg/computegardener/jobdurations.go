@@ -0,0 +1,68 @@
+package computegardener
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxDurationTemplates bounds the number of Job templates durations are learned for
+const maxDurationTemplates = 1000
+
+// templateKey identifies the Job template a pod was created from by the UID of its
+// owning CronJob, or Job for standalone Jobs, and a hash of its container images.
+// Pods not owned by a Job have no template.
+func (cs *CarbonAwareScheduler) templateKey(pod *v1.Pod) string {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil || owner.Kind != "Job" {
+		return ""
+	}
+
+	uid := owner.UID
+	if cs.jobLister != nil {
+		if job, err := cs.jobLister.Jobs(pod.Namespace).Get(owner.Name); err == nil {
+			if cronJob := metav1.GetControllerOf(job); cronJob != nil && cronJob.Kind == "CronJob" {
+				uid = cronJob.UID
+			}
+		}
+	}
+
+	h := fnv.New64a()
+	for _, c := range pod.Spec.Containers {
+		h.Write([]byte(c.Image))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%s/%x", uid, h.Sum64())
+}
+
+// observeDuration records the run duration of a completed pod
+func (cs *CarbonAwareScheduler) observeDuration(pod *v1.Pod) {
+	if cs.durations == nil || pod.Status.StartTime == nil {
+		return
+	}
+
+	// Use the latest container exit, or now if exit times are unavailable
+	finished := cs.clock.Now()
+	var latest time.Time
+	for _, status := range pod.Status.ContainerStatuses {
+		if t := status.State.Terminated; t != nil && t.FinishedAt.Time.After(latest) {
+			latest = t.FinishedAt.Time
+		}
+	}
+	if !latest.IsZero() {
+		finished = latest
+	}
+
+	cs.durations.Observe(cs.templateKey(pod), finished.Sub(pod.Status.StartTime.Time))
+}
+
+// learnedDuration returns the median run duration of the pod's Job template
+func (cs *CarbonAwareScheduler) learnedDuration(pod *v1.Pod) (time.Duration, bool) {
+	if cs.durations == nil {
+		return 0, false
+	}
+	return cs.durations.Estimate(cs.templateKey(pod))
+}
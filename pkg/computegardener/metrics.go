@@ -67,7 +67,20 @@ var (
 		[]string{"node", "pod", "phase"}, // phase: "baseline", "final"
 	)
 
-	// JobEnergyUsage tracks estimated energy usage for jobs
+	// NodePowerCalibration tracks the ratio of the wall power readings of nodes, from
+	// BMCs or PDUs, to their modeled power
+	NodePowerCalibration = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      schedulerSubsystem,
+			Name:           "node_power_calibration_ratio",
+			Help:           "Smoothed ratio of the measured wall power of nodes to their modeled power",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"node"},
+	)
+
+	// JobEnergyUsage tracks estimated energy usage for jobs by owner workload, and by
+	// pod only in debug mode
 	JobEnergyUsage = metrics.NewHistogramVec(
 		&metrics.HistogramOpts{
 			Subsystem:      schedulerSubsystem,
@@ -76,7 +89,7 @@ var (
 			Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
 			StabilityLevel: metrics.ALPHA,
 		},
-		[]string{"pod", "namespace"},
+		[]string{"namespace", "owner_kind", "owner_name", "pod"},
 	)
 
 	// SchedulingEfficiencyMetrics tracks carbon/cost improvements
@@ -123,7 +136,49 @@ var (
 		[]string{"period"}, // "peak" or "off-peak"
 	)
 
-	// JobCarbonEmissions tracks estimated carbon emissions for jobs
+	// ConservationMode reports whether a grid alert has put the scheduler in conservation mode
+	ConservationMode = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      schedulerSubsystem,
+			Name:           "conservation_mode",
+			Help:           "Whether the scheduler is in conservation mode due to an active grid alert (1) or not (0)",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	// PercentileThresholdGauge reports the threshold derived from recorded intensity in percentile mode
+	PercentileThresholdGauge = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      schedulerSubsystem,
+			Name:           "percentile_threshold",
+			Help:           "Carbon intensity threshold (gCO2eq/kWh) derived from the recorded intensity distribution of a zone",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"zone"},
+	)
+
+	// WeightedScoreGauge reports the latest weighted decision score
+	WeightedScoreGauge = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      schedulerSubsystem,
+			Name:           "weighted_score",
+			Help:           "Latest weighted score of carbon intensity, electricity price and cluster load, in [0, 1]",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	// ConcurrentPods reports the number of admitted pods holding a concurrency slot
+	ConcurrentPods = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      schedulerSubsystem,
+			Name:           "concurrent_pods",
+			Help:           "Number of admitted pods holding a concurrency slot",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	// JobCarbonEmissions tracks estimated carbon emissions for jobs by owner workload,
+	// and by pod only in debug mode
 	JobCarbonEmissions = metrics.NewHistogramVec(
 		&metrics.HistogramOpts{
 			Subsystem:      schedulerSubsystem,
@@ -132,7 +187,77 @@ var (
 			Buckets:        metrics.ExponentialBuckets(0.001, 2, 15),
 			StabilityLevel: metrics.ALPHA,
 		},
-		[]string{"pod", "namespace"},
+		[]string{"namespace", "owner_kind", "owner_name", "pod"},
+	)
+
+	// LabelCarbonEmissions rolls up the estimated carbon emissions of completed pods by
+	// the value of the accounting label
+	LabelCarbonEmissions = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      schedulerSubsystem,
+			Name:           "label_carbon_emissions_grams_total",
+			Help:           "Estimated carbon emissions in gCO2eq of completed pods by accounting label value",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"label", "value"},
+	)
+
+	// NamespaceEnergyUsage rolls up the energy of completed pods, and of running pods
+	// as they accrue it, by namespace, resumed from persisted accounting after a restart
+	NamespaceEnergyUsage = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      schedulerSubsystem,
+			Name:           "namespace_energy_kwh_total",
+			Help:           "Cumulative energy usage in kWh of accounted pods by namespace",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace"},
+	)
+
+	// NamespaceCarbonEmissions rolls up the estimated carbon emissions of completed
+	// pods, and of running pods as they accrue them, by namespace, resumed from
+	// persisted accounting after a restart
+	NamespaceCarbonEmissions = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      schedulerSubsystem,
+			Name:           "namespace_carbon_emissions_grams_total",
+			Help:           "Cumulative estimated carbon emissions in gCO2eq of accounted pods by namespace",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace"},
+	)
+
+	// NamespaceEmbodiedEmissions rolls up the amortized embodied carbon of accounted
+	// pods by namespace, included in their carbon emissions and resumed after a restart
+	NamespaceEmbodiedEmissions = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      schedulerSubsystem,
+			Name:           "namespace_embodied_emissions_grams_total",
+			Help:           "Cumulative amortized embodied carbon in gCO2eq of accounted pods by namespace",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"namespace"},
+	)
+
+	// ActiveExemptions reports the number of carbon exemptions in effect
+	ActiveExemptions = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      schedulerSubsystem,
+			Name:           "active_exemptions",
+			Help:           "Number of carbon exemptions in effect",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	// ExemptionTransitions counts carbon exemptions coming into and out of effect
+	ExemptionTransitions = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      schedulerSubsystem,
+			Name:           "exemption_transitions_total",
+			Help:           "Number of carbon exemptions activated and lapsed",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"transition"},
 	)
 )
 
@@ -143,10 +268,78 @@ func init() {
 	legacyregistry.MustRegister(SchedulingAttempts)
 	legacyregistry.MustRegister(NodeCPUUsage)
 	legacyregistry.MustRegister(NodePowerEstimate)
+	legacyregistry.MustRegister(NodePowerCalibration)
 	legacyregistry.MustRegister(JobEnergyUsage)
 	legacyregistry.MustRegister(SchedulingEfficiencyMetrics)
 	legacyregistry.MustRegister(EstimatedSavings)
 	legacyregistry.MustRegister(ElectricityRateGauge)
 	legacyregistry.MustRegister(PriceBasedDelays)
 	legacyregistry.MustRegister(JobCarbonEmissions)
+	legacyregistry.MustRegister(ConservationMode)
+	legacyregistry.MustRegister(PercentileThresholdGauge)
+	legacyregistry.MustRegister(WeightedScoreGauge)
+	legacyregistry.MustRegister(ConcurrentPods)
+	legacyregistry.MustRegister(LabelCarbonEmissions)
+	legacyregistry.MustRegister(NamespaceEnergyUsage)
+	legacyregistry.MustRegister(NamespaceCarbonEmissions)
+	legacyregistry.MustRegister(NamespaceEmbodiedEmissions)
+	legacyregistry.MustRegister(ActiveExemptions)
+	legacyregistry.MustRegister(ExemptionTransitions)
+}
+
+// decisionMetrics are the metrics recorded while deciding whether to admit a pod
+type decisionMetrics struct {
+	attempts         *metrics.CounterVec
+	carbonIntensity  *metrics.GaugeVec
+	electricityRate  *metrics.GaugeVec
+	priceDelays      *metrics.CounterVec
+	savings          *metrics.CounterVec
+	nodeCPU          *metrics.GaugeVec
+	nodePower        *metrics.GaugeVec
+	conservationMode *metrics.Gauge
+	percentile       *metrics.GaugeVec
+	weightedScore    *metrics.Gauge
+}
+
+var (
+	liveMetrics = &decisionMetrics{
+		attempts:         SchedulingAttempts,
+		carbonIntensity:  CarbonIntensityGauge,
+		electricityRate:  ElectricityRateGauge,
+		priceDelays:      PriceBasedDelays,
+		savings:          EstimatedSavings,
+		nodeCPU:          NodeCPUUsage,
+		nodePower:        NodePowerEstimate,
+		conservationMode: ConservationMode,
+		percentile:       PercentileThresholdGauge,
+		weightedScore:    WeightedScoreGauge,
+	}
+
+	// discardedMetrics are never registered, so everything recorded in them is dropped
+	discardedMetrics = &decisionMetrics{
+		attempts:         metrics.NewCounterVec(&metrics.CounterOpts{Name: "discarded"}, nil),
+		carbonIntensity:  metrics.NewGaugeVec(&metrics.GaugeOpts{Name: "discarded"}, nil),
+		electricityRate:  metrics.NewGaugeVec(&metrics.GaugeOpts{Name: "discarded"}, nil),
+		priceDelays:      metrics.NewCounterVec(&metrics.CounterOpts{Name: "discarded"}, nil),
+		savings:          metrics.NewCounterVec(&metrics.CounterOpts{Name: "discarded"}, nil),
+		nodeCPU:          metrics.NewGaugeVec(&metrics.GaugeOpts{Name: "discarded"}, nil),
+		nodePower:        metrics.NewGaugeVec(&metrics.GaugeOpts{Name: "discarded"}, nil),
+		conservationMode: metrics.NewGauge(&metrics.GaugeOpts{Name: "discarded"}),
+		percentile:       metrics.NewGaugeVec(&metrics.GaugeOpts{Name: "discarded"}, nil),
+		weightedScore:    metrics.NewGauge(&metrics.GaugeOpts{Name: "discarded"}),
+	}
+)
+
+// metrics returns the metrics the decisions of the instance are recorded in. Dry
+// runs record theirs in discarded metrics, so evaluations don't show up in them.
+func (cs *CarbonAwareScheduler) metrics() *decisionMetrics {
+	if cs.dryRun {
+		return discardedMetrics
+	}
+	return liveMetrics
+}
+
+// countAttempt counts a scheduling attempt by result
+func (cs *CarbonAwareScheduler) countAttempt(result string) {
+	cs.metrics().attempts.WithLabelValues(result).Inc()
 }
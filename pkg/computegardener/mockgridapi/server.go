@@ -0,0 +1,200 @@
+package mockgridapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/klog/v2"
+)
+
+// Step is a period of a scenario with fixed grid conditions
+type Step struct {
+	Duration        time.Duration `yaml:"duration"`
+	CarbonIntensity float64       `yaml:"carbonIntensity"`
+	// MarginalCarbonIntensity defaults to CarbonIntensity when unset
+	MarginalCarbonIntensity float64 `yaml:"marginalCarbonIntensity"`
+	Price                   float64 `yaml:"price"`       // Electricity rate in $/kWh
+	Outage                  bool    `yaml:"outage"`      // Respond with 503 Service Unavailable
+	RateLimited             bool    `yaml:"rateLimited"` // Respond with 429 Too Many Requests
+}
+
+// Scenario is a scripted sequence of steps per zone
+type Scenario struct {
+	// Loop restarts each zone's steps after the last one ends
+	Loop  bool              `yaml:"loop"`
+	Zones map[string][]Step `yaml:"zones"`
+}
+
+// LoadScenario reads a scenario from a YAML file
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %v", err)
+	}
+	scenario := &Scenario{}
+	if err := yaml.Unmarshal(data, scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario: %v", err)
+	}
+	for zone, steps := range scenario.Zones {
+		if len(steps) == 0 {
+			return nil, fmt.Errorf("zone %s has no steps", zone)
+		}
+		for i, step := range steps {
+			if step.Duration <= 0 {
+				return nil, fmt.Errorf("step %d of zone %s must have a positive duration", i, zone)
+			}
+		}
+	}
+	return scenario, nil
+}
+
+// Server serves scripted scenarios on paths compatible with the Electricity Maps
+// client used by the scheduler, plus a simple current price endpoint
+type Server struct {
+	scenario *Scenario
+	start    time.Time
+	now      func() time.Time
+	mux      *http.ServeMux
+}
+
+// NewServer creates a server whose scenario timeline starts now
+func NewServer(scenario *Scenario, now func() time.Time) *Server {
+	s := &Server{
+		scenario: scenario,
+		start:    now(),
+		now:      now,
+		mux:      http.NewServeMux(),
+	}
+	s.mux.HandleFunc("/v3/carbon-intensity/latest", s.handleLatest(false))
+	s.mux.HandleFunc("/v3/marginal-carbon-intensity/latest", s.handleLatest(true))
+	s.mux.HandleFunc("/v3/carbon-intensity/forecast", s.handleForecast)
+	s.mux.HandleFunc("/prices/current", s.handlePrice)
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	klog.V(4).InfoS("Mock grid API request", "method", r.Method, "url", r.URL.String())
+	s.mux.ServeHTTP(w, r)
+}
+
+// stepAt returns the step active for a zone at time t
+func (s *Server) stepAt(zone string, t time.Time) (Step, bool) {
+	steps, ok := s.scenario.Zones[zone]
+	if !ok {
+		return Step{}, false
+	}
+
+	var total time.Duration
+	for _, step := range steps {
+		total += step.Duration
+	}
+
+	elapsed := t.Sub(s.start)
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	if elapsed >= total {
+		if !s.scenario.Loop {
+			return steps[len(steps)-1], true
+		}
+		elapsed %= total
+	}
+
+	for _, step := range steps {
+		if elapsed < step.Duration {
+			return step, true
+		}
+		elapsed -= step.Duration
+	}
+	return steps[len(steps)-1], true
+}
+
+// currentStep resolves the zone's current step, writing an error response if the
+// zone is unknown or the step simulates a failure
+func (s *Server) currentStep(w http.ResponseWriter, r *http.Request) (string, Step, bool) {
+	zone := r.URL.Query().Get("zone")
+	step, ok := s.stepAt(zone, s.now())
+	switch {
+	case !ok:
+		http.Error(w, "zone not found", http.StatusNotFound)
+		return "", Step{}, false
+	case step.Outage:
+		http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+		return "", Step{}, false
+	case step.RateLimited:
+		http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+		return "", Step{}, false
+	}
+	return zone, step, true
+}
+
+func (s *Server) handleLatest(marginal bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zone, step, ok := s.currentStep(w, r)
+		if !ok {
+			return
+		}
+		resp := map[string]interface{}{
+			"zone":            zone,
+			"carbonIntensity": step.CarbonIntensity,
+			"datetime":        s.now().UTC().Truncate(time.Hour),
+		}
+		if marginal {
+			value := step.MarginalCarbonIntensity
+			if value == 0 {
+				value = step.CarbonIntensity
+			}
+			resp["marginalCarbonIntensity"] = value
+		}
+		writeJSON(w, resp)
+	}
+}
+
+func (s *Server) handleForecast(w http.ResponseWriter, r *http.Request) {
+	zone, _, ok := s.currentStep(w, r)
+	if !ok {
+		return
+	}
+
+	type point struct {
+		CarbonIntensity float64   `json:"carbonIntensity"`
+		Datetime        time.Time `json:"datetime"`
+	}
+	start := s.now().UTC().Truncate(time.Hour)
+	forecast := make([]point, 0, 24)
+	for i := 0; i < 24; i++ {
+		t := start.Add(time.Duration(i) * time.Hour)
+		step, _ := s.stepAt(zone, t)
+		forecast = append(forecast, point{CarbonIntensity: step.CarbonIntensity, Datetime: t})
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"zone":     zone,
+		"forecast": forecast,
+	})
+}
+
+func (s *Server) handlePrice(w http.ResponseWriter, r *http.Request) {
+	zone, step, ok := s.currentStep(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, map[string]interface{}{
+		"zone":     zone,
+		"rate":     step.Price,
+		"currency": "USD",
+		"unit":     "kWh",
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.ErrorS(err, "Failed to encode response")
+	}
+}
@@ -0,0 +1,66 @@
+package mockgridapi
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+)
+
+func TestServerScenario(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	scenario := &Scenario{
+		Loop: true,
+		Zones: map[string][]Step{
+			"TEST": {
+				{Duration: 10 * time.Minute, CarbonIntensity: 150},
+				{Duration: 5 * time.Minute, CarbonIntensity: 300},
+				{Duration: 5 * time.Minute, Outage: true},
+			},
+		},
+	}
+
+	server := httptest.NewServer(NewServer(scenario, func() time.Time { return now }))
+	defer server.Close()
+
+	client := api.NewClient(config.APIConfig{
+		URL:        server.URL + "/v3/carbon-intensity/latest?zone=",
+		Timeout:    time.Second,
+		RateLimit:  100,
+		MaxRetries: 0,
+	})
+	defer client.Close()
+
+	tests := []struct {
+		offset  time.Duration
+		want    float64
+		wantErr string
+	}{
+		{offset: 0, want: 150},
+		{offset: 12 * time.Minute, want: 300},
+		{offset: 17 * time.Minute, wantErr: "503"},
+		{offset: 21 * time.Minute, want: 150}, // looped
+	}
+
+	for _, tt := range tests {
+		now = start.Add(tt.offset)
+		data, err := client.GetCarbonIntensity(context.Background(), "TEST")
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("at +%v: GetCarbonIntensity() error = %v, want %s", tt.offset, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("at +%v: GetCarbonIntensity() error = %v", tt.offset, err)
+		}
+		if data.CarbonIntensity != tt.want {
+			t.Errorf("at +%v: intensity = %v, want %v", tt.offset, data.CarbonIntensity, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package computegardener
+
+import (
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// Annotations read from a pod's namespace as defaults for pods that don't set them
+const (
+	skipAnnotation      = "carbon-aware-scheduler.kubernetes.io/skip"
+	thresholdAnnotation = "carbon-aware-scheduler.kubernetes.io/carbon-intensity-threshold"
+)
+
+// namespaceAnnotation returns an annotation of a pod's namespace
+func (cs *CarbonAwareScheduler) namespaceAnnotation(pod *v1.Pod, key string) (string, bool) {
+	if cs.namespaceLister == nil {
+		return "", false
+	}
+	ns, err := cs.namespaceLister.Get(pod.Namespace)
+	if err != nil {
+		return "", false
+	}
+	val, ok := ns.Annotations[key]
+	return val, ok
+}
+
+// namespaceThreshold returns the default carbon intensity threshold of a pod's
+// namespace. Invalid values are ignored rather than blocking every pod of the
+// namespace.
+func (cs *CarbonAwareScheduler) namespaceThreshold(pod *v1.Pod) (float64, bool) {
+	val, ok := cs.namespaceAnnotation(pod, thresholdAnnotation)
+	if !ok {
+		return 0, false
+	}
+	threshold, err := strconv.ParseFloat(val, 64)
+	if err != nil || threshold <= 0 {
+		klog.V(2).InfoS("Ignoring invalid namespace threshold annotation", "namespace", pod.Namespace, "value", val)
+		return 0, false
+	}
+	return threshold, true
+}
+
+// namespaceMaxDelay returns the default maximum scheduling delay of a pod's namespace
+func (cs *CarbonAwareScheduler) namespaceMaxDelay(pod *v1.Pod) (time.Duration, bool) {
+	val, ok := cs.namespaceAnnotation(pod, maxDelayAnnotation)
+	if !ok {
+		return 0, false
+	}
+	delay, err := time.ParseDuration(val)
+	if err != nil || delay < 0 {
+		klog.V(2).InfoS("Ignoring invalid namespace max delay annotation", "namespace", pod.Namespace, "value", val)
+		return 0, false
+	}
+	return delay, true
+}
+
+// namespaceSkipped reports whether a pod's namespace opts its pods out of carbon-aware
+// scheduling. Pods can opt back in with skip set to "false".
+func (cs *CarbonAwareScheduler) namespaceSkipped(pod *v1.Pod) bool {
+	if _, ok := pod.Annotations[skipAnnotation]; ok {
+		return false
+	}
+	val, _ := cs.namespaceAnnotation(pod, skipAnnotation)
+	return val == "true"
+}
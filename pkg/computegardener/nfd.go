@@ -0,0 +1,98 @@
+package computegardener
+
+import (
+	"strconv"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+)
+
+// nfdLabelPrefix prefixes the feature labels published by Node Feature Discovery
+const nfdLabelPrefix = "feature.node.kubernetes.io/"
+
+// nfdProfiles picks the power profile of nodes from the hardware features published
+// by Node Feature Discovery, such as CPU model and SMT, or else estimates it from a
+// TDP hint
+type nfdProfiles struct {
+	cfg config.NFDConfig
+
+	mu    sync.RWMutex
+	nodes map[string]config.NodePower
+}
+
+func newNFDProfiles(cfg config.NFDConfig) *nfdProfiles {
+	return &nfdProfiles{cfg: cfg, nodes: make(map[string]config.NodePower)}
+}
+
+// track picks the profile of a node from its labels
+func (p *nfdProfiles) track(node *v1.Node) {
+	if p == nil {
+		return
+	}
+	profile, name, ok := p.pick(node)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !ok {
+		delete(p.nodes, node.Name)
+		return
+	}
+	if _, tracked := p.nodes[node.Name]; !tracked {
+		klog.V(2).InfoS("Picked power profile from node features", "node", node.Name, "profile", name)
+	}
+	p.nodes[node.Name] = profile
+}
+
+// forget drops a deleted node
+func (p *nfdProfiles) forget(nodeName string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.nodes, nodeName)
+}
+
+// nodePower returns the profile picked for a node, if any
+func (p *nfdProfiles) nodePower(nodeName string) (config.NodePower, bool) {
+	if p == nil {
+		return config.NodePower{}, false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	profile, ok := p.nodes[nodeName]
+	return profile, ok
+}
+
+// pick returns the first profile whose features a node has, or a profile drawing
+// the TDP of the node's CPUs at full load
+func (p *nfdProfiles) pick(node *v1.Node) (config.NodePower, string, bool) {
+	for _, profile := range p.cfg.Profiles {
+		if hasFeatures(node, profile.Features) {
+			return profile.NodePower, profile.Name, true
+		}
+	}
+
+	val, ok := node.Labels[p.cfg.TDPLabel]
+	if !ok {
+		return config.NodePower{}, "", false
+	}
+	tdp, err := strconv.ParseFloat(val, 64)
+	if err != nil || tdp <= 0 {
+		klog.V(2).InfoS("Ignoring invalid TDP label", "node", node.Name, "value", val)
+		return config.NodePower{}, "", false
+	}
+	return config.NodePower{IdlePower: tdp * p.cfg.TDPIdleFraction, MaxPower: tdp}, "tdp", true
+}
+
+// hasFeatures reports whether a node has all the given NFD features
+func hasFeatures(node *v1.Node, features map[string]string) bool {
+	for feature, value := range features {
+		if node.Labels[nfdLabelPrefix+feature] != value {
+			return false
+		}
+	}
+	return true
+}
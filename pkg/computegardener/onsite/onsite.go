@@ -0,0 +1,110 @@
+package onsite
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Telemetry is a reading from a local inverter or battery system
+type Telemetry struct {
+	ProductionWatts float64   `json:"productionWatts"` // Current on-site generation
+	StateOfCharge   float64   `json:"stateOfCharge"`   // Battery state of charge in percent
+	Timestamp       time.Time `json:"timestamp,omitempty"`
+}
+
+// Provider polls a local inverter/battery REST endpoint returning Telemetry as JSON
+type Provider struct {
+	url        string
+	interval   time.Duration
+	maxAge     time.Duration
+	httpClient *http.Client
+	now        func() time.Time
+
+	mutex  sync.RWMutex
+	latest *Telemetry
+}
+
+// NewProvider creates a new on-site telemetry provider. Readings older than maxAge
+// are treated as unavailable.
+func NewProvider(url string, interval, maxAge, timeout time.Duration, now func() time.Time) *Provider {
+	return &Provider{
+		url:        url,
+		interval:   interval,
+		maxAge:     maxAge,
+		httpClient: &http.Client{Timeout: timeout},
+		now:        now,
+	}
+}
+
+// Run polls the endpoint until stopCh is closed
+func (p *Provider) Run(ctx context.Context, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.poll(ctx); err != nil {
+			klog.V(2).InfoS("Failed to poll on-site telemetry", "url", p.url, "error", err)
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Latest returns the most recent telemetry reading if it is fresh
+func (p *Provider) Latest() (Telemetry, bool) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	if p.latest == nil || p.now().Sub(p.latest.Timestamp) > p.maxAge {
+		return Telemetry{}, false
+	}
+	return *p.latest, true
+}
+
+// Set records a telemetry reading
+func (p *Provider) Set(t Telemetry) {
+	if t.Timestamp.IsZero() {
+		t.Timestamp = p.now()
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.latest = &t
+}
+
+func (p *Provider) poll(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var t Telemetry
+	if err := json.NewDecoder(resp.Body).Decode(&t); err != nil {
+		return fmt.Errorf("failed to decode telemetry: %v", err)
+	}
+	if t.ProductionWatts < 0 || t.StateOfCharge < 0 || t.StateOfCharge > 100 {
+		return fmt.Errorf("invalid telemetry: production %f W, state of charge %f%%", t.ProductionWatts, t.StateOfCharge)
+	}
+	p.Set(t)
+	return nil
+}
@@ -0,0 +1,82 @@
+package computegardener
+
+import (
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ownerExempt reports whether a pod is exempt from carbon-aware scheduling by what
+// created it, so cluster add-ons stay schedulable without annotating them: DaemonSet
+// pods, which must run on the nodes they target, static pods and their mirror pods,
+// which the kubelet runs regardless, and StatefulSet pods if configured. Pods can opt
+// back in with skip set to "false".
+func (cs *CarbonAwareScheduler) ownerExempt(pod *v1.Pod) bool {
+	if _, ok := pod.Annotations[skipAnnotation]; ok {
+		return false
+	}
+	if _, ok := pod.Annotations[v1.MirrorPodAnnotationKey]; ok {
+		return true
+	}
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return false
+	}
+	gv, err := schema.ParseGroupVersion(owner.APIVersion)
+	if err != nil {
+		return false
+	}
+	switch gv.WithKind(owner.Kind).GroupKind() {
+	case schema.GroupKind{Group: "apps", Kind: "DaemonSet"}:
+		return true
+	case schema.GroupKind{Kind: "Node"}:
+		// Static pods are owned by their node
+		return true
+	case schema.GroupKind{Group: "apps", Kind: "StatefulSet"}:
+		return cs.config.Scheduling.ExemptStatefulSets
+	}
+	return false
+}
+
+// podWorkload returns the kind and name of the workload a pod belongs to, for
+// metrics aggregated across its pods: the Deployment of ReplicaSet pods, the CronJob
+// of Job pods if known, or the controller of other pods. Bare pods have kind Pod and
+// no name, so they share a series; the pod label names them in debug mode.
+func (cs *CarbonAwareScheduler) podWorkload(pod *v1.Pod) (kind, name string) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return "Pod", ""
+	}
+	switch owner.Kind {
+	case "ReplicaSet":
+		// ReplicaSets of a Deployment are named by the hash of its pod template
+		if hash, ok := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; ok {
+			if deployment, ok := strings.CutSuffix(owner.Name, "-"+hash); ok {
+				return "Deployment", deployment
+			}
+		}
+	case "Job":
+		if cs.jobLister != nil {
+			if job, err := cs.jobLister.Jobs(pod.Namespace).Get(owner.Name); err == nil {
+				if cronJob := metav1.GetControllerOf(job); cronJob != nil && cronJob.Kind == "CronJob" {
+					return cronJob.Kind, cronJob.Name
+				}
+			}
+		}
+	}
+	return owner.Kind, owner.Name
+}
+
+// workloadLabels returns the label values of the per-workload job metrics of a pod,
+// naming the pod only in debug mode to bound their cardinality
+func (cs *CarbonAwareScheduler) workloadLabels(pod *v1.Pod) []string {
+	kind, name := cs.podWorkload(pod)
+	podName := ""
+	if cs.config.Observability.PodMetricsEnabled {
+		podName = pod.Name
+	}
+	return []string{pod.Namespace, kind, name, podName}
+}
@@ -0,0 +1,96 @@
+package computegardener
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// tokenBucket paces admissions to a sustained rate with bounded bursts
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64 // tokens per second
+	capacity float64
+	tokens   float64
+	last     time.Time
+}
+
+func newTokenBucket(perMinute, burst float64, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:     perMinute / 60,
+		capacity: burst,
+		tokens:   burst,
+		last:     now,
+	}
+}
+
+// refill adds the tokens accrued since the last refill. Callers must hold the lock.
+func (b *tokenBucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.capacity, b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// available reports whether cost tokens are available at now, and otherwise how long
+// until they are. Costs above the bucket capacity are capped so that large requests
+// are paced rather than blocked forever.
+func (b *tokenBucket) available(now time.Time, cost float64) (bool, time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	cost = min(cost, b.capacity)
+	if b.tokens < cost {
+		return false, time.Duration((cost - b.tokens) / b.rate * float64(time.Second))
+	}
+	return true, 0
+}
+
+// take removes cost tokens at now, capped at the bucket capacity
+func (b *tokenBucket) take(now time.Time, cost float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	b.tokens -= min(cost, b.capacity)
+}
+
+// refund returns tokens taken for an admission that didn't go ahead
+func (b *tokenBucket) refund(cost float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.capacity, b.tokens+min(cost, b.capacity))
+}
+
+// admissionCost returns the tokens a pod consumes, its requested CPU cores if
+// admissions are weighted by CPU and one otherwise
+func (cs *CarbonAwareScheduler) admissionCost(pod *v1.Pod) float64 {
+	if !cs.config.Scheduling.AdmissionWeightByCPU {
+		return 1
+	}
+	var cores float64
+	for _, c := range pod.Spec.Containers {
+		cores += float64(c.Resources.Requests.Cpu().MilliValue()) / 1000
+	}
+	return cores
+}
+
+// checkAdmissionRate paces admissions through the token bucket. Tokens are taken
+// when the pod is reserved.
+func (cs *CarbonAwareScheduler) checkAdmissionRate(pod *v1.Pod) *framework.Status {
+	if cs.pacer == nil {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	if ok, wait := cs.pacer.available(cs.clock.Now(), cs.admissionCost(pod)); !ok {
+		cs.countAttempt("rate_limited")
+		return framework.NewStatus(framework.Unschedulable,
+			fmt.Sprintf("Admission rate limit reached, retry in %s", wait.Round(time.Second)))
+	}
+	return framework.NewStatus(framework.Success, "")
+}
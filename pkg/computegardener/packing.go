@@ -0,0 +1,119 @@
+package computegardener
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+)
+
+// nodeProfile returns the power profile of a node: its explicit profile, else one
+// picked from its Node Feature Discovery features, else one estimated from its
+// instance type
+func (cs *CarbonAwareScheduler) nodeProfile(nodeName string) (config.NodePower, bool) {
+	if nodePower, ok := cs.config.Power.NodePowerConfig[nodeName]; ok {
+		return nodePower, true
+	}
+	if nodePower, ok := cs.nfdProfiles.nodePower(nodeName); ok {
+		return nodePower, true
+	}
+	if power, ok := cs.instanceTypes.NodePower(nodeName); ok {
+		return config.NodePower{IdlePower: power.IdlePower, MaxPower: power.MaxPower}, true
+	}
+	return config.NodePower{}, false
+}
+
+// powerProfile returns the power profile of a node, or the defaults
+func (cs *CarbonAwareScheduler) powerProfile(nodeName string) config.NodePower {
+	if nodePower, ok := cs.nodeProfile(nodeName); ok {
+		return nodePower
+	}
+	return config.NodePower{
+		IdlePower: cs.config.Power.DefaultIdlePower,
+		MaxPower:  cs.config.Power.DefaultMaxPower,
+		Curve:     cs.config.Power.DefaultCurve,
+	}
+}
+
+// nodePowerLimits returns the idle and max power of a node from its power profile,
+// or the defaults
+func (cs *CarbonAwareScheduler) nodePowerLimits(nodeName string) (idlePower, maxPower float64) {
+	profile := cs.powerProfile(nodeName)
+	if curve := profile.Curve; len(curve) > 0 {
+		return curve[0].Watts, curve[len(curve)-1].Watts
+	}
+	return profile.IdlePower, profile.MaxPower
+}
+
+// nodePowerAt estimates the power draw of a node at a CPU utilization from 0 to 1,
+// interpolating along its power curve, or linearly between its idle and max power
+func (cs *CarbonAwareScheduler) nodePowerAt(nodeName string, utilization float64) float64 {
+	profile := cs.powerProfile(nodeName)
+	curve := profile.Curve
+	if len(curve) == 0 {
+		return profile.IdlePower + (profile.MaxPower-profile.IdlePower)*utilization
+	}
+
+	utilization = min(max(utilization, 0), 1)
+	i := sort.Search(len(curve), func(i int) bool {
+		return curve[i].Utilization >= utilization
+	})
+	if i == 0 {
+		return curve[0].Watts
+	}
+	lower, upper := curve[i-1], curve[i]
+	return lower.Watts + (upper.Watts-lower.Watts)*(utilization-lower.Utilization)/(upper.Utilization-lower.Utilization)
+}
+
+// podMilliCPU returns the CPU requested by the containers of a pod
+func podMilliCPU(pod *v1.Pod) int64 {
+	var milliCPU int64
+	for _, c := range pod.Spec.Containers {
+		milliCPU += c.Resources.Requests.Cpu().MilliValue()
+	}
+	return milliCPU
+}
+
+// requestedPower estimates the power draw of a node with extra CPU requested on top
+// of its pods. Utilization is taken from the CPU requested on the node rather than
+// from metrics, keeping per-node plugins free of API calls.
+func (cs *CarbonAwareScheduler) requestedPower(nodeInfo *framework.NodeInfo, extraMilliCPU int64) float64 {
+	nodeName := nodeInfo.Node().Name
+	allocatable := float64(nodeInfo.Allocatable.MilliCPU)
+	if allocatable <= 0 {
+		_, maxPower := cs.nodePowerLimits(nodeName)
+		return maxPower
+	}
+	utilization := min(float64(nodeInfo.Requested.MilliCPU+extraMilliCPU)/allocatable, 1)
+	return cs.nodePowerAt(nodeName, utilization)
+}
+
+// marginalPower estimates the increase in a node's power draw from placing the pod
+// there. Placing a pod on a node without pods also pays for the idle power of a
+// node that could otherwise be scaled down.
+func (cs *CarbonAwareScheduler) marginalPower(pod *v1.Pod, nodeInfo *framework.NodeInfo) float64 {
+	marginal := max(cs.requestedPower(nodeInfo, podMilliCPU(pod))-cs.requestedPower(nodeInfo, 0), 0)
+	if len(nodeInfo.Pods) == 0 {
+		idlePower, _ := cs.nodePowerLimits(nodeInfo.Node().Name)
+		marginal += idlePower
+	}
+	return marginal
+}
+
+// packingScore scores a node by the marginal power of placing the pod there,
+// relative to the node's max power. Busy nodes score higher than idle ones, as
+// the idle power of a busy node is already being paid for.
+func (cs *CarbonAwareScheduler) packingScore(pod *v1.Pod, nodeName string) int64 {
+	nodeInfo, err := cs.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil || nodeInfo.Node() == nil {
+		klog.V(2).InfoS("Failed to get node for packing score", "node", nodeName, "err", err)
+		return framework.MaxNodeScore / 2
+	}
+
+	_, maxPower := cs.nodePowerLimits(nodeName)
+	marginal := cs.marginalPower(pod, nodeInfo)
+	return int64((1 - min(marginal/maxPower, 1)) * float64(framework.MaxNodeScore))
+}
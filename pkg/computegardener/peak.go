@@ -0,0 +1,58 @@
+package computegardener
+
+import (
+	"context"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+)
+
+// peakForecastWindow is how far ahead the forecast must stay clean for pods without
+// an estimated duration to be admitted during peak hours
+const peakForecastWindow = time.Hour
+
+// forecastOverridesPeak reports whether live carbon data contradicts the configured
+// peak hours, and the forecast is configured to win. The grid counts as off-peak
+// when both the current intensity and the forecast mean over the pod's run are at
+// or below its carbon threshold, so a forecast is required.
+func (cs *CarbonAwareScheduler) forecastOverridesPeak(ctx context.Context, pod *v1.Pod) bool {
+	if cs.config.Pricing.PeakSource != config.PeakSourceForecast {
+		return false
+	}
+
+	zone := cs.podZone(pod)
+	data, err := cs.getZoneCarbonIntensityData(ctx, zone)
+	if err != nil {
+		return false
+	}
+	threshold, err := cs.carbonThreshold(pod)
+	if err != nil {
+		return false
+	}
+	current := cs.effectiveIntensity(zone, data)
+	if current > threshold {
+		return false
+	}
+
+	duration, ok := cs.estimatedDuration(pod)
+	if !ok || duration <= 0 {
+		duration = peakForecastWindow
+	}
+	// A flat forecast holding the latest value carries no information beyond it
+	now := cs.clock.Now()
+	points, err := cs.getForecast(ctx, zone, duration)
+	if err != nil || len(points) == 0 || !points[len(points)-1].Timestamp.After(now) {
+		return false
+	}
+
+	if meanIntensity(points, now, current, now, duration) > threshold {
+		return false
+	}
+
+	klog.V(2).InfoS("Forecast overrides peak hours", "pod", klog.KObj(pod), "zone", zone,
+		"carbonIntensity", current, "threshold", threshold)
+	return true
+}
@@ -0,0 +1,42 @@
+package computegardener
+
+import (
+	"sort"
+)
+
+// baseThreshold returns the threshold pods in a zone are compared against without
+// overrides, the weekend threshold on weekends if configured. In percentile mode
+// this is the configured percentile of the zone's recorded intensity, or the base
+// threshold until enough samples are recorded.
+func (cs *CarbonAwareScheduler) baseThreshold(zone string) float64 {
+	base := cs.scheduleThreshold()
+	if cs.config.Scheduling.ThresholdPercentile <= 0 || cs.history == nil {
+		return base
+	}
+
+	samples := cs.history.Samples(zone, cs.clock.Now().Add(-cs.config.Scheduling.PercentileWindow))
+	if len(samples) == 0 || len(samples) < cs.config.Scheduling.PercentileMinSamples {
+		return base
+	}
+
+	values := make([]float64, len(samples))
+	for i, s := range samples {
+		values[i] = s.CarbonIntensity
+	}
+	threshold := percentile(values, cs.config.Scheduling.ThresholdPercentile)
+	cs.metrics().percentile.WithLabelValues(zone).Set(threshold)
+	return threshold
+}
+
+// percentile returns the p-th percentile of values, interpolating linearly between
+// closest ranks. values is sorted in place.
+func percentile(values []float64, p float64) float64 {
+	sort.Float64s(values)
+	rank := p / 100 * float64(len(values)-1)
+	lower := int(rank)
+	if lower >= len(values)-1 {
+		return values[len(values)-1]
+	}
+	frac := rank - float64(lower)
+	return values[lower] + frac*(values[lower+1]-values[lower])
+}
@@ -0,0 +1,122 @@
+package computegardener
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const (
+	// permitPollInterval is how often pods waiting at Permit are re-evaluated
+	permitPollInterval = 30 * time.Second
+	// maxPermitWait is the longest the framework lets a pod wait at Permit
+	maxPermitWait = 15 * time.Minute
+)
+
+// Permit implements the Permit interface. In permit wait mode, pods delayed in
+// PreFilter wait here until their projected start instead of being rejected, and
+// are allowed as soon as their constraints clear. Pods still delayed when the wait
+// times out are rejected and go back to the scheduling queue. Pods admitted in
+// PreFilter release their PodGroup here, once they are placed.
+func (cs *CarbonAwareScheduler) Permit(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (*framework.Status, time.Duration) {
+	s, ok := readAdmissionState(state)
+	if !ok {
+		return framework.NewStatus(framework.Success, ""), 0
+	}
+	if !s.wait {
+		cs.releaseGang(pod, podsToActivate(state))
+		return framework.NewStatus(framework.Success, ""), 0
+	}
+	if s.deferBind {
+		return framework.NewStatus(framework.Success, ""), 0
+	}
+	// Members of a PodGroup activate their siblings once they are allowed
+	if activate := podsToActivate(state); activate != nil {
+		if _, ok := podGroupKey(pod); ok {
+			cs.gangActivations.Store(pod.UID, activate)
+		}
+	}
+
+	timeout := cs.projectedStart(ctx, pod).Sub(cs.clock.Now()) + permitPollInterval
+	timeout = min(max(timeout, permitPollInterval), maxPermitWait)
+
+	cs.countAttempt("permit_wait")
+	klog.V(2).InfoS("Holding pod at permit", "pod", klog.KObj(pod), "node", nodeName, "timeout", timeout)
+	return framework.NewStatus(framework.Wait, ""), timeout
+}
+
+// projectedStart returns when a delayed pod is expected to be admitted, the later
+// of its projected carbon and price starts
+func (cs *CarbonAwareScheduler) projectedStart(ctx context.Context, pod *v1.Pod) time.Time {
+	start := cs.projectedCarbonStart(ctx, pod)
+	if cs.config.Pricing.Enabled {
+		if price := cs.projectedPriceStart(pod); price.After(start) {
+			start = price
+		}
+	}
+	return start
+}
+
+func (cs *CarbonAwareScheduler) permitWorker() {
+	ticker := time.NewTicker(permitPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cs.stopCh:
+			return
+		case <-ticker.C:
+			cs.allowWaitingPods(context.Background())
+		}
+	}
+}
+
+// allowWaitingPods allows pods waiting at Permit whose constraints have cleared or
+// whose scheduling deadline has been reached, taking their admission budget. Once
+// the budget is used up, the remaining pods wait for the next poll, except members
+// of released pod groups, which are allowed along with the first.
+func (cs *CarbonAwareScheduler) allowWaitingPods(ctx context.Context) {
+	exhausted := false
+	cs.handle.IterateOverWaitingPods(func(wp framework.WaitingPod) {
+		if !slices.Contains(wp.GetPendingPlugins(), cs.Name()) {
+			return
+		}
+
+		pod := wp.GetPod()
+		gang := cs.gangReleased(pod)
+		if exhausted && !gang {
+			return
+		}
+		if cs.clock.Now().Before(cs.schedulingDeadline(pod)) && !gang {
+			result, status := cs.checkAdmission(ctx, pod)
+			if !status.IsSuccess() {
+				return
+			}
+			// The pod was assumed on a node in its own zone, reschedule it to a greener one
+			if result != nil {
+				klog.V(2).InfoS("Rejecting pod waiting at permit for a greener zone", "pod", klog.KObj(pod))
+				wp.Reject(cs.Name(), "greener zone available")
+				return
+			}
+		}
+
+		budget := &admissionState{}
+		if !cs.takeWaitingBudget(pod, budget, gang) {
+			exhausted = true
+			return
+		}
+		cs.permitBudgets.Store(pod.UID, budget)
+
+		klog.V(2).InfoS("Allowing pod waiting at permit", "pod", klog.KObj(pod))
+		var activate *framework.PodsToActivate
+		if value, ok := cs.gangActivations.LoadAndDelete(pod.UID); ok {
+			activate = value.(*framework.PodsToActivate)
+		}
+		cs.releaseGang(pod, activate)
+		wp.Allow(cs.Name())
+	})
+}
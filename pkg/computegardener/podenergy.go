@@ -0,0 +1,404 @@
+package computegardener
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/accounting"
+)
+
+// podEnergyTracker integrates the energy of bound pods from periodic samples of
+// their power, so long or bursty pods aren't accounted from a single reading
+type podEnergyTracker struct {
+	mu   sync.Mutex
+	pods map[types.UID]*podEnergy
+}
+
+// podEnergy is the energy a pod used up to its last sample, by the trapezoidal rule
+type podEnergy struct {
+	namespace string
+	name      string
+	nodeName  string
+	// baseline is the power of the node when the pod was bound
+	baseline float64
+	// kWh is the energy of the pod since it was last accrued, and additionalKWh the
+	// energy its node used above the baseline
+	kWh           float64
+	additionalKWh float64
+	// power is the power of the pod at the last sample, nodePower that of its node
+	power     float64
+	nodePower float64
+	// own is set if power is the pod's own power rather than its node's
+	own    bool
+	lastAt time.Time
+	// embodiedRate is the embodied carbon of the pod's share of its node in gCO2e
+	// per hour, accrued since accruedAt
+	embodiedRate float64
+	boundAt      time.Time
+	// accruedAt is when the energy of the pod was last accrued while it ran, and
+	// accruedKWh and accruedGrams the energy and emissions accrued until then
+	accruedAt    time.Time
+	accruedKWh   float64
+	accruedGrams float64
+	// sidecars names the native sidecars of the pod
+	sidecars []string
+}
+
+func newPodEnergyTracker() *podEnergyTracker {
+	return &podEnergyTracker{pods: make(map[types.UID]*podEnergy)}
+}
+
+// track starts sampling a pod bound to a node drawing nodePower watts
+func (t *podEnergyTracker) track(pod *v1.Pod, nodeName string, nodePower float64, now time.Time) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pods[pod.UID] = &podEnergy{
+		namespace: pod.Namespace,
+		name:      pod.Name,
+		nodeName:  nodeName,
+		baseline:  nodePower,
+		power:     nodePower,
+		nodePower: nodePower,
+		lastAt:    now,
+		boundAt:   now,
+		accruedAt: now,
+		sidecars:  nativeSidecars(pod),
+	}
+}
+
+// setEmbodiedRate sets the embodied carbon accrued by a pod, in gCO2e per hour
+func (t *podEnergyTracker) setEmbodiedRate(uid types.UID, rate float64) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.pods[uid]; ok {
+		e.embodiedRate = rate
+	}
+}
+
+// embodied returns the embodied carbon accrued by a pod from its binding, or its last
+// accrual, until now, in gCO2e
+func (t *podEnergyTracker) embodied(uid types.UID, now time.Time) float64 {
+	if t == nil {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.pods[uid]
+	if !ok {
+		return 0
+	}
+	return e.embodiedRate * max(now.Sub(e.accruedAt).Hours(), 0)
+}
+
+// podAccrual is the energy a running pod used, and the embodied carbon it accrued,
+// since its last accrual
+type podAccrual struct {
+	pod           *v1.Pod
+	kWh           float64
+	additionalKWh float64
+	embodiedGrams float64
+}
+
+// accrue returns the energy of the pods last accrued at least interval before their
+// last sample, and starts their next accrual from that sample
+func (t *podEnergyTracker) accrue(interval time.Duration) []podAccrual {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var accruals []podAccrual
+	for uid, e := range t.pods {
+		if e.lastAt.Sub(e.accruedAt) < interval {
+			continue
+		}
+		accruals = append(accruals, podAccrual{
+			pod:           e.pod(uid),
+			kWh:           e.kWh,
+			additionalKWh: e.additionalKWh,
+			embodiedGrams: e.embodiedRate * e.lastAt.Sub(e.accruedAt).Hours(),
+		})
+		e.accruedKWh += e.kWh
+		e.kWh, e.additionalKWh, e.accruedAt = 0, 0, e.lastAt
+	}
+	return accruals
+}
+
+// addAccruedEmissions adds the emissions accounted for an accrual of a pod, in gCO2e
+func (t *podEnergyTracker) addAccruedEmissions(uid types.UID, grams float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.pods[uid]; ok {
+		e.accruedGrams += grams
+	}
+}
+
+// accrued returns the energy and emissions accrued by a running pod so far
+func (t *podEnergyTracker) accrued(uid types.UID) (kWh, grams float64) {
+	if t == nil {
+		return 0, 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.pods[uid]; ok {
+		return e.accruedKWh, e.accruedGrams
+	}
+	return 0, 0
+}
+
+// forget stops sampling a deleted pod
+func (t *podEnergyTracker) forget(uid types.UID) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.pods, uid)
+}
+
+// tracked reports whether a pod is being sampled
+func (t *podEnergyTracker) tracked(uid types.UID) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.pods[uid]
+	return ok
+}
+
+// record adds a sample of the power of a pod and its node, in watts
+func (t *podEnergyTracker) record(uid types.UID, power, nodePower float64, own bool, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if e, ok := t.pods[uid]; ok {
+		e.sample(power, nodePower, own, now)
+	}
+}
+
+// sample integrates the energy since the last sample and records a new one. A pod's
+// own power starts from zero rather than its node's power at bind.
+func (e *podEnergy) sample(power, nodePower float64, own bool, now time.Time) {
+	hours := now.Sub(e.lastAt).Hours()
+	if hours < 0 {
+		return
+	}
+	last := e.power
+	if own && !e.own {
+		last = 0
+	}
+	e.kWh += (last + power) / 2 * hours / 1000
+	e.additionalKWh += ((e.nodePower+nodePower)/2 - e.baseline) * hours / 1000
+	e.power, e.nodePower, e.own, e.lastAt = power, nodePower, own, now
+}
+
+// take closes the energy of a completed pod with a final sample of its node's power,
+// and stops sampling it. Pods whose own power was sampled keep their last reading,
+// as their usage is no longer reported once they complete.
+func (t *podEnergyTracker) take(uid types.UID, nodePower float64, now time.Time) (kWh, additionalKWh float64, ok bool) {
+	if t == nil {
+		return 0, 0, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	e, ok := t.pods[uid]
+	if !ok {
+		return 0, 0, false
+	}
+	delete(t.pods, uid)
+	power := nodePower
+	if e.own {
+		power = e.power
+	}
+	e.sample(power, nodePower, e.own, now)
+	return e.kWh, e.additionalKWh, true
+}
+
+// snapshot returns the energy of the tracked pods to persist, by UID
+func (t *podEnergyTracker) snapshot() map[string]accounting.Pod {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	pods := make(map[string]accounting.Pod, len(t.pods))
+	for uid, e := range t.pods {
+		pods[string(uid)] = accounting.Pod{
+			Namespace:     e.namespace,
+			Name:          e.name,
+			NodeName:      e.nodeName,
+			Baseline:      e.baseline,
+			KWh:           e.kWh,
+			AdditionalKWh: e.additionalKWh,
+			Power:         e.power,
+			NodePower:     e.nodePower,
+			Own:           e.own,
+			LastAt:        e.lastAt,
+			BoundAt:       e.boundAt,
+			EmbodiedRate:  e.embodiedRate,
+			AccruedAt:     e.accruedAt,
+			AccruedKWh:    e.accruedKWh,
+			AccruedGrams:  e.accruedGrams,
+			Sidecars:      e.sidecars,
+		}
+	}
+	return pods
+}
+
+// restore resumes tracking pods persisted before a restart
+func (t *podEnergyTracker) restore(pods map[string]accounting.Pod) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for uid, p := range pods {
+		// Accounting persisted before the binding time was kept accrues from the last flush
+		boundAt := p.BoundAt
+		if boundAt.IsZero() {
+			boundAt = p.LastAt
+		}
+		accruedAt := p.AccruedAt
+		if accruedAt.IsZero() {
+			accruedAt = boundAt
+		}
+		t.pods[types.UID(uid)] = &podEnergy{
+			namespace:     p.Namespace,
+			name:          p.Name,
+			nodeName:      p.NodeName,
+			baseline:      p.Baseline,
+			kWh:           p.KWh,
+			additionalKWh: p.AdditionalKWh,
+			power:         p.Power,
+			nodePower:     p.NodePower,
+			own:           p.Own,
+			lastAt:        p.LastAt,
+			embodiedRate:  p.EmbodiedRate,
+			boundAt:       boundAt,
+			accruedAt:     accruedAt,
+			accruedKWh:    p.AccruedKWh,
+			accruedGrams:  p.AccruedGrams,
+			sidecars:      p.Sidecars,
+		}
+	}
+}
+
+// byNode returns the tracked pods grouped by node
+func (t *podEnergyTracker) byNode() map[string]map[types.UID]*v1.Pod {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	nodes := make(map[string]map[types.UID]*v1.Pod)
+	for uid, e := range t.pods {
+		if nodes[e.nodeName] == nil {
+			nodes[e.nodeName] = make(map[types.UID]*v1.Pod)
+		}
+		nodes[e.nodeName][uid] = e.pod(uid)
+	}
+	return nodes
+}
+
+// pod returns the tracked metadata of a pod
+func (e *podEnergy) pod(uid types.UID) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: e.namespace, Name: e.name, UID: uid},
+		Spec:       v1.PodSpec{NodeName: e.nodeName},
+	}
+	always := v1.ContainerRestartPolicyAlways
+	for _, name := range e.sidecars {
+		pod.Spec.InitContainers = append(pod.Spec.InitContainers, v1.Container{Name: name, RestartPolicy: &always})
+	}
+	return pod
+}
+
+// samplePodEnergy samples the power of each tracked pod and its node. Pods whose
+// node's energy source measures pods use that reading. With per-pod attribution,
+// other pods are attributed their share of the node's power by their CPU usage in
+// the metrics API, leaving out their sidecars if configured; without it, pods are
+// accounted the power of their node.
+func (cs *CarbonAwareScheduler) samplePodEnergy(ctx context.Context) {
+	now := cs.clock.Now()
+	for nodeName, pods := range cs.podEnergy.byNode() {
+		nodePower := cs.estimateNodePower(nodeName)
+		_, measured := cs.energySource.NodePower(nodeName)
+		var nodeCores, nodeMemory, memoryPower float64
+		nodeSampled := false
+		for uid, pod := range pods {
+			if power, ok := cs.energySource.PodPower(pod); ok {
+				cs.podEnergy.record(uid, power, nodePower, true, now)
+				continue
+			}
+			if !cs.config.Power.PodAttributionEnabled {
+				cs.podEnergy.record(uid, nodePower, nodePower, false, now)
+				continue
+			}
+			if !nodeSampled {
+				nodeSampled = true
+				metrics, err := cs.metricsClient.NodeMetricses().Get(ctx, nodeName, metav1.GetOptions{})
+				if err != nil {
+					klog.V(2).InfoS("Failed to get node metrics for pod energy", "node", nodeName, "err", err)
+					continue
+				}
+				nodeCores = metrics.Usage.Cpu().AsApproximateFloat64()
+				nodeMemory = metrics.Usage.Memory().AsApproximateFloat64()
+				if !measured {
+					memoryPower = min(cs.memoryPower(nodeMemory/bytesPerGB), nodePower)
+				}
+			}
+			if nodeCores <= 0 {
+				continue
+			}
+			metrics, err := cs.metricsClient.PodMetricses(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+			if err != nil {
+				// Pods are only reported once running
+				klog.V(4).InfoS("Failed to get pod metrics for pod energy", "pod", klog.KObj(pod), "err", err)
+				continue
+			}
+			// Init containers are reported while they run, so their usage counts
+			var podCores, podMemory float64
+			for _, c := range metrics.Containers {
+				if cs.isSidecar(pod, c.Name) {
+					continue
+				}
+				podCores += c.Usage.Cpu().AsApproximateFloat64()
+				podMemory += c.Usage.Memory().AsApproximateFloat64()
+			}
+			// The modeled memory power is attributed by memory usage, the rest by CPU usage
+			power := (nodePower - memoryPower) * min(podCores/nodeCores, 1)
+			if memoryPower > 0 && nodeMemory > 0 {
+				power += memoryPower * min(podMemory/nodeMemory, 1)
+			}
+			cs.podEnergy.record(uid, power, nodePower, true, now)
+		}
+	}
+}
+
+// podEnergyWorker samples the power of bound pods
+func (cs *CarbonAwareScheduler) podEnergyWorker(ctx context.Context) {
+	ticker := time.NewTicker(cs.config.Power.PodSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cs.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cs.samplePodEnergy(ctx)
+			if cs.config.Power.AccrualInterval > 0 {
+				cs.accruePodEnergy()
+			}
+		}
+	}
+}
+
+// accruePodEnergy accounts the energy and emissions of long-running pods every
+// accrual interval, so they count toward namespace totals and budgets as they run
+// rather than all at once on completion, and aren't lost if the scheduler restarts
+func (cs *CarbonAwareScheduler) accruePodEnergy() {
+	for _, a := range cs.podEnergy.accrue(cs.config.Power.AccrualInterval) {
+		carbonEmissions, _ := cs.accountPodEnergy(a.pod, a.kWh, a.additionalKWh, a.embodiedGrams)
+		cs.podEnergy.addAccruedEmissions(a.pod.UID, carbonEmissions)
+		klog.V(4).InfoS("Accrued energy of running pod", "pod", klog.KObj(a.pod), "energyKWh", a.kWh)
+	}
+}
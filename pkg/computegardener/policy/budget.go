@@ -0,0 +1,312 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+)
+
+var (
+	budgetResource        = v1alpha1.SchemeGroupVersion.WithResource("carbonbudgets")
+	clusterBudgetResource = v1alpha1.SchemeGroupVersion.WithResource("clustercarbonbudgets")
+)
+
+// PeriodStart returns the start of the budget period containing now. Periods start
+// at midnight UTC, on Monday for weekly periods.
+func PeriodStart(period string, now time.Time) time.Time {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if period == v1alpha1.BudgetPeriodWeek {
+		// Weekday counts from Sunday
+		start = start.AddDate(0, 0, -((int(start.Weekday()) + 6) % 7))
+	}
+	return start
+}
+
+// Ledger accrues emissions per scope, such as a namespace, over the current day and
+// week. Emissions are held in memory, so a restarted scheduler starts its periods
+// empty.
+type Ledger struct {
+	mu   sync.Mutex
+	used map[string]map[string]*usage // scope to period to usage
+}
+
+// usage is the emissions accrued in a period
+type usage struct {
+	start time.Time
+	grams float64
+}
+
+// NewLedger returns an empty ledger
+func NewLedger() *Ledger {
+	return &Ledger{used: make(map[string]map[string]*usage)}
+}
+
+// Accrue adds emissions of a scope, in gCO2e, to its current periods
+func (l *Ledger) Accrue(scope string, grams float64, now time.Time) {
+	if grams <= 0 || math.IsNaN(grams) {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	periods, ok := l.used[scope]
+	if !ok {
+		periods = make(map[string]*usage)
+		l.used[scope] = periods
+	}
+	for _, period := range []string{v1alpha1.BudgetPeriodDay, v1alpha1.BudgetPeriodWeek} {
+		start := PeriodStart(period, now)
+		u, ok := periods[period]
+		if !ok || !u.start.Equal(start) {
+			u = &usage{start: start}
+			periods[period] = u
+		}
+		u.grams += grams
+	}
+}
+
+// Used returns the emissions of a scope accrued in the current period, in gCO2e
+func (l *Ledger) Used(scope, period string, now time.Time) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	u, ok := l.used[scope][period]
+	if !ok || !u.start.Equal(PeriodStart(period, now)) {
+		return 0
+	}
+	return u.grams
+}
+
+// Ledger scopes
+const (
+	namespaceScope = "namespace/"
+	labelScope     = "label/"
+)
+
+// Exhausted is an exhausted budget
+type Exhausted struct {
+	// Name identifies the budget, as namespace/name for a CarbonBudget and name for a
+	// ClusterCarbonBudget
+	Name   string
+	Action string
+	// ThresholdFactor multiplies carbon intensity thresholds for the demote action
+	ThresholdFactor float64
+	Limit           float64
+	Used            float64
+}
+
+// BudgetDefaults apply to budgets that don't set their action or demotion factor
+type BudgetDefaults struct {
+	Action          string
+	ThresholdFactor float64
+}
+
+// Budgets enforces the CarbonBudgets of namespaces, and the ClusterCarbonBudgets of
+// accounting label values, against the emissions accrued in a ledger
+type Budgets struct {
+	budgets        cache.Indexer
+	clusterBudgets cache.Indexer
+	ledger         *Ledger
+	client         dynamic.Interface // nil if budget status is not reported
+	defaults       BudgetDefaults
+}
+
+// NewBudgets returns budgets over indexers holding typed CarbonBudgets and
+// ClusterCarbonBudgets
+func NewBudgets(budgets, clusterBudgets cache.Indexer, ledger *Ledger, client dynamic.Interface, defaults BudgetDefaults) *Budgets {
+	return &Budgets{
+		budgets:        budgets,
+		clusterBudgets: clusterBudgets,
+		ledger:         ledger,
+		client:         client,
+		defaults:       defaults,
+	}
+}
+
+// StartBudgets watches the CarbonBudgets and ClusterCarbonBudgets of a source. Pods
+// have no budget until the informers have synced, or if the CRDs are not installed.
+func StartBudgets(ctx context.Context, src Source, defaults BudgetDefaults) (*Budgets, error) {
+	client, factory, err := src.informers()
+	if err != nil {
+		return nil, err
+	}
+	if src.Hub {
+		client = nil
+	}
+
+	budgets := factory.ForResource(budgetResource).Informer()
+	if err := budgets.SetTransform(toTyped(func() runtime.Object { return &v1alpha1.CarbonBudget{} })); err != nil {
+		return nil, err
+	}
+	clusterBudgets := factory.ForResource(clusterBudgetResource).Informer()
+	if err := clusterBudgets.SetTransform(toTyped(func() runtime.Object { return &v1alpha1.ClusterCarbonBudget{} })); err != nil {
+		return nil, err
+	}
+
+	factory.Start(ctx.Done())
+	return NewBudgets(budgets.GetIndexer(), clusterBudgets.GetIndexer(), NewLedger(), client, defaults), nil
+}
+
+// Accrue adds the emissions of a pod, in gCO2e, to its namespace and to the value of
+// its accounting label, if any
+func (b *Budgets) Accrue(namespace, labelValue string, grams float64, now time.Time) {
+	if b == nil {
+		return
+	}
+	b.ledger.Accrue(namespaceScope+namespace, grams, now)
+	if labelValue != "" {
+		b.ledger.Accrue(labelScope+labelValue, grams, now)
+	}
+}
+
+// Exhausted returns an exhausted budget of a pod's namespace or accounting label
+// value, if any. The budget with the most severe action is returned: blocking before
+// demoting before auditing, and the lowest factor among demoting budgets.
+func (b *Budgets) Exhausted(namespace, labelValue string, now time.Time) (Exhausted, bool) {
+	if b == nil {
+		return Exhausted{}, false
+	}
+
+	var found Exhausted
+	var ok bool
+	consider := func(name, scope string, spec v1alpha1.CarbonBudgetSpec) {
+		used := b.ledger.Used(scope, spec.Period, now)
+		if used < spec.Limit {
+			return
+		}
+		e := Exhausted{
+			Name:            name,
+			Action:          b.action(spec),
+			ThresholdFactor: b.thresholdFactor(spec),
+			Limit:           spec.Limit,
+			Used:            used,
+		}
+		if !ok || moreSevere(e, found) {
+			found, ok = e, true
+		}
+	}
+
+	objs, err := b.budgets.ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		objs = namespaced(b.budgets.List(), namespace)
+	}
+	for _, obj := range objs {
+		if budget, isBudget := obj.(*v1alpha1.CarbonBudget); isBudget {
+			consider(namespace+"/"+budget.Name, namespaceScope+namespace, budget.Spec)
+		}
+	}
+	if labelValue != "" && b.clusterBudgets != nil {
+		for _, obj := range b.clusterBudgets.List() {
+			if budget, isBudget := obj.(*v1alpha1.ClusterCarbonBudget); isBudget && budget.Spec.LabelValue == labelValue {
+				consider(budget.Name, labelScope+labelValue, budget.Spec.CarbonBudgetSpec)
+			}
+		}
+	}
+	return found, ok
+}
+
+// actionSeverity orders the actions taken on exhausted budgets
+var actionSeverity = map[string]int{
+	v1alpha1.BudgetActionAudit:  0,
+	v1alpha1.BudgetActionDemote: 1,
+	v1alpha1.BudgetActionBlock:  2,
+}
+
+// moreSevere reports whether exhausted budget a restricts pods more than b
+func moreSevere(a, b Exhausted) bool {
+	if actionSeverity[a.Action] != actionSeverity[b.Action] {
+		return actionSeverity[a.Action] > actionSeverity[b.Action]
+	}
+	return a.Action == v1alpha1.BudgetActionDemote && a.ThresholdFactor < b.ThresholdFactor
+}
+
+// action returns the action of a budget, defaulting to the configured action
+func (b *Budgets) action(spec v1alpha1.CarbonBudgetSpec) string {
+	if spec.Action != "" {
+		return spec.Action
+	}
+	if b.defaults.Action != "" {
+		return b.defaults.Action
+	}
+	return v1alpha1.BudgetActionBlock
+}
+
+// thresholdFactor returns the demotion factor of a budget, defaulting to the
+// configured factor
+func (b *Budgets) thresholdFactor(spec v1alpha1.CarbonBudgetSpec) float64 {
+	if f := spec.DemotedThresholdFactor; f != nil && *f > 0 && *f <= 1 {
+		return *f
+	}
+	return b.defaults.ThresholdFactor
+}
+
+// SyncStatus reports the emissions accrued against each budget in its status. Only
+// budgets whose status changed are patched.
+func (b *Budgets) SyncStatus(ctx context.Context, now time.Time) {
+	if b == nil || b.client == nil {
+		return
+	}
+	for _, obj := range b.budgets.List() {
+		budget, ok := obj.(*v1alpha1.CarbonBudget)
+		if !ok {
+			continue
+		}
+		status := b.status(namespaceScope+budget.Namespace, budget.Spec, now)
+		if !statusEqual(budget.Status, status) {
+			b.patchStatus(ctx, b.client.Resource(budgetResource).Namespace(budget.Namespace), budget.Name, status)
+		}
+	}
+	if b.clusterBudgets == nil {
+		return
+	}
+	for _, obj := range b.clusterBudgets.List() {
+		budget, ok := obj.(*v1alpha1.ClusterCarbonBudget)
+		if !ok {
+			continue
+		}
+		status := b.status(labelScope+budget.Spec.LabelValue, budget.Spec.CarbonBudgetSpec, now)
+		if !statusEqual(budget.Status, status) {
+			b.patchStatus(ctx, b.client.Resource(clusterBudgetResource), budget.Name, status)
+		}
+	}
+}
+
+// patchStatus writes the status of a budget
+func (b *Budgets) patchStatus(ctx context.Context, client dynamic.ResourceInterface, name string, status v1alpha1.CarbonBudgetStatus) {
+	patch, err := json.Marshal(map[string]interface{}{"status": status})
+	if err != nil {
+		return
+	}
+	if _, err := client.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}, "status"); err != nil {
+		klog.ErrorS(err, "Failed to update carbon budget status", "budget", name)
+	}
+}
+
+// status returns the current status of a budget
+func (b *Budgets) status(scope string, spec v1alpha1.CarbonBudgetSpec, now time.Time) v1alpha1.CarbonBudgetStatus {
+	used := b.ledger.Used(scope, spec.Period, now)
+	start := metav1.NewTime(PeriodStart(spec.Period, now))
+	return v1alpha1.CarbonBudgetStatus{
+		// Rounded to the gram, so status isn't patched for negligible changes
+		Used:        math.Round(used),
+		PeriodStart: &start,
+		Exhausted:   used >= spec.Limit,
+	}
+}
+
+func statusEqual(a, b v1alpha1.CarbonBudgetStatus) bool {
+	return a.Used == b.Used && a.Exhausted == b.Exhausted &&
+		a.PeriodStart != nil && b.PeriodStart != nil && a.PeriodStart.Equal(b.PeriodStart)
+}
@@ -0,0 +1,257 @@
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+)
+
+func TestPeriodStart(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2024, 1, 3, 15, 30, 0, 0, time.UTC)
+
+	if got, want := PeriodStart(v1alpha1.BudgetPeriodDay, now), time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("day period start = %v, want %v", got, want)
+	}
+	if got, want := PeriodStart(v1alpha1.BudgetPeriodWeek, now), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("week period start = %v, want %v", got, want)
+	}
+	sunday := time.Date(2024, 1, 7, 23, 0, 0, 0, time.UTC)
+	if got, want := PeriodStart(v1alpha1.BudgetPeriodWeek, sunday), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("week period start on Sunday = %v, want %v", got, want)
+	}
+}
+
+func TestLedger(t *testing.T) {
+	monday := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := NewLedger()
+
+	l.Accrue("team-a", 100, monday)
+	l.Accrue("team-a", 50, monday.Add(time.Hour))
+	l.Accrue("team-a", -10, monday.Add(time.Hour))
+	l.Accrue("team-b", 10, monday)
+
+	if got := l.Used("team-a", v1alpha1.BudgetPeriodDay, monday.Add(2*time.Hour)); got != 150 {
+		t.Errorf("day usage = %v, want 150", got)
+	}
+
+	tuesday := monday.Add(24 * time.Hour)
+	if got := l.Used("team-a", v1alpha1.BudgetPeriodDay, tuesday); got != 0 {
+		t.Errorf("day usage after reset = %v, want 0", got)
+	}
+	l.Accrue("team-a", 20, tuesday)
+	if got := l.Used("team-a", v1alpha1.BudgetPeriodDay, tuesday); got != 20 {
+		t.Errorf("day usage = %v, want 20", got)
+	}
+	if got := l.Used("team-a", v1alpha1.BudgetPeriodWeek, tuesday); got != 170 {
+		t.Errorf("week usage = %v, want 170", got)
+	}
+	if got := l.Used("team-a", v1alpha1.BudgetPeriodWeek, monday.Add(7*24*time.Hour)); got != 0 {
+		t.Errorf("week usage after reset = %v, want 0", got)
+	}
+}
+
+func TestBudgets(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	budget := func(namespace, name, period, action string, limit float64) *v1alpha1.CarbonBudget {
+		return &v1alpha1.CarbonBudget{
+			TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "CarbonBudget"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+			Spec:       v1alpha1.CarbonBudgetSpec{Limit: limit, Period: period, Action: action},
+		}
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	objs := []*v1alpha1.CarbonBudget{
+		budget("team-a", "daily", v1alpha1.BudgetPeriodDay, v1alpha1.BudgetActionAudit, 100),
+		budget("team-a", "weekly", v1alpha1.BudgetPeriodWeek, "", 500),
+		budget("team-b", "daily", v1alpha1.BudgetPeriodDay, "", 1000),
+	}
+	var unstructuredObjs []runtime.Object
+	for _, obj := range objs {
+		if err := indexer.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unstructuredObjs = append(unstructuredObjs, &unstructured.Unstructured{Object: u})
+	}
+
+	clusterIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	clusterBudget := &v1alpha1.ClusterCarbonBudget{
+		TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "ClusterCarbonBudget"},
+		ObjectMeta: metav1.ObjectMeta{Name: "ml"},
+		Spec: v1alpha1.ClusterCarbonBudgetSpec{
+			CarbonBudgetSpec: v1alpha1.CarbonBudgetSpec{Limit: 600, Period: v1alpha1.BudgetPeriodWeek},
+			LabelValue:       "ml",
+		},
+	}
+	if err := clusterIndexer.Add(clusterBudget); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(clusterBudget)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unstructuredObjs = append(unstructuredObjs, &unstructured.Unstructured{Object: obj})
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{
+			budgetResource:        "CarbonBudgetList",
+			clusterBudgetResource: "ClusterCarbonBudgetList",
+		}, unstructuredObjs...)
+	b := NewBudgets(indexer, clusterIndexer, NewLedger(), client, BudgetDefaults{})
+
+	if _, ok := b.Exhausted("team-a", "ml", now); ok {
+		t.Error("expected no exhausted budget")
+	}
+
+	b.Accrue("team-a", "ml", 200, now)
+	got, ok := b.Exhausted("team-a", "ml", now)
+	if !ok || got.Name != "team-a/daily" || got.Action != v1alpha1.BudgetActionAudit || got.Used != 200 {
+		t.Errorf("expected daily budget exhausted, got %+v, %v", got, ok)
+	}
+
+	b.Accrue("team-a", "", 300, now)
+	if got, ok := b.Exhausted("team-a", "", now); !ok || got.Name != "team-a/weekly" || got.Action != v1alpha1.BudgetActionBlock {
+		t.Errorf("expected blocking weekly budget first, got %+v, %v", got, ok)
+	}
+	if got, ok := b.Exhausted("team-a", "", now.Add(7*24*time.Hour)); ok {
+		t.Errorf("expected budgets reset next week, got %+v", got)
+	}
+
+	// The label budget spans namespaces, and only counts pods carrying the label
+	b.Accrue("team-b", "ml", 400, now)
+	if got, ok := b.Exhausted("team-b", "ml", now); !ok || got.Name != "ml" || got.Used != 600 {
+		t.Errorf("expected label budget exhausted, got %+v, %v", got, ok)
+	}
+	if got, ok := b.Exhausted("team-b", "", now); ok {
+		t.Errorf("expected team-b budget not exhausted, got %+v", got)
+	}
+
+	b.SyncStatus(context.Background(), now)
+	u, err := client.Resource(budgetResource).Namespace("team-a").Get(context.Background(), "weekly", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var synced v1alpha1.CarbonBudget
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &synced); err != nil {
+		t.Fatal(err)
+	}
+	if synced.Status.Used != 500 || !synced.Status.Exhausted || !synced.Status.PeriodStart.Equal(&metav1.Time{Time: now.Truncate(24 * time.Hour)}) {
+		t.Errorf("unexpected status: %+v", synced.Status)
+	}
+
+	u, err = client.Resource(clusterBudgetResource).Get(context.Background(), "ml", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var syncedCluster v1alpha1.ClusterCarbonBudget
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &syncedCluster); err != nil {
+		t.Fatal(err)
+	}
+	if syncedCluster.Status.Used != 600 || !syncedCluster.Status.Exhausted {
+		t.Errorf("unexpected cluster budget status: %+v", syncedCluster.Status)
+	}
+}
+
+func TestBudgetActions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	factor := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name       string
+		specs      []v1alpha1.CarbonBudgetSpec
+		defaults   BudgetDefaults
+		wantAction string
+		wantFactor float64
+	}{
+		{
+			name:       "block by default",
+			specs:      []v1alpha1.CarbonBudgetSpec{{Limit: 100, Period: v1alpha1.BudgetPeriodDay}},
+			wantAction: v1alpha1.BudgetActionBlock,
+		},
+		{
+			name:       "configured default action",
+			specs:      []v1alpha1.CarbonBudgetSpec{{Limit: 100, Period: v1alpha1.BudgetPeriodDay}},
+			defaults:   BudgetDefaults{Action: v1alpha1.BudgetActionDemote, ThresholdFactor: 0.5},
+			wantAction: v1alpha1.BudgetActionDemote,
+			wantFactor: 0.5,
+		},
+		{
+			name: "budget factor overrides default",
+			specs: []v1alpha1.CarbonBudgetSpec{
+				{Limit: 100, Period: v1alpha1.BudgetPeriodDay, Action: v1alpha1.BudgetActionDemote, DemotedThresholdFactor: factor(0.8)},
+			},
+			defaults:   BudgetDefaults{ThresholdFactor: 0.5},
+			wantAction: v1alpha1.BudgetActionDemote,
+			wantFactor: 0.8,
+		},
+		{
+			name: "demote over audit",
+			specs: []v1alpha1.CarbonBudgetSpec{
+				{Limit: 100, Period: v1alpha1.BudgetPeriodDay, Action: v1alpha1.BudgetActionAudit},
+				{Limit: 100, Period: v1alpha1.BudgetPeriodWeek, Action: v1alpha1.BudgetActionDemote},
+			},
+			defaults:   BudgetDefaults{ThresholdFactor: 0.5},
+			wantAction: v1alpha1.BudgetActionDemote,
+			wantFactor: 0.5,
+		},
+		{
+			name: "lowest demotion factor",
+			specs: []v1alpha1.CarbonBudgetSpec{
+				{Limit: 100, Period: v1alpha1.BudgetPeriodDay, Action: v1alpha1.BudgetActionDemote, DemotedThresholdFactor: factor(0.8)},
+				{Limit: 100, Period: v1alpha1.BudgetPeriodWeek, Action: v1alpha1.BudgetActionDemote, DemotedThresholdFactor: factor(0.4)},
+			},
+			wantAction: v1alpha1.BudgetActionDemote,
+			wantFactor: 0.4,
+		},
+		{
+			name: "block over demote",
+			specs: []v1alpha1.CarbonBudgetSpec{
+				{Limit: 100, Period: v1alpha1.BudgetPeriodDay, Action: v1alpha1.BudgetActionDemote},
+				{Limit: 100, Period: v1alpha1.BudgetPeriodWeek, Action: v1alpha1.BudgetActionBlock},
+			},
+			wantAction: v1alpha1.BudgetActionBlock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+			for i, spec := range tt.specs {
+				b := &v1alpha1.CarbonBudget{
+					ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: string(rune('a' + i))},
+					Spec:       spec,
+				}
+				if err := indexer.Add(b); err != nil {
+					t.Fatal(err)
+				}
+			}
+			b := NewBudgets(indexer, nil, NewLedger(), nil, tt.defaults)
+			b.Accrue("team-a", "", 200, now)
+
+			got, ok := b.Exhausted("team-a", "", now)
+			if !ok {
+				t.Fatal("expected an exhausted budget")
+			}
+			if got.Action != tt.wantAction {
+				t.Errorf("action = %s, want %s", got.Action, tt.wantAction)
+			}
+			if tt.wantAction == v1alpha1.BudgetActionDemote && got.ThresholdFactor != tt.wantFactor {
+				t.Errorf("threshold factor = %v, want %v", got.ThresholdFactor, tt.wantFactor)
+			}
+		})
+	}
+}
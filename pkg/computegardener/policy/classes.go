@@ -0,0 +1,51 @@
+package policy
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+)
+
+// Classes looks up the WorkloadClasses pods reference by name
+type Classes struct {
+	classes cache.Indexer
+}
+
+// NewClasses returns a lookup over an indexer holding typed workload classes
+func NewClasses(classes cache.Indexer) *Classes {
+	return &Classes{classes: classes}
+}
+
+// StartClasses watches workload classes in the source and returns a lookup over
+// them. Classes are not found until the informer has synced, or if the CRD is not
+// installed.
+func StartClasses(ctx context.Context, src Source) (*Classes, error) {
+	_, factory, err := src.informers()
+	if err != nil {
+		return nil, err
+	}
+
+	classes := factory.ForResource(v1alpha1.SchemeGroupVersion.WithResource("workloadclasses")).Informer()
+	if err := classes.SetTransform(toTyped(func() runtime.Object { return &v1alpha1.WorkloadClass{} })); err != nil {
+		return nil, err
+	}
+
+	factory.Start(ctx.Done())
+	return NewClasses(classes.GetIndexer()), nil
+}
+
+// Get returns the workload class with the given name, if any
+func (c *Classes) Get(name string) (*v1alpha1.WorkloadClass, bool) {
+	if c == nil || name == "" {
+		return nil, false
+	}
+	obj, ok, err := c.classes.GetByKey(name)
+	if err != nil || !ok {
+		return nil, false
+	}
+	class, ok := obj.(*v1alpha1.WorkloadClass)
+	return class, ok
+}
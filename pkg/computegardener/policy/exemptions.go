@@ -0,0 +1,91 @@
+package policy
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+)
+
+// Exemptions finds the CarbonExemptions in effect for a pod
+type Exemptions struct {
+	exemptions cache.Indexer
+	namespaces corelisters.NamespaceLister
+}
+
+// NewExemptions returns a lookup over an indexer holding typed exemptions
+func NewExemptions(exemptions cache.Indexer, namespaces corelisters.NamespaceLister) *Exemptions {
+	return &Exemptions{exemptions: exemptions, namespaces: namespaces}
+}
+
+// StartExemptions watches exemptions in the source and returns a lookup over them.
+// No pod is exempted until the informer has synced, or if the CRD is not installed.
+func StartExemptions(ctx context.Context, src Source, namespaces corelisters.NamespaceLister) (*Exemptions, error) {
+	_, factory, err := src.informers()
+	if err != nil {
+		return nil, err
+	}
+
+	exemptions := factory.ForResource(v1alpha1.SchemeGroupVersion.WithResource("carbonexemptions")).Informer()
+	if err := exemptions.SetTransform(toTyped(func() runtime.Object { return &v1alpha1.CarbonExemption{} })); err != nil {
+		return nil, err
+	}
+
+	factory.Start(ctx.Done())
+	return NewExemptions(exemptions.GetIndexer(), namespaces), nil
+}
+
+// InEffect reports whether an exemption applies at a time: from its start, or
+// immediately if unset, until it expires
+func InEffect(e *v1alpha1.CarbonExemption, now time.Time) bool {
+	if e.Spec.Start != nil && now.Before(e.Spec.Start.Time) {
+		return false
+	}
+	return now.Before(e.Spec.Expires.Time)
+}
+
+// List returns all exemptions, sorted by name
+func (e *Exemptions) List() []*v1alpha1.CarbonExemption {
+	if e == nil {
+		return nil
+	}
+	var out []*v1alpha1.CarbonExemption
+	for _, obj := range e.exemptions.List() {
+		if ex, ok := obj.(*v1alpha1.CarbonExemption); ok {
+			out = append(out, ex)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Active returns an exemption in effect for a pod, if any. When several are, the one
+// expiring last is returned.
+func (e *Exemptions) Active(pod *v1.Pod, now time.Time) (*v1alpha1.CarbonExemption, bool) {
+	var active *v1alpha1.CarbonExemption
+	var nsLabels labels.Set
+	for _, ex := range e.List() {
+		if !InEffect(ex, now) || !selects(ex.Spec.PodSelector, labels.Set(pod.Labels)) {
+			continue
+		}
+		if ex.Spec.NamespaceSelector != nil {
+			if nsLabels == nil {
+				nsLabels = namespaceLabels(e.namespaces, pod.Namespace)
+			}
+			if !selects(ex.Spec.NamespaceSelector, nsLabels) {
+				continue
+			}
+		}
+		if active == nil || ex.Spec.Expires.After(active.Spec.Expires.Time) {
+			active = ex
+		}
+	}
+	return active, active != nil
+}
@@ -0,0 +1,372 @@
+// Package policy resolves the CarbonPolicy and ClusterCarbonPolicy that applies to a
+// pod, and tracks the CarbonBudgets of namespaces. Resources are watched with dynamic
+// informers, so no generated clientset is needed, and converted to their typed form
+// as they are cached.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+)
+
+// PolicyAnnotation names the policy a pod is bound to, regardless of the selectors
+// of the policies: a CarbonPolicy in the pod's namespace, or else a
+// ClusterCarbonPolicy
+const PolicyAnnotation = "compute-gardener.dev/policy"
+
+// Policy is the policy resolved for a pod, merged from all the policies selecting it
+type Policy struct {
+	// Name identifies the most specific policy, as namespace/name for a CarbonPolicy
+	// and name for a ClusterCarbonPolicy
+	Name string
+	// Spec holds the merged settings; selectors, skip restrictions and enforced
+	// inclusion are not merged
+	Spec v1alpha1.CarbonPolicySpec
+	// Sources maps the JSON name of each merged setting to the policy it came from
+	Sources map[string]string
+	// SkipRestrictions maps each policy restricting the skip annotation to its
+	// restriction; all of them apply
+	SkipRestrictions map[string]*v1alpha1.SkipRestriction
+	// EnforcedBy names the first policy enforcing the inclusion of the pod, if any
+	EnforcedBy string
+}
+
+// Resolver finds the policy applying to a pod. Every CarbonPolicy in the pod's
+// namespace and ClusterCarbonPolicy selecting the pod applies, and each setting is
+// taken from the most specific policy setting it: CarbonPolicies before
+// ClusterCarbonPolicies, and among policies of the same kind the oldest first, with
+// ties broken by name. Settings no policy sets are left unset. Skip restrictions and
+// enforced inclusion are collected from all policies rather than merged.
+//
+// A pod naming a policy with the policy annotation is bound to that policy alone,
+// whose settings apply even if its selectors don't select the pod. The skip
+// restrictions of the policies selecting the pod still apply, so naming a policy
+// can't lift them, and the annotation is ignored if any of them enforces inclusion.
+// Pods naming a policy that doesn't exist resolve as if they named none.
+type Resolver struct {
+	policies        cache.Indexer
+	clusterPolicies cache.Indexer
+	namespaces      corelisters.NamespaceLister
+	selectors       *selectorCache
+}
+
+// NewResolver returns a resolver over indexers holding typed policies
+func NewResolver(policies, clusterPolicies cache.Indexer, namespaces corelisters.NamespaceLister) *Resolver {
+	return &Resolver{
+		policies:        policies,
+		clusterPolicies: clusterPolicies,
+		namespaces:      namespaces,
+		selectors:       &selectorCache{},
+	}
+}
+
+// Start watches the policies of a source and returns a resolver over them. Pods
+// resolve to no policy until the informers have synced, or if the CRDs are not
+// installed.
+func Start(ctx context.Context, src Source, namespaces corelisters.NamespaceLister) (*Resolver, error) {
+	_, factory, err := src.informers()
+	if err != nil {
+		return nil, err
+	}
+
+	policies := factory.ForResource(v1alpha1.SchemeGroupVersion.WithResource("carbonpolicies")).Informer()
+	if err := policies.SetTransform(toTyped(func() runtime.Object { return &v1alpha1.CarbonPolicy{} })); err != nil {
+		return nil, err
+	}
+	clusterPolicies := factory.ForResource(v1alpha1.SchemeGroupVersion.WithResource("clustercarbonpolicies")).Informer()
+	if err := clusterPolicies.SetTransform(toTyped(func() runtime.Object { return &v1alpha1.ClusterCarbonPolicy{} })); err != nil {
+		return nil, err
+	}
+
+	r := NewResolver(policies.GetIndexer(), clusterPolicies.GetIndexer(), namespaces)
+	for _, informer := range []cache.SharedIndexInformer{policies, clusterPolicies} {
+		if _, err := informer.AddEventHandler(r.selectors.handler()); err != nil {
+			return nil, err
+		}
+	}
+	factory.Start(ctx.Done())
+	return r, nil
+}
+
+// toTyped converts the unstructured objects of an informer to their typed form
+func toTyped(newObj func() runtime.Object) cache.TransformFunc {
+	return func(obj interface{}) (interface{}, error) {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			// Tombstones keep the object they were created from
+			return obj, nil
+		}
+		typed := newObj()
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, typed); err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %v", u.GetName(), err)
+		}
+		return typed, nil
+	}
+}
+
+// Resolve returns the policy applying to a pod, if any
+func (r *Resolver) Resolve(pod *v1.Pod) (Policy, bool) {
+	if r == nil {
+		return Policy{}, false
+	}
+	podLabels := labels.Set(pod.Labels)
+
+	objs, err := r.policies.ByIndex(cache.NamespaceIndex, pod.Namespace)
+	if err != nil {
+		objs = namespaced(r.policies.List(), pod.Namespace)
+	}
+	var matched []candidate
+	for _, obj := range objs {
+		p, ok := obj.(*v1alpha1.CarbonPolicy)
+		if ok && r.selectors.matches(p.Spec.PodSelector, podLabels) {
+			matched = append(matched, candidate{p.Namespace + "/" + p.Name, &p.ObjectMeta, p.Spec})
+		}
+	}
+	namespacedPolicies := byAge(matched)
+
+	var nsLabels labels.Set
+	matched = nil
+	for _, obj := range r.clusterPolicies.List() {
+		p, ok := obj.(*v1alpha1.ClusterCarbonPolicy)
+		if !ok || !r.selectors.matches(p.Spec.PodSelector, podLabels) {
+			continue
+		}
+		if p.Spec.NamespaceSelector != nil {
+			if nsLabels == nil {
+				nsLabels = namespaceLabels(r.namespaces, pod.Namespace)
+			}
+			if !r.selectors.matches(p.Spec.NamespaceSelector, nsLabels) {
+				continue
+			}
+		}
+		matched = append(matched, candidate{p.Name, &p.ObjectMeta, p.Spec})
+	}
+	candidates := append(namespacedPolicies, byAge(matched)...)
+
+	if name := pod.Annotations[PolicyAnnotation]; name != "" {
+		if enforced(candidates) {
+			klog.V(2).InfoS("Ignoring carbon policy named by pod under enforced inclusion",
+				"pod", klog.KObj(pod), "policy", name)
+			return merge(candidates)
+		}
+		if named, ok := r.named(pod.Namespace, name); ok {
+			p, _ := merge([]candidate{named})
+			for _, c := range candidates {
+				addRestrictions(&p, c)
+			}
+			return p, true
+		}
+		klog.V(2).InfoS("Carbon policy named by pod not found, using the policies selecting it",
+			"pod", klog.KObj(pod), "policy", name)
+	}
+	return merge(candidates)
+}
+
+// named returns the CarbonPolicy with a name in a namespace, or else the
+// ClusterCarbonPolicy with that name
+func (r *Resolver) named(namespace, name string) (candidate, bool) {
+	if obj, ok, err := r.policies.GetByKey(namespace + "/" + name); err == nil && ok {
+		if p, ok := obj.(*v1alpha1.CarbonPolicy); ok {
+			return candidate{p.Namespace + "/" + p.Name, &p.ObjectMeta, p.Spec}, true
+		}
+	}
+	if obj, ok, err := r.clusterPolicies.GetByKey(name); err == nil && ok {
+		if p, ok := obj.(*v1alpha1.ClusterCarbonPolicy); ok {
+			return candidate{p.Name, &p.ObjectMeta, p.Spec}, true
+		}
+	}
+	return candidate{}, false
+}
+
+// merge takes each setting from the first of the candidates setting it, and the skip
+// restrictions and enforced inclusion of all of them
+func merge(candidates []candidate) (Policy, bool) {
+	if len(candidates) == 0 {
+		return Policy{}, false
+	}
+
+	p := Policy{Name: candidates[0].name, Sources: make(map[string]string)}
+	for _, c := range candidates {
+		if p.Spec.CarbonIntensityThreshold == nil && c.spec.CarbonIntensityThreshold != nil {
+			p.Spec.CarbonIntensityThreshold = c.spec.CarbonIntensityThreshold
+			p.Sources["carbonIntensityThreshold"] = c.name
+		}
+		if p.Spec.MaxSchedulingDelay == nil && c.spec.MaxSchedulingDelay != nil {
+			p.Spec.MaxSchedulingDelay = c.spec.MaxSchedulingDelay
+			p.Sources["maxSchedulingDelay"] = c.name
+		}
+		if p.Spec.PeakSchedules == nil && len(c.spec.PeakSchedules) > 0 {
+			p.Spec.PeakSchedules = c.spec.PeakSchedules
+			p.Sources["peakSchedules"] = c.name
+		}
+		if p.Spec.EnforcementMode == "" && c.spec.EnforcementMode != "" {
+			p.Spec.EnforcementMode = c.spec.EnforcementMode
+			p.Sources["enforcementMode"] = c.name
+		}
+		addRestrictions(&p, c)
+	}
+	return p, true
+}
+
+// enforced reports whether any of the candidates enforces inclusion
+func enforced(candidates []candidate) bool {
+	for _, c := range candidates {
+		if c.spec.EnforcedInclusion {
+			return true
+		}
+	}
+	return false
+}
+
+// addRestrictions adds the skip restriction and enforced inclusion of a candidate, if
+// any, to a policy
+func addRestrictions(p *Policy, c candidate) {
+	if c.spec.EnforcedInclusion && p.EnforcedBy == "" {
+		p.EnforcedBy = c.name
+	}
+	if c.spec.SkipRestriction == nil {
+		return
+	}
+	if p.SkipRestrictions == nil {
+		p.SkipRestrictions = make(map[string]*v1alpha1.SkipRestriction)
+	}
+	p.SkipRestrictions[c.name] = c.spec.SkipRestriction
+}
+
+// candidate is a policy matching a pod
+type candidate struct {
+	name string
+	meta *metav1.ObjectMeta
+	spec v1alpha1.CarbonPolicySpec
+}
+
+// namespaceLabels returns the labels of a namespace, or none if it isn't known
+func namespaceLabels(namespaces corelisters.NamespaceLister, name string) labels.Set {
+	if namespaces == nil {
+		return labels.Set{}
+	}
+	ns, err := namespaces.Get(name)
+	if err != nil {
+		return labels.Set{}
+	}
+	return labels.Set(ns.Labels)
+}
+
+// selects reports whether a label selector matches a set of labels. An unset
+// selector matches everything; an invalid one matches nothing.
+func selects(selector *metav1.LabelSelector, set labels.Set) bool {
+	if selector == nil {
+		return true
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		klog.V(2).InfoS("Ignoring carbon policy with invalid selector", "err", err)
+		return false
+	}
+	return s.Matches(set)
+}
+
+// selectorCache holds the selectors of policies parsed as the policies are cached, keyed
+// by the selector they were parsed from, so they aren't parsed again for every pod.
+// Selectors missing from it, e.g. of policies added to an indexer directly, are parsed
+// on first use.
+type selectorCache struct {
+	parsed sync.Map
+}
+
+// matches reports whether a label selector matches a set of labels. An unset selector
+// matches everything; an invalid one matches nothing.
+func (c *selectorCache) matches(selector *metav1.LabelSelector, set labels.Set) bool {
+	if selector == nil {
+		return true
+	}
+	if s, ok := c.parsed.Load(selector); ok {
+		return s.(labels.Selector).Matches(set)
+	}
+	return c.parse(selector).Matches(set)
+}
+
+// parse parses a selector into the cache
+func (c *selectorCache) parse(selector *metav1.LabelSelector) labels.Selector {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		klog.V(2).InfoS("Ignoring carbon policy with invalid selector", "err", err)
+		s = labels.Nothing()
+	}
+	c.parsed.Store(selector, s)
+	return s
+}
+
+// handler returns the informer event handler keeping the cache in sync with the
+// cached policies
+func (c *selectorCache) handler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.forEach(obj, func(s *metav1.LabelSelector) { c.parse(s) })
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			c.forEach(oldObj, func(s *metav1.LabelSelector) { c.parsed.Delete(s) })
+			c.forEach(newObj, func(s *metav1.LabelSelector) { c.parse(s) })
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			c.forEach(obj, func(s *metav1.LabelSelector) { c.parsed.Delete(s) })
+		},
+	}
+}
+
+// forEach calls f with the selectors a policy sets
+func (c *selectorCache) forEach(obj interface{}, f func(*metav1.LabelSelector)) {
+	var spec *v1alpha1.CarbonPolicySpec
+	switch p := obj.(type) {
+	case *v1alpha1.CarbonPolicy:
+		spec = &p.Spec
+	case *v1alpha1.ClusterCarbonPolicy:
+		spec = &p.Spec
+	default:
+		return
+	}
+	for _, s := range []*metav1.LabelSelector{spec.PodSelector, spec.NamespaceSelector} {
+		if s != nil {
+			f(s)
+		}
+	}
+}
+
+// byAge sorts policies by creation, oldest first, breaking ties by name
+func byAge(candidates []candidate) []candidate {
+	sort.Slice(candidates, func(i, j int) bool {
+		ti, tj := candidates[i].meta.CreationTimestamp, candidates[j].meta.CreationTimestamp
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return candidates[i].meta.Name < candidates[j].meta.Name
+	})
+	return candidates
+}
+
+// namespaced filters objects by namespace, for indexers without a namespace index
+func namespaced(objs []interface{}, namespace string) []interface{} {
+	var out []interface{}
+	for _, obj := range objs {
+		if m, ok := obj.(metav1.Object); ok && m.GetNamespace() == namespace {
+			out = append(out, obj)
+		}
+	}
+	return out
+}
@@ -0,0 +1,361 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+)
+
+func TestResolve(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	meta := func(namespace, name string, age time.Duration) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: namespace, Name: name, CreationTimestamp: metav1.NewTime(created.Add(-age))}
+	}
+	batch := &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "batch"}}
+
+	policies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, p := range []*v1alpha1.CarbonPolicy{
+		{ObjectMeta: meta("team-a", "batch", time.Hour), Spec: v1alpha1.CarbonPolicySpec{PodSelector: batch}},
+		{ObjectMeta: meta("team-a", "newer", time.Minute)},
+		{ObjectMeta: meta("team-a", "older", time.Hour)},
+		{ObjectMeta: meta("team-b", "other", time.Hour), Spec: v1alpha1.CarbonPolicySpec{PodSelector: batch}},
+	} {
+		if err := policies.Add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	clusterPolicies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, p := range []*v1alpha1.ClusterCarbonPolicy{
+		{ObjectMeta: meta("", "research", time.Hour), Spec: v1alpha1.CarbonPolicySpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"dept": "research"}},
+		}},
+		{ObjectMeta: meta("", "default", time.Minute)},
+	} {
+		if err := clusterPolicies.Add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	namespaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range []*v1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "lab", Labels: map[string]string{"dept": "research"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+	} {
+		if err := namespaces.Add(ns); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewResolver(policies, clusterPolicies, corelisters.NewNamespaceLister(namespaces))
+
+	tests := []struct {
+		name      string
+		namespace string
+		labels    map[string]string
+		want      string
+	}{
+		{
+			name:      "oldest matching namespaced policy",
+			namespace: "team-a",
+			labels:    map[string]string{"tier": "batch"},
+			want:      "team-a/batch",
+		},
+		{
+			name:      "ties broken by age",
+			namespace: "team-a",
+			want:      "team-a/older",
+		},
+		{
+			name:      "unmatched pod selector falls back to cluster policy",
+			namespace: "team-b",
+			want:      "default",
+		},
+		{
+			name:      "namespace selector",
+			namespace: "lab",
+			want:      "research",
+		},
+		{
+			name:      "unselected namespace",
+			namespace: "web",
+			want:      "default",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: tt.namespace, Name: "pod", Labels: tt.labels}}
+			got, ok := r.Resolve(pod)
+			if !ok {
+				t.Fatalf("expected policy %s, got none", tt.want)
+			}
+			if got.Name != tt.want {
+				t.Errorf("expected policy %s, got %s", tt.want, got.Name)
+			}
+		})
+	}
+
+	t.Run("no policies", func(t *testing.T) {
+		empty := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+		r := NewResolver(empty, empty, nil)
+		if got, ok := r.Resolve(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "web"}}); ok {
+			t.Errorf("expected no policy, got %s", got.Name)
+		}
+	})
+}
+
+func TestToTyped(t *testing.T) {
+	threshold := 150.0
+	in := &v1alpha1.CarbonPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "batch"},
+		Spec: v1alpha1.CarbonPolicySpec{
+			CarbonIntensityThreshold: &threshold,
+			MaxSchedulingDelay:       &metav1.Duration{Duration: 6 * time.Hour},
+			PeakSchedules:            []v1alpha1.PeakSchedule{{DayOfWeek: "12345", StartTime: "16:00", EndTime: "21:00"}},
+		},
+	}
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := toTyped(func() runtime.Object { return &v1alpha1.CarbonPolicy{} })(&unstructured.Unstructured{Object: obj})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, ok := out.(*v1alpha1.CarbonPolicy)
+	if !ok {
+		t.Fatalf("expected a CarbonPolicy, got %T", out)
+	}
+	if *p.Spec.CarbonIntensityThreshold != threshold || p.Spec.MaxSchedulingDelay.Duration != 6*time.Hour ||
+		len(p.Spec.PeakSchedules) != 1 || p.Spec.PeakSchedules[0].StartTime != "16:00" {
+		t.Errorf("unexpected conversion: %+v", p.Spec)
+	}
+
+	tombstone := cache.DeletedFinalStateUnknown{Key: "team-a/batch", Obj: p}
+	if got, err := toTyped(func() runtime.Object { return &v1alpha1.CarbonPolicy{} })(tombstone); err != nil || got != tombstone {
+		t.Errorf("expected tombstone to pass through, got %v, %v", got, err)
+	}
+}
+
+func TestResolveMerge(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	meta := func(namespace, name string, age time.Duration) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Namespace: namespace, Name: name, CreationTimestamp: metav1.NewTime(created.Add(-age))}
+	}
+	threshold := func(v float64) *float64 { return &v }
+	peak := []v1alpha1.PeakSchedule{{DayOfWeek: "12345", StartTime: "16:00", EndTime: "21:00"}}
+	ops := &v1alpha1.SkipRestriction{Groups: []string{"ops"}}
+	ci := &v1alpha1.SkipRestriction{Users: []string{"system:serviceaccount:team-a:ci"}}
+
+	policies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, p := range []*v1alpha1.CarbonPolicy{
+		{ObjectMeta: meta("team-a", "threshold", time.Hour), Spec: v1alpha1.CarbonPolicySpec{CarbonIntensityThreshold: threshold(150)}},
+		{ObjectMeta: meta("team-a", "newer", time.Minute), Spec: v1alpha1.CarbonPolicySpec{
+			CarbonIntensityThreshold: threshold(300),
+			EnforcementMode:          v1alpha1.EnforcementModeAudit,
+			EnforcedInclusion:        true,
+			SkipRestriction:          ci,
+		}},
+		{ObjectMeta: meta("team-b", "relaxed", time.Hour), Spec: v1alpha1.CarbonPolicySpec{
+			PodSelector:              &metav1.LabelSelector{MatchLabels: map[string]string{"app": "batch"}},
+			CarbonIntensityThreshold: threshold(500),
+			EnforcementMode:          v1alpha1.EnforcementModeAudit,
+		}},
+	} {
+		if err := policies.Add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clusterPolicies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, p := range []*v1alpha1.ClusterCarbonPolicy{
+		{ObjectMeta: meta("", "cluster", time.Hour), Spec: v1alpha1.CarbonPolicySpec{
+			CarbonIntensityThreshold: threshold(400),
+			MaxSchedulingDelay:       &metav1.Duration{Duration: 6 * time.Hour},
+			EnforcementMode:          v1alpha1.EnforcementModeEnforce,
+			PeakSchedules:            peak,
+			SkipRestriction:          ops,
+		}},
+	} {
+		if err := clusterPolicies.Add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewResolver(policies, clusterPolicies, nil)
+
+	tests := []struct {
+		name        string
+		namespace   string
+		policy      string
+		wantName    string
+		wantSpec    v1alpha1.CarbonPolicySpec
+		wantSources map[string]string
+		wantSkip    map[string]*v1alpha1.SkipRestriction
+		wantEnforce string
+	}{
+		{
+			name:      "namespaced policies over cluster policy",
+			namespace: "team-a",
+			wantName:  "team-a/threshold",
+			wantSpec: v1alpha1.CarbonPolicySpec{
+				CarbonIntensityThreshold: threshold(150),
+				MaxSchedulingDelay:       &metav1.Duration{Duration: 6 * time.Hour},
+				EnforcementMode:          v1alpha1.EnforcementModeAudit,
+				PeakSchedules:            peak,
+			},
+			wantSources: map[string]string{
+				"carbonIntensityThreshold": "team-a/threshold",
+				"maxSchedulingDelay":       "cluster",
+				"enforcementMode":          "team-a/newer",
+				"peakSchedules":            "cluster",
+			},
+			wantSkip:    map[string]*v1alpha1.SkipRestriction{"team-a/newer": ci, "cluster": ops},
+			wantEnforce: "team-a/newer",
+		},
+		{
+			name:      "cluster policy only",
+			namespace: "team-b",
+			wantName:  "cluster",
+			wantSpec: v1alpha1.CarbonPolicySpec{
+				CarbonIntensityThreshold: threshold(400),
+				MaxSchedulingDelay:       &metav1.Duration{Duration: 6 * time.Hour},
+				EnforcementMode:          v1alpha1.EnforcementModeEnforce,
+				PeakSchedules:            peak,
+			},
+			wantSources: map[string]string{
+				"carbonIntensityThreshold": "cluster",
+				"maxSchedulingDelay":       "cluster",
+				"enforcementMode":          "cluster",
+				"peakSchedules":            "cluster",
+			},
+			wantSkip: map[string]*v1alpha1.SkipRestriction{"cluster": ops},
+		},
+		{
+			name:      "named policy alone, keeping skip restrictions of selecting policies",
+			namespace: "team-b",
+			policy:    "relaxed",
+			wantName:  "team-b/relaxed",
+			wantSpec: v1alpha1.CarbonPolicySpec{
+				CarbonIntensityThreshold: threshold(500),
+				EnforcementMode:          v1alpha1.EnforcementModeAudit,
+			},
+			wantSources: map[string]string{
+				"carbonIntensityThreshold": "team-b/relaxed",
+				"enforcementMode":          "team-b/relaxed",
+			},
+			wantSkip: map[string]*v1alpha1.SkipRestriction{"cluster": ops},
+		},
+		{
+			name:      "named policy ignored under enforced inclusion",
+			namespace: "team-a",
+			policy:    "cluster",
+			wantName:  "team-a/threshold",
+			wantSpec: v1alpha1.CarbonPolicySpec{
+				CarbonIntensityThreshold: threshold(150),
+				MaxSchedulingDelay:       &metav1.Duration{Duration: 6 * time.Hour},
+				EnforcementMode:          v1alpha1.EnforcementModeAudit,
+				PeakSchedules:            peak,
+			},
+			wantSources: map[string]string{
+				"carbonIntensityThreshold": "team-a/threshold",
+				"maxSchedulingDelay":       "cluster",
+				"enforcementMode":          "team-a/newer",
+				"peakSchedules":            "cluster",
+			},
+			wantSkip:    map[string]*v1alpha1.SkipRestriction{"team-a/newer": ci, "cluster": ops},
+			wantEnforce: "team-a/newer",
+		},
+		{
+			name:      "named policy of another namespace ignored",
+			namespace: "team-b",
+			policy:    "newer",
+			wantName:  "cluster",
+			wantSpec: v1alpha1.CarbonPolicySpec{
+				CarbonIntensityThreshold: threshold(400),
+				MaxSchedulingDelay:       &metav1.Duration{Duration: 6 * time.Hour},
+				EnforcementMode:          v1alpha1.EnforcementModeEnforce,
+				PeakSchedules:            peak,
+			},
+			wantSources: map[string]string{
+				"carbonIntensityThreshold": "cluster",
+				"maxSchedulingDelay":       "cluster",
+				"enforcementMode":          "cluster",
+				"peakSchedules":            "cluster",
+			},
+			wantSkip: map[string]*v1alpha1.SkipRestriction{"cluster": ops},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: tt.namespace, Name: "pod"}}
+			if tt.policy != "" {
+				pod.Annotations = map[string]string{PolicyAnnotation: tt.policy}
+			}
+			got, ok := r.Resolve(pod)
+			if !ok {
+				t.Fatal("expected a policy")
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("Name = %s, want %s", got.Name, tt.wantName)
+			}
+			if !equality.Semantic.DeepEqual(got.Spec, tt.wantSpec) {
+				t.Errorf("Spec = %+v, want %+v", got.Spec, tt.wantSpec)
+			}
+			if !equality.Semantic.DeepEqual(got.Sources, tt.wantSources) {
+				t.Errorf("Sources = %v, want %v", got.Sources, tt.wantSources)
+			}
+			if !equality.Semantic.DeepEqual(got.SkipRestrictions, tt.wantSkip) {
+				t.Errorf("SkipRestrictions = %v, want %v", got.SkipRestrictions, tt.wantSkip)
+			}
+			if got.EnforcedBy != tt.wantEnforce {
+				t.Errorf("EnforcedBy = %q, want %q", got.EnforcedBy, tt.wantEnforce)
+			}
+		})
+	}
+}
+
+func TestSelectorCache(t *testing.T) {
+	c := &selectorCache{}
+	handler := c.handler()
+	old := &v1alpha1.CarbonPolicy{Spec: v1alpha1.CarbonPolicySpec{
+		PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "batch"}},
+	}}
+	handler.OnAdd(old, false)
+	if _, ok := c.parsed.Load(old.Spec.PodSelector); !ok {
+		t.Fatal("selector not parsed when the policy was added")
+	}
+	if !c.matches(old.Spec.PodSelector, labels.Set{"app": "batch"}) || c.matches(old.Spec.PodSelector, labels.Set{}) {
+		t.Error("parsed selector doesn't match as the selector does")
+	}
+
+	updated := &v1alpha1.CarbonPolicy{Spec: v1alpha1.CarbonPolicySpec{
+		PodSelector: &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: "Invalid"},
+		}},
+	}}
+	handler.OnUpdate(old, updated)
+	if _, ok := c.parsed.Load(old.Spec.PodSelector); ok {
+		t.Error("selector of the old policy kept after an update")
+	}
+	if c.matches(updated.Spec.PodSelector, labels.Set{"app": "batch"}) {
+		t.Error("invalid selector matches, want it to match nothing")
+	}
+
+	handler.OnDelete(cache.DeletedFinalStateUnknown{Obj: updated})
+	if _, ok := c.parsed.Load(updated.Spec.PodSelector); ok {
+		t.Error("selector kept after the policy was deleted")
+	}
+}
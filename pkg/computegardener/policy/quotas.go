@@ -0,0 +1,68 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	schedv1alpha1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+)
+
+// Quotas looks up the ElasticQuotas of namespaces, which the capacityscheduling
+// plugin enforces
+type Quotas struct {
+	quotas cache.Indexer
+}
+
+// NewQuotas returns a lookup over an indexer holding typed elastic quotas
+func NewQuotas(quotas cache.Indexer) *Quotas {
+	return &Quotas{quotas: quotas}
+}
+
+// StartQuotas watches elastic quotas in the cluster and returns a lookup over them.
+// Namespaces have no quota until the informer has synced, or if the CRD is not
+// installed.
+func StartQuotas(ctx context.Context, cfg *rest.Config) (*Quotas, error) {
+	client, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %v", err)
+	}
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(client, 0)
+
+	quotas := factory.ForResource(schedv1alpha1.SchemeGroupVersion.WithResource("elasticquotas")).Informer()
+	if err := quotas.SetTransform(toTyped(func() runtime.Object { return &schedv1alpha1.ElasticQuota{} })); err != nil {
+		return nil, err
+	}
+
+	factory.Start(ctx.Done())
+	return NewQuotas(quotas.GetIndexer()), nil
+}
+
+// Get returns the elastic quota of a namespace, if any. Of several quotas in a
+// namespace the first by name applies.
+func (q *Quotas) Get(namespace string) (*schedv1alpha1.ElasticQuota, bool) {
+	if q == nil {
+		return nil, false
+	}
+	objs, err := q.quotas.ByIndex(cache.NamespaceIndex, namespace)
+	if err != nil {
+		objs = namespaced(q.quotas.List(), namespace)
+	}
+	var quotas []*schedv1alpha1.ElasticQuota
+	for _, obj := range objs {
+		if quota, ok := obj.(*schedv1alpha1.ElasticQuota); ok {
+			quotas = append(quotas, quota)
+		}
+	}
+	if len(quotas) == 0 {
+		return nil, false
+	}
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].Name < quotas[j].Name })
+	return quotas[0], true
+}
@@ -0,0 +1,217 @@
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+)
+
+var sloResource = v1alpha1.SchemeGroupVersion.WithResource("carbonslos")
+
+// Defaults of the threshold adjustment of a CarbonSLO
+const (
+	defaultSLOMinFactor = 0.5
+	defaultSLOMaxFactor = 1.0
+)
+
+// Ledger scope suffixes of the CPU-hours accrued against an SLO
+const (
+	sloScope      = "slo/"
+	sloTotalScope = "/total"
+	sloCleanScope = "/clean"
+)
+
+// SLOs tracks the CPU-hours of the pods selected by CarbonSLOs executed under their
+// carbon intensity, and the threshold adjustment keeping each objective on track
+type SLOs struct {
+	slos   cache.Indexer
+	ledger *Ledger
+	client dynamic.Interface // nil if SLO status is not reported
+}
+
+// NewSLOs returns SLOs over an indexer holding typed CarbonSLOs
+func NewSLOs(slos cache.Indexer, ledger *Ledger, client dynamic.Interface) *SLOs {
+	return &SLOs{slos: slos, ledger: ledger, client: client}
+}
+
+// StartSLOs watches CarbonSLOs in the source. No pod is adjusted until the informer
+// has synced, or if the CRD is not installed. SLO status is not reported to a hub,
+// like budget status.
+func StartSLOs(ctx context.Context, src Source) (*SLOs, error) {
+	client, factory, err := src.informers()
+	if err != nil {
+		return nil, err
+	}
+	if src.Hub {
+		client = nil
+	}
+
+	slos := factory.ForResource(sloResource).Informer()
+	if err := slos.SetTransform(toTyped(func() runtime.Object { return &v1alpha1.CarbonSLO{} })); err != nil {
+		return nil, err
+	}
+
+	factory.Start(ctx.Done())
+	return NewSLOs(slos.GetIndexer(), NewLedger(), client), nil
+}
+
+// selecting returns the CarbonSLOs selecting a pod
+func (s *SLOs) selecting(pod *v1.Pod) []*v1alpha1.CarbonSLO {
+	objs, err := s.slos.ByIndex(cache.NamespaceIndex, pod.Namespace)
+	if err != nil {
+		objs = namespaced(s.slos.List(), pod.Namespace)
+	}
+	var slos []*v1alpha1.CarbonSLO
+	for _, obj := range objs {
+		if slo, ok := obj.(*v1alpha1.CarbonSLO); ok && selects(slo.Spec.PodSelector, labels.Set(pod.Labels)) {
+			slos = append(slos, slo)
+		}
+	}
+	return slos
+}
+
+// sloKey is the ledger scope prefix of an SLO
+func sloKey(slo *v1alpha1.CarbonSLO) string {
+	return sloScope + slo.Namespace + "/" + slo.Name
+}
+
+// Accrue adds the CPU-hours of a completed pod, executed at a carbon intensity in
+// gCO2/kWh, to the SLOs selecting it
+func (s *SLOs) Accrue(pod *v1.Pod, cpuHours, intensity float64, now time.Time) {
+	if s == nil {
+		return
+	}
+	for _, slo := range s.selecting(pod) {
+		key := sloKey(slo)
+		s.ledger.Accrue(key+sloTotalScope, cpuHours, now)
+		if intensity <= slo.Spec.CarbonIntensity {
+			s.ledger.Accrue(key+sloCleanScope, cpuHours, now)
+		}
+	}
+}
+
+// attainment returns the CPU-hours accrued against an SLO in its current period, and
+// the percentage of them that is clean
+func (s *SLOs) attainment(slo *v1alpha1.CarbonSLO, now time.Time) (total, clean, attainment float64) {
+	key := sloKey(slo)
+	total = s.ledger.Used(key+sloTotalScope, slo.Spec.Period, now)
+	clean = s.ledger.Used(key+sloCleanScope, slo.Spec.Period, now)
+	if total > 0 {
+		attainment = 100 * clean / total
+	}
+	return total, clean, attainment
+}
+
+// thresholdFactor returns the factor an SLO multiplies the thresholds of the pods it
+// selects by: the ratio of attainment to target, within the bounds of the adjustment.
+// SLOs without an adjustment, or short of its minimum CPU-hours, don't adjust.
+func (s *SLOs) thresholdFactor(slo *v1alpha1.CarbonSLO, now time.Time) (float64, bool) {
+	adj := slo.Spec.ThresholdAdjustment
+	if adj == nil || slo.Spec.Target <= 0 {
+		return 1, false
+	}
+	total, _, attainment := s.attainment(slo, now)
+	if total == 0 || total < adj.MinCPUHours {
+		return 1, false
+	}
+	minFactor, maxFactor := defaultSLOMinFactor, defaultSLOMaxFactor
+	if adj.MinFactor != nil && *adj.MinFactor > 0 && *adj.MinFactor <= 1 {
+		minFactor = *adj.MinFactor
+	}
+	if adj.MaxFactor != nil && *adj.MaxFactor >= 1 {
+		maxFactor = *adj.MaxFactor
+	}
+	return math.Max(minFactor, math.Min(maxFactor, attainment/slo.Spec.Target)), true
+}
+
+// ThresholdFactor returns the factor the carbon intensity threshold of a pod is
+// multiplied by to keep the SLOs selecting it on track, and the SLO setting it, as
+// namespace/name. Of several adjusting SLOs the lowest factor applies.
+func (s *SLOs) ThresholdFactor(pod *v1.Pod, now time.Time) (float64, string) {
+	if s == nil {
+		return 1, ""
+	}
+	factor, name := 1.0, ""
+	adjusted := false
+	for _, slo := range s.selecting(pod) {
+		f, ok := s.thresholdFactor(slo, now)
+		if ok && (!adjusted || f < factor) {
+			factor, name, adjusted = f, slo.Namespace+"/"+slo.Name, true
+		}
+	}
+	return factor, name
+}
+
+// SyncStatus reports the attainment of each SLO in its status. Only SLOs whose status
+// changed are patched.
+func (s *SLOs) SyncStatus(ctx context.Context, now time.Time) {
+	if s == nil || s.client == nil {
+		return
+	}
+	for _, obj := range s.slos.List() {
+		slo, ok := obj.(*v1alpha1.CarbonSLO)
+		if !ok {
+			continue
+		}
+		status := s.status(slo, now)
+		if sloStatusEqual(slo.Status, status) {
+			continue
+		}
+		patch, err := json.Marshal(map[string]interface{}{"status": status})
+		if err != nil {
+			continue
+		}
+		client := s.client.Resource(sloResource).Namespace(slo.Namespace)
+		if _, err := client.Patch(ctx, slo.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status"); err != nil {
+			klog.ErrorS(err, "Failed to update carbon SLO status", "slo", klog.KObj(slo))
+		}
+	}
+}
+
+// status returns the current status of an SLO
+func (s *SLOs) status(slo *v1alpha1.CarbonSLO, now time.Time) v1alpha1.CarbonSLOStatus {
+	total, clean, attainment := s.attainment(slo, now)
+	start := metav1.NewTime(PeriodStart(slo.Spec.Period, now))
+	// Rounded to hundredths, so status isn't patched for negligible changes
+	status := v1alpha1.CarbonSLOStatus{
+		PeriodStart:   &start,
+		CPUHours:      roundHundredths(total),
+		CleanCPUHours: roundHundredths(clean),
+	}
+	if total > 0 {
+		a := roundHundredths(attainment)
+		status.Attainment = &a
+		status.Met = attainment >= slo.Spec.Target
+	}
+	if factor, ok := s.thresholdFactor(slo, now); ok {
+		f := roundHundredths(factor)
+		status.ThresholdFactor = &f
+	}
+	return status
+}
+
+func roundHundredths(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func sloStatusEqual(a, b v1alpha1.CarbonSLOStatus) bool {
+	return a.CPUHours == b.CPUHours && a.CleanCPUHours == b.CleanCPUHours && a.Met == b.Met &&
+		floatPtrEqual(a.Attainment, b.Attainment) && floatPtrEqual(a.ThresholdFactor, b.ThresholdFactor) &&
+		a.PeriodStart != nil && b.PeriodStart != nil && a.PeriodStart.Equal(b.PeriodStart)
+}
+
+func floatPtrEqual(a, b *float64) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
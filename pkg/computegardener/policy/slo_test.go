@@ -0,0 +1,118 @@
+package policy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+)
+
+func TestSLOs(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	factor := func(f float64) *float64 { return &f }
+
+	slo := func(name string, selector map[string]string, adj *v1alpha1.SLOThresholdAdjustment) *v1alpha1.CarbonSLO {
+		s := &v1alpha1.CarbonSLO{
+			TypeMeta:   metav1.TypeMeta{APIVersion: v1alpha1.SchemeGroupVersion.String(), Kind: "CarbonSLO"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: name},
+			Spec: v1alpha1.CarbonSLOSpec{
+				CarbonIntensity:     200,
+				Target:              90,
+				Period:              v1alpha1.BudgetPeriodDay,
+				ThresholdAdjustment: adj,
+			},
+		}
+		if selector != nil {
+			s.Spec.PodSelector = &metav1.LabelSelector{MatchLabels: selector}
+		}
+		return s
+	}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	objs := []*v1alpha1.CarbonSLO{
+		slo("batch", map[string]string{"tier": "batch"}, &v1alpha1.SLOThresholdAdjustment{MinCPUHours: 2, MaxFactor: factor(1.05)}),
+		slo("all", nil, nil),
+	}
+	var unstructuredObjs []runtime.Object
+	for _, obj := range objs {
+		if err := indexer.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		unstructuredObjs = append(unstructuredObjs, &unstructured.Unstructured{Object: u})
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{sloResource: "CarbonSLOList"}, unstructuredObjs...)
+	s := NewSLOs(indexer, NewLedger(), client)
+
+	batch := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "job", Labels: map[string]string{"tier": "batch"}}}
+	web := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "web"}}
+	other := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "team-b", Name: "job", Labels: map[string]string{"tier": "batch"}}}
+
+	if f, name := s.ThresholdFactor(batch, now); f != 1 || name != "" {
+		t.Errorf("expected no adjustment without CPU-hours, got %v from %q", f, name)
+	}
+
+	// Under the minimum CPU-hours thresholds are not adjusted
+	s.Accrue(batch, 1, 300, now)
+	if f, name := s.ThresholdFactor(batch, now); f != 1 || name != "" {
+		t.Errorf("expected no adjustment under minimum CPU-hours, got %v from %q", f, name)
+	}
+
+	// 3 of 4 CPU-hours clean is 75% against a 90% target
+	s.Accrue(batch, 3, 150, now)
+	if f, name := s.ThresholdFactor(batch, now); name != "team-a/batch" || f < 0.833 || f > 0.834 {
+		t.Errorf("expected batch SLO to tighten threshold to 0.83, got %v from %q", f, name)
+	}
+	if f, name := s.ThresholdFactor(web, now); f != 1 || name != "" {
+		t.Errorf("expected unselected pod not adjusted, got %v from %q", f, name)
+	}
+	if f, _ := s.ThresholdFactor(other, now); f != 1 {
+		t.Errorf("expected pod of other namespace not adjusted, got %v", f)
+	}
+
+	// Exceeding the target relaxes thresholds up to the maximum factor
+	s.Accrue(batch, 20, 100, now)
+	if f, _ := s.ThresholdFactor(batch, now); f != 1.05 {
+		t.Errorf("expected threshold relaxed to 1.05, got %v", f)
+	}
+	if f, _ := s.ThresholdFactor(batch, now.Add(24*time.Hour)); f != 1 {
+		t.Errorf("expected no adjustment in the next period, got %v", f)
+	}
+
+	s.Accrue(web, 6, 400, now)
+	s.SyncStatus(context.Background(), now)
+	status := func(name string) v1alpha1.CarbonSLOStatus {
+		u, err := client.Resource(sloResource).Namespace("team-a").Get(context.Background(), name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var synced v1alpha1.CarbonSLO
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &synced); err != nil {
+			t.Fatal(err)
+		}
+		return synced.Status
+	}
+
+	got := status("batch")
+	if got.CPUHours != 24 || got.CleanCPUHours != 23 || got.Attainment == nil || *got.Attainment != 95.83 ||
+		!got.Met || got.ThresholdFactor == nil || *got.ThresholdFactor != 1.05 {
+		t.Errorf("unexpected batch status: %+v", got)
+	}
+	got = status("all")
+	if got.CPUHours != 30 || got.CleanCPUHours != 23 || got.Met || got.ThresholdFactor != nil ||
+		!got.PeriodStart.Equal(&metav1.Time{Time: now.Truncate(24 * time.Hour)}) {
+		t.Errorf("unexpected status of SLO without adjustment: %+v", got)
+	}
+}
@@ -0,0 +1,58 @@
+package policy
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Source is the cluster policies and budgets are read from: the scheduler's own
+// cluster, or a hub cluster serving a fleet of clusters. Namespaced objects in a hub
+// apply to the namespaces of the same name in each cluster.
+type Source struct {
+	// Config connects to the cluster
+	Config *rest.Config
+	// Selector restricts the objects read to those whose labels it matches, e.g. to
+	// the objects of a hub meant for this cluster. Empty selects all objects.
+	Selector string
+	// Hub is set when the objects are shared by several clusters. Budget status is
+	// not written to a hub, since each cluster only accrues its own emissions.
+	Hub bool
+}
+
+// LocalSource returns the source reading the scheduler's own cluster
+func LocalSource(cfg *rest.Config) Source {
+	return Source{Config: cfg}
+}
+
+// HubSource returns the source reading the hub cluster a kubeconfig file connects
+// to, restricted to the objects selector matches
+func HubSource(kubeconfig, selector string) (Source, error) {
+	if _, err := labels.Parse(selector); err != nil {
+		return Source{}, fmt.Errorf("invalid hub selector %q: %v", selector, err)
+	}
+	cfg, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to load hub kubeconfig %s: %v", kubeconfig, err)
+	}
+	return Source{Config: cfg, Selector: selector, Hub: true}, nil
+}
+
+// informers returns a client for the source, and an informer factory watching the
+// objects it selects
+func (s Source) informers() (dynamic.Interface, dynamicinformer.DynamicSharedInformerFactory, error) {
+	client, err := dynamic.NewForConfig(s.Config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create dynamic client: %v", err)
+	}
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, 0, metav1.NamespaceAll,
+		func(opts *metav1.ListOptions) {
+			opts.LabelSelector = s.Selector
+		})
+	return client, factory, nil
+}
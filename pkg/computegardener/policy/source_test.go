@@ -0,0 +1,47 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const hubKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: hub
+  cluster:
+    server: https://hub.example.com:6443
+users:
+- name: scheduler
+  user:
+    token: secret
+contexts:
+- name: hub
+  context:
+    cluster: hub
+    user: scheduler
+current-context: hub
+`
+
+func TestHubSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kubeconfig")
+	if err := os.WriteFile(path, []byte(hubKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	src, err := HubSource(path, "fleet=prod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !src.Hub || src.Selector != "fleet=prod" || src.Config.Host != "https://hub.example.com:6443" {
+		t.Errorf("unexpected hub source: %+v", src)
+	}
+
+	if _, err := HubSource(path, "fleet in prod"); err == nil {
+		t.Error("expected an invalid selector to fail")
+	}
+	if _, err := HubSource(filepath.Join(t.TempDir(), "missing"), ""); err == nil {
+		t.Error("expected a missing kubeconfig to fail")
+	}
+}
@@ -0,0 +1,111 @@
+package computegardener
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/utils/ptr"
+)
+
+// delayStateKey is the CycleState key the reason a pod was delayed is stored under
+const delayStateKey framework.StateKey = Name + "/delay"
+
+// delayState records why PreFilter delayed a pod
+type delayState struct {
+	reason string
+}
+
+// Clone implements framework.StateData
+func (s *delayState) Clone() framework.StateData {
+	return s
+}
+
+// writeDelayState records that PreFilter delayed a pod
+func writeDelayState(state *framework.CycleState, status *framework.Status) {
+	if state == nil || status.Code() != framework.Unschedulable {
+		return
+	}
+	state.Write(delayStateKey, &delayState{reason: status.Message()})
+}
+
+// PostFilter explains delays of pods held by this plugin. It never makes a pod
+// schedulable, but replaces the terse PreFilter reason in the pod's status message
+// with the intensity and threshold the pod was evaluated against, when it is
+// expected to be retried, and how long it has waited, and records them in an Event.
+// The delay is also reported in the pod's CarbonAwareDelayed condition, and in its
+// CarbonDelay if enabled.
+func (cs *CarbonAwareScheduler) PostFilter(ctx context.Context, state *framework.CycleState, pod *v1.Pod, filteredNodeStatusMap framework.NodeToStatusMap) (*framework.PostFilterResult, *framework.Status) {
+	if state == nil {
+		return nil, framework.NewStatus(framework.Unschedulable)
+	}
+	data, err := state.Read(delayStateKey)
+	if err != nil {
+		// The pod was rejected by other plugins
+		return nil, framework.NewStatus(framework.Unschedulable)
+	}
+
+	reason := data.(*delayState).reason
+	condReason := delayConditionReason(reason)
+	cs.setDelayedCondition(pod, v1.ConditionTrue, condReason, delayConditionMessages[condReason])
+
+	diagnosis := cs.diagnoseDelay(ctx, pod)
+	cs.delayStatus.delayed(pod, reason, diagnosis, cs.clock.Now())
+	msg := diagnosis.message(reason)
+	cs.handle.EventRecorder().Eventf(pod, nil, v1.EventTypeNormal, "CarbonAwareDiagnostics", "Scheduling", "%s", msg)
+	return nil, framework.NewStatus(framework.Unschedulable, msg)
+}
+
+// delayDiagnosis is the state a delay decision was based on. Values that aren't
+// available are nil.
+type delayDiagnosis struct {
+	zone           string
+	intensity      *float64
+	threshold      *float64
+	rate           *float64
+	nextTransition time.Time
+	waited         *time.Duration
+}
+
+// diagnoseDelay returns the state a pod's delay decision was based on
+func (cs *CarbonAwareScheduler) diagnoseDelay(ctx context.Context, pod *v1.Pod) delayDiagnosis {
+	zone := cs.podZone(pod)
+	d := delayDiagnosis{zone: zone, nextTransition: cs.projectedStart(ctx, pod)}
+
+	if data, err := cs.getZoneCarbonIntensityData(ctx, zone); err == nil {
+		d.intensity = ptr.To(cs.effectiveIntensity(zone, data))
+	}
+	if threshold, err := cs.carbonThreshold(pod); err == nil {
+		d.threshold = ptr.To(threshold)
+	}
+	if cs.config.Pricing.Enabled && cs.pricingImpl != nil {
+		d.rate = ptr.To(cs.pricingImpl.GetCurrentRate(cs.clock.Now()))
+	}
+	if submitted := submittedAt(pod); !submitted.IsZero() {
+		d.waited = ptr.To(cs.clock.Since(submitted))
+	}
+	return d
+}
+
+// message describes a delay for reason with the state it was based on
+func (d delayDiagnosis) message(reason string) string {
+	fields := []string{"zone=" + d.zone}
+	if d.intensity != nil {
+		fields = append(fields, fmt.Sprintf("intensity=%.1f", *d.intensity))
+	}
+	if d.threshold != nil {
+		fields = append(fields, fmt.Sprintf("threshold=%.1f", *d.threshold))
+	}
+	if d.rate != nil {
+		fields = append(fields, fmt.Sprintf("rate=%.4f", *d.rate))
+	}
+	fields = append(fields, "nextTransition="+d.nextTransition.UTC().Format(time.RFC3339))
+	if d.waited != nil {
+		fields = append(fields, "waited="+d.waited.Round(time.Second).String())
+	}
+
+	return fmt.Sprintf("Delayed by carbon-aware scheduling: %s (%s)", reason, strings.Join(fields, ", "))
+}
@@ -0,0 +1,47 @@
+package computegardener
+
+import (
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// powerCapLabel is the power cap of a node in watts
+const powerCapLabel = "carbon-aware-scheduler.kubernetes.io/power-cap"
+
+// nodePowerCap returns the power cap of a node from its label, or its max power
+func (cs *CarbonAwareScheduler) nodePowerCap(node *v1.Node) float64 {
+	if val, ok := node.Labels[powerCapLabel]; ok {
+		powerCap, err := strconv.ParseFloat(val, 64)
+		if err == nil && powerCap > 0 {
+			return powerCap
+		}
+		klog.V(2).InfoS("Ignoring invalid power cap label", "node", node.Name, "value", val)
+	}
+	_, maxPower := cs.nodePowerLimits(node.Name)
+	return maxPower
+}
+
+// checkPowerCap rejects nodes whose measured power draw is close to their power
+// cap, where another pod could get the node throttled. Nodes without a fresh
+// reading are not filtered.
+func (cs *CarbonAwareScheduler) checkPowerCap(node *v1.Node) *framework.Status {
+	if cs.nodePower == nil {
+		return nil
+	}
+	power, ok := cs.nodePower.Value(node.Name)
+	if !ok {
+		return nil
+	}
+
+	powerCap := cs.nodePowerCap(node)
+	if power >= cs.config.PowerCap.Threshold*powerCap {
+		cs.countAttempt("power_cap")
+		return framework.NewStatus(framework.Unschedulable,
+			fmt.Sprintf("node is drawing %.0fW, close to its %.0fW power cap", power, powerCap))
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package computegardener
+
+import (
+	"sync"
+	"time"
+)
+
+// powerMetricsStore holds the power of nodes recorded at the binding and completion
+// of pods. Entries are dropped when their pod is deleted, and otherwise expire after
+// a TTL, and the oldest are evicted beyond a maximum number of entries, so pods
+// whose deletion is missed don't leak.
+type powerMetricsStore struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]powerMetric // key format: "nodeName/podName/phase"
+	lastPrune  time.Time
+}
+
+type powerMetric struct {
+	power float64
+	at    time.Time
+}
+
+func newPowerMetricsStore(ttl time.Duration, maxEntries int) *powerMetricsStore {
+	return &powerMetricsStore{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    make(map[string]powerMetric),
+	}
+}
+
+// Store records a power in watts, pruning expired entries at most once per TTL and
+// evicting the oldest entry when full
+func (s *powerMetricsStore) Store(key string, power float64, now time.Time) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastPrune) >= s.ttl {
+		s.prune(now)
+	}
+	if _, ok := s.entries[key]; !ok && len(s.entries) >= s.maxEntries {
+		s.prune(now)
+		if len(s.entries) >= s.maxEntries {
+			s.evictOldest()
+		}
+	}
+	s.entries[key] = powerMetric{power: power, at: now}
+}
+
+// Load returns a recorded power, if it hasn't been evicted
+func (s *powerMetricsStore) Load(key string) (float64, bool) {
+	if s == nil {
+		return 0, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.entries[key]
+	return m.power, ok
+}
+
+// Delete drops a recorded power
+func (s *powerMetricsStore) Delete(key string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// Len returns the number of recorded powers
+func (s *powerMetricsStore) Len() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+// prune drops entries older than the TTL
+func (s *powerMetricsStore) prune(now time.Time) {
+	for key, m := range s.entries {
+		if now.Sub(m.at) > s.ttl {
+			delete(s.entries, key)
+		}
+	}
+	s.lastPrune = now
+}
+
+// evictOldest drops the least recently stored entry
+func (s *powerMetricsStore) evictOldest() {
+	var oldest string
+	var oldestAt time.Time
+	for key, m := range s.entries {
+		if oldest == "" || m.at.Before(oldestAt) {
+			oldest, oldestAt = key, m.at
+		}
+	}
+	delete(s.entries, oldest)
+}
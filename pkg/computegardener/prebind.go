@@ -0,0 +1,63 @@
+package computegardener
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+)
+
+// PreBind implements the PreBind interface. It re-reads the cached intensity of the
+// pod's zone just before binding, and aborts if it has spiked above the abort
+// threshold since the pod was admitted, closing the race on long scheduling cycles.
+// Aborted pods are unreserved by the framework and retried.
+func (cs *CarbonAwareScheduler) PreBind(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) *framework.Status {
+	if s, ok := readAdmissionState(state); ok {
+		cs.claimPermitBudget(pod, s)
+	}
+
+	abort := cs.config.Scheduling.AbortCarbonIntensityThreshold
+	if abort <= 0 || !cs.carbonGated(pod) {
+		return nil
+	}
+	s, ok := readAdmissionState(state)
+	if !ok || !s.reserved || s.deferBind {
+		return nil
+	}
+
+	data, ok := cs.cache.Get(s.zone)
+	if !ok {
+		return nil
+	}
+	intensity := cs.effectiveIntensity(s.zone, data)
+
+	// Pods allowed above the abort threshold are only aborted over their own threshold
+	if threshold, err := cs.carbonThreshold(pod); err == nil && threshold > abort {
+		abort = threshold
+	}
+	if intensity <= abort {
+		return nil
+	}
+
+	cs.countAttempt("prebind_abort")
+	klog.V(2).InfoS("Aborting bind after carbon intensity spike", "pod", klog.KObj(pod), "node", nodeName,
+		"zone", s.zone, "reservedIntensity", s.intensity, "intensity", intensity, "abortThreshold", abort)
+	return framework.NewStatus(framework.Unschedulable,
+		fmt.Sprintf("Carbon intensity in zone %s rose to %.2f, above abort threshold (%.2f), before binding", s.zone, intensity, abort))
+}
+
+// carbonGated reports whether a pod is subject to carbon gating, rather than admitted
+// regardless of intensity
+func (cs *CarbonAwareScheduler) carbonGated(pod *v1.Pod) bool {
+	if cs.isOptedOut(pod) || cs.isReleased(pod) || cs.hasExceededMaxDelay(pod) {
+		return false
+	}
+	if latest, ok := cs.latestStart(pod); ok && !cs.clock.Now().Before(latest) {
+		return false
+	}
+	return cs.enforcementMode(pod) != config.EnforcementModeAudit
+}
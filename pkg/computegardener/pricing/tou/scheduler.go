@@ -21,17 +21,8 @@ func New(config config.PricingConfig) *Scheduler {
 
 // GetCurrentRate returns the current electricity rate based on configured schedules
 func (s *Scheduler) GetCurrentRate(now time.Time) float64 {
-	weekday := fmt.Sprintf("%d", now.Weekday())
-	currentTime := now.Format("15:04")
-
 	for _, schedule := range s.config.Schedules {
-		// Check if current day is in schedule
-		if !containsDay(schedule.DayOfWeek, weekday) {
-			continue
-		}
-
-		// Check if current time is within schedule
-		if currentTime >= schedule.StartTime && currentTime <= schedule.EndTime {
+		if InSchedule(schedule, now) {
 			return schedule.PeakRate
 		}
 	}
@@ -45,6 +36,18 @@ func (s *Scheduler) GetCurrentRate(now time.Time) float64 {
 	return 0 // No schedules configured
 }
 
+// InSchedule reports whether now falls within the days and times of a schedule
+func InSchedule(schedule config.Schedule, now time.Time) bool {
+	// Check if current day is in schedule
+	if !containsDay(schedule.DayOfWeek, fmt.Sprintf("%d", now.Weekday())) {
+		return false
+	}
+
+	// Check if current time is within schedule
+	currentTime := now.Format("15:04")
+	return currentTime >= schedule.StartTime && currentTime <= schedule.EndTime
+}
+
 // containsDay checks if a day is included in a day string (e.g. "1,2,3" contains "2")
 func containsDay(days string, day string) bool {
 	for _, d := range days {
@@ -0,0 +1,297 @@
+package promquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Sample is a single value of an instant query result
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Client runs instant queries against the Prometheus HTTP API
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a new Prometheus query client
+func NewClient(baseURL string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string          `json:"resultType"`
+		Result     json.RawMessage `json:"result"`
+	} `json:"data"`
+}
+
+type vectorSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  [2]interface{}    `json:"value"`
+}
+
+// Query runs an instant query and returns its samples. Scalar results are
+// returned as a single sample without labels.
+func (c *Client) Query(ctx context.Context, query string) ([]Sample, error) {
+	u := fmt.Sprintf("%s/api/v1/query?query=%s", c.baseURL, url.QueryEscape(query))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var qr queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&qr); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %v", err)
+	}
+	if qr.Status != "success" {
+		return nil, fmt.Errorf("query failed: %s", qr.Error)
+	}
+
+	switch qr.Data.ResultType {
+	case "vector":
+		var vector []vectorSample
+		if err := json.Unmarshal(qr.Data.Result, &vector); err != nil {
+			return nil, fmt.Errorf("failed to decode vector result: %v", err)
+		}
+		samples := make([]Sample, 0, len(vector))
+		for _, v := range vector {
+			value, err := parseValue(v.Value)
+			if err != nil {
+				return nil, err
+			}
+			samples = append(samples, Sample{Labels: v.Metric, Value: value})
+		}
+		return samples, nil
+	case "scalar":
+		var scalar [2]interface{}
+		if err := json.Unmarshal(qr.Data.Result, &scalar); err != nil {
+			return nil, fmt.Errorf("failed to decode scalar result: %v", err)
+		}
+		value, err := parseValue(scalar)
+		if err != nil {
+			return nil, err
+		}
+		return []Sample{{Value: value}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported result type: %s", qr.Data.ResultType)
+	}
+}
+
+func parseValue(v [2]interface{}) (float64, error) {
+	s, ok := v[1].(string)
+	if !ok {
+		return 0, fmt.Errorf("invalid sample value: %v", v[1])
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid sample value: %v", err)
+	}
+	return value, nil
+}
+
+// Gauge periodically evaluates a query reducing it to a single value (the maximum
+// across returned samples) and caches the result
+type Gauge struct {
+	client   *Client
+	query    string
+	interval time.Duration
+	maxAge   time.Duration
+	now      func() time.Time
+
+	mutex     sync.RWMutex
+	value     float64
+	updatedAt time.Time
+}
+
+// NewGauge creates a new Gauge. Values older than maxAge are treated as unavailable.
+func NewGauge(client *Client, query string, interval, maxAge time.Duration, now func() time.Time) *Gauge {
+	return &Gauge{
+		client:   client,
+		query:    query,
+		interval: interval,
+		maxAge:   maxAge,
+		now:      now,
+	}
+}
+
+// Run evaluates the query until stopCh is closed
+func (g *Gauge) Run(ctx context.Context, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := g.update(ctx); err != nil {
+			klog.V(2).InfoS("Failed to evaluate Prometheus query", "query", g.query, "error", err)
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Value returns the latest value if it is fresh
+func (g *Gauge) Value() (float64, bool) {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	if g.updatedAt.IsZero() || g.now().Sub(g.updatedAt) > g.maxAge {
+		return 0, false
+	}
+	return g.value, true
+}
+
+// Set records a value
+func (g *Gauge) Set(value float64) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.value = value
+	g.updatedAt = g.now()
+}
+
+func (g *Gauge) update(ctx context.Context) error {
+	samples, err := g.client.Query(ctx, g.query)
+	if err != nil {
+		return err
+	}
+	if len(samples) == 0 {
+		return fmt.Errorf("query returned no samples")
+	}
+
+	max := samples[0].Value
+	for _, s := range samples[1:] {
+		if s.Value > max {
+			max = s.Value
+		}
+	}
+	g.Set(max)
+	return nil
+}
+
+// Vector periodically evaluates a query and caches its samples keyed by the value
+// of one of their labels, such as the node they were scraped from
+type Vector struct {
+	client   *Client
+	query    string
+	labels   []string
+	interval time.Duration
+	maxAge   time.Duration
+	now      func() time.Time
+
+	mutex     sync.RWMutex
+	values    map[string]float64
+	updatedAt time.Time
+}
+
+// NewVector creates a new Vector keying samples by label. Values older than maxAge
+// are treated as unavailable.
+func NewVector(client *Client, query, label string, interval, maxAge time.Duration, now func() time.Time) *Vector {
+	return NewVectorByLabels(client, query, []string{label}, interval, maxAge, now)
+}
+
+// NewVectorByLabels creates a new Vector keying samples by the values of several
+// labels joined with "/", such as namespace/pod. Samples missing any of the labels
+// are dropped.
+func NewVectorByLabels(client *Client, query string, labels []string, interval, maxAge time.Duration, now func() time.Time) *Vector {
+	return &Vector{
+		client:   client,
+		query:    query,
+		labels:   labels,
+		interval: interval,
+		maxAge:   maxAge,
+		now:      now,
+	}
+}
+
+// Run evaluates the query until stopCh is closed
+func (v *Vector) Run(ctx context.Context, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(v.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := v.update(ctx); err != nil {
+			klog.V(2).InfoS("Failed to evaluate Prometheus query", "query", v.query, "error", err)
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Value returns the latest value for a key if it is fresh
+func (v *Vector) Value(key string) (float64, bool) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	if v.updatedAt.IsZero() || v.now().Sub(v.updatedAt) > v.maxAge {
+		return 0, false
+	}
+	value, ok := v.values[key]
+	return value, ok
+}
+
+// Set replaces the cached values
+func (v *Vector) Set(values map[string]float64) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	v.values = values
+	v.updatedAt = v.now()
+}
+
+func (v *Vector) update(ctx context.Context) error {
+	samples, err := v.client.Query(ctx, v.query)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]float64, len(samples))
+	for _, s := range samples {
+		if key, ok := v.key(s); ok {
+			values[key] = s.Value
+		}
+	}
+	v.Set(values)
+	return nil
+}
+
+// key returns the key of a sample, if it carries all the labels
+func (v *Vector) key(s Sample) (string, bool) {
+	values := make([]string, 0, len(v.labels))
+	for _, label := range v.labels {
+		value := s.Labels[label]
+		if value == "" {
+			return "", false
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, "/"), true
+}
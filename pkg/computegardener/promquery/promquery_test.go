@@ -0,0 +1,134 @@
+package promquery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []float64
+		wantErr bool
+	}{
+		{
+			name: "vector result",
+			body: `{"status":"success","data":{"resultType":"vector","result":[` +
+				`{"metric":{"room":"a"},"value":[1700000000,"24.5"]},` +
+				`{"metric":{"room":"b"},"value":[1700000000,"27"]}]}}`,
+			want: []float64{24.5, 27},
+		},
+		{
+			name: "scalar result",
+			body: `{"status":"success","data":{"resultType":"scalar","result":[1700000000,"0.82"]}}`,
+			want: []float64{0.82},
+		},
+		{
+			name:    "query error",
+			body:    `{"status":"error","error":"parse error"}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if got := r.URL.Query().Get("query"); got != "max(dc_temperature)" {
+					t.Errorf("query = %q, want %q", got, "max(dc_temperature)")
+				}
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			samples, err := NewClient(server.URL, time.Second).Query(context.Background(), "max(dc_temperature)")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Query() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(samples) != len(tt.want) {
+				t.Fatalf("Query() returned %d samples, want %d", len(samples), len(tt.want))
+			}
+			for i, s := range samples {
+				if s.Value != tt.want[i] {
+					t.Errorf("Query() sample %d = %v, want %v", i, s.Value, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` +
+			`{"metric":{"node":"node-a"},"value":[1700000000,"310.5"]},` +
+			`{"metric":{"node":"node-b"},"value":[1700000000,"120"]},` +
+			`{"metric":{"instance":"10.0.0.3:9100"},"value":[1700000000,"99"]}]}}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	vector := NewVector(NewClient(server.URL, time.Second), "node_power_watts", "node", time.Minute, 5*time.Minute,
+		func() time.Time { return now })
+
+	if _, ok := vector.Value("node-a"); ok {
+		t.Error("Value() before the first update should be unavailable")
+	}
+	if err := vector.update(context.Background()); err != nil {
+		t.Fatalf("update() error = %v", err)
+	}
+
+	tests := []struct {
+		key    string
+		want   float64
+		wantOK bool
+	}{
+		{key: "node-a", want: 310.5, wantOK: true},
+		{key: "node-b", want: 120, wantOK: true},
+		{key: "node-c", wantOK: false},
+	}
+	for _, tt := range tests {
+		if got, ok := vector.Value(tt.key); ok != tt.wantOK || got != tt.want {
+			t.Errorf("Value(%q) = %v, %v, want %v, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	now = now.Add(10 * time.Minute)
+	if _, ok := vector.Value("node-a"); ok {
+		t.Error("Value() of stale samples should be unavailable")
+	}
+}
+
+func TestVectorByLabels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[` +
+			`{"metric":{"namespace":"team-a","pod":"job"},"value":[1700000000,"12.5"]},` +
+			`{"metric":{"namespace":"team-b","pod":"job"},"value":[1700000000,"40"]},` +
+			`{"metric":{"pod":"orphan"},"value":[1700000000,"3"]}]}}`))
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	vector := NewVectorByLabels(NewClient(server.URL, time.Second), "pod_power_watts", []string{"namespace", "pod"},
+		time.Minute, 5*time.Minute, func() time.Time { return now })
+	if err := vector.update(context.Background()); err != nil {
+		t.Fatalf("update() error = %v", err)
+	}
+
+	tests := []struct {
+		key    string
+		want   float64
+		wantOK bool
+	}{
+		{key: "team-a/job", want: 12.5, wantOK: true},
+		{key: "team-b/job", want: 40, wantOK: true},
+		{key: "orphan", wantOK: false},
+	}
+	for _, tt := range tests {
+		if got, ok := vector.Value(tt.key); ok != tt.wantOK || got != tt.want {
+			t.Errorf("Value(%q) = %v, %v, want %v, %v", tt.key, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package computegardener
+
+// pue returns the power usage effectiveness of the facility hosting a node, from
+// the PUE of its grid zone or else the cluster's
+func (cs *CarbonAwareScheduler) pue(nodeName string) float64 {
+	pue, ok := cs.config.Power.ZonePUE[cs.nodeZone(nodeName)]
+	if !ok {
+		pue = cs.config.Power.PUE
+	}
+	// An unset PUE counts IT energy only
+	return max(pue, 1)
+}
@@ -0,0 +1,58 @@
+package computegardener
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/queuesort"
+)
+
+// Less orders pending pods by priority, as the in-tree PrioritySort does, and pods of
+// the same priority by their scheduling deadline, so that pods closest to it are tried
+// first when a window opens. The queue needs an order that doesn't change while pods
+// wait, so the deadline is taken from the pod alone: policies, workload classes,
+// namespace defaults and learned durations are left out.
+func (cs *CarbonAwareScheduler) Less(pInfo1, pInfo2 *framework.QueuedPodInfo) bool {
+	if podPriority(pInfo1.Pod) == podPriority(pInfo2.Pod) {
+		d1 := cs.queueDeadline(pInfo1.Pod)
+		d2 := cs.queueDeadline(pInfo2.Pod)
+		if !d1.Equal(d2) {
+			return d1.Before(d2)
+		}
+	}
+
+	s := &queuesort.PrioritySort{}
+	return s.Less(pInfo1, pInfo2)
+}
+
+// queueDeadline returns the deadline a pod is ordered by in the queue: its creation
+// time plus its max-delay annotation or the configured maximum scheduling delay, or
+// its latest start for its deadline and estimated-duration annotations if earlier
+func (cs *CarbonAwareScheduler) queueDeadline(pod *v1.Pod) time.Time {
+	delay := cs.config.Scheduling.MaxSchedulingDelay
+	if d, err := time.ParseDuration(pod.Annotations[maxDelayAnnotation]); err == nil && d >= 0 {
+		delay = d
+	}
+	deadline := pod.CreationTimestamp.Add(delay)
+
+	finish, err := time.Parse(time.RFC3339, pod.Annotations[deadlineAnnotation])
+	if err != nil {
+		return deadline
+	}
+	if d, err := time.ParseDuration(pod.Annotations[estimatedDurationAnnotation]); err == nil && d >= 0 {
+		finish = finish.Add(-d)
+	}
+	if finish.Before(deadline) {
+		return finish
+	}
+	return deadline
+}
+
+// podPriority returns the priority of a pod, 0 if unset
+func podPriority(pod *v1.Pod) int32 {
+	if pod.Spec.Priority != nil {
+		return *pod.Spec.Priority
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package computegardener
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/api/v1/resource"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// checkQuotaBorrowing holds pods that would take their namespace's usage over the
+// min of its ElasticQuota, borrowing capacity from other quotas up to its max, while
+// the carbon intensity of their zone is above the borrowing limit. Pods within their
+// guaranteed min are left to the capacityscheduling plugin, so quota elasticity
+// absorbs clean energy but not dirty.
+func (cs *CarbonAwareScheduler) checkQuotaBorrowing(ctx context.Context, pod *v1.Pod) *framework.Status {
+	limit := cs.config.Policy.QuotaBorrowingMaxIntensity
+	if cs.quotas == nil || limit <= 0 {
+		return framework.NewStatus(framework.Success, "")
+	}
+	quota, ok := cs.quotas.Get(pod.Namespace)
+	if !ok || len(quota.Spec.Min) == 0 {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	zone := cs.podZone(pod)
+	data, err := cs.getZoneCarbonIntensityData(ctx, zone)
+	if err != nil {
+		// The carbon intensity check reports the error
+		return framework.NewStatus(framework.Success, "")
+	}
+	intensity := cs.effectiveIntensity(zone, data)
+	if intensity <= limit {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	over := cs.overQuotaMin(pod, quota.Spec.Min)
+	if len(over) == 0 {
+		return framework.NewStatus(framework.Success, "")
+	}
+	cs.countAttempt("quota_borrowing")
+	klog.V(2).InfoS("Holding pod borrowing beyond its elastic quota", "pod", klog.KObj(pod),
+		"quota", klog.KObj(quota), "resources", over, "intensity", intensity, "limit", limit)
+	return framework.NewStatus(framework.Unschedulable, fmt.Sprintf(
+		"Elastic quota %s borrowing of %s paused while carbon intensity (%.2f) exceeds borrowing limit (%.2f)",
+		quota.Name, strings.Join(over, ", "), intensity, limit))
+}
+
+// overQuotaMin returns the resources of a quota's min that the requests of a pod,
+// added to those of the pods already assigned in its namespace, would exceed
+func (cs *CarbonAwareScheduler) overQuotaMin(pod *v1.Pod, min v1.ResourceList) []string {
+	used := v1.ResourceList{}
+	if cs.podLister != nil {
+		pods, err := cs.podLister.Pods(pod.Namespace).List(labels.Everything())
+		if err != nil {
+			klog.ErrorS(err, "Failed to list pods for elastic quota usage", "namespace", pod.Namespace)
+		}
+		for _, p := range pods {
+			if p.UID == pod.UID || p.Spec.NodeName == "" ||
+				p.Status.Phase == v1.PodSucceeded || p.Status.Phase == v1.PodFailed {
+				continue
+			}
+			addResources(used, resource.PodRequests(p, resource.PodResourcesOptions{}))
+		}
+	}
+	addResources(used, resource.PodRequests(pod, resource.PodResourcesOptions{}))
+
+	var over []string
+	for name, guaranteed := range min {
+		if quantity, ok := used[name]; ok && quantity.Cmp(guaranteed) > 0 {
+			over = append(over, string(name))
+		}
+	}
+	sort.Strings(over)
+	return over
+}
+
+// addResources adds the quantities of b to a
+func addResources(a, b v1.ResourceList) {
+	for name, quantity := range b {
+		sum := a[name]
+		sum.Add(quantity)
+		a[name] = sum
+	}
+}
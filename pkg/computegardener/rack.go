@@ -0,0 +1,119 @@
+package computegardener
+
+import (
+	"fmt"
+	"strconv"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+const (
+	// rackLabel identifies the rack or PDU a node draws power from
+	rackLabel = "carbon-aware-scheduler.kubernetes.io/rack"
+	// rackPowerBudgetLabel is the power budget of a node's rack in watts
+	rackPowerBudgetLabel = "carbon-aware-scheduler.kubernetes.io/rack-power-budget"
+
+	// rackStateKey is the CycleState key rack power is stored under
+	rackStateKey framework.StateKey = Name + "/rack"
+)
+
+// rackState holds the estimated power draw and budget of each rack with a budget
+type rackState struct {
+	power  map[string]float64
+	budget map[string]float64
+}
+
+// Clone implements framework.StateData. The state is never modified once written.
+func (s *rackState) Clone() framework.StateData {
+	return s
+}
+
+// writeRackState sums the estimated power of the nodes of each rack with a budget,
+// once per scheduling cycle
+func (cs *CarbonAwareScheduler) writeRackState(state *framework.CycleState) {
+	if state == nil || !cs.config.Power.RackBudgetsEnabled {
+		return
+	}
+
+	nodes, err := cs.handle.SnapshotSharedLister().NodeInfos().List()
+	if err != nil {
+		klog.ErrorS(err, "Failed to list nodes for rack power budgets")
+		return
+	}
+
+	s := &rackState{power: make(map[string]float64), budget: make(map[string]float64)}
+	for _, nodeInfo := range nodes {
+		node := nodeInfo.Node()
+		if node == nil || node.Labels[rackLabel] == "" {
+			continue
+		}
+		rack := node.Labels[rackLabel]
+		s.power[rack] += cs.rackNodePower(nodeInfo)
+
+		// Nodes of a rack may disagree on its budget, the lowest applies
+		if budget, ok := rackPowerBudget(node); ok {
+			if current, seen := s.budget[rack]; !seen || budget < current {
+				s.budget[rack] = budget
+			}
+		}
+	}
+	state.Write(rackStateKey, s)
+}
+
+// rackNodePower returns the power a node draws from its rack: the power measured at
+// the wall by its PDU outlets or BMC, or the power requested by its pods if higher,
+// as pods just bound may not draw power yet
+func (cs *CarbonAwareScheduler) rackNodePower(nodeInfo *framework.NodeInfo) float64 {
+	requested := cs.requestedPower(nodeInfo, 0)
+	name := nodeInfo.Node().Name
+	if !cs.energySource.WallPower(name) {
+		return requested
+	}
+	if measured, ok := cs.energySource.NodePower(name); ok {
+		return max(measured, requested)
+	}
+	return requested
+}
+
+// rackPowerBudget returns the rack power budget declared on a node
+func rackPowerBudget(node *v1.Node) (float64, bool) {
+	val, ok := node.Labels[rackPowerBudgetLabel]
+	if !ok {
+		return 0, false
+	}
+	budget, err := strconv.ParseFloat(val, 64)
+	if err != nil || budget <= 0 {
+		klog.V(2).InfoS("Ignoring invalid rack power budget label", "node", node.Name, "value", val)
+		return 0, false
+	}
+	return budget, true
+}
+
+// checkRackBudget rejects nodes if placing the pod would take the estimated power
+// draw of their rack over its budget
+func (cs *CarbonAwareScheduler) checkRackBudget(state *framework.CycleState, pod *v1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	if !cs.config.Power.RackBudgetsEnabled {
+		return nil
+	}
+	data, err := state.Read(rackStateKey)
+	if err != nil {
+		return nil
+	}
+	s := data.(*rackState)
+
+	rack := nodeInfo.Node().Labels[rackLabel]
+	budget, ok := s.budget[rack]
+	if rack == "" || !ok {
+		return nil
+	}
+
+	added := cs.requestedPower(nodeInfo, podMilliCPU(pod)) - cs.requestedPower(nodeInfo, 0)
+	if s.power[rack]+added > budget {
+		cs.countAttempt("rack_power_budget")
+		return framework.NewStatus(framework.Unschedulable,
+			fmt.Sprintf("rack %s would exceed its power budget (%.0fW of %.0fW)", rack, s.power[rack]+added, budget))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package computegardener
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// batchReleaser limits the number of held pods released per interval, so that pods
+// held for a low-carbon window don't all start at the window boundary.
+// A size of 0 leaves releases unlimited.
+type batchReleaser struct {
+	mu          sync.Mutex
+	size        int
+	weekendSize int
+	interval    time.Duration
+
+	windowStart time.Time
+	released    int
+}
+
+func newBatchReleaser(size, weekendSize int, interval time.Duration) *batchReleaser {
+	return &batchReleaser{
+		size:        size,
+		weekendSize: weekendSize,
+		interval:    interval,
+	}
+}
+
+// sizeAt returns the batch size for the day of the week of now
+func (r *batchReleaser) sizeAt(now time.Time) int {
+	if r.weekendSize > 0 && isWeekend(now) {
+		return r.weekendSize
+	}
+	return r.size
+}
+
+// allow reports whether another pod can be released in the batch current at now
+func (r *batchReleaser) allow(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Sub(r.windowStart) >= r.interval {
+		r.windowStart = now
+		r.released = 0
+	}
+	if size := r.sizeAt(now); size > 0 && r.released >= size {
+		return false
+	}
+	r.released++
+	return true
+}
+
+// available reports whether the batch current at now has room for another pod
+func (r *batchReleaser) available(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	size := r.sizeAt(now)
+	return size == 0 || now.Sub(r.windowStart) >= r.interval || r.released < size
+}
+
+// refund returns a slot taken for a release that didn't go ahead
+func (r *batchReleaser) refund() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.released > 0 {
+		r.released--
+	}
+}
+
+// next returns the start of the next batch
+func (r *batchReleaser) next() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.windowStart.Add(r.interval)
+}
+
+// checkReleaseBatch admits pods that were previously held only while the current
+// release batch has room. The slot is taken when the pod is reserved.
+func (cs *CarbonAwareScheduler) checkReleaseBatch(pod *v1.Pod) *framework.Status {
+	if cs.releaser == nil {
+		return framework.NewStatus(framework.Success, "")
+	}
+	if _, held := cs.heldPods.Load(pod.UID); !held {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	if !cs.releaser.available(cs.clock.Now()) {
+		cs.countAttempt("release_batch_full")
+		return framework.NewStatus(framework.Unschedulable,
+			fmt.Sprintf("Release batch full, next batch at %s", cs.releaser.next().Format(time.RFC3339)))
+	}
+
+	return framework.NewStatus(framework.Success, "")
+}
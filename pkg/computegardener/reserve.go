@@ -0,0 +1,177 @@
+package computegardener
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// admissionStateKey is the CycleState key admission bookkeeping is stored under
+const admissionStateKey framework.StateKey = Name + "/admission"
+
+// admissionState records the admission budget a pod uses, so that it is only
+// consumed once the pod is reserved on a node and returned if the pod is unreserved
+type admissionState struct {
+	// cost is the number of pacing tokens the pod consumes
+	cost float64
+	// released is set if the pod takes a slot in the current release batch
+	released bool
+	// reserved is set once the budget has been consumed
+	reserved bool
+	// wait is set if the pod is held at Permit until its constraints clear
+	wait bool
+	// slot is set if the pod holds a concurrency slot
+	slot bool
+	// nominated is set for preemptor pods, which consume no budget
+	nominated bool
+	// deferBind is set if a waiting pod is held at Bind rather than at Permit
+	deferBind bool
+
+	// zone and intensity record the grid zone of the node a pod was reserved on and
+	// its carbon intensity at the time, for the later stages of the cycle
+	zone      string
+	intensity float64
+}
+
+// Clone implements framework.StateData
+func (s *admissionState) Clone() framework.StateData {
+	copy := *s
+	return &copy
+}
+
+// writeAdmissionState records the admission budget of a pod admitted in PreFilter.
+// Pods held at Permit and nominated pods don't consume any budget.
+func (cs *CarbonAwareScheduler) writeAdmissionState(state *framework.CycleState, pod *v1.Pod, wait bool) {
+	if state == nil {
+		return
+	}
+	if wait {
+		state.Write(admissionStateKey, &admissionState{wait: true, deferBind: cs.defersBind(pod)})
+		return
+	}
+	if isNominated(pod) {
+		state.Write(admissionStateKey, &admissionState{nominated: true})
+		return
+	}
+	_, held := cs.heldPods.Load(pod.UID)
+	state.Write(admissionStateKey, &admissionState{
+		cost:     cs.admissionCost(pod),
+		released: held && cs.releaser != nil,
+	})
+}
+
+// takeWaitingBudget consumes the admission budget of a pod held at Permit or Bind as
+// it is allowed, which it didn't consume when reserved, recording it in s to be
+// returned if the pod is unreserved. Unless forced, it reports false without
+// consuming anything while the admission rate, release batch or concurrency limit
+// is reached, so held pods aren't all released at once when their constraints clear.
+func (cs *CarbonAwareScheduler) takeWaitingBudget(pod *v1.Pod, s *admissionState, force bool) bool {
+	if !force {
+		for _, check := range []func(*v1.Pod) *framework.Status{cs.checkAdmissionRate, cs.checkReleaseBatch, cs.checkConcurrency} {
+			if status := check(pod); !status.IsSuccess() {
+				return false
+			}
+		}
+	}
+
+	now := cs.clock.Now()
+	if _, held := cs.heldPods.LoadAndDelete(pod.UID); held && cs.releaser != nil {
+		cs.releaser.allow(now)
+		s.released = true
+	}
+	s.cost = cs.admissionCost(pod)
+	if cs.pacer != nil {
+		cs.pacer.take(now, s.cost)
+	}
+	if cs.slots != nil {
+		cs.slots.take(pod.UID)
+		s.slot = true
+	}
+	return true
+}
+
+// claimPermitBudget moves the admission budget taken for a pod allowed at Permit
+// into its cycle state, so it is returned if the pod is unreserved
+func (cs *CarbonAwareScheduler) claimPermitBudget(pod *v1.Pod, s *admissionState) {
+	if !s.wait {
+		return
+	}
+	if val, ok := cs.permitBudgets.LoadAndDelete(pod.UID); ok {
+		budget := val.(*admissionState)
+		s.cost, s.released, s.slot = budget.cost, budget.released, budget.slot
+	}
+}
+
+func readAdmissionState(state *framework.CycleState) (*admissionState, bool) {
+	if state == nil {
+		return nil, false
+	}
+	data, err := state.Read(admissionStateKey)
+	if err != nil {
+		return nil, false
+	}
+	s, ok := data.(*admissionState)
+	return s, ok
+}
+
+// Reserve implements the Reserve interface. It consumes the pod's admission budget
+// now that the pod has a node, and records the carbon intensity it is placed under.
+func (cs *CarbonAwareScheduler) Reserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) *framework.Status {
+	s, ok := readAdmissionState(state)
+	if !ok {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	now := cs.clock.Now()
+	if s.released {
+		cs.releaser.allow(now)
+		cs.heldPods.Delete(pod.UID)
+	}
+	if cs.pacer != nil {
+		cs.pacer.take(now, s.cost)
+	}
+	// Pods held at Permit or Bind and nominated pods take no slot, like they consume
+	// no other budget. Held pods consume theirs once allowed.
+	if cs.slots != nil && !s.wait && !s.nominated {
+		cs.slots.take(pod.UID)
+		s.slot = true
+	}
+	s.zone = cs.nodeZone(nodeName)
+	if data, ok := cs.cache.Get(s.zone); ok {
+		s.intensity = cs.effectiveIntensity(s.zone, data)
+	}
+	s.reserved = true
+
+	return framework.NewStatus(framework.Success, "")
+}
+
+// Unreserve implements the Reserve interface. It returns the admission budget of a
+// pod rejected at Permit or failing to bind, so the budget isn't leaked.
+func (cs *CarbonAwareScheduler) Unreserve(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
+	s, ok := readAdmissionState(state)
+	cs.gangActivations.Delete(pod.UID)
+	if !ok || !s.reserved {
+		cs.permitBudgets.Delete(pod.UID)
+		return
+	}
+	cs.claimPermitBudget(pod, s)
+	cs.permitBudgets.Delete(pod.UID)
+
+	if s.released {
+		cs.releaser.refund()
+		cs.heldPods.Store(pod.UID, struct{}{})
+	}
+	if cs.pacer != nil {
+		cs.pacer.refund(s.cost)
+	}
+	if s.slot {
+		cs.slots.release(pod.UID)
+		s.slot = false
+	}
+	s.zone, s.intensity = "", 0
+	s.reserved = false
+
+	klog.V(4).InfoS("Returned admission budget", "pod", klog.KObj(pod), "node", nodeName)
+}
@@ -2,8 +2,8 @@ package computegardener
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"net/http"
 	"strconv"
 	"sync"
 	"time"
@@ -11,17 +11,17 @@ import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
-	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
-	metricsv1beta1 "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
 
 	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
-	schedulercache "sigs.k8s.io/scheduler-plugins/pkg/computegardener/cache"
 	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/clock"
 	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
-	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/pricing"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/gridalert"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/history"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/pricing/tou"
 )
 
 const (
@@ -34,27 +34,73 @@ type CarbonAwareScheduler struct {
 	handle framework.Handle
 	config *config.Config
 
-	// Components
-	apiClient     *api.Client
-	cache         *schedulercache.Cache
-	pricingImpl   pricing.Implementation
-	clock         clock.Clock
-	metricsClient metricsv1beta1.MetricsV1beta1Interface
-
-	// Metric value cache
-	powerMetrics sync.Map // map[string]float64 - key format: "nodeName/podName/phase"
+	// Data layer, shared by the instances of every profile
+	*dataLayer
+
+	clock      clock.Clock
+	hysteresis *hysteresis
+
+	namespaceLister corelisters.NamespaceLister
+
+	// Pods held by admission constraints, released in batches if enabled
+	heldPods sync.Map       // map[types.UID]struct{}
+	releaser *batchReleaser // nil if batch release is disabled
+	// Admission budget taken by pods allowed at Permit, until their binding claims it
+	permitBudgets sync.Map // map[types.UID]*admissionState
+	// Policies resolved for pods in their last scheduling cycle
+	resolvedPolicies sync.Map // map[types.UID]*policyState
+
+	// Last carbon or price verdict of delayed pods, reused until their data changes
+	backoff sync.Map // map[types.UID]*backoffEntry
+	// Release times of coscheduling PodGroups with an admitted member
+	gangReleases sync.Map // map[string]time.Time - namespace/name to release time
+	// Pods to activate at the end of the cycles of PodGroup members waiting at Permit
+	gangActivations sync.Map // map[types.UID]*framework.PodsToActivate
+	// Lister of the pods of released PodGroups, nil unless gang release is enabled
+	gangPods corelisters.PodLister
+	// Intensity delayed pods were first delayed at, until they are bound
+	initialIntensity sync.Map // map[types.UID]float64
+
+	// Admission pacing, nil if disabled
+	pacer *tokenBucket
+	// Concurrency slots of admitted pods, nil if disabled
+	slots *concurrencySlots
+
+	// Pod patches made outside the scheduling cycle that haven't completed
+	patches sync.WaitGroup
+
+	// Set if the data layer is owned by the plugin instance of another profile
+	sharesData bool
+	// Set on instances evaluating pods without scheduling them, which record no
+	// events, annotations or metrics
+	dryRun bool
 
 	// Shutdown
-	stopCh chan struct{}
+	stopCh    chan struct{}
+	closeOnce sync.Once
 }
 
 var (
-	_ framework.PreFilterPlugin = &CarbonAwareScheduler{}
-	_ framework.PostBindPlugin  = &CarbonAwareScheduler{}
-	_ framework.Plugin          = &CarbonAwareScheduler{}
+	_ framework.QueueSortPlugin   = &CarbonAwareScheduler{}
+	_ framework.PreEnqueuePlugin  = &CarbonAwareScheduler{}
+	_ framework.EnqueueExtensions = &CarbonAwareScheduler{}
+	_ framework.PreFilterPlugin   = &CarbonAwareScheduler{}
+	_ framework.FilterPlugin      = &CarbonAwareScheduler{}
+	_ framework.PostFilterPlugin  = &CarbonAwareScheduler{}
+	_ framework.ScorePlugin       = &CarbonAwareScheduler{}
+	_ framework.ScoreExtensions   = &CarbonAwareScheduler{}
+	_ framework.ReservePlugin     = &CarbonAwareScheduler{}
+	_ framework.PreScorePlugin    = &CarbonAwareScheduler{}
+	_ framework.PermitPlugin      = &CarbonAwareScheduler{}
+	_ framework.PreBindPlugin     = &CarbonAwareScheduler{}
+	_ framework.BindPlugin        = &CarbonAwareScheduler{}
+	_ framework.PostBindPlugin    = &CarbonAwareScheduler{}
+	_ framework.Plugin            = &CarbonAwareScheduler{}
 )
 
-// New initializes a new plugin and returns it
+// New initializes a new plugin and returns it. The plugin may be enabled in several
+// profiles: the first instance creates the data layer, which the instances of the
+// other profiles share.
 func New(ctx context.Context, obj runtime.Object, h framework.Handle) (framework.Plugin, error) {
 	// Load and validate configuration
 	cfg, err := config.Load(obj)
@@ -62,78 +108,87 @@ func New(ctx context.Context, obj runtime.Object, h framework.Handle) (framework
 		return nil, fmt.Errorf("failed to load config: %v", err)
 	}
 
-	// Initialize components
-	apiClient := api.NewClient(cfg.API)
-	dataCache := schedulercache.New(cfg.API.CacheTTL, cfg.API.MaxCacheAge)
+	scheduler := &CarbonAwareScheduler{
+		handle:     h,
+		config:     cfg,
+		clock:      clock.RealClock{},
+		hysteresis: newHysteresis(),
+		stopCh:     make(chan struct{}),
 
-	// Initialize pricing implementation if enabled
-	pricingImpl, err := pricing.Factory(cfg.Pricing)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize pricing implementation: %v", err)
+		namespaceLister: h.SharedInformerFactory().Core().V1().Namespaces().Lister(),
 	}
 
-	// Initialize metrics client
-	metricsClient, err := metricsv1beta1.NewForConfig(h.KubeConfig())
-	if err != nil {
-		return nil, fmt.Errorf("failed to create metrics client: %v", err)
+	sharedDataMu.Lock()
+	defer sharedDataMu.Unlock()
+	if sharedData != nil {
+		klog.InfoS("Sharing carbon-aware data layer", "profile", profileName(h), "owner", profileName(sharedData.handle))
+		scheduler.shareDataLayer(sharedData)
+	} else {
+		if err := scheduler.startDataLayer(ctx, h); err != nil {
+			return nil, err
+		}
+		sharedData = scheduler
 	}
 
-	scheduler := &CarbonAwareScheduler{
-		handle:        h,
-		config:        cfg,
-		apiClient:     apiClient,
-		cache:         dataCache,
-		pricingImpl:   pricingImpl,
-		clock:         clock.RealClock{},
-		metricsClient: metricsClient,
-		stopCh:        make(chan struct{}),
+	if cfg.Scheduling.ReleaseBatchSize > 0 || cfg.Scheduling.WeekendReleaseBatchSize > 0 {
+		scheduler.releaser = newBatchReleaser(cfg.Scheduling.ReleaseBatchSize, cfg.Scheduling.WeekendReleaseBatchSize,
+			cfg.Scheduling.ReleaseBatchInterval)
+	}
+
+	if cfg.Scheduling.WaitMode == config.WaitModePermit {
+		go scheduler.permitWorker()
+	}
+
+	if cfg.Scheduling.GangReleaseTTL > 0 {
+		scheduler.gangPods = h.SharedInformerFactory().Core().V1().Pods().Lister()
+		go scheduler.gangWorker()
 	}
 
-	// Start health check worker
-	go scheduler.healthCheckWorker(ctx)
+	if cfg.Scheduling.AdmissionRate > 0 {
+		scheduler.pacer = newTokenBucket(cfg.Scheduling.AdmissionRate, cfg.Scheduling.AdmissionBurst, scheduler.clock.Now())
+	}
 
-	// Register pod informer to track completion
+	if cfg.Scheduling.MaxConcurrentPods > 0 || cfg.Scheduling.WeekendMaxConcurrentPods > 0 || cfg.GridAlert.MaxConcurrentPods > 0 {
+		scheduler.slots = newConcurrencySlots()
+	}
+
+	// Track the pods of this profile's admission state
 	h.SharedInformerFactory().Core().V1().Pods().Informer().AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			UpdateFunc: func(oldObj, newObj interface{}) {
 				oldPod := oldObj.(*v1.Pod)
 				newPod := newObj.(*v1.Pod)
 
-				// Check if pod has completed
-				if oldPod.Status.Phase != v1.PodSucceeded && newPod.Status.Phase == v1.PodSucceeded {
-					scheduler.handlePodCompletion(newPod)
+				if !isFinished(oldPod) && isFinished(newPod) {
+					scheduler.releaseSlot(newPod)
 				}
-			},
-		},
-	)
 
-	// Register shutdown handler
-	h.SharedInformerFactory().Core().V1().Nodes().Informer().AddEventHandler(
-		cache.ResourceEventHandlerFuncs{
+				// Check if pod has been released by an operator
+				if !scheduler.isReleased(oldPod) && scheduler.isReleased(newPod) {
+					scheduler.handleRelease(newPod)
+				}
+
+				// Re-evaluate pods whose annotations changed
+				if annotationsChanged(oldPod, newPod) {
+					scheduler.backoff.Delete(newPod.UID)
+				}
+			},
 			DeleteFunc: func(obj interface{}) {
-				klog.V(2).InfoS("Handling shutdown", "plugin", scheduler.Name())
-				scheduler.Close()
+				if pod, ok := deletedPod(obj); ok {
+					scheduler.heldPods.Delete(pod.UID)
+					scheduler.permitBudgets.Delete(pod.UID)
+					scheduler.gangActivations.Delete(pod.UID)
+					scheduler.resolvedPolicies.Delete(pod.UID)
+					scheduler.backoff.Delete(pod.UID)
+					scheduler.releaseSlot(pod)
+					scheduler.initialIntensity.Delete(pod.UID)
+					scheduler.delayStatus.forget(pod.UID)
+				}
 			},
 		},
 	)
 
-	// Start metrics server (insecure) on a separate mux
-	go func() {
-		metricsPort := fmt.Sprint(":", scheduler.config.Observability.MetricsPort)
-		metricsMux := http.NewServeMux()
-		metricsMux.Handle("/metrics", legacyregistry.Handler())
-
-		metricsServer := &http.Server{
-			Addr:    metricsPort,
-			Handler: metricsMux,
-		}
-
-		klog.InfoS("Starting metrics server", "addr", metricsPort)
-		if err := metricsServer.ListenAndServe(); err != nil {
-			klog.ErrorS(err, "Failed to start metrics server")
-		}
-	}()
-
+	profiles.Store(profileName(h), scheduler)
 	return scheduler, nil
 }
 
@@ -149,27 +204,180 @@ func (cs *CarbonAwareScheduler) PreFilter(ctx context.Context, state *framework.
 		PodSchedulingLatency.WithLabelValues("total").Observe(cs.clock.Since(startTime).Seconds())
 	}()
 
+	cs.writePolicyState(state, pod)
+	cs.writeCarbonData(state, pod)
+
+	// Preemptors are not delayed again once their victims are being evicted
+	if isNominated(pod) {
+		cs.countAttempt("nominated")
+		cs.writeAdmissionState(state, pod, false)
+		cs.writeRackState(state)
+		return nil, framework.NewStatus(framework.Success, "nominated by preemption")
+	}
+
+	// Admit the members of released pod groups along with the first
+	if cs.gangReleased(pod) {
+		cs.countAttempt("pod_group_released")
+		cs.writeAdmissionState(state, pod, false)
+		cs.writeRackState(state)
+		return nil, framework.NewStatus(framework.Success, "pod group released")
+	}
+
+	result, status := cs.preFilter(ctx, pod)
+	if status.Code() == framework.Wait {
+		// Hold the pod at Permit, or at Bind if it defers binding, rather than rejecting it
+		cs.writeAdmissionState(state, pod, true)
+		cs.writeRackState(state)
+		return nil, framework.NewStatus(framework.Success, status.Message())
+	}
+	if status.IsSuccess() {
+		// Pace and limit admissions regardless of why the pod was admitted
+		if paced := cs.checkAdmissionRate(pod); !paced.IsSuccess() {
+			status = paced
+		} else if limited := cs.checkConcurrency(pod); !limited.IsSuccess() {
+			status = limited
+		}
+	}
+	if !status.IsSuccess() {
+		writeDelayState(state, status)
+		return nil, status
+	}
+	cs.writeAdmissionState(state, pod, false)
+	cs.writeRackState(state)
+	return result, status
+}
+
+// preFilter decides whether a pod is admitted for scheduling now or held
+func (cs *CarbonAwareScheduler) preFilter(ctx context.Context, pod *v1.Pod) (*framework.PreFilterResult, *framework.Status) {
+	// Hold pods of namespaces that exhausted their carbon budget, regardless of their
+	// delay budget, until the budget resets
+	if status := cs.checkBudget(pod); !status.IsSuccess() {
+		return nil, status
+	}
+
 	// Check if pod has been waiting too long
 	if cs.hasExceededMaxDelay(pod) {
-		SchedulingAttempts.WithLabelValues("max_delay_exceeded").Inc()
+		cs.countAttempt("max_delay_exceeded")
 		return nil, framework.NewStatus(framework.Success, "maximum scheduling delay exceeded")
 	}
 
+	// Check if pod must start now to meet its deadline
+	if latest, ok := cs.latestStart(pod); ok && !cs.clock.Now().Before(latest) {
+		cs.countAttempt("deadline_reached")
+		return nil, framework.NewStatus(framework.Success, "latest start for deadline reached")
+	}
+
 	// Check if pod has annotation to opt-out
 	if cs.isOptedOut(pod) {
-		SchedulingAttempts.WithLabelValues("skipped").Inc()
+		cs.countAttempt("skipped")
 		return nil, framework.NewStatus(framework.Success, "")
 	}
 
+	// Check if pod has been released by an operator
+	if cs.isReleased(pod) {
+		cs.countAttempt("released")
+		return nil, framework.NewStatus(framework.Success, "released by annotation")
+	}
+
+	// Check admission constraints, holding the pod if any is not met
+	result, status := cs.checkAdmission(ctx, pod)
+	if !status.IsSuccess() {
+		if status.Code() == framework.Unschedulable {
+			// In audit mode record the delay that would have applied and admit the pod
+			if cs.enforcementMode(pod) == config.EnforcementModeAudit {
+				cs.countAttempt("audit")
+				klog.V(2).InfoS("Admitting pod in audit mode", "pod", klog.KObj(pod), "reason", status.Message())
+				return nil, framework.NewStatus(framework.Success, "audit mode: "+status.Message())
+			}
+			cs.heldPods.Store(pod.UID, struct{}{})
+			if cs.config.Scheduling.WaitMode == config.WaitModePermit || cs.defersBind(pod) {
+				return nil, framework.NewStatus(framework.Wait, status.Message())
+			}
+		}
+		return nil, status
+	}
+
+	// Release previously held pods in batches
+	if status := cs.checkReleaseBatch(pod); !status.IsSuccess() {
+		return nil, status
+	}
+
+	return result, framework.NewStatus(framework.Success, "")
+}
+
+// checkAdmission checks the grid, facility, price and carbon constraints a pod is
+// admitted under. Pods delayed on carbon intensity in their zone may be restricted
+// to nodes in zones under their threshold instead.
+func (cs *CarbonAwareScheduler) checkAdmission(ctx context.Context, pod *v1.Pod) (*framework.PreFilterResult, *framework.Status) {
+	// Check for active demand response events
+	if status := cs.checkDemandResponse(); !status.IsSuccess() {
+		return nil, status
+	}
+
+	// Check for active grid alerts
+	if status := cs.checkGridAlert(); !status.IsSuccess() {
+		return nil, status
+	}
+
+	// Check on-site generation and battery constraints if enabled
+	if status := cs.checkOnSiteConstraints(); !status.IsSuccess() {
+		return nil, status
+	}
+
+	// Check thermal constraints if enabled
+	if status := cs.checkThermalConstraints(pod); !status.IsSuccess() {
+		return nil, status
+	}
+
+	// Carbon and price gating is suspended during maintenance windows
+	if cs.inMaintenanceWindow() {
+		cs.countAttempt("maintenance_window")
+		return nil, framework.NewStatus(framework.Success, "maintenance window active")
+	}
+
+	// Hold pods borrowing beyond their elastic quota while intensity is high
+	if status := cs.checkQuotaBorrowing(ctx, pod); !status.IsSuccess() {
+		return nil, status
+	}
+
+	// Combine carbon, price and load into a single decision if configured
+	if cs.config.Scheduling.DecisionMode == config.DecisionModeWeighted {
+		return nil, cs.checkWeightedScore(ctx, pod)
+	}
+
+	// Skip re-evaluation while the data a delay was based on is unchanged
+	if status := cs.checkBackoff(pod); !status.IsSuccess() {
+		return nil, status
+	}
+
+	// Hold pods during the peak hours of their carbon policy
+	if status := cs.checkPolicyPeak(pod); !status.IsSuccess() {
+		return nil, status
+	}
+
 	// Check pricing constraints if enabled
 	if cs.config.Pricing.Enabled {
 		if status := cs.checkPricingConstraints(ctx, pod); !status.IsSuccess() {
+			if status.Code() == framework.Unschedulable {
+				start := cs.projectedPriceStart(pod)
+				cs.recordProjectedStart(pod, start, status)
+				cs.backOff(pod, start, status)
+			}
 			return nil, status
 		}
 	}
 
 	// Check carbon intensity constraints
 	if status := cs.checkCarbonIntensityConstraints(ctx, pod); !status.IsSuccess() {
+		if status.Code() == framework.Unschedulable {
+			if nodes := cs.greenZoneNodes(ctx, pod); nodes.Len() > 0 {
+				cs.countAttempt("green_zones")
+				return &framework.PreFilterResult{NodeNames: nodes}, framework.NewStatus(framework.Success, "")
+			}
+			start := cs.projectedCarbonStart(ctx, pod)
+			cs.recordProjectedStart(pod, start, status)
+			cs.backOff(pod, start, status)
+		}
 		return nil, status
 	}
 
@@ -181,24 +389,76 @@ func (cs *CarbonAwareScheduler) PreFilterExtensions() framework.PreFilterExtensi
 	return nil
 }
 
-// Close cleans up resources
+// Close cleans up resources. It is called by the framework on shutdown and is safe
+// to call more than once.
 func (cs *CarbonAwareScheduler) Close() error {
+	cs.closeOnce.Do(cs.close)
+	return nil
+}
+
+func (cs *CarbonAwareScheduler) close() {
 	close(cs.stopCh)
+	if cs.sharesData {
+		return
+	}
+
+	sharedDataMu.Lock()
+	if sharedData == cs {
+		sharedData = nil
+	}
+	sharedDataMu.Unlock()
+
 	cs.apiClient.Close()
 	cs.cache.Close()
-	return nil
+	if cs.history != nil {
+		if err := cs.history.Flush(); err != nil {
+			klog.ErrorS(err, "Failed to flush carbon intensity history")
+		}
+	}
+	cs.flushAccounting()
 }
 
 func (cs *CarbonAwareScheduler) hasExceededMaxDelay(pod *v1.Pod) bool {
-	if creationTime := pod.CreationTimestamp; !creationTime.IsZero() {
-		return cs.clock.Since(creationTime.Time) > cs.config.Scheduling.MaxSchedulingDelay
+	if submitted := submittedAt(pod); !submitted.IsZero() {
+		return cs.clock.Since(submitted) > cs.maxSchedulingDelay(pod)
 	}
 	return false
 }
 
+// isOptedOut reports whether a pod is out of carbon-aware scheduling. In namespaces
+// whose policies enforce inclusion, only exemptions granted by operators apply.
 func (cs *CarbonAwareScheduler) isOptedOut(pod *v1.Pod) bool {
-	return pod.Annotations["carbon-aware-scheduler.kubernetes.io/skip"] == "true" ||
-		pod.Annotations["price-aware-scheduler.kubernetes.io/skip"] == "true"
+	if cs.ownerExempt(pod) || cs.isExempted(pod) {
+		return true
+	}
+	if cs.inclusionEnforced(pod) {
+		return false
+	}
+	return cs.podOptedOut(pod) || cs.namespaceSkipped(pod) || cs.classExempt(pod)
+}
+
+// isReleased reports whether a pod has been released from gating with the release annotation
+func (cs *CarbonAwareScheduler) isReleased(pod *v1.Pod) bool {
+	val, _ := cs.podOverride(pod, releaseAnnotation)
+	return val == "true"
+}
+
+// handleRelease stops holding a pending pod that was released by annotation. The
+// annotation update itself requeues the pod, which is then admitted immediately.
+func (cs *CarbonAwareScheduler) handleRelease(pod *v1.Pod) {
+	if pod.Spec.NodeName != "" {
+		return
+	}
+	if _, held := cs.heldPods.LoadAndDelete(pod.UID); !held {
+		return
+	}
+
+	klog.InfoS("Releasing held pod by annotation", "pod", klog.KObj(pod))
+	cs.handle.EventRecorder().Eventf(pod, nil, v1.EventTypeNormal, "CarbonAwareRelease", "Scheduling",
+		"Released from carbon-aware gating by %s annotation", releaseAnnotation)
+	if wp := cs.handle.GetWaitingPod(pod.UID); wp != nil {
+		wp.Allow(cs.Name())
+	}
 }
 
 func (cs *CarbonAwareScheduler) checkPricingConstraints(ctx context.Context, pod *v1.Pod) *framework.Status {
@@ -208,19 +468,9 @@ func (cs *CarbonAwareScheduler) checkPricingConstraints(ctx context.Context, pod
 
 	rate := cs.pricingImpl.GetCurrentRate(cs.clock.Now())
 
-	// Get threshold from pod annotation, env var, or use off-peak rate as threshold
-	var threshold float64
-	if val, ok := pod.Annotations["price-aware-scheduler.kubernetes.io/price-threshold"]; ok {
-		if t, err := strconv.ParseFloat(val, 64); err == nil {
-			threshold = t
-		} else {
-			return framework.NewStatus(framework.Error, "invalid electricity price threshold annotation")
-		}
-	} else if len(cs.config.Pricing.Schedules) > 0 {
-		// Use off-peak rate as default threshold
-		threshold = cs.config.Pricing.Schedules[0].OffPeakRate
-	} else {
-		return framework.NewStatus(framework.Error, "no pricing schedules configured")
+	threshold, err := cs.priceThreshold(pod)
+	if err != nil {
+		return framework.NewStatus(framework.Error, err.Error())
 	}
 
 	// Record current electricity rate
@@ -228,12 +478,17 @@ func (cs *CarbonAwareScheduler) checkPricingConstraints(ctx context.Context, pod
 	if rate <= threshold {
 		period = "off-peak"
 	}
-	ElectricityRateGauge.WithLabelValues("tou", period).Set(rate)
+	cs.metrics().electricityRate.WithLabelValues("tou", period).Set(rate)
 
 	if rate > threshold {
-		PriceBasedDelays.WithLabelValues(period).Inc()
-		savings := rate - threshold
-		EstimatedSavings.WithLabelValues("cost", "dollars").Add(savings)
+		// Live carbon data may show the grid isn't under the stress peak hours assume
+		if cs.forecastOverridesPeak(ctx, pod) {
+			cs.countAttempt("peak_overridden")
+			return framework.NewStatus(framework.Success, "")
+		}
+
+		cs.metrics().priceDelays.WithLabelValues(period).Inc()
+		cs.metrics().savings.WithLabelValues("cost", "dollars").Add(rate - threshold)
 
 		return framework.NewStatus(
 			framework.Unschedulable,
@@ -246,82 +501,517 @@ func (cs *CarbonAwareScheduler) checkPricingConstraints(ctx context.Context, pod
 	return framework.NewStatus(framework.Success, "")
 }
 
+// inMaintenanceWindow reports whether a configured maintenance window is active
+func (cs *CarbonAwareScheduler) inMaintenanceWindow() bool {
+	now := cs.clock.Now()
+	for _, window := range cs.config.Maintenance.Windows {
+		if tou.InSchedule(window, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// priceThreshold returns the electricity rate threshold that applies to a pod
+func (cs *CarbonAwareScheduler) priceThreshold(pod *v1.Pod) (float64, error) {
+	// Get threshold from pod annotation, env var, or use off-peak rate as threshold
+	if val, ok := pod.Annotations["price-aware-scheduler.kubernetes.io/price-threshold"]; ok {
+		t, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid electricity price threshold annotation")
+		}
+		return t, nil
+	}
+	if len(cs.config.Pricing.Schedules) > 0 {
+		// Use off-peak rate as default threshold
+		return cs.config.Pricing.Schedules[0].OffPeakRate, nil
+	}
+	return 0, fmt.Errorf("no pricing schedules configured")
+}
+
 func (cs *CarbonAwareScheduler) checkCarbonIntensityConstraints(ctx context.Context, pod *v1.Pod) *framework.Status {
-	// Get carbon intensity data
-	data, err := cs.getCarbonIntensityData(ctx)
+	// Get carbon intensity data for the zone the pod is evaluated against
+	zone := cs.podZone(pod)
+	data, err := cs.getZoneCarbonIntensityData(ctx, zone)
 	if err != nil {
-		SchedulingAttempts.WithLabelValues("error").Inc()
+		cs.countAttempt("error")
 		return framework.NewStatus(framework.Error, fmt.Sprintf("failed to get carbon intensity data: %v", err))
 	}
 
 	// Record carbon intensity metric
-	CarbonIntensityGauge.WithLabelValues(cs.config.API.Region).Set(data.CarbonIntensity)
-
-	// Get threshold from pod annotation or use configured threshold
-	threshold := cs.config.Scheduling.BaseCarbonIntensityThreshold
-	if val, ok := pod.Annotations["carbon-aware-scheduler.kubernetes.io/carbon-intensity-threshold"]; ok {
-		if t, err := strconv.ParseFloat(val, 64); err == nil {
-			threshold = t
-		} else {
-			return framework.NewStatus(framework.Error, "invalid carbon intensity threshold annotation")
-		}
+	cs.metrics().carbonIntensity.WithLabelValues(zone).Set(data.CarbonIntensity)
+	intensity := cs.effectiveIntensity(zone, data)
+
+	threshold, err := cs.carbonThreshold(pod)
+	if err != nil {
+		return framework.NewStatus(framework.Error, err.Error())
 	}
 
-	if data.CarbonIntensity > threshold {
-		SchedulingAttempts.WithLabelValues("intensity_exceeded").Inc()
-		// Record scheduling efficiency metrics
-		if initialIntensity, ok := pod.Annotations["carbon-aware-scheduler.kubernetes.io/initial-intensity"]; ok {
-			if initial, err := strconv.ParseFloat(initialIntensity, 64); err == nil {
-				delta := data.CarbonIntensity - initial
-				SchedulingEfficiencyMetrics.WithLabelValues("carbon_intensity_delta", pod.Name).Set(delta)
+	strictness := podStrictness(pod)
+	if strictness == strictnessBestEffort && cs.inclusionEnforced(pod) {
+		strictness = ""
+	}
+	if cs.exceedsCarbonThreshold(zone, intensity, threshold) {
+		// Best-effort pods only wait for a window under their threshold within a short horizon
+		if strictness == strictnessBestEffort && !cs.hasBestEffortWindow(ctx, pod, zone, threshold) {
+			cs.countAttempt("best_effort")
+			return framework.NewStatus(framework.Success, "no window under threshold within best-effort horizon")
+		}
 
-				// Estimate savings based on delta
-				if delta < 0 { // negative delta means improvement
-					EstimatedSavings.WithLabelValues("carbon", "grams_co2").Add(-delta)
-				}
-			}
-		} else {
-			// First time seeing this pod, initialize annotations if needed
-			if pod.Annotations == nil {
-				pod.Annotations = make(map[string]string)
-			}
-			pod.Annotations["carbon-aware-scheduler.kubernetes.io/initial-intensity"] = fmt.Sprintf("%.2f", data.CarbonIntensity)
+		// Don't delay pods whose energy use is too small to matter
+		if cs.isLightPod(pod) {
+			cs.countAttempt("light_pod")
+			return framework.NewStatus(framework.Success, "estimated energy below gating minimum")
 		}
 
-		msg := fmt.Sprintf("Current carbon intensity (%.2f) exceeds threshold (%.2f)", data.CarbonIntensity, threshold)
+		// Don't delay if intensity is only going to get worse, unless the pod is strict
+		if cs.config.Scheduling.TrendHorizon > 0 && strictness != strictnessStrict && cs.isRising(ctx, zone, intensity) {
+			cs.countAttempt("rising_trend")
+			return framework.NewStatus(framework.Success, "carbon intensity rising over trend horizon")
+		}
 
-		// Track node CPU usage if pod was previously running
-		if pod.Spec.NodeName != "" {
+		// Don't delay if waiting won't lead to a lower intensity
+		if cs.config.Scheduling.OptimalWindowEnabled && strictness != strictnessStrict && !cs.hasBetterWindow(ctx, pod, zone, intensity) {
+			cs.countAttempt("no_better_window")
+			return framework.NewStatus(framework.Success, "no lower intensity window before scheduling deadline")
+		}
+
+		cs.countAttempt("intensity_exceeded")
+		// Savings are accounted for when the pod is bound
+		cs.recordInitialIntensity(pod, intensity)
+
+		msg := fmt.Sprintf("Current carbon intensity (%.2f) exceeds threshold (%.2f)", intensity, threshold)
+		if intensity <= threshold {
+			msg = fmt.Sprintf("Current carbon intensity (%.2f) has not dropped below release threshold (%.2f)",
+				intensity, cs.releaseThreshold(threshold))
+		}
+
+		// Track node CPU usage if pod was previously running. Dry runs don't query
+		// the nodes of submitted pods.
+		if pod.Spec.NodeName != "" && !cs.dryRun {
 			nodeName := pod.Spec.NodeName
 			// Record pre-job metrics
-			NodeCPUUsage.WithLabelValues(nodeName, pod.Name, "pre_job").Set(cs.getNodeCPUUsage(nodeName))
+			cs.metrics().nodeCPU.WithLabelValues(nodeName, pod.Name, "pre_job").Set(cs.getNodeCPUUsage(nodeName))
 			power := cs.estimateNodePower(nodeName)
-			NodePowerEstimate.WithLabelValues(nodeName, pod.Name, "pre_job").Set(power)
+			cs.metrics().nodePower.WithLabelValues(nodeName, pod.Name, "pre_job").Set(power)
 		}
 
 		return framework.NewStatus(framework.Unschedulable, msg)
 	}
 
+	// Strict pods wait for the cleanest window even under their threshold
+	if strictness == strictnessStrict {
+		return cs.checkStrict(ctx, pod, zone, intensity)
+	}
+
+	return framework.NewStatus(framework.Success, "")
+}
+
+// carbonThreshold returns the carbon intensity threshold that applies to a pod
+func (cs *CarbonAwareScheduler) carbonThreshold(pod *v1.Pod) (float64, error) {
+	// Get threshold from pod annotation, its namespace annotation, its carbon policy or
+	// the configured threshold
+	defaultThreshold, ok := cs.classThreshold(pod)
+	if !ok {
+		defaultThreshold, ok = cs.namespaceThreshold(pod)
+	}
+	if !ok {
+		defaultThreshold, ok = cs.policyThreshold(pod)
+	}
+	if !ok {
+		defaultThreshold = cs.baseThreshold(cs.podZone(pod))
+	}
+	threshold := defaultThreshold
+	if val, ok := cs.podOverride(pod, thresholdAnnotation); ok {
+		var err error
+		if threshold, err = strconv.ParseFloat(val, 64); err != nil {
+			return 0, fmt.Errorf("invalid carbon intensity threshold annotation")
+		}
+	}
+
+	// Relax the threshold as the pod ages
+	threshold *= cs.agingFactor(pod)
+
+	// Tighten the threshold for energy-heavy pods, pods over a demoting carbon budget,
+	// during demand response events and in conservation mode, and adjust it to keep
+	// carbon SLOs on track
+	threshold *= cs.energyFactor(pod)
+	threshold *= cs.budgetFactor(pod)
+	threshold *= cs.sloFactor(pod)
+	threshold *= cs.demandResponseFactor()
+	if _, ok := cs.conservationMode(); ok {
+		threshold *= cs.config.GridAlert.ThresholdFactor
+	}
+
+	return threshold, nil
+}
+
+// annotationThreshold returns the threshold from a pod annotation, or defaultValue if
+// the annotation is not set
+func annotationThreshold(pod *v1.Pod, key string, defaultValue float64) (float64, error) {
+	val, ok := pod.Annotations[key]
+	if !ok {
+		return defaultValue, nil
+	}
+	return strconv.ParseFloat(val, 64)
+}
+
+// checkThermalConstraints delays pods while the data-center temperature or cooling
+// load query exceeds its threshold. Missing or stale values fail open.
+func (cs *CarbonAwareScheduler) checkThermalConstraints(pod *v1.Pod) *framework.Status {
+	if cs.thermal == nil {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	threshold, err := annotationThreshold(pod, "carbon-aware-scheduler.kubernetes.io/thermal-threshold", cs.config.Thermal.Threshold)
+	if err != nil {
+		return framework.NewStatus(framework.Error, "invalid thermal threshold annotation")
+	}
+
+	value, ok := cs.thermal.Value()
+	if !ok {
+		klog.V(2).InfoS("Thermal signal unavailable, not gating on it")
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	if value > threshold {
+		cs.countAttempt("thermal_exceeded")
+		return framework.NewStatus(
+			framework.Unschedulable,
+			fmt.Sprintf("Current cooling load (%.2f) exceeds threshold (%.2f)", value, threshold),
+		)
+	}
+	return framework.NewStatus(framework.Success, "")
+}
+
+// checkDemandResponse blocks admissions while a pausing demand response event is active
+func (cs *CarbonAwareScheduler) checkDemandResponse() *framework.Status {
+	if cs.demandResp == nil {
+		return framework.NewStatus(framework.Success, "")
+	}
+	for _, event := range cs.demandResp.Active() {
+		if event.Pause || cs.config.DemandResponse.PauseAdmissions {
+			cs.countAttempt("demand_response")
+			return framework.NewStatus(
+				framework.Unschedulable,
+				fmt.Sprintf("Demand response event %s active until %s", event.ID, event.End().Format(time.RFC3339)),
+			)
+		}
+	}
 	return framework.NewStatus(framework.Success, "")
 }
 
+// demandResponseFactor returns the strictest threshold factor of all active demand
+// response events, or 1 if none are active
+func (cs *CarbonAwareScheduler) demandResponseFactor() float64 {
+	if cs.demandResp == nil {
+		return 1
+	}
+	factor := 1.0
+	for _, event := range cs.demandResp.Active() {
+		f := event.ThresholdFactor
+		if f == 0 {
+			f = cs.config.DemandResponse.ThresholdFactor
+		}
+		if f < factor {
+			factor = f
+		}
+	}
+	return factor
+}
+
+// conservationMode returns the active grid alert, if any
+func (cs *CarbonAwareScheduler) conservationMode() (gridalert.Alert, bool) {
+	if cs.gridAlerts == nil {
+		return gridalert.Alert{}, false
+	}
+	alert, ok := cs.gridAlerts.Active()
+	if ok {
+		cs.metrics().conservationMode.Set(1)
+	} else {
+		cs.metrics().conservationMode.Set(0)
+	}
+	return alert, ok
+}
+
+// checkGridAlert blocks admissions in conservation mode if configured to do so
+func (cs *CarbonAwareScheduler) checkGridAlert() *framework.Status {
+	alert, ok := cs.conservationMode()
+	if !ok || !cs.config.GridAlert.PauseAdmissions {
+		return framework.NewStatus(framework.Success, "")
+	}
+	cs.countAttempt("grid_alert")
+	return framework.NewStatus(
+		framework.Unschedulable,
+		fmt.Sprintf("Grid alert %s active, scheduler in conservation mode", alert.ID),
+	)
+}
+
+// checkOnSiteConstraints admits pods only while on-site generation or battery state of
+// charge exceeds the configured minimums. Missing or stale telemetry fails open.
+func (cs *CarbonAwareScheduler) checkOnSiteConstraints() *framework.Status {
+	if cs.onSite == nil {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	telemetry, ok := cs.onSite.Latest()
+	if !ok {
+		klog.V(2).InfoS("On-site telemetry unavailable, not gating on it")
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	minProduction := cs.config.OnSite.MinProductionKW
+	minCharge := cs.config.OnSite.MinStateOfCharge
+	productionKW := telemetry.ProductionWatts / 1000
+	if (minProduction > 0 && productionKW > minProduction) || (minCharge > 0 && telemetry.StateOfCharge > minCharge) {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	cs.countAttempt("onsite_insufficient")
+	return framework.NewStatus(
+		framework.Unschedulable,
+		fmt.Sprintf("On-site generation (%.2f kW) and battery charge (%.0f%%) below minimums", productionKW, telemetry.StateOfCharge),
+	)
+}
+
+// exceedsCarbonThreshold reports whether intensity blocks scheduling against threshold.
+// With hysteresis enabled the blocked state is latched per zone against the base
+// threshold until intensity drops below the release threshold; while the zone is
+// latched a pod stays blocked until intensity drops below its own release threshold.
+func (cs *CarbonAwareScheduler) exceedsCarbonThreshold(zone string, intensity, threshold float64) bool {
+	if cs.config.Scheduling.ReleaseCarbonIntensityThreshold <= 0 {
+		return intensity > threshold
+	}
+	base := cs.baseThreshold(zone)
+	latched := cs.hysteresis.isBlocked(zone, intensity, base, cs.releaseThreshold(base), cs.clock.Now())
+	if latched {
+		return intensity >= cs.releaseThreshold(threshold)
+	}
+	return intensity > threshold
+}
+
+// releaseThreshold returns the release threshold for a block threshold, keeping the
+// configured band width for thresholds overridden by pod annotations
+func (cs *CarbonAwareScheduler) releaseThreshold(threshold float64) float64 {
+	band := cs.config.Scheduling.BaseCarbonIntensityThreshold - cs.config.Scheduling.ReleaseCarbonIntensityThreshold
+	return threshold - band
+}
+
+// podZone returns the grid zone a pod should be evaluated against. The region
+// annotation accepts either a grid zone or a cloud region known to the zone mapper.
+func (cs *CarbonAwareScheduler) podZone(pod *v1.Pod) string {
+	if val, ok := pod.Annotations[regionAnnotation]; ok && val != "" {
+		if zone, ok := cs.zoneMapper.ZoneForRegion(val); ok {
+			return zone
+		}
+		return val
+	}
+	return cs.config.API.Region
+}
+
 func (cs *CarbonAwareScheduler) getCarbonIntensityData(ctx context.Context) (*api.ElectricityData, error) {
+	return cs.getZoneCarbonIntensityData(ctx, cs.config.API.Region)
+}
+
+// getZoneCarbonIntensityData returns carbon intensity data for a zone, preferring the cache
+func (cs *CarbonAwareScheduler) getZoneCarbonIntensityData(ctx context.Context, zone string) (*api.ElectricityData, error) {
 	// Check cache first
-	if data, found := cs.cache.Get(cs.config.API.Region); found {
+	if data, found := cs.cache.Get(zone); found {
 		return data, nil
 	}
+	// Dry runs only read cached data, so evaluations neither fetch the zones of
+	// submitted pods nor record them
+	if cs.dryRun {
+		if fallback, ok := cs.fallbackData(ctx, zone); ok {
+			return fallback, nil
+		}
+		return nil, fmt.Errorf("no cached carbon intensity for zone %s", zone)
+	}
 
 	// Fetch from API
-	data, err := cs.apiClient.GetCarbonIntensity(ctx, cs.config.API.Region)
+	data, err := cs.apiClient.GetCarbonIntensity(ctx, zone)
 	if err != nil {
+		if fallback, ok := cs.fallbackData(ctx, zone); ok {
+			return fallback, nil
+		}
 		return nil, err
 	}
+	cs.markAPISuccess()
 
 	// Update cache
-	cs.cache.Set(cs.config.API.Region, data)
+	cs.cache.Set(zone, data)
+	cs.recordSample(zone, data)
 	return data, nil
 }
 
+// recordSample adds fetched intensity data to the smoother and history store, if enabled
+func (cs *CarbonAwareScheduler) recordSample(zone string, data *api.ElectricityData) {
+	if cs.smoother != nil {
+		cs.smoother.add(zone, data.Timestamp, data.CarbonIntensity)
+	}
+	if cs.history != nil {
+		cs.history.Add(zone, history.Sample{
+			Timestamp:       data.Timestamp,
+			CarbonIntensity: data.CarbonIntensity,
+		})
+	}
+}
+
+// effectiveIntensity returns the intensity used for threshold comparison, smoothed
+// over recent samples if smoothing is enabled
+func (cs *CarbonAwareScheduler) effectiveIntensity(zone string, data *api.ElectricityData) float64 {
+	if cs.smoother == nil {
+		return data.CarbonIntensity
+	}
+	return cs.smoother.value(zone, data.CarbonIntensity)
+}
+
+// historyWorker periodically persists the history store
+func (cs *CarbonAwareScheduler) historyWorker() {
+	ticker := time.NewTicker(cs.config.History.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cs.stopCh:
+			return
+		case <-ticker.C:
+			if err := cs.history.Flush(); err != nil {
+				klog.ErrorS(err, "Failed to flush carbon intensity history")
+			}
+		}
+	}
+}
+
+// markAPISuccess records that the carbon data API was reachable
+func (cs *CarbonAwareScheduler) markAPISuccess() {
+	cs.lastAPISuccess.Store(cs.clock.Now().UnixNano())
+}
+
+// useFallback reports whether fallback is enabled and the API has been unreachable
+// for longer than the configured threshold
+func (cs *CarbonAwareScheduler) useFallback() bool {
+	return cs.fallback != nil &&
+		cs.clock.Since(time.Unix(0, cs.lastAPISuccess.Load())) >= cs.config.Fallback.After
+}
+
+// fallbackData returns synthetic intensity data when useFallback allows it. Synthetic
+// data is never cached so real data is used as soon as the API recovers.
+func (cs *CarbonAwareScheduler) fallbackData(ctx context.Context, zone string) (*api.ElectricityData, bool) {
+	if !cs.useFallback() {
+		return nil, false
+	}
+
+	data, err := cs.fallback.GetCarbonIntensity(ctx, zone)
+	if err != nil {
+		return nil, false
+	}
+	klog.V(2).InfoS("Using synthetic carbon intensity, API unreachable",
+		"zone", zone,
+		"carbonIntensity", data.CarbonIntensity)
+	return data, true
+}
+
+// trackNodeZone records the grid zone of a node so its data is kept fresh
+func (cs *CarbonAwareScheduler) trackNodeZone(node *v1.Node) {
+	if zone, ok := cs.zoneMapper.ZoneForNode(node); ok {
+		cs.nodeZones.Store(node.Name, zone)
+	} else {
+		cs.nodeZones.Delete(node.Name)
+	}
+}
+
+// trackedZones returns the configured zones plus any discovered from nodes
+func (cs *CarbonAwareScheduler) trackedZones() []string {
+	tracked := cs.config.API.TrackedZones()
+	seen := make(map[string]bool, len(tracked))
+	for _, zone := range tracked {
+		seen[zone] = true
+	}
+	cs.nodeZones.Range(func(_, value interface{}) bool {
+		if zone := value.(string); !seen[zone] {
+			seen[zone] = true
+			tracked = append(tracked, zone)
+		}
+		return true
+	})
+	return tracked
+}
+
+// getForecast returns the carbon intensity forecast for a zone over the given horizon
+// from the cache, which the health check refreshes, so scheduling cycles never wait
+// on the forecast API. Without a cached forecast it degrades to the synthetic curve
+// when falling back, or else a flat forecast at the current intensity.
+func (cs *CarbonAwareScheduler) getForecast(ctx context.Context, zone string, horizon time.Duration) ([]api.Point, error) {
+	if points, found := cs.cache.GetForecast(zone, horizon); found {
+		return points, nil
+	}
+	if cs.useFallback() {
+		return cs.fallback.Forecast(ctx, zone, horizon)
+	}
+	return api.Forecast(ctx, cachedProvider{cs}, zone, horizon)
+}
+
+// cachedProvider adapts the scheduler's cached data access to the api.Provider
+// interface, so flat forecasts are served from the cache
+type cachedProvider struct {
+	cs *CarbonAwareScheduler
+}
+
+func (p cachedProvider) GetCarbonIntensity(ctx context.Context, zone string) (*api.ElectricityData, error) {
+	return p.cs.getZoneCarbonIntensityData(ctx, zone)
+}
+
+// refreshZones fetches data for all tracked zones whose cache entries are stale,
+// so scheduling cycles are served from the cache rather than serialized API calls
+func (cs *CarbonAwareScheduler) refreshZones(ctx context.Context) error {
+	stale := cs.cache.Stale(cs.trackedZones())
+	if len(stale) == 0 {
+		return nil
+	}
+
+	results, errs := cs.apiClient.GetCarbonIntensities(ctx, stale)
+	if len(results) > 0 {
+		cs.markAPISuccess()
+	}
+	for zone, data := range results {
+		cs.cache.Set(zone, data)
+		cs.recordSample(zone, data)
+	}
+	for zone, err := range errs {
+		klog.V(2).InfoS("Failed to refresh zone", "zone", zone, "error", err)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("failed to refresh %d of %d zones", len(errs), len(stale))
+	}
+	return nil
+}
+
+// refreshForecasts fetches forecasts for all tracked zones whose cached forecast is stale
+func (cs *CarbonAwareScheduler) refreshForecasts(ctx context.Context) error {
+	if !cs.config.API.ForecastEnabled {
+		return nil
+	}
+
+	var failed int
+	stale := cs.cache.StaleForecasts(cs.trackedZones())
+	for _, zone := range stale {
+		points, err := cs.apiClient.GetForecast(ctx, zone)
+		if err != nil {
+			klog.V(2).InfoS("Failed to refresh forecast", "zone", zone, "error", err)
+			failed++
+			continue
+		}
+		cs.cache.SetForecast(zone, points)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to refresh forecasts for %d of %d zones", failed, len(stale))
+	}
+	return nil
+}
+
 func (cs *CarbonAwareScheduler) healthCheckWorker(ctx context.Context) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
@@ -338,26 +1028,41 @@ func (cs *CarbonAwareScheduler) healthCheckWorker(ctx context.Context) {
 	}
 }
 
+// healthCheck refreshes stale zones and forecasts. Forecasts are refreshed even if
+// zones fail to, and the errors of both are returned.
 func (cs *CarbonAwareScheduler) healthCheck(ctx context.Context) error {
-	_, err := cs.getCarbonIntensityData(ctx)
-	return err
+	return errors.Join(cs.refreshZones(ctx), cs.refreshForecasts(ctx))
 }
 
-// PostBind implements the PostBind interface
+// PostBind implements the PostBind interface. It records the carbon intensity the
+// pod was bound at, and the baseline power of its node, and starts sampling the
+// power of the pod.
 func (cs *CarbonAwareScheduler) PostBind(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) {
+	cs.permitBudgets.Delete(pod.UID)
+	cs.gangActivations.Delete(pod.UID)
+	cs.resolvedPolicies.Delete(pod.UID)
+	cs.recordBoundIntensity(ctx, state, pod, nodeName)
+	cs.setDelayedCondition(pod, v1.ConditionFalse, reasonAdmitted, "Bound to "+nodeName)
+	cs.delayStatus.admitted(pod, nodeName, cs.clock.Now())
+
 	// Record baseline CPU/power when pod is bound but hasn't started
 	baselineCPU := cs.getNodeCPUUsage(nodeName)
 	baselinePower := cs.estimateNodePower(nodeName)
 
 	// Store in cache and set metric
 	key := fmt.Sprintf("%s/%s/baseline", nodeName, pod.Name)
-	cs.powerMetrics.Store(key, baselinePower)
+	cs.powerMetrics.Store(key, baselinePower, cs.clock.Now())
 
 	NodeCPUUsage.WithLabelValues(nodeName, pod.Name, "baseline").Set(baselineCPU)
 	NodePowerEstimate.WithLabelValues(nodeName, pod.Name, "baseline").Set(baselinePower)
+	cs.podEnergy.track(pod, nodeName, baselinePower, cs.clock.Now())
+	if rate := cs.embodiedRate(pod, nodeName); rate > 0 {
+		cs.podEnergy.setEmbodiedRate(pod.UID, rate)
+	}
 }
 
-// handlePodCompletion records metrics when a pod completes
+// handlePodCompletion records metrics when a pod completes, fails, or is deleted
+// before finishing
 func (cs *CarbonAwareScheduler) handlePodCompletion(pod *v1.Pod) {
 	nodeName := pod.Spec.NodeName
 	if nodeName == "" {
@@ -370,49 +1075,91 @@ func (cs *CarbonAwareScheduler) handlePodCompletion(pod *v1.Pod) {
 
 	// Store in cache and set metric
 	key := fmt.Sprintf("%s/%s/final", nodeName, pod.Name)
-	cs.powerMetrics.Store(key, finalPower)
+	cs.powerMetrics.Store(key, finalPower, cs.clock.Now())
 
 	NodeCPUUsage.WithLabelValues(nodeName, pod.Name, "final").Set(finalCPU)
 	NodePowerEstimate.WithLabelValues(nodeName, pod.Name, "final").Set(finalPower)
 
-	// Calculate energy usage and carbon emissions based on baseline and final measurements
-	if baselinePower, ok := cs.getPowerMetric(nodeName, pod.Name, "baseline"); ok {
-		duration := cs.clock.Since(pod.Status.StartTime.Time)
-		// Use final power as better representation of average
-		energyKWh := (finalPower * duration.Hours()) / 1000 // Convert W*h to kWh
+	// Calculate energy usage and carbon emissions from the power sampled since the pod
+	// was bound, closed with the final measurement
+	if energyKWh, ok := cs.accountCompletedPod(pod, finalPower, cs.clock.Now()); ok {
+		if pod.Status.Phase != v1.PodSucceeded {
+			klog.V(2).InfoS("Accounted energy of unfinished pod", "pod", klog.KObj(pod),
+				"phase", pod.Status.Phase, "reason", pod.Status.Reason, "energyKWh", energyKWh)
+		}
+	}
+}
 
-		JobEnergyUsage.WithLabelValues(pod.Name, pod.Namespace).Observe(energyKWh)
+// accountCompletedPod accounts the energy of a completed pod since its last accrual,
+// closed with a final sample of its node's power, and records the energy and
+// emissions of the whole job. It returns the energy of the job in kWh.
+func (cs *CarbonAwareScheduler) accountCompletedPod(pod *v1.Pod, nodePower float64, now time.Time) (float64, bool) {
+	embodiedGrams := cs.podEnergy.embodied(pod.UID, now)
+	accruedKWh, accruedGrams := cs.podEnergy.accrued(pod.UID)
+	energyKWh, additionalEnergyKWh, ok := cs.podEnergy.take(pod.UID, nodePower, now)
+	if !ok {
+		return 0, false
+	}
+	carbonEmissions, known := cs.accountPodEnergy(pod, energyKWh, additionalEnergyKWh, embodiedGrams)
 
-		// Get current carbon intensity
-		data, err := cs.getCarbonIntensityData(context.Background())
-		if err == nil {
-			// Calculate carbon emissions (gCO2eq) = energy (kWh) * intensity (gCO2eq/kWh)
-			carbonEmissions := energyKWh * data.CarbonIntensity
-			JobCarbonEmissions.WithLabelValues(pod.Name, pod.Namespace).Observe(carbonEmissions)
-		}
+	energyKWh += accruedKWh
+	labels := cs.workloadLabels(pod)
+	JobEnergyUsage.WithLabelValues(labels...).Observe(energyKWh)
+	if known || accruedGrams > 0 {
+		JobCarbonEmissions.WithLabelValues(labels...).Observe(carbonEmissions + accruedGrams)
+	}
+	return energyKWh, true
+}
 
-		// Calculate additional energy from job (above baseline)
-		additionalPower := finalPower - baselinePower
-		if additionalPower > 0 {
-			additionalEnergyKWh := (additionalPower * duration.Hours()) / 1000
-			EstimatedSavings.WithLabelValues("energy", "kwh").Add(additionalEnergyKWh)
+// accountPodEnergy records the energy of a pod, and the emissions at the current
+// carbon intensity of its facility energy, scaled by the PUE of its node, plus the
+// embodied carbon it accrued. It returns the emissions in gCO2e, and whether they
+// are known.
+func (cs *CarbonAwareScheduler) accountPodEnergy(pod *v1.Pod, energyKWh, additionalEnergyKWh, embodiedGrams float64) (float64, bool) {
+	NamespaceEnergyUsage.WithLabelValues(pod.Namespace).Add(energyKWh)
+	if embodiedGrams > 0 {
+		NamespaceEmbodiedEmissions.WithLabelValues(pod.Namespace).Add(embodiedGrams)
+		cs.accounting.AddEmbodied(pod.Namespace, embodiedGrams)
+	}
 
-			// Calculate additional carbon emissions if we have intensity data
-			if err == nil {
-				additionalEmissions := additionalEnergyKWh * data.CarbonIntensity
-				EstimatedSavings.WithLabelValues("carbon", "grams_co2").Add(additionalEmissions)
-			}
+	// Get current carbon intensity
+	carbonEmissions := embodiedGrams
+	pue := cs.pue(pod.Spec.NodeName)
+	data, err := cs.getCarbonIntensityData(context.Background())
+	if err == nil {
+		// Calculate carbon emissions (gCO2eq) = energy (kWh) * PUE * intensity (gCO2eq/kWh)
+		carbonEmissions += energyKWh * pue * data.CarbonIntensity
+	}
+	known := err == nil || embodiedGrams > 0
+	if known {
+		NamespaceCarbonEmissions.WithLabelValues(pod.Namespace).Add(carbonEmissions)
+		cs.accrueEmissions(pod, carbonEmissions)
+	}
+	cs.accounting.Add(pod.Namespace, energyKWh, carbonEmissions)
+
+	if additionalEnergyKWh > 0 {
+		EstimatedSavings.WithLabelValues("energy", "kwh").Add(additionalEnergyKWh)
+
+		// Calculate additional carbon emissions if we have intensity data
+		if err == nil {
+			additionalEmissions := additionalEnergyKWh * pue * data.CarbonIntensity
+			EstimatedSavings.WithLabelValues("carbon", "grams_co2").Add(additionalEmissions)
 		}
 	}
+	return carbonEmissions, known
 }
 
-// getPowerMetric retrieves a previously recorded power metric from cache
-func (cs *CarbonAwareScheduler) getPowerMetric(nodeName, podName, phase string) (float64, bool) {
-	key := fmt.Sprintf("%s/%s/%s", nodeName, podName, phase)
-	if value, ok := cs.powerMetrics.Load(key); ok {
-		return value.(float64), true
+// forgetPodPower drops the power recorded for a deleted pod
+func (cs *CarbonAwareScheduler) forgetPodPower(pod *v1.Pod) {
+	nodeName := pod.Spec.NodeName
+	if nodeName == "" {
+		return
+	}
+	for _, phase := range []string{"baseline", "final"} {
+		cs.powerMetrics.Delete(fmt.Sprintf("%s/%s/%s", nodeName, pod.Name, phase))
+		NodeCPUUsage.DeleteLabelValues(nodeName, pod.Name, phase)
+		NodePowerEstimate.DeleteLabelValues(nodeName, pod.Name, phase)
 	}
-	return 0, false
 }
 
 // getNodeCPUUsage returns the current CPU usage (0-1) for a node
@@ -438,21 +1185,28 @@ func (cs *CarbonAwareScheduler) getNodeCPUUsage(nodeName string) float64 {
 	return cpuUsage
 }
 
-// estimateNodePower estimates power consumption based on CPU usage
+// estimateNodePower returns the measured power of a node from the energy source of
+// its pool, or else estimates it from CPU and memory usage and its disks and NICs,
+// plus the estimated power of its GPUs, which only BMCs and PDUs measure. Estimates
+// of nodes measured at the wall are calibrated against their past readings.
 func (cs *CarbonAwareScheduler) estimateNodePower(nodeName string) float64 {
-	cpuUsage := cs.getNodeCPUUsage(nodeName)
-
-	// Get node-specific power config if available, otherwise use defaults
-	var idlePower, maxPower float64
-	if nodePower, ok := cs.config.Power.NodePowerConfig[nodeName]; ok {
-		idlePower = nodePower.IdlePower
-		maxPower = nodePower.MaxPower
-	} else {
-		idlePower = cs.config.Power.DefaultIdlePower
-		maxPower = cs.config.Power.DefaultMaxPower
+	if power, ok := cs.energySource.NodePower(nodeName); ok {
+		if cs.energySource.WallPower(nodeName) {
+			cs.calibrateNodePower(nodeName, power)
+			return power
+		}
+		return power + cs.gpuPower(nodeName)
 	}
+	return cs.modelNodePower(nodeName) * cs.calibration(nodeName)
+}
+
+// modelNodePower estimates the power of a node from its power model
+func (cs *CarbonAwareScheduler) modelNodePower(nodeName string) float64 {
+	cpuUsage := cs.getNodeCPUUsage(nodeName)
 
-	// Linear interpolation between idle and max power based on CPU usage
-	estimatedPower := idlePower + (maxPower-idlePower)*cpuUsage
-	return estimatedPower
+	// Estimate from the node's power curve, or its idle and max power, adjusted for
+	// the frequency and SMT of its CPUs
+	power := cs.cpuPowerAt(nodeName, cpuUsage)
+	power += cs.memoryPower(cs.getNodeMemoryGB(nodeName)) + cs.devicePower(nodeName)
+	return power + cs.gpuPower(nodeName)
 }
@@ -2,29 +2,63 @@ package computegardener
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
 	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/events"
 	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
 	"k8s.io/kubernetes/pkg/scheduler/framework"
+	tf "k8s.io/kubernetes/pkg/scheduler/testing/framework"
 	metricsapi "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	metricsv1beta1 "k8s.io/metrics/pkg/client/clientset/versioned/typed/metrics/v1beta1"
+	"k8s.io/utils/ptr"
 
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+	schedv1alpha1 "sigs.k8s.io/scheduler-plugins/apis/scheduling/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/accounting"
 	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
 	schedulercache "sigs.k8s.io/scheduler-plugins/pkg/computegardener/cache"
 	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/clock"
 	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/demandresponse"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/durations"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/energysource"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/gridalert"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/history"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/instancetypes"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/onsite"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/policy"
 	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/pricing/mock"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/pricing/tou"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/promquery"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/zones"
 )
 
 // mockMetricsClient implements metricsv1beta1.MetricsV1beta1Interface for testing
@@ -70,6 +104,70 @@ func (c *testConfig) GetObjectKind() schema.ObjectKind {
 // mockHandle implements framework.Handle for testing
 type mockHandle struct {
 	framework.Handle
+	recorder    events.EventRecorder
+	nodeInfos   framework.NodeInfoLister
+	waitingPods []*mockWaitingPod
+}
+
+func (m *mockHandle) GetWaitingPod(uid types.UID) framework.WaitingPod {
+	for _, wp := range m.waitingPods {
+		if wp.pod.UID == uid {
+			return wp
+		}
+	}
+	return nil
+}
+
+func (m *mockHandle) IterateOverWaitingPods(callback func(framework.WaitingPod)) {
+	for _, wp := range m.waitingPods {
+		callback(wp)
+	}
+}
+
+// mockWaitingPod implements framework.WaitingPod for testing
+type mockWaitingPod struct {
+	framework.WaitingPod
+	pod     *v1.Pod
+	pending []string
+	allowed bool
+}
+
+func (m *mockWaitingPod) GetPod() *v1.Pod {
+	return m.pod
+}
+
+func (m *mockWaitingPod) GetPendingPlugins() []string {
+	return m.pending
+}
+
+// Allow marks the pod allowed and, like the framework, no longer pending on the plugin
+func (m *mockWaitingPod) Allow(pluginName string) {
+	m.allowed = true
+	m.pending = slices.DeleteFunc(m.pending, func(name string) bool { return name == pluginName })
+}
+
+func (m *mockHandle) SnapshotSharedLister() framework.SharedLister {
+	return &mockSharedLister{nodeInfos: m.nodeInfos}
+}
+
+// mockSharedLister implements framework.SharedLister for testing
+type mockSharedLister struct {
+	framework.SharedLister
+	nodeInfos framework.NodeInfoLister
+}
+
+func (m *mockSharedLister) NodeInfos() framework.NodeInfoLister {
+	if m.nodeInfos == nil {
+		return tf.NodeInfoLister{}
+	}
+	return m.nodeInfos
+}
+
+func (m *mockHandle) EventRecorder() events.EventRecorder {
+	if m.recorder == nil {
+		return &events.FakeRecorder{}
+	}
+	return m.recorder
 }
 
 func (m *mockHandle) KubeConfig() *rest.Config {
@@ -102,6 +200,19 @@ func (m *mockCoreV1) Nodes() corev1.NodeInterface {
 	return &mockNodes{}
 }
 
+func (m *mockCoreV1) Pods(namespace string) corev1.PodInterface {
+	return &mockPods{}
+}
+
+// mockPods implements corev1.PodInterface for testing
+type mockPods struct {
+	corev1.PodInterface
+}
+
+func (m *mockPods) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts metav1.PatchOptions, subresources ...string) (*v1.Pod, error) {
+	return &v1.Pod{}, nil
+}
+
 // mockNodes implements corev1.NodeInterface for testing
 type mockNodes struct {
 	corev1.NodeInterface
@@ -142,13 +253,18 @@ func newTestScheduler(cfg *config.Config, carbonIntensity float64, rate float64,
 	})
 
 	return &CarbonAwareScheduler{
-		handle:       &mockHandle{},
-		config:       cfg,
-		apiClient:    mockClient,
-		cache:        cache,
-		pricingImpl:  mock.New(rate),
-		clock:        clock.NewMockClock(mockTime),
-		powerMetrics: sync.Map{},
+		handle: &mockHandle{},
+		config: cfg,
+		dataLayer: &dataLayer{
+			apiClient:     mockClient,
+			cache:         cache,
+			pricingImpl:   mock.New(rate),
+			metricsClient: &mockMetricsClient{},
+			zoneMapper:    zones.NewMapper(cfg.API.RegionZoneMap),
+			powerMetrics:  newPowerMetricsStore(time.Hour, 1000),
+		},
+		clock:      clock.NewMockClock(mockTime),
+		hysteresis: newHysteresis(),
 	}
 }
 
@@ -263,6 +379,72 @@ func TestPreFilter(t *testing.T) {
 			podCreationTime: baseTime,
 			wantStatus:      framework.NewStatus(framework.Success, "maximum scheduling delay exceeded"),
 		},
+		{
+			name: "pod should schedule - annotated max delay exceeded",
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(baseTime.Add(-time.Hour)),
+					Annotations: map[string]string{
+						"carbon-aware-scheduler.kubernetes.io/max-delay": "30m",
+					},
+				},
+			},
+			carbonIntensity: 250,
+			threshold:       200,
+			maxDelay:        24 * time.Hour,
+			podCreationTime: baseTime,
+			wantStatus:      framework.NewStatus(framework.Success, "maximum scheduling delay exceeded"),
+		},
+		{
+			name: "pod should not schedule - annotated max delay not exceeded",
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(baseTime.Add(-25 * time.Hour)),
+					Annotations: map[string]string{
+						"carbon-aware-scheduler.kubernetes.io/max-delay": "48h",
+					},
+				},
+			},
+			carbonIntensity: 250,
+			threshold:       200,
+			maxDelay:        24 * time.Hour,
+			podCreationTime: baseTime,
+			wantStatus:      framework.NewStatus(framework.Unschedulable, "Current carbon intensity (250.00) exceeds threshold (200.00)"),
+		},
+		{
+			name: "pod should schedule - latest start for deadline reached",
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(baseTime),
+					Annotations: map[string]string{
+						"carbon-aware-scheduler.kubernetes.io/deadline":           "2024-01-01T14:00:00Z",
+						"carbon-aware-scheduler.kubernetes.io/estimated-duration": "2h",
+					},
+				},
+			},
+			carbonIntensity: 250,
+			threshold:       200,
+			maxDelay:        24 * time.Hour,
+			podCreationTime: baseTime,
+			wantStatus:      framework.NewStatus(framework.Success, "latest start for deadline reached"),
+		},
+		{
+			name: "pod should not schedule - latest start for deadline not reached",
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					CreationTimestamp: metav1.NewTime(baseTime),
+					Annotations: map[string]string{
+						"carbon-aware-scheduler.kubernetes.io/deadline":           "2024-01-01T14:00:00Z",
+						"carbon-aware-scheduler.kubernetes.io/estimated-duration": "1h",
+					},
+				},
+			},
+			carbonIntensity: 250,
+			threshold:       200,
+			maxDelay:        24 * time.Hour,
+			podCreationTime: baseTime,
+			wantStatus:      framework.NewStatus(framework.Unschedulable, "Current carbon intensity (250.00) exceeds threshold (200.00)"),
+		},
 		{
 			name: "pod should not schedule - high electricity rate",
 			pod: &v1.Pod{
@@ -466,6 +648,35 @@ func TestCheckCarbonIntensityConstraints(t *testing.T) {
 			threshold:       200,
 			wantStatus:      framework.NewStatus(framework.Success, ""),
 		},
+		{
+			name: "zone override from annotation",
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"carbon-aware-scheduler.kubernetes.io/region": "green-region",
+					},
+				},
+			},
+			carbonIntensity: 250,
+			threshold:       200,
+			wantStatus:      framework.NewStatus(framework.Success, ""),
+		},
+		{
+			name: "zone override from cloud region annotation",
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"carbon-aware-scheduler.kubernetes.io/region": "us-west-2",
+					},
+				},
+			},
+			carbonIntensity: 150,
+			threshold:       200,
+			wantStatus: framework.NewStatus(
+				framework.Unschedulable,
+				"Current carbon intensity (400.00) exceeds threshold (200.00)",
+			),
+		},
 	}
 
 	for _, tt := range tests {
@@ -483,6 +694,8 @@ func TestCheckCarbonIntensityConstraints(t *testing.T) {
 			}
 
 			scheduler := newTestScheduler(&cfg.Config, tt.carbonIntensity, 0, baseTime)
+			scheduler.cache.Set("green-region", &api.ElectricityData{CarbonIntensity: 50, Timestamp: baseTime})
+			scheduler.cache.Set("US-NW-BPAT", &api.ElectricityData{CarbonIntensity: 400, Timestamp: baseTime})
 
 			got := scheduler.checkCarbonIntensityConstraints(context.Background(), tt.pod)
 			if got.Code() != tt.wantStatus.Code() || got.Message() != tt.wantStatus.Message() {
@@ -521,9 +734,9 @@ func TestPostBind(t *testing.T) {
 
 	// Verify power metric was stored
 	key := fmt.Sprintf("%s/%s/baseline", nodeName, pod.Name)
-	if value, ok := scheduler.powerMetrics.Load(key); !ok {
+	if power, ok := scheduler.powerMetrics.Load(key); !ok {
 		t.Errorf("PostBind() did not store power metric")
-	} else if power, ok := value.(float64); !ok || power != 100 { // Should be idle power since mock returns 0 CPU usage
+	} else if power != 100 { // Should be idle power since mock returns 0 CPU usage
 		t.Errorf("PostBind() stored power = %v, want %v", power, 100)
 	}
 }
@@ -589,7 +802,7 @@ func TestHandlePodCompletion(t *testing.T) {
 			cfg := &testConfig{
 				Config: config.Config{
 					Power: config.PowerConfig{
-						DefaultIdlePower: tt.baselinePower,
+						DefaultIdlePower: tt.finalPower, // mock metrics report 0 CPU, so node power is idle power
 						DefaultMaxPower:  400,
 					},
 				},
@@ -598,18 +811,21 @@ func TestHandlePodCompletion(t *testing.T) {
 			mockTime := tt.pod.Status.StartTime.Time.Add(tt.duration)
 			scheduler := newTestScheduler(&cfg.Config, tt.carbonIntensity, 0, mockTime)
 
-			// Store baseline power
-			baselineKey := fmt.Sprintf("%s/%s/baseline", tt.pod.Spec.NodeName, tt.pod.Name)
-			scheduler.powerMetrics.Store(baselineKey, tt.baselinePower)
+			// Track the pod from the baseline power at its start
+			scheduler.podEnergy = newPodEnergyTracker()
+			scheduler.podEnergy.track(tt.pod, tt.pod.Spec.NodeName, tt.baselinePower, tt.pod.Status.StartTime.Time)
 
 			// Test handlePodCompletion
 			scheduler.handlePodCompletion(tt.pod)
+			if _, _, ok := scheduler.podEnergy.take(tt.pod.UID, tt.finalPower, mockTime); ok {
+				t.Errorf("handlePodCompletion() did not account the sampled energy")
+			}
 
 			// Verify final power metric was stored
 			finalKey := fmt.Sprintf("%s/%s/final", tt.pod.Spec.NodeName, tt.pod.Name)
-			if value, ok := scheduler.powerMetrics.Load(finalKey); !ok {
+			if power, ok := scheduler.powerMetrics.Load(finalKey); !ok {
 				t.Errorf("handlePodCompletion() did not store power metric")
-			} else if power, ok := value.(float64); !ok || power != tt.finalPower {
+			} else if power != tt.finalPower {
 				t.Errorf("handlePodCompletion() stored power = %v, want %v", power, tt.finalPower)
 			}
 
@@ -643,3 +859,6842 @@ func TestHandlePodCompletion(t *testing.T) {
 		})
 	}
 }
+
+// usageMetricsClient reports fixed CPU usage of nodes and pods, in cores, and
+// optionally memory usage, in GB, and the CPU usage of further pod containers
+type usageMetricsClient struct {
+	metricsv1beta1.MetricsV1beta1Interface
+	nodes      map[string]float64
+	pods       map[string]float64 // namespace/name to usage
+	nodeMemory map[string]float64
+	podMemory  map[string]float64
+	containers map[string]map[string]float64 // namespace/name to container usage
+}
+
+func (m *usageMetricsClient) NodeMetricses() metricsv1beta1.NodeMetricsInterface {
+	return &usageNodeMetrics{usage: m.nodes, memory: m.nodeMemory}
+}
+
+func (m *usageMetricsClient) PodMetricses(namespace string) metricsv1beta1.PodMetricsInterface {
+	return &usagePodMetrics{namespace: namespace, usage: m.pods, memory: m.podMemory, containers: m.containers}
+}
+
+// memoryQuantity converts GB of memory usage to a quantity
+func memoryQuantity(gb float64) resource.Quantity {
+	return *resource.NewQuantity(int64(gb*1e9), resource.DecimalSI)
+}
+
+type usageNodeMetrics struct {
+	metricsv1beta1.NodeMetricsInterface
+	usage  map[string]float64
+	memory map[string]float64
+}
+
+func (m *usageNodeMetrics) Get(ctx context.Context, name string, opts metav1.GetOptions) (*metricsapi.NodeMetrics, error) {
+	cores, ok := m.usage[name]
+	if !ok {
+		return nil, fmt.Errorf("node %s not found", name)
+	}
+	return &metricsapi.NodeMetrics{Usage: v1.ResourceList{
+		v1.ResourceCPU:    *resource.NewMilliQuantity(int64(cores*1000), resource.DecimalSI),
+		v1.ResourceMemory: memoryQuantity(m.memory[name]),
+	}}, nil
+}
+
+type usagePodMetrics struct {
+	metricsv1beta1.PodMetricsInterface
+	namespace  string
+	usage      map[string]float64
+	memory     map[string]float64
+	containers map[string]map[string]float64
+}
+
+func (m *usagePodMetrics) Get(ctx context.Context, name string, opts metav1.GetOptions) (*metricsapi.PodMetrics, error) {
+	cores, ok := m.usage[m.namespace+"/"+name]
+	if !ok {
+		return nil, fmt.Errorf("pod %s/%s not found", m.namespace, name)
+	}
+	metrics := &metricsapi.PodMetrics{Containers: []metricsapi.ContainerMetrics{{
+		Name: "main",
+		Usage: v1.ResourceList{
+			v1.ResourceCPU:    *resource.NewMilliQuantity(int64(cores*1000), resource.DecimalSI),
+			v1.ResourceMemory: memoryQuantity(m.memory[m.namespace+"/"+name]),
+		},
+	}}}
+	for container, cores := range m.containers[m.namespace+"/"+name] {
+		metrics.Containers = append(metrics.Containers, metricsapi.ContainerMetrics{
+			Name:  container,
+			Usage: v1.ResourceList{v1.ResourceCPU: *resource.NewMilliQuantity(int64(cores*1000), resource.DecimalSI)},
+		})
+	}
+	return metrics, nil
+}
+
+func TestPodEnergyAttribution(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			Power: config.PowerConfig{
+				// Constant node power, so pods split 100W by their share of node usage
+				DefaultIdlePower:      100,
+				DefaultMaxPower:       100,
+				PodAttributionEnabled: true,
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 200, 0, baseTime)
+	mockClock := scheduler.clock.(*clock.MockClock)
+	scheduler.podEnergy = newPodEnergyTracker()
+	scheduler.metricsClient = &usageMetricsClient{
+		nodes: map[string]float64{"node-1": 2},
+		pods:  map[string]float64{"default/busy": 1.5, "default/idle": 0.5},
+	}
+
+	newPod := func(name string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(name)}}
+	}
+	busy, idle, pending := newPod("busy"), newPod("idle"), newPod("pending")
+	for _, pod := range []*v1.Pod{busy, idle, pending} {
+		scheduler.podEnergy.track(pod, "node-1", 100, baseTime)
+	}
+
+	scheduler.samplePodEnergy(context.Background())
+	mockClock.Set(baseTime.Add(30 * time.Minute))
+	scheduler.samplePodEnergy(context.Background())
+	mockClock.Set(baseTime.Add(time.Hour))
+
+	tests := []struct {
+		pod  *v1.Pod
+		want float64
+	}{
+		{pod: busy, want: 0.075},    // 75W for an hour
+		{pod: idle, want: 0.025},    // 25W for an hour
+		{pod: pending, want: 0.100}, // never reported by the metrics API, so its node's 100W
+	}
+	for _, tt := range tests {
+		got, _, ok := scheduler.podEnergy.take(tt.pod.UID, 100, mockClock.Now())
+		if !ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("take(%s) = %v, %v, want %v", tt.pod.Name, got, ok, tt.want)
+		}
+	}
+	if _, _, ok := scheduler.podEnergy.take(busy.UID, 100, mockClock.Now()); ok {
+		t.Error("take() should stop tracking a completed pod")
+	}
+}
+
+func TestSidecarEnergy(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	always := v1.ContainerRestartPolicyAlways
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "meshed", UID: "meshed"},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{
+				{Name: "setup"},
+				{Name: "log-shipper", RestartPolicy: &always},
+			},
+			Containers: []v1.Container{{Name: "main"}, {Name: "istio-proxy"}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		exclude bool
+		want    float64
+	}{
+		// 1 core of the job and its setup, and 0.5 cores of each sidecar, of 4 cores
+		{name: "included", exclude: false, want: 0.050},
+		{name: "excluded", exclude: true, want: 0.025},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					Power: config.PowerConfig{
+						DefaultIdlePower:      100,
+						DefaultMaxPower:       100,
+						PodAttributionEnabled: true,
+						ExcludeSidecars:       tt.exclude,
+						SidecarContainers:     []string{"istio-proxy"},
+					},
+				},
+			}
+			scheduler := newTestScheduler(&cfg.Config, 200, 0, baseTime)
+			mockClock := scheduler.clock.(*clock.MockClock)
+			scheduler.podEnergy = newPodEnergyTracker()
+			scheduler.metricsClient = &usageMetricsClient{
+				nodes: map[string]float64{"node-1": 4},
+				pods:  map[string]float64{"default/meshed": 0.5},
+				containers: map[string]map[string]float64{"default/meshed": {
+					"setup": 0.5, "log-shipper": 0.5, "istio-proxy": 0.5,
+				}},
+			}
+
+			scheduler.podEnergy.track(pod, "node-1", 100, baseTime)
+			scheduler.samplePodEnergy(context.Background())
+			mockClock.Set(baseTime.Add(time.Hour))
+			scheduler.samplePodEnergy(context.Background())
+
+			got, _, ok := scheduler.podEnergy.take(pod.UID, 100, mockClock.Now())
+			if !ok || math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("take() = %v, %v, want %v", got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodEnergyAccrual(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			Power: config.PowerConfig{
+				DefaultIdlePower: 100,
+				DefaultMaxPower:  100,
+				AccrualInterval:  time.Hour,
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 200, 0, baseTime)
+	mockClock := scheduler.clock.(*clock.MockClock)
+	scheduler.podEnergy = newPodEnergyTracker()
+	store, err := accounting.NewFileStore(filepath.Join(t.TempDir(), "accounting.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduler.accounting = store
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "long", UID: "long"},
+		Spec:       v1.PodSpec{NodeName: "node-1"},
+	}
+	scheduler.podEnergy.track(pod, "node-1", 100, baseTime)
+	scheduler.podEnergy.setEmbodiedRate(pod.UID, 10)
+
+	wantTotals := func(kWh, grams float64) {
+		t.Helper()
+		totals := store.Namespaces()["default"]
+		if math.Abs(totals.EnergyKWh-kWh) > 1e-9 || math.Abs(totals.EmissionsGrams-grams) > 1e-9 {
+			t.Errorf("totals = %+v, want %vkWh and %vg", totals, kWh, grams)
+		}
+	}
+
+	// 100W for an hour at 200 gCO2/kWh, plus 10g of embodied carbon
+	mockClock.Set(baseTime.Add(time.Hour))
+	scheduler.samplePodEnergy(context.Background())
+	scheduler.accruePodEnergy()
+	wantTotals(0.1, 30)
+
+	// Not yet due for another accrual
+	mockClock.Set(baseTime.Add(90 * time.Minute))
+	scheduler.samplePodEnergy(context.Background())
+	scheduler.accruePodEnergy()
+	wantTotals(0.1, 30)
+
+	// Accruals survive a restart, and completion accounts only the rest of the job
+	restored := newPodEnergyTracker()
+	restored.restore(scheduler.podEnergy.snapshot())
+	scheduler.podEnergy = restored
+
+	kWh, ok := scheduler.accountCompletedPod(pod, 100, baseTime.Add(2*time.Hour))
+	if !ok || math.Abs(kWh-0.2) > 1e-9 {
+		t.Errorf("accountCompletedPod() = %v, %v, want the job's 0.2kWh", kWh, ok)
+	}
+	wantTotals(0.2, 60)
+}
+
+func TestPodEnergySampling(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newPodEnergyTracker()
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "bursty", UID: "bursty"}}
+
+	// Node power rises from 100W at bind to 200W and 300W, integrated by the
+	// trapezoidal rule: 75Wh over the first half hour and 125Wh over the second
+	tracker.track(pod, "node-1", 100, baseTime)
+	tracker.record(pod.UID, 200, 200, false, baseTime.Add(30*time.Minute))
+	kWh, additionalKWh, ok := tracker.take(pod.UID, 300, baseTime.Add(time.Hour))
+	if !ok || math.Abs(kWh-0.2) > 1e-9 {
+		t.Errorf("take() energy = %v, %v, want 0.2", kWh, ok)
+	}
+	// Above the 100W baseline: 25Wh and 75Wh
+	if math.Abs(additionalKWh-0.1) > 1e-9 {
+		t.Errorf("take() additional energy = %v, want 0.1", additionalKWh)
+	}
+
+	if _, _, ok := tracker.take("untracked", 100, baseTime); ok {
+		t.Error("take() of an untracked pod should report no energy")
+	}
+	var disabled *podEnergyTracker
+	disabled.track(pod, "node-1", 100, baseTime)
+	if _, _, ok := disabled.take(pod.UID, 100, baseTime); ok {
+		t.Error("take() of a nil tracker should report no energy")
+	}
+}
+
+func TestUnfinishedPodAccounting(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			Power: config.PowerConfig{
+				DefaultIdlePower: 100,
+				DefaultMaxPower:  400,
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 200, 0, baseTime.Add(time.Hour))
+	scheduler.podEnergy = newPodEnergyTracker()
+
+	// A pod deleted while still running is accounted like a completed one
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "cancelled", UID: "cancelled"},
+		Spec:       v1.PodSpec{NodeName: "node-1"},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	scheduler.powerMetrics.Store("node-1/cancelled/baseline", 100, baseTime)
+	scheduler.podEnergy.track(pod, "node-1", 100, baseTime)
+
+	deleted, ok := deletedPod(cache.DeletedFinalStateUnknown{Key: "default/cancelled", Obj: pod})
+	if !ok || deleted.UID != pod.UID {
+		t.Fatalf("deletedPod() of tombstone = %v, %v, want the pod", deleted, ok)
+	}
+	if isFinished(deleted) {
+		t.Fatal("isFinished() of running pod = true, want false")
+	}
+	scheduler.handlePodCompletion(deleted)
+	if _, _, ok := scheduler.podEnergy.take(pod.UID, 100, baseTime.Add(time.Hour)); ok {
+		t.Error("handlePodCompletion() did not account the deleted pod")
+	}
+
+	// Its recorded power is dropped
+	scheduler.forgetPodPower(deleted)
+	for _, phase := range []string{"baseline", "final"} {
+		if _, ok := scheduler.powerMetrics.Load("node-1/cancelled/" + phase); ok {
+			t.Errorf("forgetPodPower() kept %s power", phase)
+		}
+	}
+
+	// Evicted pods finish as failed
+	evicted := pod.DeepCopy()
+	evicted.Status = v1.PodStatus{Phase: v1.PodFailed, Reason: "Evicted"}
+	if !isFinished(evicted) {
+		t.Error("isFinished() of evicted pod = false, want true")
+	}
+}
+
+func TestPowerMetricsStore(t *testing.T) {
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := newPowerMetricsStore(time.Hour, 2)
+
+	store.Store("node-1/a/baseline", 100, baseTime)
+	store.Store("node-1/b/baseline", 150, baseTime.Add(time.Minute))
+
+	// Full: the oldest entry is evicted
+	store.Store("node-1/c/baseline", 200, baseTime.Add(2*time.Minute))
+	if _, ok := store.Load("node-1/a/baseline"); ok {
+		t.Error("Store() did not evict the oldest entry when full")
+	}
+	if power, ok := store.Load("node-1/c/baseline"); !ok || power != 200 {
+		t.Errorf("Load() = %v, %v, want 200, true", power, ok)
+	}
+
+	// Overwriting an entry doesn't evict another
+	store.Store("node-1/c/baseline", 250, baseTime.Add(3*time.Minute))
+	if store.Len() != 2 {
+		t.Errorf("Len() after overwrite = %d, want 2", store.Len())
+	}
+
+	// Entries past the TTL are pruned
+	store.Store("node-2/d/final", 300, baseTime.Add(90*time.Minute))
+	if _, ok := store.Load("node-1/b/baseline"); ok {
+		t.Error("Store() did not prune an expired entry")
+	}
+	if store.Len() != 1 {
+		t.Errorf("Len() after pruning = %d, want 1", store.Len())
+	}
+
+	store.Delete("node-2/d/final")
+	if store.Len() != 0 {
+		t.Errorf("Len() after Delete() = %d, want 0", store.Len())
+	}
+
+	var disabled *powerMetricsStore
+	disabled.Store("node-1/a/baseline", 100, baseTime)
+	if _, ok := disabled.Load("node-1/a/baseline"); ok {
+		t.Error("Load() of a nil store should report nothing")
+	}
+}
+
+func TestAccountingRestore(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "accounting.json")
+	cfg := &testConfig{
+		Config: config.Config{
+			Power: config.PowerConfig{
+				DefaultIdlePower: 100,
+				DefaultMaxPower:  400,
+			},
+		},
+	}
+
+	pod := func(name string, phase v1.PodPhase) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: name, UID: types.UID(name)},
+			Spec:       v1.PodSpec{NodeName: "node-1"},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+
+	// Before the restart, two pods run for an hour at 100W
+	store, err := accounting.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore() error = %v", err)
+	}
+	before := newTestScheduler(&cfg.Config, 200, 0, baseTime)
+	before.podEnergy = newPodEnergyTracker()
+	before.accounting = store
+	before.podEnergy.track(pod("finished", v1.PodRunning), "node-1", 100, baseTime)
+	before.podEnergy.track(pod("deleted", v1.PodRunning), "node-1", 100, baseTime)
+	before.podEnergy.record("finished", 100, 100, false, baseTime.Add(time.Hour))
+	before.podEnergy.record("deleted", 100, 100, false, baseTime.Add(time.Hour))
+	before.flushAccounting()
+
+	// After it, one finished and the other was deleted
+	restored, err := accounting.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore() reload error = %v", err)
+	}
+	after := newTestScheduler(&cfg.Config, 200, 0, baseTime.Add(2*time.Hour))
+	after.podEnergy = newPodEnergyTracker()
+	after.accounting = restored
+	pods := restored.Pods()
+	after.podEnergy.restore(pods)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := indexer.Add(pod("finished", v1.PodSucceeded)); err != nil {
+		t.Fatal(err)
+	}
+	after.reconcileAccounting(pods, corelisters.NewPodLister(indexer))
+
+	for _, uid := range []types.UID{"finished", "deleted"} {
+		if after.podEnergy.tracked(uid) {
+			t.Errorf("reconcileAccounting() left pod %s tracked", uid)
+		}
+	}
+
+	// The finished pod is accounted through to now (0.2 kWh), the deleted one up
+	// to the last flush (0.1 kWh), at 200 gCO2/kWh
+	totals := restored.Namespaces()["team-a"]
+	if math.Abs(totals.EnergyKWh-0.3) > 1e-9 || math.Abs(totals.EmissionsGrams-60) > 1e-9 {
+		t.Errorf("namespace totals = %+v, want 0.3 kWh and 60 g", totals)
+	}
+}
+
+func TestRefreshZones(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+				Zones:  []string{"test-region", "other-region"},
+			},
+		},
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 150, 0, baseTime)
+	scheduler.cache.Set("other-region", &api.ElectricityData{CarbonIntensity: 300, Timestamp: baseTime})
+
+	// All tracked zones are fresh, so no API calls should be made
+	if err := scheduler.refreshZones(context.Background()); err != nil {
+		t.Errorf("refreshZones() error = %v, want nil", err)
+	}
+
+	data, err := scheduler.getZoneCarbonIntensityData(context.Background(), "other-region")
+	if err != nil {
+		t.Fatalf("getZoneCarbonIntensityData() error = %v", err)
+	}
+	if data.CarbonIntensity != 300 {
+		t.Errorf("getZoneCarbonIntensityData() intensity = %v, want 300", data.CarbonIntensity)
+	}
+
+	if got := cfg.API.TrackedZones(); len(got) != 2 {
+		t.Errorf("TrackedZones() = %v, want 2 deduplicated zones", got)
+	}
+
+	// Stale zones are fetched in one batch, from the marginal endpoint if supported
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/marginal" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"marginalCarbonIntensity":420,"timestamp":%q}`, baseTime.Format(time.RFC3339))
+	}))
+	defer server.Close()
+	scheduler.nodeZones.Store("node-1", "batch-region")
+	scheduler.nodeZones.Store("node-2", "marginal-region")
+	scheduler.apiClient = api.NewClient(config.APIConfig{
+		Key:          "test-key",
+		Timeout:      time.Second,
+		RateLimit:    100,
+		FetchWorkers: 2,
+		SignalType:   config.SignalTypeMarginal,
+		URL:          server.URL + "/intensity?zone=",
+		MarginalURL:  server.URL + "/marginal?zone=",
+	})
+	if err := scheduler.refreshZones(context.Background()); err != nil {
+		t.Fatalf("refreshZones() error = %v, want nil", err)
+	}
+	for _, zone := range []string{"batch-region", "marginal-region"} {
+		data, err := scheduler.getZoneCarbonIntensityData(context.Background(), zone)
+		if err != nil {
+			t.Fatalf("getZoneCarbonIntensityData(%s) error = %v", zone, err)
+		}
+		if data.CarbonIntensity != 420 || data.SignalType != config.SignalTypeMarginal {
+			t.Errorf("getZoneCarbonIntensityData(%s) = %+v, want the marginal intensity", zone, data)
+		}
+	}
+
+	// Marginal signals aren't requested from providers that don't serve them
+	requests.Store(0)
+	scheduler.nodeZones.Store("node-3", "unsupported-region")
+	scheduler.apiClient = api.NewClient(config.APIConfig{
+		Key:        "test-key",
+		Timeout:    time.Second,
+		RateLimit:  100,
+		SignalType: config.SignalTypeMarginal,
+		URL:        server.URL + "/intensity?zone=",
+	})
+	if err := scheduler.refreshZones(context.Background()); err == nil {
+		t.Error("refreshZones() error = nil, want an error without marginal support")
+	}
+	if n := requests.Load(); n != 0 {
+		t.Errorf("server received %d requests, want none without marginal support", n)
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:             "test-key",
+				Region:          "test-region",
+				Zones:           []string{"other-region"},
+				ForecastEnabled: true,
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 150, 0, baseTime)
+
+	// Intensity requests fail, forecast requests succeed
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/forecast" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, `{"forecast":[{"datetime":%q,"carbonIntensity":90}]}`, baseTime.Add(time.Hour).Format(time.RFC3339))
+	}))
+	defer server.Close()
+	scheduler.apiClient = api.NewClient(config.APIConfig{
+		Key:         "test-key",
+		Timeout:     time.Second,
+		RateLimit:   100,
+		URL:         server.URL + "/intensity?zone=",
+		ForecastURL: server.URL + "/forecast?zone=",
+	})
+
+	err := scheduler.healthCheck(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "zones") {
+		t.Errorf("healthCheck() error = %v, want the zone refresh error", err)
+	}
+	if stale := scheduler.cache.StaleForecasts([]string{"other-region"}); len(stale) != 0 {
+		t.Error("forecasts were not refreshed after the zones failed to")
+	}
+}
+
+func TestTrackNodeZone(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:           "test-key",
+				Region:        "US-CAL-CISO",
+				RegionZoneMap: map[string]string{"us-west-2": "US-NW-PACW"},
+			},
+		},
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 150, 0, baseTime)
+
+	nodes := []*v1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "aws-override", Labels: map[string]string{v1.LabelTopologyRegion: "us-west-2"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "gcp-builtin", Labels: map[string]string{v1.LabelTopologyRegion: "europe-west4"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "unknown", Labels: map[string]string{v1.LabelTopologyRegion: "on-prem"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "unlabeled"}},
+	}
+	for _, node := range nodes {
+		scheduler.trackNodeZone(node)
+	}
+
+	got := scheduler.trackedZones()
+	want := map[string]bool{"US-CAL-CISO": true, "US-NW-PACW": true, "NL": true}
+	if len(got) != len(want) {
+		t.Fatalf("trackedZones() = %v, want %v", got, want)
+	}
+	for _, zone := range got {
+		if !want[zone] {
+			t.Errorf("trackedZones() contains unexpected zone %q", zone)
+		}
+	}
+}
+
+func TestGetForecast(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+		},
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 175, 0, baseTime)
+
+	// Forecasts are disabled, so the forecast degrades to the latest cached value
+	points, err := scheduler.getForecast(context.Background(), "test-region", 6*time.Hour)
+	if err != nil {
+		t.Fatalf("getForecast() error = %v", err)
+	}
+	if len(points) != 1 || points[0].CarbonIntensity != 175 {
+		t.Errorf("getForecast() = %v, want single point with intensity 175", points)
+	}
+
+	// Cached forecasts take precedence
+	now := time.Now().Truncate(time.Hour)
+	scheduler.cache.SetForecast("test-region", []api.Point{
+		{Timestamp: now, CarbonIntensity: 180},
+		{Timestamp: now.Add(time.Hour), CarbonIntensity: 120},
+	})
+	points, err = scheduler.getForecast(context.Background(), "test-region", 6*time.Hour)
+	if err != nil {
+		t.Fatalf("getForecast() error = %v", err)
+	}
+	if len(points) != 2 || points[1].CarbonIntensity != 120 {
+		t.Errorf("getForecast() = %v, want cached forecast", points)
+	}
+
+	// Uncached forecasts are only fetched by the health check, never by scheduling
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		fmt.Fprintf(w, `{"forecast":[{"datetime":%q,"carbonIntensity":90}]}`, now.Format(time.RFC3339))
+	}))
+	defer server.Close()
+	scheduler.config.API.ForecastEnabled = true
+	scheduler.apiClient = api.NewClient(config.APIConfig{
+		Key:         "test-key",
+		Timeout:     time.Second,
+		RateLimit:   10,
+		ForecastURL: server.URL + "/?zone=",
+	})
+	points, err = scheduler.getForecast(context.Background(), "other-region", 6*time.Hour)
+	if err == nil || requests.Load() != 0 {
+		t.Errorf("getForecast() of an uncached zone = %v, %v after %d requests, want no request", points, err, requests.Load())
+	}
+	scheduler.cache.Set("other-region", &api.ElectricityData{CarbonIntensity: 140, Timestamp: baseTime})
+	points, err = scheduler.getForecast(context.Background(), "other-region", 6*time.Hour)
+	if err != nil || len(points) != 1 || points[0].CarbonIntensity != 140 || requests.Load() != 0 {
+		t.Errorf("getForecast() of an uncached forecast = %v, %v, want a flat forecast at 140 without requests", points, err)
+	}
+	scheduler.config.API.Zones = []string{"other-region"}
+	if err := scheduler.refreshForecasts(context.Background()); err != nil {
+		t.Fatalf("refreshForecasts() error = %v", err)
+	}
+	points, err = scheduler.getForecast(context.Background(), "other-region", 6*time.Hour)
+	if err != nil || len(points) != 1 || points[0].CarbonIntensity != 90 {
+		t.Errorf("getForecast() after refresh = %v, %v, want the refreshed forecast", points, err)
+	}
+}
+
+func TestMarginalSignal(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		want      float64
+		wantError bool
+	}{
+		{
+			name: "marginal value",
+			body: `{"carbonIntensity": 300, "marginalCarbonIntensity": 550}`,
+			want: 550,
+		},
+		{
+			name:      "missing marginal value",
+			body:      `{"carbonIntensity": 300}`,
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			client := api.NewClient(config.APIConfig{
+				Key:         "test-key",
+				Timeout:     time.Second,
+				RateLimit:   10,
+				MarginalURL: server.URL + "/?zone=",
+				SignalType:  config.SignalTypeMarginal,
+			})
+			defer client.Close()
+
+			data, err := client.GetCarbonIntensity(context.Background(), "test-region")
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("GetCarbonIntensity() = %+v, want error for missing marginal value", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetCarbonIntensity() error = %v", err)
+			}
+			if data.CarbonIntensity != tt.want || data.SignalType != config.SignalTypeMarginal {
+				t.Errorf("GetCarbonIntensity() = %v (%s), want %v (%s)", data.CarbonIntensity, data.SignalType, tt.want, config.SignalTypeMarginal)
+			}
+		})
+	}
+}
+
+func TestFallbackData(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	// Solar noon at 12:00 UTC, so noon yields the minimum intensity
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Fallback: config.FallbackConfig{
+				Enabled:         true,
+				After:           15 * time.Minute,
+				MinIntensity:    100,
+				MaxIntensity:    400,
+				SolarNoonOffset: 12 * time.Hour,
+			},
+		},
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 0, 0, baseTime)
+	scheduler.fallback = api.NewSynthetic(cfg.Fallback, scheduler.clock)
+
+	// API recently reachable, no fallback
+	scheduler.lastAPISuccess.Store(baseTime.Add(-5 * time.Minute).UnixNano())
+	if _, ok := scheduler.fallbackData(context.Background(), "test-region"); ok {
+		t.Errorf("fallbackData() ok = true before threshold, want false")
+	}
+
+	// API unreachable beyond threshold
+	scheduler.lastAPISuccess.Store(baseTime.Add(-time.Hour).UnixNano())
+	data, ok := scheduler.fallbackData(context.Background(), "test-region")
+	if !ok {
+		t.Fatalf("fallbackData() ok = false after threshold, want true")
+	}
+	if data.CarbonIntensity != 100 {
+		t.Errorf("fallbackData() intensity = %v at solar noon, want 100", data.CarbonIntensity)
+	}
+
+	if got := scheduler.fallback.At(baseTime.Add(12 * time.Hour)); got != 400 {
+		t.Errorf("At() = %v at solar midnight, want 400", got)
+	}
+}
+
+func TestSmoothedIntensity(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				SmoothingWindow:              3,
+				SmoothingAlpha:               0.5,
+			},
+		},
+	}
+
+	// A single noisy reading of 260 after a run of low values
+	scheduler := newTestScheduler(&cfg.Config, 260, 0, baseTime)
+	scheduler.smoother = newSmoother(cfg.Scheduling.SmoothingWindow, cfg.Scheduling.SmoothingAlpha)
+	for i, v := range []float64{500, 150, 150, 260} {
+		scheduler.recordSample("test-region", &api.ElectricityData{
+			CarbonIntensity: v,
+			Timestamp:       baseTime.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	// Window of 3 drops the 500 sample: 150 -> 150 -> 0.5*260 + 0.5*150 = 205
+	got := scheduler.checkCarbonIntensityConstraints(context.Background(), &v1.Pod{})
+	want := framework.NewStatus(framework.Unschedulable, "Current carbon intensity (205.00) exceeds threshold (200.00)")
+	if got.Code() != want.Code() || got.Message() != want.Message() {
+		t.Errorf("checkCarbonIntensityConstraints() = %v, want %v", got, want)
+	}
+}
+
+func TestCarbonIntensityHysteresis(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold:    220,
+				ReleaseCarbonIntensityThreshold: 180,
+			},
+		},
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 0, 0, baseTime)
+
+	steps := []struct {
+		intensity float64
+		wantCode  framework.Code
+	}{
+		{intensity: 200, wantCode: framework.Success},       // below block threshold
+		{intensity: 230, wantCode: framework.Unschedulable}, // blocks
+		{intensity: 200, wantCode: framework.Unschedulable}, // still above release threshold
+		{intensity: 170, wantCode: framework.Success},       // released
+		{intensity: 200, wantCode: framework.Success},       // stays released below block threshold
+	}
+
+	for i, step := range steps {
+		scheduler.cache.Set("test-region", &api.ElectricityData{CarbonIntensity: step.intensity, Timestamp: baseTime})
+		got := scheduler.checkCarbonIntensityConstraints(context.Background(), &v1.Pod{})
+		if got.Code() != step.wantCode {
+			t.Errorf("step %d: checkCarbonIntensityConstraints() at %v = %v, want %v", i, step.intensity, got.Code(), step.wantCode)
+		}
+	}
+}
+
+func TestCarbonIntensityHysteresisPerPodThreshold(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold:    220,
+				ReleaseCarbonIntensityThreshold: 180,
+			},
+		},
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 0, 0, baseTime)
+	mockClock := scheduler.clock.(*clock.MockClock)
+
+	if !scheduler.exceedsCarbonThreshold("test-region", 230, 220) {
+		t.Fatal("exceedsCarbonThreshold() = false above base threshold, want true")
+	}
+
+	// An aging pod's threshold changes every cycle; the zone latch must still hold
+	for i, threshold := range []float64{221, 222.5, 224, 226} {
+		if !scheduler.exceedsCarbonThreshold("test-region", 200, threshold) {
+			t.Errorf("cycle %d: exceedsCarbonThreshold(200, %v) = false, want latched", i, threshold)
+		}
+	}
+
+	// A pod whose threshold band sits below the intensity is released while latched
+	if scheduler.exceedsCarbonThreshold("test-region", 200, 250) {
+		t.Error("exceedsCarbonThreshold(200, 250) = true, want released below pod release threshold")
+	}
+
+	if got := len(scheduler.hysteresis.blocked); got != 1 {
+		t.Errorf("hysteresis entries = %d, want 1", got)
+	}
+
+	// Latches not evaluated for longer than latchTTL are evicted
+	scheduler.exceedsCarbonThreshold("other-zone", 230, 220)
+	mockClock.Set(baseTime.Add(latchTTL + time.Minute))
+	scheduler.exceedsCarbonThreshold("other-zone", 230, 220)
+	if _, ok := scheduler.hysteresis.blocked["test-region"]; ok {
+		t.Error("stale latch for test-region was not evicted")
+	}
+	if got := len(scheduler.hysteresis.blocked); got != 1 {
+		t.Errorf("hysteresis entries after eviction = %d, want 1", got)
+	}
+}
+
+func TestDemandResponse(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		event      demandresponse.Event
+		wantStatus *framework.Status
+	}{
+		{
+			name: "event tightens threshold",
+			event: demandresponse.Event{
+				ID:       "evt-1",
+				Start:    baseTime.Add(-time.Hour),
+				Duration: demandresponse.Duration{Duration: 2 * time.Hour},
+			},
+			wantStatus: framework.NewStatus(
+				framework.Unschedulable,
+				"Current carbon intensity (180.00) exceeds threshold (150.00)",
+			),
+		},
+		{
+			name: "event pauses admissions",
+			event: demandresponse.Event{
+				ID:       "evt-2",
+				Start:    baseTime.Add(-time.Hour),
+				Duration: demandresponse.Duration{Duration: 2 * time.Hour},
+				Pause:    true,
+			},
+			wantStatus: framework.NewStatus(
+				framework.Unschedulable,
+				"Demand response event evt-2 active until 2024-01-01T13:00:00Z",
+			),
+		},
+		{
+			name: "event not yet active",
+			event: demandresponse.Event{
+				ID:       "evt-3",
+				Start:    baseTime.Add(time.Hour),
+				Duration: demandresponse.Duration{Duration: 2 * time.Hour},
+				Pause:    true,
+			},
+			wantStatus: framework.NewStatus(framework.Success, ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+					},
+					DemandResponse: config.DemandResponseConfig{
+						Enabled:         true,
+						ThresholdFactor: 0.75,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 180, 0, baseTime)
+			scheduler.demandResp = demandresponse.NewManager(scheduler.clock.Now)
+			if err := scheduler.demandResp.Add(tt.event); err != nil {
+				t.Fatalf("Add() error = %v", err)
+			}
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(baseTime)}}
+			_, got := scheduler.PreFilter(context.Background(), nil, pod)
+			if got.Code() != tt.wantStatus.Code() || got.Message() != tt.wantStatus.Message() {
+				t.Errorf("PreFilter() = %v, want %v", got, tt.wantStatus)
+			}
+		})
+	}
+
+	t.Run("webhook requires the token", func(t *testing.T) {
+		manager := demandresponse.NewManager(func() time.Time { return baseTime })
+		handler := requireToken("secret", manager)
+		body := `{"eventID": "evt-1", "dtstart": "2024-01-01T11:00:00Z", "duration": "2h"}`
+
+		for _, auth := range []string{"", "Bearer wrong"} {
+			req := httptest.NewRequest(http.MethodPost, "/demand-response/events", strings.NewReader(body))
+			if auth != "" {
+				req.Header.Set("Authorization", auth)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("POST with %q = %d, want %d", auth, rec.Code, http.StatusUnauthorized)
+			}
+		}
+		if events := manager.Active(); len(events) != 0 {
+			t.Errorf("Active() = %v, want no events from unauthorized requests", events)
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/demand-response/events", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusAccepted {
+			t.Errorf("POST with the token = %d, want %d", rec.Code, http.StatusAccepted)
+		}
+		if events := manager.Active(); len(events) != 1 {
+			t.Errorf("Active() = %v, want the posted event", events)
+		}
+	})
+}
+
+func TestGridAlertConservationMode(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		alert      gridalert.Alert
+		pause      bool
+		wantStatus *framework.Status
+	}{
+		{
+			name:       "no active alert",
+			alert:      gridalert.Alert{ID: "flex-alert", Active: false},
+			wantStatus: framework.NewStatus(framework.Success, ""),
+		},
+		{
+			name:  "active alert tightens threshold",
+			alert: gridalert.Alert{ID: "flex-alert", Active: true},
+			wantStatus: framework.NewStatus(
+				framework.Unschedulable,
+				"Current carbon intensity (180.00) exceeds threshold (100.00)",
+			),
+		},
+		{
+			name:       "expired alert",
+			alert:      gridalert.Alert{ID: "flex-alert", Active: true, Until: baseTime.Add(-time.Minute)},
+			wantStatus: framework.NewStatus(framework.Success, ""),
+		},
+		{
+			name:  "active alert pauses admissions",
+			alert: gridalert.Alert{ID: "flex-alert", Active: true},
+			pause: true,
+			wantStatus: framework.NewStatus(
+				framework.Unschedulable,
+				"Grid alert flex-alert active, scheduler in conservation mode",
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+					},
+					GridAlert: config.GridAlertConfig{
+						Enabled:         true,
+						ThresholdFactor: 0.5,
+						PauseAdmissions: tt.pause,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 180, 0, baseTime)
+			scheduler.gridAlerts = gridalert.NewPoller("http://mock-url/", time.Minute, time.Second, scheduler.clock.Now)
+			scheduler.gridAlerts.Set(tt.alert)
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(baseTime)}}
+			_, got := scheduler.PreFilter(context.Background(), nil, pod)
+			if got.Code() != tt.wantStatus.Code() || got.Message() != tt.wantStatus.Message() {
+				t.Errorf("PreFilter() = %v, want %v", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCheckOnSiteConstraints(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		telemetry *onsite.Telemetry
+		wantCode  framework.Code
+	}{
+		{
+			name:     "no telemetry fails open",
+			wantCode: framework.Success,
+		},
+		{
+			name:      "generation above minimum",
+			telemetry: &onsite.Telemetry{ProductionWatts: 12000, StateOfCharge: 10},
+			wantCode:  framework.Success,
+		},
+		{
+			name:      "battery above minimum",
+			telemetry: &onsite.Telemetry{ProductionWatts: 0, StateOfCharge: 90},
+			wantCode:  framework.Success,
+		},
+		{
+			name:      "generation and battery below minimums",
+			telemetry: &onsite.Telemetry{ProductionWatts: 2000, StateOfCharge: 40},
+			wantCode:  framework.Unschedulable,
+		},
+		{
+			name:      "stale telemetry fails open",
+			telemetry: &onsite.Telemetry{ProductionWatts: 0, StateOfCharge: 0, Timestamp: baseTime.Add(-time.Hour)},
+			wantCode:  framework.Success,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					OnSite: config.OnSiteConfig{
+						Enabled:          true,
+						MaxAge:           5 * time.Minute,
+						MinProductionKW:  10,
+						MinStateOfCharge: 80,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 0, 0, baseTime)
+			scheduler.onSite = onsite.NewProvider("http://mock-url/", time.Minute, cfg.OnSite.MaxAge, time.Second, scheduler.clock.Now)
+			if tt.telemetry != nil {
+				scheduler.onSite.Set(*tt.telemetry)
+			}
+
+			if got := scheduler.checkOnSiteConstraints(); got.Code() != tt.wantCode {
+				t.Errorf("checkOnSiteConstraints() = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCheckThermalConstraints(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		pod        *v1.Pod
+		value      *float64
+		wantStatus *framework.Status
+	}{
+		{
+			name:       "no value fails open",
+			pod:        &v1.Pod{},
+			wantStatus: framework.NewStatus(framework.Success, ""),
+		},
+		{
+			name:       "under threshold",
+			pod:        &v1.Pod{},
+			value:      func() *float64 { v := 0.6; return &v }(),
+			wantStatus: framework.NewStatus(framework.Success, ""),
+		},
+		{
+			name:  "over threshold",
+			pod:   &v1.Pod{},
+			value: func() *float64 { v := 0.9; return &v }(),
+			wantStatus: framework.NewStatus(
+				framework.Unschedulable,
+				"Current cooling load (0.90) exceeds threshold (0.80)",
+			),
+		},
+		{
+			name: "custom threshold from annotation",
+			pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"carbon-aware-scheduler.kubernetes.io/thermal-threshold": "0.95",
+					},
+				},
+			},
+			value:      func() *float64 { v := 0.9; return &v }(),
+			wantStatus: framework.NewStatus(framework.Success, ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					Thermal: config.ThermalConfig{
+						Enabled:   true,
+						Threshold: 0.8,
+						MaxAge:    5 * time.Minute,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 0, 0, baseTime)
+			scheduler.thermal = promquery.NewGauge(nil, "", time.Minute, cfg.Thermal.MaxAge, scheduler.clock.Now)
+			if tt.value != nil {
+				scheduler.thermal.Set(*tt.value)
+			}
+
+			got := scheduler.checkThermalConstraints(tt.pod)
+			if got.Code() != tt.wantStatus.Code() || got.Message() != tt.wantStatus.Message() {
+				t.Errorf("checkThermalConstraints() = %v, want %v", got, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestOptimalWindow(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	now := time.Now().Truncate(time.Hour)
+
+	tests := []struct {
+		name     string
+		forecast []api.Point
+		duration string
+		wantCode framework.Code
+	}{
+		{
+			name: "lower window within max delay",
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 250},
+				{Timestamp: now.Add(2 * time.Hour), CarbonIntensity: 180},
+			},
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name: "no lower window within max delay",
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 250},
+				{Timestamp: now.Add(2 * time.Hour), CarbonIntensity: 260},
+				{Timestamp: now.Add(4 * time.Hour), CarbonIntensity: 300},
+			},
+			wantCode: framework.Success,
+		},
+		{
+			name: "lower window after max delay",
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 250},
+				{Timestamp: now.Add(3 * time.Hour), CarbonIntensity: 270},
+				{Timestamp: now.Add(8 * time.Hour), CarbonIntensity: 100},
+			},
+			wantCode: framework.Success,
+		},
+		{
+			name: "dip too short for estimated duration",
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 250},
+				{Timestamp: now.Add(time.Hour), CarbonIntensity: 150},
+				{Timestamp: now.Add(2 * time.Hour), CarbonIntensity: 400},
+			},
+			duration: "10h",
+			wantCode: framework.Success,
+		},
+		{
+			name: "lower mean intensity over estimated duration",
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 250},
+				{Timestamp: now.Add(time.Hour), CarbonIntensity: 400},
+				{Timestamp: now.Add(3 * time.Hour), CarbonIntensity: 100},
+			},
+			duration: "4h",
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:     "no forecast keeps gating",
+			wantCode: framework.Unschedulable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           6 * time.Hour,
+						OptimalWindowEnabled:         true,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 250, 0, now)
+			if tt.forecast != nil {
+				scheduler.cache.SetForecast("test-region", tt.forecast)
+			}
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now)}}
+			if tt.duration != "" {
+				pod.Annotations = map[string]string{
+					"carbon-aware-scheduler.kubernetes.io/estimated-duration": tt.duration,
+				}
+			}
+			if got := scheduler.checkCarbonIntensityConstraints(context.Background(), pod); got.Code() != tt.wantCode {
+				t.Errorf("checkCarbonIntensityConstraints() = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestProjectedStart(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	// Monday
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           6 * time.Hour,
+			},
+			Pricing: config.PricingConfig{
+				Enabled:  true,
+				Provider: "tou",
+				Schedules: []config.Schedule{
+					{DayOfWeek: "1", StartTime: "10:00", EndTime: "13:59", PeakRate: 0.30, OffPeakRate: 0.10},
+				},
+			},
+		},
+	}
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(baseTime)}}
+	deadline := baseTime.Add(6 * time.Hour)
+
+	t.Run("next off-peak transition", func(t *testing.T) {
+		scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+		scheduler.pricingImpl = tou.New(cfg.Pricing)
+
+		want := time.Date(2024, 1, 1, 14, 0, 0, 0, time.UTC)
+		if got := scheduler.projectedPriceStart(pod); !got.Equal(want) {
+			t.Errorf("projectedPriceStart() = %v, want %v", got, want)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		forecast []api.Point
+		want     time.Time
+	}{
+		{
+			name: "forecast window",
+			forecast: []api.Point{
+				{Timestamp: baseTime, CarbonIntensity: 250},
+				{Timestamp: baseTime.Add(time.Hour), CarbonIntensity: 220},
+				{Timestamp: baseTime.Add(2 * time.Hour), CarbonIntensity: 200},
+			},
+			want: baseTime.Add(2 * time.Hour),
+		},
+		{
+			name: "no window before deadline",
+			forecast: []api.Point{
+				{Timestamp: baseTime, CarbonIntensity: 250},
+				{Timestamp: baseTime.Add(time.Hour), CarbonIntensity: 260},
+			},
+			want: deadline,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+			scheduler.cache.SetForecast("test-region", tt.forecast)
+
+			if got := scheduler.projectedCarbonStart(context.Background(), pod); !got.Equal(tt.want) {
+				t.Errorf("projectedCarbonStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("event emitted once per projection", func(t *testing.T) {
+		recorder := events.NewFakeRecorder(10)
+		scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+		scheduler.handle = &mockHandle{recorder: recorder}
+
+		status := framework.NewStatus(framework.Unschedulable, "Current carbon intensity (250.00) exceeds threshold (200.00)")
+		scheduler.recordProjectedStart(pod, deadline, status)
+
+		annotated := pod.DeepCopy()
+		annotated.Annotations = map[string]string{projectedStartAnnotation: deadline.Format(time.RFC3339)}
+		scheduler.recordProjectedStart(annotated, deadline, status)
+
+		if got := len(recorder.Events); got != 1 {
+			t.Errorf("recorded %d events, want 1", got)
+		}
+	})
+}
+
+func TestLearnedDuration(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+			},
+		},
+	}
+
+	// Jobs created by the same CronJob share a template
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	cronJobRef := metav1.OwnerReference{Kind: "CronJob", Name: "nightly", UID: "cronjob-uid", Controller: ptr.To(true)}
+	for _, name := range []string{"nightly-1", "nightly-2"} {
+		indexer.Add(&batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "default",
+			UID:             types.UID(name + "-uid"),
+			OwnerReferences: []metav1.OwnerReference{cronJobRef},
+		}})
+	}
+
+	jobPod := func(job, image string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				OwnerReferences: []metav1.OwnerReference{
+					{Kind: "Job", Name: job, UID: types.UID(job + "-uid"), Controller: ptr.To(true)},
+				},
+			},
+			Spec: v1.PodSpec{Containers: []v1.Container{{Image: image}}},
+		}
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 150, 0, baseTime)
+	scheduler.durations = durations.NewEstimator(5, 10)
+	scheduler.jobLister = batchlisters.NewJobLister(indexer)
+
+	completed := jobPod("nightly-1", "etl:v1")
+	completed.Status = v1.PodStatus{
+		Phase:     v1.PodSucceeded,
+		StartTime: &metav1.Time{Time: baseTime.Add(-5 * time.Hour)},
+		ContainerStatuses: []v1.ContainerStatus{{
+			State: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{
+				FinishedAt: metav1.NewTime(baseTime.Add(-2 * time.Hour)),
+			}},
+		}},
+	}
+	scheduler.observeDuration(completed)
+
+	annotated := jobPod("nightly-2", "etl:v1")
+	annotated.Annotations = map[string]string{"carbon-aware-scheduler.kubernetes.io/estimated-duration": "1h"}
+
+	tests := []struct {
+		name   string
+		pod    *v1.Pod
+		want   time.Duration
+		wantOK bool
+	}{
+		{
+			name:   "next run of the same cronjob",
+			pod:    jobPod("nightly-2", "etl:v1"),
+			want:   3 * time.Hour,
+			wantOK: true,
+		},
+		{
+			name:   "different image",
+			pod:    jobPod("nightly-2", "etl:v2"),
+			wantOK: false,
+		},
+		{
+			name:   "annotation takes precedence",
+			pod:    annotated,
+			want:   time.Hour,
+			wantOK: true,
+		},
+		{
+			name:   "pod without job owner",
+			pod:    &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{Image: "etl:v1"}}}},
+			wantOK: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := scheduler.estimatedDuration(tt.pod)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("estimatedDuration() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestEnergyWeightedThresholds(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	pod := func(annotations map[string]string, cpu string) *v1.Pod {
+		p := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: annotations}}
+		if cpu != "" {
+			p.Spec.Containers = []v1.Container{{
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)},
+				},
+			}}
+		}
+		return p
+	}
+
+	tests := []struct {
+		name            string
+		pod             *v1.Pod
+		carbonIntensity float64
+		wantCode        framework.Code
+	}{
+		{
+			name:            "no estimate under threshold",
+			pod:             pod(nil, ""),
+			carbonIntensity: 180,
+			wantCode:        framework.Success,
+		},
+		{
+			name:            "heavy annotation tightens threshold",
+			pod:             pod(map[string]string{"carbon-aware-scheduler.kubernetes.io/estimated-energy-kwh": "50"}, ""),
+			carbonIntensity: 180,
+			wantCode:        framework.Unschedulable,
+		},
+		{
+			name:            "heavy from requests and duration",
+			pod:             pod(map[string]string{"carbon-aware-scheduler.kubernetes.io/estimated-duration": "100h"}, "16"),
+			carbonIntensity: 180,
+			wantCode:        framework.Unschedulable,
+		},
+		{
+			name:            "no estimate over threshold",
+			pod:             pod(nil, ""),
+			carbonIntensity: 250,
+			wantCode:        framework.Unschedulable,
+		},
+		{
+			name:            "light annotation admitted",
+			pod:             pod(map[string]string{"carbon-aware-scheduler.kubernetes.io/estimated-energy-kwh": "0.5"}, ""),
+			carbonIntensity: 250,
+			wantCode:        framework.Success,
+		},
+		{
+			name:            "light from requests and duration",
+			pod:             pod(map[string]string{"carbon-aware-scheduler.kubernetes.io/estimated-duration": "10h"}, "1"),
+			carbonIntensity: 250,
+			wantCode:        framework.Success,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						EnergyLightKWh:               1,
+						EnergyHeavyKWh:               10,
+						EnergyHeavyFactor:            0.8,
+					},
+					Power: config.PowerConfig{
+						WattsPerCore: 10,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, tt.carbonIntensity, 0, baseTime)
+			if got := scheduler.checkCarbonIntensityConstraints(context.Background(), tt.pod); got.Code() != tt.wantCode {
+				t.Errorf("checkCarbonIntensityConstraints() = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestTrendAwareDecisions(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	now := time.Now().Truncate(time.Hour)
+
+	tests := []struct {
+		name     string
+		forecast []api.Point
+		history  []history.Sample
+		wantCode framework.Code
+	}{
+		{
+			name: "forecast rising",
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 250},
+				{Timestamp: now.Add(time.Hour), CarbonIntensity: 270},
+				{Timestamp: now.Add(2 * time.Hour), CarbonIntensity: 320},
+			},
+			wantCode: framework.Success,
+		},
+		{
+			name: "forecast dips",
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 250},
+				{Timestamp: now.Add(time.Hour), CarbonIntensity: 270},
+				{Timestamp: now.Add(2 * time.Hour), CarbonIntensity: 220},
+			},
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name: "history rising without forecast",
+			history: []history.Sample{
+				{Timestamp: now.Add(-2 * time.Hour), CarbonIntensity: 200},
+				{Timestamp: now.Add(-time.Hour), CarbonIntensity: 230},
+				{Timestamp: now, CarbonIntensity: 250},
+			},
+			wantCode: framework.Success,
+		},
+		{
+			name: "history falling without forecast",
+			history: []history.Sample{
+				{Timestamp: now.Add(-2 * time.Hour), CarbonIntensity: 300},
+				{Timestamp: now.Add(-time.Hour), CarbonIntensity: 280},
+				{Timestamp: now, CarbonIntensity: 250},
+			},
+			wantCode: framework.Unschedulable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						TrendHorizon:                 3 * time.Hour,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 250, 0, now)
+			if tt.forecast != nil {
+				scheduler.cache.SetForecast("test-region", tt.forecast)
+			}
+			store, err := history.NewFileStore("", 24*time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range tt.history {
+				store.Add("test-region", s)
+			}
+			scheduler.history = store
+
+			if got := scheduler.checkCarbonIntensityConstraints(context.Background(), &v1.Pod{}); got.Code() != tt.wantCode {
+				t.Errorf("checkCarbonIntensityConstraints() = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPercentileThreshold(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	now := time.Now().Truncate(time.Hour)
+
+	tests := []struct {
+		name            string
+		samples         int
+		carbonIntensity float64
+		wantCode        framework.Code
+	}{
+		{
+			name:            "below percentile",
+			samples:         24,
+			carbonIntensity: 260,
+			wantCode:        framework.Success,
+		},
+		{
+			name:            "above percentile",
+			samples:         24,
+			carbonIntensity: 280,
+			wantCode:        framework.Unschedulable,
+		},
+		{
+			name:            "too few samples uses base threshold",
+			samples:         12,
+			carbonIntensity: 260,
+			wantCode:        framework.Unschedulable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						ThresholdPercentile:          75,
+						PercentileWindow:             7 * 24 * time.Hour,
+						PercentileMinSamples:         24,
+					},
+				},
+			}
+
+			// Hourly samples of 100, 110, ..., the 75th percentile of 24 samples is 272.5
+			store, err := history.NewFileStore("", 7*24*time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < tt.samples; i++ {
+				store.Add("test-region", history.Sample{
+					Timestamp:       now.Add(time.Duration(i-tt.samples) * time.Hour),
+					CarbonIntensity: float64(100 + 10*i),
+				})
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, tt.carbonIntensity, 0, now)
+			scheduler.history = store
+
+			if got := scheduler.checkCarbonIntensityConstraints(context.Background(), &v1.Pod{}); got.Code() != tt.wantCode {
+				t.Errorf("checkCarbonIntensityConstraints() = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAgingThreshold(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		curve     string
+		waited    time.Duration
+		submitted time.Duration
+		wantCode  framework.Code
+	}{
+		{
+			name:     "no aging",
+			curve:    config.AgingCurveNone,
+			waited:   5 * time.Hour,
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:     "linear just created",
+			curve:    config.AgingCurveLinear,
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:     "linear halfway relaxes to 300",
+			curve:    config.AgingCurveLinear,
+			waited:   5 * time.Hour,
+			wantCode: framework.Success,
+		},
+		{
+			name:     "quadratic halfway relaxes to 250",
+			curve:    config.AgingCurveQuadratic,
+			waited:   5 * time.Hour,
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:     "quadratic near deadline",
+			curve:    config.AgingCurveQuadratic,
+			waited:   9 * time.Hour,
+			wantCode: framework.Success,
+		},
+		{
+			name:      "back-dated submission time is ignored",
+			curve:     config.AgingCurveLinear,
+			submitted: 5 * time.Hour,
+			wantCode:  framework.Unschedulable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           10 * time.Hour,
+						AgingCurve:                   tt.curve,
+						AgingMaxFactor:               2,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 280, 0, baseTime)
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(baseTime.Add(-tt.waited))}}
+			if tt.submitted > 0 {
+				pod.Annotations = map[string]string{
+					submittedAtAnnotation: baseTime.Add(-tt.submitted).Format(time.RFC3339),
+				}
+			}
+
+			if got := scheduler.checkCarbonIntensityConstraints(context.Background(), pod); got.Code() != tt.wantCode {
+				t.Errorf("checkCarbonIntensityConstraints() = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestSubmittedAtMaxDelay(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 300, 0, baseTime)
+
+	tests := []struct {
+		name        string
+		submittedAt string
+		want        bool
+	}{
+		{name: "no stamp uses creation time", want: false},
+		{name: "back-dated stamp uses creation time", submittedAt: "2023-12-31T11:00:00Z", want: false},
+		{name: "invalid stamp uses creation time", submittedAt: "yesterday", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(baseTime.Add(-time.Hour))}}
+			if tt.submittedAt != "" {
+				pod.Annotations = map[string]string{submittedAtAnnotation: tt.submittedAt}
+			}
+			if got := scheduler.hasExceededMaxDelay(pod); got != tt.want {
+				t.Errorf("hasExceededMaxDelay() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// admit runs a pod through PreFilter and, if admitted, reserves it on a node
+func admit(scheduler *CarbonAwareScheduler, pod *v1.Pod) *framework.Status {
+	state := framework.NewCycleState()
+	_, status := scheduler.PreFilter(context.Background(), state, pod)
+	if status.IsSuccess() {
+		scheduler.Reserve(context.Background(), state, pod, "node-1")
+	}
+	return status
+}
+
+func TestReleaseBatch(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+				ReleaseBatchSize:             2,
+				ReleaseBatchInterval:         30 * time.Second,
+			},
+		},
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+	scheduler.releaser = newBatchReleaser(cfg.Scheduling.ReleaseBatchSize, 0, cfg.Scheduling.ReleaseBatchInterval)
+	mockClock := scheduler.clock.(*clock.MockClock)
+
+	var pods []*v1.Pod
+	for i := 0; i < 3; i++ {
+		pods = append(pods, &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:              fmt.Sprintf("pod-%d", i),
+			UID:               types.UID(fmt.Sprintf("uid-%d", i)),
+			CreationTimestamp: metav1.NewTime(baseTime),
+		}})
+	}
+
+	// Hold all pods while intensity is high
+	for _, pod := range pods {
+		if status := admit(scheduler, pod); status.Code() != framework.Unschedulable {
+			t.Fatalf("PreFilter(%s) = %v, want Unschedulable", pod.Name, status)
+		}
+	}
+
+	// A pod that was never held isn't subject to batching
+	newPod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "new", UID: "uid-new", CreationTimestamp: metav1.NewTime(baseTime)}}
+
+	// Window opens
+	scheduler.cache.Set("test-region", &api.ElectricityData{CarbonIntensity: 150, Timestamp: baseTime})
+
+	wantCodes := []framework.Code{framework.Success, framework.Success, framework.Unschedulable}
+	for i, pod := range pods {
+		if status := admit(scheduler, pod); status.Code() != wantCodes[i] {
+			t.Errorf("PreFilter(%s) = %v, want %v", pod.Name, status, wantCodes[i])
+		}
+	}
+	if status := admit(scheduler, newPod); !status.IsSuccess() {
+		t.Errorf("PreFilter(%s) = %v, want Success", newPod.Name, status)
+	}
+
+	// Next batch
+	mockClock.Set(baseTime.Add(30 * time.Second))
+	if status := admit(scheduler, pods[2]); !status.IsSuccess() {
+		t.Errorf("PreFilter(%s) = %v, want Success", pods[2].Name, status)
+	}
+}
+
+func TestAdmissionRate(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	cpuPod := func(cpu string) *v1.Pod {
+		return &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)},
+			},
+		}}}}
+	}
+
+	tests := []struct {
+		name        string
+		weightByCPU bool
+		pods        []*v1.Pod
+		advance     time.Duration
+		wantCodes   []framework.Code
+	}{
+		{
+			name:      "burst then paced",
+			pods:      []*v1.Pod{{}, {}, {}},
+			wantCodes: []framework.Code{framework.Success, framework.Success, framework.Unschedulable},
+		},
+		{
+			name:      "tokens refill",
+			pods:      []*v1.Pod{{}, {}, {}},
+			advance:   10 * time.Second,
+			wantCodes: []framework.Code{framework.Success, framework.Success, framework.Success},
+		},
+		{
+			name:        "weighted by CPU",
+			weightByCPU: true,
+			pods:        []*v1.Pod{cpuPod("1500m"), cpuPod("1")},
+			wantCodes:   []framework.Code{framework.Success, framework.Unschedulable},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           24 * time.Hour,
+						AdmissionRate:                6,
+						AdmissionBurst:               2,
+						AdmissionWeightByCPU:         tt.weightByCPU,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 150, 0, baseTime)
+			scheduler.pacer = newTokenBucket(cfg.Scheduling.AdmissionRate, cfg.Scheduling.AdmissionBurst, baseTime)
+			mockClock := scheduler.clock.(*clock.MockClock)
+
+			for i, pod := range tt.pods {
+				if i == len(tt.pods)-1 {
+					mockClock.Set(baseTime.Add(tt.advance))
+				}
+				if status := admit(scheduler, pod); status.Code() != tt.wantCodes[i] {
+					t.Errorf("PreFilter() for pod %d = %v, want %v", i, status, tt.wantCodes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestUnreserveReturnsAdmissionBudget(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+				AdmissionRate:                1,
+				AdmissionBurst:               1,
+				ReleaseBatchSize:             1,
+				ReleaseBatchInterval:         time.Minute,
+			},
+		},
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 150, 0, baseTime)
+	scheduler.pacer = newTokenBucket(cfg.Scheduling.AdmissionRate, cfg.Scheduling.AdmissionBurst, baseTime)
+	scheduler.releaser = newBatchReleaser(cfg.Scheduling.ReleaseBatchSize, 0, cfg.Scheduling.ReleaseBatchInterval)
+
+	first := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "first", UID: "uid-first", CreationTimestamp: metav1.NewTime(baseTime)}}
+	second := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "second", UID: "uid-second", CreationTimestamp: metav1.NewTime(baseTime)}}
+	scheduler.heldPods.Store(first.UID, struct{}{})
+
+	// Pods rejected after PreFilter, e.g. by Filter, never consume the budget
+	if _, status := scheduler.PreFilter(context.Background(), framework.NewCycleState(), first); !status.IsSuccess() {
+		t.Fatalf("PreFilter(first) = %v, want Success", status)
+	}
+
+	// Pods failing after Reserve return it
+	state := framework.NewCycleState()
+	if _, status := scheduler.PreFilter(context.Background(), state, first); !status.IsSuccess() {
+		t.Fatalf("PreFilter(first) = %v, want Success", status)
+	}
+	scheduler.Reserve(context.Background(), state, first, "node-1")
+	if status := admit(scheduler, second); status.IsSuccess() {
+		t.Fatalf("admit(second) = %v, want Unschedulable while budget is reserved", status)
+	}
+	scheduler.Unreserve(context.Background(), state, first, "node-1")
+
+	if _, held := scheduler.heldPods.Load(first.UID); !held {
+		t.Error("expected unreserved pod to be held again")
+	}
+	if status := admit(scheduler, second); !status.IsSuccess() {
+		t.Errorf("admit(second) = %v, want Success after Unreserve", status)
+	}
+}
+
+func TestMaintenanceWindows(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	// Monday
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		windows  []config.Schedule
+		wantCode framework.Code
+	}{
+		{
+			name:     "no windows",
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:     "inside window",
+			windows:  []config.Schedule{{DayOfWeek: "1", StartTime: "11:00", EndTime: "13:00"}},
+			wantCode: framework.Success,
+		},
+		{
+			name:     "other day",
+			windows:  []config.Schedule{{DayOfWeek: "06", StartTime: "11:00", EndTime: "13:00"}},
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:     "later in the day",
+			windows:  []config.Schedule{{DayOfWeek: "1", StartTime: "13:00", EndTime: "14:00"}},
+			wantCode: framework.Unschedulable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+					},
+					Maintenance: config.MaintenanceConfig{
+						Windows: tt.windows,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+			if _, got := scheduler.checkAdmission(context.Background(), &v1.Pod{}); got.Code() != tt.wantCode {
+				t.Errorf("checkAdmission() = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestReleaseAnnotation(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+			},
+		},
+	}
+
+	recorder := events.NewFakeRecorder(10)
+	scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+	scheduler.handle = &mockHandle{recorder: recorder}
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "etl", UID: "uid-etl", CreationTimestamp: metav1.NewTime(baseTime)}}
+	if _, status := scheduler.PreFilter(context.Background(), nil, pod); status.Code() != framework.Unschedulable {
+		t.Fatalf("PreFilter() = %v, want Unschedulable", status)
+	}
+
+	released := pod.DeepCopy()
+	released.Annotations = map[string]string{"carbon-aware-scheduler.kubernetes.io/release": "true"}
+	scheduler.handleRelease(released)
+
+	if _, held := scheduler.heldPods.Load(pod.UID); held {
+		t.Error("expected released pod to no longer be held")
+	}
+	var releaseEvents int
+	for len(recorder.Events) > 0 {
+		if strings.Contains(<-recorder.Events, "CarbonAwareRelease") {
+			releaseEvents++
+		}
+	}
+	if releaseEvents != 1 {
+		t.Errorf("recorded %d release events, want 1", releaseEvents)
+	}
+
+	want := framework.NewStatus(framework.Success, "released by annotation")
+	if _, status := scheduler.PreFilter(context.Background(), nil, released); status.Code() != want.Code() || status.Message() != want.Message() {
+		t.Errorf("PreFilter() = %v, want %v", status, want)
+	}
+}
+
+func TestEnforcementMode(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	indexer.Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "onboarding",
+		Labels: map[string]string{"carbon-aware-scheduler.kubernetes.io/mode": "audit"},
+	}})
+	indexer.Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+		Name:   "trusted",
+		Labels: map[string]string{"carbon-aware-scheduler.kubernetes.io/mode": "enforce"},
+	}})
+	indexer.Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}})
+
+	tests := []struct {
+		name        string
+		defaultMode string
+		namespace   string
+		wantCode    framework.Code
+	}{
+		{
+			name:        "enforced by default",
+			defaultMode: config.EnforcementModeEnforce,
+			namespace:   "default",
+			wantCode:    framework.Unschedulable,
+		},
+		{
+			name:        "audit namespace",
+			defaultMode: config.EnforcementModeEnforce,
+			namespace:   "onboarding",
+			wantCode:    framework.Success,
+		},
+		{
+			name:        "audited by default",
+			defaultMode: config.EnforcementModeAudit,
+			namespace:   "default",
+			wantCode:    framework.Success,
+		},
+		{
+			name:        "enforce namespace",
+			defaultMode: config.EnforcementModeAudit,
+			namespace:   "trusted",
+			wantCode:    framework.Unschedulable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           24 * time.Hour,
+						EnforcementMode:              tt.defaultMode,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+			scheduler.namespaceLister = corelisters.NewNamespaceLister(indexer)
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: tt.namespace, CreationTimestamp: metav1.NewTime(baseTime)}}
+			if _, status := scheduler.PreFilter(context.Background(), nil, pod); status.Code() != tt.wantCode {
+				t.Errorf("PreFilter() = %v, want %v", status, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestStrictness(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	now := time.Now().Truncate(time.Hour)
+
+	tests := []struct {
+		name            string
+		mode            string
+		carbonIntensity float64
+		forecast        []api.Point
+		wantCode        framework.Code
+	}{
+		{
+			name:            "best-effort waits for window within horizon",
+			mode:            "best-effort",
+			carbonIntensity: 250,
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 250},
+				{Timestamp: now.Add(time.Hour), CarbonIntensity: 180},
+			},
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:            "best-effort admitted when window is beyond horizon",
+			mode:            "best-effort",
+			carbonIntensity: 250,
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 250},
+				{Timestamp: now.Add(time.Hour), CarbonIntensity: 260},
+				{Timestamp: now.Add(4 * time.Hour), CarbonIntensity: 180},
+			},
+			wantCode: framework.Success,
+		},
+		{
+			name:            "best-effort admitted without forecast",
+			mode:            "best-effort",
+			carbonIntensity: 250,
+			wantCode:        framework.Success,
+		},
+		{
+			name:            "strict waits for cleaner window under threshold",
+			mode:            "strict",
+			carbonIntensity: 150,
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 150},
+				{Timestamp: now.Add(3 * time.Hour), CarbonIntensity: 120},
+			},
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:            "strict admitted at cleanest window",
+			mode:            "strict",
+			carbonIntensity: 150,
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 150},
+				{Timestamp: now.Add(3 * time.Hour), CarbonIntensity: 170},
+			},
+			wantCode: framework.Success,
+		},
+		{
+			name:            "strict ignores optimal window shortcut",
+			mode:            "strict",
+			carbonIntensity: 250,
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 250},
+				{Timestamp: now.Add(3 * time.Hour), CarbonIntensity: 300},
+			},
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:            "default uses optimal window shortcut",
+			carbonIntensity: 250,
+			forecast: []api.Point{
+				{Timestamp: now, CarbonIntensity: 250},
+				{Timestamp: now.Add(3 * time.Hour), CarbonIntensity: 300},
+			},
+			wantCode: framework.Success,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           12 * time.Hour,
+						OptimalWindowEnabled:         true,
+						BestEffortHorizon:            2 * time.Hour,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, tt.carbonIntensity, 0, now)
+			if tt.forecast != nil {
+				scheduler.cache.SetForecast("test-region", tt.forecast)
+			}
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now)}}
+			if tt.mode != "" {
+				pod.Annotations = map[string]string{"carbon-aware-scheduler.kubernetes.io/strictness": tt.mode}
+			}
+			if got := scheduler.checkCarbonIntensityConstraints(context.Background(), pod); got.Code() != tt.wantCode {
+				t.Errorf("checkCarbonIntensityConstraints() = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+
+	t.Run("off skips gating", func(t *testing.T) {
+		cfg := &config.Config{
+			API:        config.APIConfig{Region: "test-region"},
+			Scheduling: config.SchedulingConfig{BaseCarbonIntensityThreshold: 200, MaxSchedulingDelay: 12 * time.Hour},
+		}
+		scheduler := newTestScheduler(cfg, 250, 0, now)
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(now),
+			Annotations:       map[string]string{"carbon-aware-scheduler.kubernetes.io/strictness": "off"},
+		}}
+		if _, status := scheduler.PreFilter(context.Background(), nil, pod); !status.IsSuccess() {
+			t.Errorf("PreFilter() = %v, want Success", status)
+		}
+	})
+}
+
+func TestWeightedDecision(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	loadedNode := func(requested, allocatable int64) *framework.NodeInfo {
+		n := framework.NewNodeInfo()
+		n.Requested = &framework.Resource{MilliCPU: requested}
+		n.Allocatable = &framework.Resource{MilliCPU: allocatable}
+		return n
+	}
+
+	tests := []struct {
+		name            string
+		weights         config.DecisionWeights
+		cutoff          float64
+		carbonIntensity float64
+		rate            float64
+		nodes           tf.NodeInfoLister
+		wantCode        framework.Code
+	}{
+		{
+			name:            "carbon above threshold offset by cheap price",
+			weights:         config.DecisionWeights{Carbon: 1, Price: 1},
+			cutoff:          0.5,
+			carbonIntensity: 250,
+			rate:            0.05,
+			wantCode:        framework.Success,
+		},
+		{
+			name:            "carbon and price both above thresholds",
+			weights:         config.DecisionWeights{Carbon: 1, Price: 1},
+			cutoff:          0.5,
+			carbonIntensity: 250,
+			rate:            0.2,
+			wantCode:        framework.Unschedulable,
+		},
+		{
+			name:            "carbon alone at threshold",
+			weights:         config.DecisionWeights{Carbon: 1},
+			cutoff:          0.5,
+			carbonIntensity: 200,
+			rate:            0.5,
+			wantCode:        framework.Success,
+		},
+		{
+			name:            "high cluster load tips the balance",
+			weights:         config.DecisionWeights{Carbon: 1, Load: 1},
+			cutoff:          0.5,
+			carbonIntensity: 180,
+			nodes:           tf.NodeInfoLister{loadedNode(900, 1000), loadedNode(1000, 1000)},
+			wantCode:        framework.Unschedulable,
+		},
+		{
+			name:            "idle cluster offsets dirty grid",
+			weights:         config.DecisionWeights{Carbon: 1, Load: 1},
+			cutoff:          0.5,
+			carbonIntensity: 300,
+			nodes:           tf.NodeInfoLister{loadedNode(0, 1000), loadedNode(100, 1000)},
+			wantCode:        framework.Success,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           24 * time.Hour,
+						DecisionMode:                 config.DecisionModeWeighted,
+						Weights:                      tt.weights,
+						WeightedCutoff:               tt.cutoff,
+					},
+					Pricing: config.PricingConfig{
+						Enabled:   true,
+						Schedules: []config.Schedule{{OffPeakRate: 0.1}},
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, tt.carbonIntensity, tt.rate, baseTime)
+			scheduler.handle = &mockHandle{nodeInfos: tt.nodes}
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(baseTime)}}
+			if _, status := scheduler.PreFilter(context.Background(), nil, pod); status.Code() != tt.wantCode {
+				t.Errorf("PreFilter() = %v, want %v", status, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestWeekendSchedule(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	monday := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	saturday := time.Date(2024, 1, 6, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		now           time.Time
+		weekend       float64
+		wantCode      framework.Code
+		wantBatchSize int
+		wantLimit     int
+	}{
+		{
+			name:          "weekday uses base threshold",
+			now:           monday,
+			weekend:       150,
+			wantCode:      framework.Success,
+			wantBatchSize: 5,
+			wantLimit:     5,
+		},
+		{
+			name:          "weekend uses weekend threshold",
+			now:           saturday,
+			weekend:       150,
+			wantCode:      framework.Unschedulable,
+			wantBatchSize: 1,
+			wantLimit:     1,
+		},
+		{
+			name:          "weekend without weekend threshold",
+			now:           saturday,
+			wantCode:      framework.Success,
+			wantBatchSize: 1,
+			wantLimit:     1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold:    200,
+						WeekendCarbonIntensityThreshold: tt.weekend,
+						MaxSchedulingDelay:              24 * time.Hour,
+						MaxConcurrentPods:               5,
+						WeekendMaxConcurrentPods:        1,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 180, 0, tt.now)
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(tt.now)}}
+			if _, status := scheduler.PreFilter(context.Background(), nil, pod); status.Code() != tt.wantCode {
+				t.Errorf("PreFilter() = %v, want %v", status, tt.wantCode)
+			}
+
+			releaser := newBatchReleaser(5, 1, 30*time.Second)
+			if got := releaser.sizeAt(tt.now); got != tt.wantBatchSize {
+				t.Errorf("sizeAt() = %d, want %d", got, tt.wantBatchSize)
+			}
+			if got := scheduler.concurrencyLimit(); got != tt.wantLimit {
+				t.Errorf("concurrencyLimit() = %d, want %d", got, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestLess(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	queuedPod := func(created time.Time, priority int32, annotations map[string]string) *framework.QueuedPodInfo {
+		return &framework.QueuedPodInfo{
+			PodInfo: &framework.PodInfo{Pod: &v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(created), Annotations: annotations},
+				Spec:       v1.PodSpec{Priority: &priority},
+			}},
+			Timestamp: created,
+		}
+	}
+
+	tests := []struct {
+		name string
+		p1   *framework.QueuedPodInfo
+		p2   *framework.QueuedPodInfo
+		want bool
+	}{
+		{
+			name: "higher priority goes first",
+			p1:   queuedPod(baseTime, 0, nil),
+			p2:   queuedPod(baseTime.Add(time.Hour), 100, nil),
+			want: false,
+		},
+		{
+			name: "older pod has less delay budget left",
+			p1:   queuedPod(baseTime, 0, nil),
+			p2:   queuedPod(baseTime.Add(time.Hour), 0, nil),
+			want: true,
+		},
+		{
+			name: "shorter max delay goes first",
+			p1:   queuedPod(baseTime.Add(time.Hour), 0, map[string]string{maxDelayAnnotation: "1h"}),
+			p2:   queuedPod(baseTime, 0, nil),
+			want: true,
+		},
+		{
+			name: "deadline annotation goes first",
+			p1:   queuedPod(baseTime, 0, nil),
+			p2: queuedPod(baseTime.Add(time.Hour), 0, map[string]string{
+				deadlineAnnotation:          baseTime.Add(4 * time.Hour).Format(time.RFC3339),
+				estimatedDurationAnnotation: "2h",
+			}),
+			want: false,
+		},
+		{
+			name: "same deadline falls back to queue order",
+			p1:   queuedPod(baseTime.Add(-48*time.Hour), 0, map[string]string{maxDelayAnnotation: "72h"}),
+			p2:   queuedPod(baseTime, 0, nil),
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           24 * time.Hour,
+					},
+				},
+			}
+			scheduler := newTestScheduler(&cfg.Config, 150, 0, baseTime)
+
+			if got := scheduler.Less(tt.p1, tt.p2); got != tt.want {
+				t.Errorf("Less() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequeueBackoff(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+				RequeueBackoff:               5 * time.Minute,
+			},
+		},
+	}
+
+	newPod := func(uid string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: uid, UID: types.UID(uid), CreationTimestamp: metav1.NewTime(baseTime)}}
+	}
+
+	t.Run("reuses verdict until backoff expires", func(t *testing.T) {
+		scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+		pod := newPod("expiry")
+
+		if _, status := scheduler.PreFilter(context.Background(), nil, pod); status.Code() != framework.Unschedulable {
+			t.Fatalf("PreFilter() = %v, want Unschedulable", status)
+		}
+
+		// Raise the threshold without refreshing data, the pod keeps backing off
+		scheduler.config.Scheduling.BaseCarbonIntensityThreshold = 300
+		defer func() { scheduler.config.Scheduling.BaseCarbonIntensityThreshold = 200 }()
+
+		scheduler.clock.(*clock.MockClock).Set(baseTime.Add(4 * time.Minute))
+		if _, status := scheduler.PreFilter(context.Background(), nil, pod); status.Code() != framework.Unschedulable {
+			t.Errorf("PreFilter() during backoff = %v, want Unschedulable", status)
+		}
+
+		scheduler.clock.(*clock.MockClock).Set(baseTime.Add(5 * time.Minute))
+		if _, status := scheduler.PreFilter(context.Background(), nil, pod); !status.IsSuccess() {
+			t.Errorf("PreFilter() after backoff = %v, want Success", status)
+		}
+	})
+
+	t.Run("data refresh ends backoff", func(t *testing.T) {
+		scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+		pod := newPod("refresh")
+
+		if _, status := scheduler.PreFilter(context.Background(), nil, pod); status.Code() != framework.Unschedulable {
+			t.Fatalf("PreFilter() = %v, want Unschedulable", status)
+		}
+
+		scheduler.cache.Set("test-region", &api.ElectricityData{CarbonIntensity: 150, Timestamp: baseTime})
+		if _, status := scheduler.PreFilter(context.Background(), nil, pod); !status.IsSuccess() {
+			t.Errorf("PreFilter() after refresh = %v, want Success", status)
+		}
+	})
+
+	t.Run("annotation changes", func(t *testing.T) {
+		pod := newPod("annotations")
+		projected := pod.DeepCopy()
+		projected.Annotations = map[string]string{projectedStartAnnotation: baseTime.Format(time.RFC3339)}
+		if annotationsChanged(pod, projected) {
+			t.Errorf("annotationsChanged() = true for projected start, want false")
+		}
+
+		skipped := projected.DeepCopy()
+		skipped.Annotations["carbon-aware-scheduler.kubernetes.io/skip"] = "true"
+		if !annotationsChanged(projected, skipped) {
+			t.Errorf("annotationsChanged() = false for skip annotation, want true")
+		}
+	})
+}
+
+func TestPeakSource(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	now := time.Now().Truncate(time.Hour)
+
+	tests := []struct {
+		name            string
+		peakSource      string
+		carbonIntensity float64
+		forecast        []api.Point
+		wantCode        framework.Code
+	}{
+		{
+			name:            "schedule wins",
+			peakSource:      config.PeakSourceSchedule,
+			carbonIntensity: 100,
+			forecast:        []api.Point{{Timestamp: now.Add(30 * time.Minute), CarbonIntensity: 100}},
+			wantCode:        framework.Unschedulable,
+		},
+		{
+			name:            "clean forecast overrides peak",
+			peakSource:      config.PeakSourceForecast,
+			carbonIntensity: 100,
+			forecast:        []api.Point{{Timestamp: now.Add(30 * time.Minute), CarbonIntensity: 120}},
+			wantCode:        framework.Success,
+		},
+		{
+			name:            "dirty forecast keeps peak",
+			peakSource:      config.PeakSourceForecast,
+			carbonIntensity: 100,
+			forecast:        []api.Point{{Timestamp: now.Add(10 * time.Minute), CarbonIntensity: 400}},
+			wantCode:        framework.Unschedulable,
+		},
+		{
+			name:            "high current intensity keeps peak",
+			peakSource:      config.PeakSourceForecast,
+			carbonIntensity: 250,
+			forecast:        []api.Point{{Timestamp: now.Add(30 * time.Minute), CarbonIntensity: 100}},
+			wantCode:        framework.Unschedulable,
+		},
+		{
+			name:            "no forecast keeps peak",
+			peakSource:      config.PeakSourceForecast,
+			carbonIntensity: 100,
+			wantCode:        framework.Unschedulable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           24 * time.Hour,
+					},
+					Pricing: config.PricingConfig{
+						Enabled:  true,
+						Provider: "tou",
+						Schedules: []config.Schedule{
+							{DayOfWeek: "0123456", StartTime: "00:00", EndTime: "23:59", PeakRate: 0.30, OffPeakRate: 0.10},
+						},
+						PeakSource: tt.peakSource,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, tt.carbonIntensity, 0, now)
+			scheduler.pricingImpl = tou.New(cfg.Pricing)
+			scheduler.fallback = nil
+			if tt.forecast != nil {
+				scheduler.cache.SetForecast("test-region", tt.forecast)
+			}
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now)}}
+			if _, status := scheduler.PreFilter(context.Background(), nil, pod); status.Code() != tt.wantCode {
+				t.Errorf("PreFilter() = %v, want %v", status, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPreEnqueue(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		mode     string
+		reject   bool
+		wantCode framework.Code
+	}{
+		{
+			name:     "new pod is enqueued",
+			mode:     config.EnforcementModeEnforce,
+			wantCode: framework.Success,
+		},
+		{
+			name:     "pod backing off is gated",
+			mode:     config.EnforcementModeEnforce,
+			reject:   true,
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:     "audit mode is never gated",
+			mode:     config.EnforcementModeAudit,
+			reject:   true,
+			wantCode: framework.Success,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           24 * time.Hour,
+						RequeueBackoff:               5 * time.Minute,
+						EnforcementMode:              tt.mode,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", UID: "uid", CreationTimestamp: metav1.NewTime(baseTime)}}
+			if tt.reject {
+				scheduler.PreFilter(context.Background(), nil, pod)
+			}
+
+			if status := scheduler.PreEnqueue(context.Background(), pod); status.Code() != tt.wantCode {
+				t.Errorf("PreEnqueue() = %v, want %v", status, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestIsSchedulableAfterPodChange(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", UID: "uid"}}
+	withAnnotations := func(p *v1.Pod, annotations map[string]string) *v1.Pod {
+		p = p.DeepCopy()
+		p.Annotations = annotations
+		return p
+	}
+
+	tests := []struct {
+		name   string
+		oldObj *v1.Pod
+		newObj *v1.Pod
+		want   framework.QueueingHint
+	}{
+		{
+			name:   "release annotation added",
+			oldObj: pod,
+			newObj: withAnnotations(pod, map[string]string{releaseAnnotation: "true"}),
+			want:   framework.Queue,
+		},
+		{
+			name:   "projected start written by the scheduler",
+			oldObj: pod,
+			newObj: withAnnotations(pod, map[string]string{projectedStartAnnotation: "2024-01-01T14:00:00Z"}),
+			want:   framework.QueueSkip,
+		},
+		{
+			name:   "status change",
+			oldObj: pod,
+			newObj: func() *v1.Pod {
+				p := pod.DeepCopy()
+				p.Status.Phase = v1.PodPending
+				return p
+			}(),
+			want: framework.QueueSkip,
+		},
+		{
+			name:   "other pod changed",
+			oldObj: &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", UID: "other"}},
+			newObj: &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", UID: "other", Annotations: map[string]string{releaseAnnotation: "true"}}},
+			want:   framework.QueueSkip,
+		},
+	}
+
+	cfg := &testConfig{Config: config.Config{Scheduling: config.SchedulingConfig{BaseCarbonIntensityThreshold: 200}}}
+	scheduler := newTestScheduler(&cfg.Config, 150, 0, time.Now())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := scheduler.isSchedulableAfterPodChange(klog.Background(), pod, tt.oldObj, tt.newObj)
+			if err != nil {
+				t.Fatalf("isSchedulableAfterPodChange() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("isSchedulableAfterPodChange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPermitWaitMode(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	newConfig := func(mode string) *testConfig {
+		return &testConfig{
+			Config: config.Config{
+				API: config.APIConfig{
+					Key:    "test-key",
+					Region: "test-region",
+				},
+				Scheduling: config.SchedulingConfig{
+					BaseCarbonIntensityThreshold: 200,
+					MaxSchedulingDelay:           24 * time.Hour,
+					WaitMode:                     mode,
+				},
+			},
+		}
+	}
+	newPod := func(uid string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: uid, UID: types.UID(uid), CreationTimestamp: metav1.NewTime(baseTime)}}
+	}
+
+	t.Run("requeue mode rejects delayed pods", func(t *testing.T) {
+		scheduler := newTestScheduler(&newConfig(config.WaitModeRequeue).Config, 250, 0, baseTime)
+		state := framework.NewCycleState()
+		if _, status := scheduler.PreFilter(context.Background(), state, newPod("requeue")); status.Code() != framework.Unschedulable {
+			t.Errorf("PreFilter() = %v, want Unschedulable", status)
+		}
+	})
+
+	t.Run("permit mode holds delayed pods", func(t *testing.T) {
+		scheduler := newTestScheduler(&newConfig(config.WaitModePermit).Config, 250, 0, baseTime)
+		pod := newPod("wait")
+		state := framework.NewCycleState()
+		if _, status := scheduler.PreFilter(context.Background(), state, pod); !status.IsSuccess() {
+			t.Fatalf("PreFilter() = %v, want Success", status)
+		}
+		if status := scheduler.Reserve(context.Background(), state, pod, "node"); !status.IsSuccess() {
+			t.Fatalf("Reserve() = %v, want Success", status)
+		}
+
+		status, timeout := scheduler.Permit(context.Background(), state, pod, "node")
+		if status.Code() != framework.Wait {
+			t.Fatalf("Permit() = %v, want Wait", status)
+		}
+		if timeout < permitPollInterval || timeout > maxPermitWait {
+			t.Errorf("Permit() timeout = %v, want between %v and %v", timeout, permitPollInterval, maxPermitWait)
+		}
+	})
+
+	t.Run("permit mode admits clean pods", func(t *testing.T) {
+		scheduler := newTestScheduler(&newConfig(config.WaitModePermit).Config, 150, 0, baseTime)
+		pod := newPod("clean")
+		state := framework.NewCycleState()
+		if _, status := scheduler.PreFilter(context.Background(), state, pod); !status.IsSuccess() {
+			t.Fatalf("PreFilter() = %v, want Success", status)
+		}
+		if status, _ := scheduler.Permit(context.Background(), state, pod, "node"); !status.IsSuccess() {
+			t.Errorf("Permit() = %v, want Success", status)
+		}
+	})
+
+	t.Run("waiting pods are allowed when constraints clear", func(t *testing.T) {
+		scheduler := newTestScheduler(&newConfig(config.WaitModePermit).Config, 250, 0, baseTime)
+		waiting := &mockWaitingPod{pod: newPod("waiting"), pending: []string{Name}}
+		other := &mockWaitingPod{pod: newPod("other"), pending: []string{"Coscheduling"}}
+		scheduler.handle = &mockHandle{waitingPods: []*mockWaitingPod{waiting, other}}
+
+		scheduler.allowWaitingPods(context.Background())
+		if waiting.allowed {
+			t.Errorf("pod allowed while intensity is above threshold")
+		}
+
+		scheduler.cache.Set("test-region", &api.ElectricityData{CarbonIntensity: 150, Timestamp: baseTime})
+		scheduler.allowWaitingPods(context.Background())
+		if !waiting.allowed {
+			t.Errorf("pod not allowed after intensity dropped")
+		}
+		if other.allowed {
+			t.Errorf("pod waiting on another plugin allowed")
+		}
+	})
+
+	t.Run("release annotation allows waiting pods", func(t *testing.T) {
+		scheduler := newTestScheduler(&newConfig(config.WaitModePermit).Config, 250, 0, baseTime)
+		pod := newPod("released")
+		waiting := &mockWaitingPod{pod: pod, pending: []string{Name}}
+		scheduler.handle = &mockHandle{waitingPods: []*mockWaitingPod{waiting}}
+
+		if _, status := scheduler.PreFilter(context.Background(), framework.NewCycleState(), pod); !status.IsSuccess() {
+			t.Fatalf("PreFilter() = %v, want Success", status)
+		}
+		scheduler.handleRelease(pod)
+		if !waiting.allowed {
+			t.Errorf("released pod not allowed")
+		}
+	})
+
+	t.Run("waiting pods are allowed within the admission budget", func(t *testing.T) {
+		cfg := newConfig(config.WaitModePermit)
+		cfg.Scheduling.MaxConcurrentPods = 1
+		scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+		scheduler.slots = newConcurrencySlots()
+		scheduler.pacer = newTokenBucket(1, 2, baseTime)
+
+		var waiting []*mockWaitingPod
+		states := make(map[types.UID]*framework.CycleState)
+		for _, name := range []string{"first", "second", "third"} {
+			pod := newPod(name)
+			state := framework.NewCycleState()
+			if _, status := scheduler.PreFilter(context.Background(), state, pod); !status.IsSuccess() {
+				t.Fatalf("PreFilter(%s) = %v, want Success", name, status)
+			}
+			scheduler.Reserve(context.Background(), state, pod, "node")
+			states[pod.UID] = state
+			waiting = append(waiting, &mockWaitingPod{pod: pod, pending: []string{Name}})
+		}
+		scheduler.patches.Wait()
+		scheduler.handle = &mockHandle{waitingPods: waiting}
+
+		allowed := func() int {
+			count := 0
+			for _, wp := range waiting {
+				if wp.allowed {
+					count++
+				}
+			}
+			return count
+		}
+
+		// One concurrency slot releases a single pod when intensity drops
+		scheduler.cache.Set("test-region", &api.ElectricityData{CarbonIntensity: 150, Timestamp: baseTime})
+		scheduler.allowWaitingPods(context.Background())
+		if got := allowed(); got != 1 || !waiting[0].allowed {
+			t.Fatalf("allowed %d pods, want the first only", got)
+		}
+
+		// The slot is returned if the allowed pod fails to bind
+		first := waiting[0].pod
+		scheduler.Unreserve(context.Background(), states[first.UID], first, "node")
+		scheduler.allowWaitingPods(context.Background())
+		if got := allowed(); got != 2 || !waiting[1].allowed {
+			t.Fatalf("allowed %d pods after unreserve, want the second too", got)
+		}
+
+		// The slot frees once the bound pod finishes, after which the tokens are used up
+		second := waiting[1].pod
+		scheduler.PreBind(context.Background(), states[second.UID], second, "node")
+		scheduler.slots.release(second.UID)
+		scheduler.allowWaitingPods(context.Background())
+		if got := allowed(); got != 3 {
+			t.Fatalf("allowed %d pods after the slot was freed, want all", got)
+		}
+		scheduler.slots.release(waiting[2].pod.UID)
+		waiting[0].pending, waiting[0].allowed = []string{Name}, false
+		scheduler.allowWaitingPods(context.Background())
+		if waiting[0].allowed {
+			t.Errorf("pod allowed after the admission rate was used up")
+		}
+
+		// Budgets of bound pods are forgotten
+		third := waiting[2].pod
+		if _, ok := scheduler.permitBudgets.Load(third.UID); !ok {
+			t.Fatal("expected the budget of the allowed pod to be kept until it binds")
+		}
+		scheduler.PostBind(context.Background(), states[third.UID], third, "node")
+		scheduler.permitBudgets.Range(func(uid, _ interface{}) bool {
+			t.Errorf("expected no permit budgets once pods are bound or unreserved, got one for %v", uid)
+			return true
+		})
+	})
+}
+
+func TestScore(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:           "test-key",
+				Region:        "US-CAL-CISO",
+				RegionZoneMap: map[string]string{"us-west-2": "US-NW-PACW"},
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+			},
+			Scoring: config.ScoringConfig{
+				Weights: config.ScoreWeights{Zone: 1, Efficiency: 1, Packing: 1},
+			},
+		},
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+	scheduler.cache.Set("US-NW-PACW", &api.ElectricityData{CarbonIntensity: 50, Timestamp: baseTime})
+	scheduler.trackNodeZone(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "green", Labels: map[string]string{v1.LabelTopologyRegion: "us-west-2"}}})
+
+	tests := []struct {
+		name     string
+		nodeName string
+		want     int64
+	}{
+		{
+			name:     "node in greener zone",
+			nodeName: "green",
+			want:     87,
+		},
+		{
+			name:     "node without zone uses default region",
+			nodeName: "unlabeled",
+			want:     37,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, status := scheduler.Score(context.Background(), nil, &v1.Pod{}, tt.nodeName)
+			if !status.IsSuccess() {
+				t.Fatalf("Score() status = %v", status)
+			}
+			if got != tt.want {
+				t.Errorf("Score() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("zone scores prepared in PreScore", func(t *testing.T) {
+		var nodes []*framework.NodeInfo
+		for _, tt := range tests {
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: tt.nodeName}})
+			nodes = append(nodes, nodeInfo)
+		}
+		state := framework.NewCycleState()
+		if status := scheduler.PreScore(context.Background(), state, &v1.Pod{}, nodes); !status.IsSuccess() {
+			t.Fatalf("PreScore() status = %v", status)
+		}
+
+		// Scores are looked up from the cycle state rather than recomputed
+		scheduler.cache.Set("US-NW-PACW", &api.ElectricityData{CarbonIntensity: 400, Timestamp: baseTime})
+		defer scheduler.cache.Set("US-NW-PACW", &api.ElectricityData{CarbonIntensity: 50, Timestamp: baseTime})
+		for _, tt := range tests {
+			got, _ := scheduler.Score(context.Background(), state, &v1.Pod{}, tt.nodeName)
+			if got != tt.want {
+				t.Errorf("Score(%s) = %d, want %d", tt.nodeName, got, tt.want)
+			}
+		}
+	})
+}
+
+func TestGreenZoneNodes(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		nodes         map[string]string
+		pacwIntensity float64
+		annotations   map[string]string
+		wantCode      framework.Code
+		wantNodes     []string
+	}{
+		{
+			name:          "restricted to greener zone",
+			nodes:         map[string]string{"cal": "us-west-1", "pacw-1": "us-west-2", "pacw-2": "us-west-2"},
+			pacwIntensity: 50,
+			wantCode:      framework.Success,
+			wantNodes:     []string{"pacw-1", "pacw-2"},
+		},
+		{
+			name:          "every zone dirty",
+			nodes:         map[string]string{"cal": "us-west-1", "pacw-1": "us-west-2"},
+			pacwIntensity: 300,
+			wantCode:      framework.Unschedulable,
+		},
+		{
+			name:          "pod pinned to region",
+			nodes:         map[string]string{"cal": "us-west-1", "pacw-1": "us-west-2"},
+			pacwIntensity: 50,
+			annotations:   map[string]string{regionAnnotation: "US-CAL-CISO"},
+			wantCode:      framework.Unschedulable,
+		},
+		{
+			name:          "single zone cluster",
+			nodes:         map[string]string{"cal-1": "us-west-1", "cal-2": "us-west-1"},
+			pacwIntensity: 50,
+			wantCode:      framework.Unschedulable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:           "test-key",
+						Region:        "US-CAL-CISO",
+						RegionZoneMap: map[string]string{"us-west-1": "US-CAL-CISO", "us-west-2": "US-NW-PACW"},
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           24 * time.Hour,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+			scheduler.cache.Set("US-NW-PACW", &api.ElectricityData{CarbonIntensity: tt.pacwIntensity, Timestamp: baseTime})
+			for name, region := range tt.nodes {
+				scheduler.trackNodeZone(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{v1.LabelTopologyRegion: region}}})
+			}
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: tt.annotations, CreationTimestamp: metav1.NewTime(baseTime)}}
+			result, status := scheduler.PreFilter(context.Background(), framework.NewCycleState(), pod)
+			if status.Code() != tt.wantCode {
+				t.Fatalf("PreFilter() = %v, want %v", status, tt.wantCode)
+			}
+			if tt.wantNodes == nil {
+				if result != nil {
+					t.Errorf("PreFilter() result = %v, want nil", result.NodeNames)
+				}
+				return
+			}
+			if result == nil || !result.NodeNames.Equal(sets.New(tt.wantNodes...)) {
+				t.Errorf("PreFilter() result = %v, want %v", result, tt.wantNodes)
+			}
+		})
+	}
+}
+
+func TestEfficiencyScore(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+			},
+			Scoring: config.ScoringConfig{
+				Weights: config.ScoreWeights{Zone: 1, Efficiency: 1, Packing: 1},
+			},
+			Power: config.PowerConfig{
+				ReferencePerfPerWatt: 1,
+				NodePowerConfig: map[string]config.NodePower{
+					"profiled": {IdlePower: 100, MaxPower: 400, PerfPerWatt: 3},
+				},
+			},
+		},
+	}
+
+	labeledNode := func(name string, labels map[string]string) *framework.NodeInfo {
+		n := framework.NewNodeInfo()
+		n.SetNode(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}})
+		return n
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 100, 0, baseTime)
+	scheduler.handle = &mockHandle{nodeInfos: tf.NodeInfoLister{
+		labeledNode("efficient", map[string]string{perfPerWattLabel: "4"}),
+		labeledNode("inefficient", map[string]string{perfPerWattLabel: "0.25"}),
+		labeledNode("unrated", nil),
+		labeledNode("profiled", map[string]string{perfPerWattLabel: "0.25"}),
+	}}
+
+	optedIn := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{preferEfficientAnnotation: "true"}}}
+
+	tests := []struct {
+		name     string
+		pod      *v1.Pod
+		nodeName string
+		want     int64
+	}{
+		{
+			name:     "efficient node",
+			pod:      optedIn,
+			nodeName: "efficient",
+			want:     (75 + 80) / 2,
+		},
+		{
+			name:     "inefficient node",
+			pod:      optedIn,
+			nodeName: "inefficient",
+			want:     (75 + 20) / 2,
+		},
+		{
+			name:     "unrated node counts as reference",
+			pod:      optedIn,
+			nodeName: "unrated",
+			want:     (75 + 50) / 2,
+		},
+		{
+			name:     "power profile overrides label",
+			pod:      optedIn,
+			nodeName: "profiled",
+			want:     (75 + 75) / 2,
+		},
+		{
+			name:     "pod not opted in",
+			pod:      &v1.Pod{},
+			nodeName: "efficient",
+			want:     75,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, status := scheduler.Score(context.Background(), nil, tt.pod, tt.nodeName)
+			if !status.IsSuccess() {
+				t.Fatalf("Score() status = %v", status)
+			}
+			if got != tt.want {
+				t.Errorf("Score() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodePowerCurve(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	// Modern servers draw well below the linear estimate at low utilization
+	curve := []config.PowerPoint{
+		{Utilization: 0, Watts: 60},
+		{Utilization: 0.1, Watts: 95},
+		{Utilization: 0.5, Watts: 180},
+		{Utilization: 1, Watts: 320},
+	}
+	cfg := &testConfig{
+		Config: config.Config{
+			Power: config.PowerConfig{
+				DefaultIdlePower: 100,
+				DefaultMaxPower:  400,
+				DefaultCurve:     curve,
+				NodePowerConfig: map[string]config.NodePower{
+					"linear-node": {IdlePower: 100, MaxPower: 300},
+				},
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 0, 0, time.Now())
+
+	tests := []struct {
+		node        string
+		utilization float64
+		want        float64
+	}{
+		{node: "node-1", utilization: 0, want: 60},
+		{node: "node-1", utilization: 0.05, want: 77.5},
+		{node: "node-1", utilization: 0.3, want: 137.5},
+		{node: "node-1", utilization: 1, want: 320},
+		{node: "node-1", utilization: 1.5, want: 320},
+		// Profiles without a curve keep the linear model
+		{node: "linear-node", utilization: 0.5, want: 200},
+	}
+	for _, tt := range tests {
+		if got := scheduler.nodePowerAt(tt.node, tt.utilization); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("nodePowerAt(%q, %v) = %v, want %v", tt.node, tt.utilization, got, tt.want)
+		}
+	}
+
+	if idle, peak := scheduler.nodePowerLimits("node-1"); idle != 60 || peak != 320 {
+		t.Errorf("nodePowerLimits() = %v, %v, want the ends of the curve", idle, peak)
+	}
+	// Mock metrics report no CPU usage
+	if got := scheduler.estimateNodePower("node-1"); got != 60 {
+		t.Errorf("estimateNodePower() = %v, want 60", got)
+	}
+
+	// Known instance types take precedence over the default curve, but not profiles
+	scheduler.instanceTypes, _ = instancetypes.New("")
+	for _, name := range []string{"aws-1", "linear-node"} {
+		scheduler.instanceTypes.TrackNode(&v1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{v1.LabelInstanceTypeStable: "m5.large"},
+		}})
+	}
+	want, _ := instancetypes.Builtin("m5.large")
+	if idle, peak := scheduler.nodePowerLimits("aws-1"); idle != want.IdlePower || peak != want.MaxPower {
+		t.Errorf("nodePowerLimits() of m5.large = %v, %v, want %+v", idle, peak, want)
+	}
+	if got := scheduler.nodePowerAt("aws-1", 0.5); math.Abs(got-(want.IdlePower+want.MaxPower)/2) > 1e-9 {
+		t.Errorf("nodePowerAt() of m5.large = %v, want the linear estimate", got)
+	}
+	if idle, peak := scheduler.nodePowerLimits("linear-node"); idle != 100 || peak != 300 {
+		t.Errorf("nodePowerLimits() of profiled node = %v, %v, want its profile", idle, peak)
+	}
+}
+
+func TestNFDProfiles(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	cfg := &testConfig{
+		Config: config.Config{
+			Power: config.PowerConfig{
+				DefaultIdlePower: 100,
+				DefaultMaxPower:  400,
+				NodePowerConfig: map[string]config.NodePower{
+					"explicit": {IdlePower: 50, MaxPower: 150},
+				},
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 0, 0, time.Now())
+	scheduler.nfdProfiles = newNFDProfiles(config.NFDConfig{
+		Enabled: true,
+		Profiles: []config.NFDProfile{
+			{
+				Name:      "epyc-smt",
+				Features:  map[string]string{"cpu-model.vendor_id": "AMD", "cpu-hardware_multithreading": "true"},
+				NodePower: config.NodePower{IdlePower: 120, MaxPower: 480},
+			},
+			{
+				Name:      "epyc",
+				Features:  map[string]string{"cpu-model.vendor_id": "AMD"},
+				NodePower: config.NodePower{IdlePower: 110, MaxPower: 420},
+			},
+		},
+		TDPLabel:        "feature.node.kubernetes.io/cpu-tdp",
+		TDPIdleFraction: 0.25,
+	})
+
+	node := func(name string, labels map[string]string) *v1.Node {
+		return &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+	scheduler.nfdProfiles.track(node("amd-smt", map[string]string{
+		"feature.node.kubernetes.io/cpu-model.vendor_id":         "AMD",
+		"feature.node.kubernetes.io/cpu-hardware_multithreading": "true",
+	}))
+	scheduler.nfdProfiles.track(node("amd", map[string]string{
+		"feature.node.kubernetes.io/cpu-model.vendor_id": "AMD",
+	}))
+	scheduler.nfdProfiles.track(node("intel-tdp", map[string]string{
+		"feature.node.kubernetes.io/cpu-model.vendor_id": "Intel",
+		"feature.node.kubernetes.io/cpu-tdp":             "280",
+	}))
+	scheduler.nfdProfiles.track(node("intel", map[string]string{
+		"feature.node.kubernetes.io/cpu-model.vendor_id": "Intel",
+	}))
+	scheduler.nfdProfiles.track(node("explicit", map[string]string{
+		"feature.node.kubernetes.io/cpu-model.vendor_id": "AMD",
+	}))
+
+	tests := []struct {
+		node     string
+		wantIdle float64
+		wantMax  float64
+	}{
+		{node: "amd-smt", wantIdle: 120, wantMax: 480},
+		{node: "amd", wantIdle: 110, wantMax: 420},
+		{node: "intel-tdp", wantIdle: 70, wantMax: 280},
+		// No profile matches and no TDP hint
+		{node: "intel", wantIdle: 100, wantMax: 400},
+		// Explicit profiles take precedence
+		{node: "explicit", wantIdle: 50, wantMax: 150},
+	}
+	for _, tt := range tests {
+		if idle, peak := scheduler.nodePowerLimits(tt.node); idle != tt.wantIdle || peak != tt.wantMax {
+			t.Errorf("nodePowerLimits(%q) = %v, %v, want %v, %v", tt.node, idle, peak, tt.wantIdle, tt.wantMax)
+		}
+	}
+
+	scheduler.nfdProfiles.forget("amd")
+	if idle, _ := scheduler.nodePowerLimits("amd"); idle != 100 {
+		t.Errorf("nodePowerLimits() of forgotten node = %v, want the default", idle)
+	}
+}
+
+func TestGPUPower(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			Power: config.PowerConfig{
+				DefaultIdlePower:    100,
+				DefaultMaxPower:     400,
+				DefaultGPUIdlePower: 50,
+				DefaultGPUMaxPower:  300,
+				NodePowerConfig: map[string]config.NodePower{
+					"h100": {IdlePower: 200, MaxPower: 800, GPUIdlePower: 70, GPUMaxPower: 700},
+				},
+			},
+			GPU: config.GPUConfig{Enabled: true, ResourceName: "nvidia.com/gpu"},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 0, 0, now)
+	scheduler.nodeGPUs = new(sync.Map)
+	scheduler.gpuUtilization = promquery.NewVector(nil, "", "Hostname", time.Minute, 5*time.Minute,
+		func() time.Time { return now })
+	scheduler.gpuUtilization.Set(map[string]float64{"a100": 2, "h100": 1.5})
+
+	gpuNode := func(name string, gpus int64) *v1.Node {
+		return &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: v1.NodeStatus{Allocatable: v1.ResourceList{
+				"nvidia.com/gpu": *resource.NewQuantity(gpus, resource.DecimalSI),
+			}},
+		}
+	}
+	scheduler.trackNodeGPUs(gpuNode("a100", 4))
+	scheduler.trackNodeGPUs(gpuNode("h100", 2))
+	scheduler.trackNodeGPUs(gpuNode("unmeasured", 2))
+	scheduler.trackNodeGPUs(gpuNode("cpu-only", 0))
+
+	// Mock metrics report no CPU usage, so CPUs draw idle power
+	tests := []struct {
+		node string
+		want float64
+	}{
+		// 4 GPUs idle at 50W, plus 2 GPUs' worth of utilization at 250W
+		{node: "a100", want: 100 + 4*50 + 2*250},
+		{node: "h100", want: 200 + 2*70 + 1.5*630},
+		// GPUs without a utilization reading are counted as idle
+		{node: "unmeasured", want: 100 + 2*50},
+		{node: "cpu-only", want: 100},
+	}
+	for _, tt := range tests {
+		if got := scheduler.estimateNodePower(tt.node); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("estimateNodePower(%q) = %v, want %v", tt.node, got, tt.want)
+		}
+	}
+}
+
+func TestCPUPower(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			Power: config.PowerConfig{
+				DefaultIdlePower: 100,
+				DefaultMaxPower:  300,
+			},
+			CPU: config.CPUConfig{
+				FrequencyEnabled:  true,
+				FrequencyExponent: 2,
+				SMTEnabled:        true,
+				SMTLabel:          "feature.node.kubernetes.io/cpu-hardware_multithreading",
+				SMTSiblingPower:   0.25,
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 0, 0, now)
+	scheduler.cpuFrequency = promquery.NewVector(nil, "", "node", time.Minute, 5*time.Minute,
+		func() time.Time { return now })
+	scheduler.cpuFrequency.Set(map[string]float64{"slow": 0.5, "turbo": 1.2})
+	scheduler.smtNodes = new(sync.Map)
+	for name, smt := range map[string]string{"smt": "true", "no-smt": "false"} {
+		scheduler.trackNodeSMT(&v1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{"feature.node.kubernetes.io/cpu-hardware_multithreading": smt},
+		}})
+	}
+
+	tests := []struct {
+		node        string
+		utilization float64
+		want        float64
+	}{
+		{node: "no-smt", utilization: 0.5, want: 200},
+		// Half the logical CPUs keep every core busy, drawing 1/1.25 of dynamic power
+		{node: "smt", utilization: 0.5, want: 260},
+		{node: "smt", utilization: 1, want: 300},
+		// Dynamic power scales with the square of the relative frequency
+		{node: "slow", utilization: 1, want: 150},
+		{node: "slow", utilization: 0, want: 100},
+		// Frequencies above the max are clamped
+		{node: "turbo", utilization: 1, want: 300},
+	}
+	for _, tt := range tests {
+		if got := scheduler.cpuPowerAt(tt.node, tt.utilization); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("cpuPowerAt(%q, %v) = %v, want %v", tt.node, tt.utilization, got, tt.want)
+		}
+	}
+
+	// Without fresh frequency readings, power isn't adjusted
+	now = now.Add(10 * time.Minute)
+	if got := scheduler.cpuPowerAt("slow", 1); got != 300 {
+		t.Errorf("cpuPowerAt() with a stale frequency = %v, want 300", got)
+	}
+}
+
+// waitForNodePower waits for the first reading of a node's power by a meter polling
+// in the background
+func waitForNodePower(t *testing.T, meter *energysource.Meter, nodeName string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, ok := meter.NodePower(nodeName); ok {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("power of %s was never read", nodeName)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNodePowerCalibration(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	bmc := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"PowerControl": [{"PowerConsumedWatts": 150}]}`)
+	}))
+	defer bmc.Close()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			Power: config.PowerConfig{
+				DefaultIdlePower: 100,
+				DefaultMaxPower:  300,
+			},
+			EnergySource: config.EnergySourceConfig{
+				Default:          config.EnergySourceRedfish,
+				RedfishAddresses: map[string]string{"metal-1": bmc.URL},
+				RedfishPowerPath: "/redfish/v1/Chassis/1/Power",
+				PollInterval:     time.Hour,
+				MaxAge:           5 * time.Minute,
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 0, 0, baseTime)
+	mockClock := scheduler.clock.(*clock.MockClock)
+	scheduler.nodeCalibration = new(sync.Map)
+	scheduler.energySource = energysource.New(cfg.EnergySource, time.Second, mockClock.Now)
+	scheduler.energySource.TrackNode(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "metal-1"}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scheduler.energySource.Run(ctx, scheduler.stopCh)
+	waitForNodePower(t, scheduler.energySource, "metal-1")
+
+	// BMC readings are the node's power, calibrating the 100W model
+	if got := scheduler.estimateNodePower("metal-1"); got != 150 {
+		t.Errorf("estimateNodePower() with a BMC reading = %v, want 150", got)
+	}
+	if got := scheduler.calibration("metal-1"); got != 1.5 {
+		t.Errorf("calibration() = %v, want 1.5", got)
+	}
+
+	// Once readings are stale, the model is scaled by the calibration
+	mockClock.Set(baseTime.Add(10 * time.Minute))
+	if got := scheduler.estimateNodePower("metal-1"); math.Abs(got-150) > 1e-9 {
+		t.Errorf("estimateNodePower() with a stale reading = %v, want 150", got)
+	}
+	if got := scheduler.estimateNodePower("no-bmc"); got != 100 {
+		t.Errorf("estimateNodePower() of an uncalibrated node = %v, want 100", got)
+	}
+
+	// New readings are smoothed into the calibration
+	scheduler.calibrateNodePower("metal-1", 250)
+	if got := scheduler.calibration("metal-1"); math.Abs(got-1.7) > 1e-9 {
+		t.Errorf("calibration() after a new reading = %v, want 1.7", got)
+	}
+
+	scheduler.forgetCalibration("metal-1")
+	if got := scheduler.calibration("metal-1"); got != 1 {
+		t.Errorf("calibration() of a forgotten node = %v, want 1", got)
+	}
+}
+
+func TestComponentPower(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			Power: config.PowerConfig{
+				DefaultIdlePower:      100,
+				DefaultMaxPower:       100,
+				PodAttributionEnabled: true,
+				MemoryWattsPerGB:      0.5,
+				DiskWatts:             5,
+				NICWatts:              10,
+				DefaultDisks:          2,
+				DefaultNICs:           1,
+				NodePowerConfig: map[string]config.NodePower{
+					"storage-1": {IdlePower: 100, MaxPower: 100, Disks: 6},
+				},
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 200, 0, baseTime)
+	mockClock := scheduler.clock.(*clock.MockClock)
+	scheduler.podEnergy = newPodEnergyTracker()
+	scheduler.metricsClient = &usageMetricsClient{
+		nodes:      map[string]float64{"node-1": 2, "storage-1": 0},
+		pods:       map[string]float64{"default/compute": 1.5, "default/cache": 0.5},
+		nodeMemory: map[string]float64{"node-1": 100},
+		podMemory:  map[string]float64{"default/compute": 20, "default/cache": 80},
+	}
+
+	// 100W of CPU, 50W for 100GB of memory, 10W for 2 disks and 10W for a NIC
+	if got := scheduler.estimateNodePower("node-1"); math.Abs(got-170) > 1e-9 {
+		t.Errorf("estimateNodePower(node-1) = %v, want 170", got)
+	}
+	// The profile's disks override the default
+	if got := scheduler.estimateNodePower("storage-1"); math.Abs(got-140) > 1e-9 {
+		t.Errorf("estimateNodePower(storage-1) = %v, want 140", got)
+	}
+
+	newPod := func(name string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, UID: types.UID(name)}}
+	}
+	compute, cache := newPod("compute"), newPod("cache")
+	for _, pod := range []*v1.Pod{compute, cache} {
+		scheduler.podEnergy.track(pod, "node-1", 170, baseTime)
+	}
+	scheduler.samplePodEnergy(context.Background())
+	mockClock.Set(baseTime.Add(time.Hour))
+	scheduler.samplePodEnergy(context.Background())
+
+	// 120W split by CPU usage and 50W by memory usage
+	tests := []struct {
+		pod  *v1.Pod
+		want float64
+	}{
+		{pod: compute, want: 0.100}, // 90W + 10W
+		{pod: cache, want: 0.070},   // 30W + 40W
+	}
+	for _, tt := range tests {
+		got, _, ok := scheduler.podEnergy.take(tt.pod.UID, 170, mockClock.Now())
+		if !ok || math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("take(%s) = %v, %v, want %v", tt.pod.Name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestPUE(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Region:        "US-CAL-CISO",
+				RegionZoneMap: map[string]string{"us-west-2": "US-NW-PACW"},
+			},
+			Power: config.PowerConfig{
+				PUE:     1.5,
+				ZonePUE: map[string]float64{"US-NW-PACW": 1.2},
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 200, 0, baseTime)
+	store, err := accounting.NewFileStore(filepath.Join(t.TempDir(), "accounting.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduler.accounting = store
+	scheduler.trackNodeZone(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "west", Labels: map[string]string{v1.LabelTopologyRegion: "us-west-2"}}})
+
+	tests := []struct {
+		namespace string
+		nodeName  string
+		want      float64
+	}{
+		// 1kWh of IT energy at 200 gCO2/kWh
+		{namespace: "cluster-pue", nodeName: "node-1", want: 300},
+		{namespace: "zone-pue", nodeName: "west", want: 240},
+	}
+	for _, tt := range tests {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: tt.namespace, Name: "job"},
+			Spec:       v1.PodSpec{NodeName: tt.nodeName},
+		}
+		scheduler.accountPodEnergy(pod, 1, 0, 0)
+		totals := store.Namespaces()[tt.namespace]
+		if totals.EnergyKWh != 1 || math.Abs(totals.EmissionsGrams-tt.want) > 1e-9 {
+			t.Errorf("%s totals = %+v, want 1kWh and %vg", tt.namespace, totals, tt.want)
+		}
+	}
+
+	// An unset PUE counts IT energy only
+	scheduler.config.Power.PUE = 0
+	if got := scheduler.pue("node-1"); got != 1 {
+		t.Errorf("pue() without a PUE = %v, want 1", got)
+	}
+}
+
+func TestEmbodiedCarbon(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			Power: config.PowerConfig{
+				EmbodiedCarbonPerHour: 10,
+				NodePowerConfig: map[string]config.NodePower{
+					"gpu-1": {IdlePower: 100, MaxPower: 400, EmbodiedCarbonPerHour: 40},
+				},
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 200, 0, baseTime)
+	store, err := accounting.NewFileStore(filepath.Join(t.TempDir(), "accounting.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduler.accounting = store
+	scheduler.podEnergy = newPodEnergyTracker()
+
+	node := func(name string) *framework.NodeInfo {
+		n := framework.NewNodeInfo()
+		n.SetNode(&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: v1.NodeStatus{Allocatable: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("4"),
+				v1.ResourceMemory: resource.MustParse("16Gi"),
+			}},
+		})
+		return n
+	}
+	scheduler.handle = &mockHandle{nodeInfos: tf.NodeInfoLister{node("node-1"), node("gpu-1")}}
+
+	newPod := func(namespace, nodeName, cpu, memory string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "job", UID: types.UID(namespace)},
+			Spec: v1.PodSpec{NodeName: nodeName, Containers: []v1.Container{{
+				Resources: v1.ResourceRequirements{Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse(cpu),
+					v1.ResourceMemory: resource.MustParse(memory),
+				}},
+			}}},
+		}
+	}
+
+	tests := []struct {
+		pod  *v1.Pod
+		want float64
+	}{
+		// Half the node's memory for 2 hours at 10 gCO2e per node-hour
+		{pod: newPod("memory-bound", "node-1", "1", "8Gi"), want: 10},
+		// A quarter of the node's CPU at the profile's 40 gCO2e per node-hour
+		{pod: newPod("cpu-bound", "gpu-1", "1", "1Gi"), want: 20},
+	}
+	for _, tt := range tests {
+		scheduler.podEnergy.track(tt.pod, tt.pod.Spec.NodeName, 0, baseTime)
+		scheduler.podEnergy.setEmbodiedRate(tt.pod.UID, scheduler.embodiedRate(tt.pod, tt.pod.Spec.NodeName))
+	}
+
+	// Embodied carbon accrues across a restart
+	restored := newPodEnergyTracker()
+	restored.restore(scheduler.podEnergy.snapshot())
+	scheduler.podEnergy = restored
+
+	end := baseTime.Add(2 * time.Hour)
+	for _, tt := range tests {
+		embodied := scheduler.podEnergy.embodied(tt.pod.UID, end)
+		scheduler.accountPodEnergy(tt.pod, 0, 0, embodied)
+		totals := store.Namespaces()[tt.pod.Namespace]
+		if math.Abs(totals.EmissionsGrams-tt.want) > 1e-9 || math.Abs(totals.EmbodiedGrams-tt.want) > 1e-9 {
+			t.Errorf("%s totals = %+v, want %v of embodied emissions", tt.pod.Namespace, totals, tt.want)
+		}
+	}
+
+	// Without embodied carbon configured, pods accrue none
+	scheduler.config.Power.EmbodiedCarbonPerHour = 0
+	if got := scheduler.embodiedRate(tests[0].pod, "node-1"); got != 0 {
+		t.Errorf("embodiedRate() without embodied carbon = %v, want 0", got)
+	}
+}
+
+func TestPackingScore(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+			},
+			Scoring: config.ScoringConfig{
+				Weights: config.ScoreWeights{Zone: 1, Efficiency: 1, Packing: 1},
+			},
+			Power: config.PowerConfig{
+				DefaultIdlePower:     100,
+				DefaultMaxPower:      400,
+				ReferencePerfPerWatt: 1,
+				PackingEnabled:       true,
+			},
+		},
+	}
+
+	cpuPod := func(cpu string) *v1.Pod {
+		return &v1.Pod{Spec: v1.PodSpec{Containers: []v1.Container{{
+			Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)}},
+		}}}}
+	}
+	node := func(name string, pods ...*v1.Pod) *framework.NodeInfo {
+		n := framework.NewNodeInfo(pods...)
+		n.SetNode(&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status:     v1.NodeStatus{Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")}},
+		})
+		return n
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 100, 0, baseTime)
+	scheduler.handle = &mockHandle{nodeInfos: tf.NodeInfoLister{
+		node("busy", cpuPod("2")),
+		node("empty"),
+	}}
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		nodeName string
+		want     int64
+	}{
+		{
+			name:     "busy node only adds dynamic power",
+			enabled:  true,
+			nodeName: "busy",
+			want:     (75 + 81) / 2,
+		},
+		{
+			name:     "empty node adds idle power",
+			enabled:  true,
+			nodeName: "empty",
+			want:     (75 + 56) / 2,
+		},
+		{
+			name:     "unknown node scores neutrally",
+			enabled:  true,
+			nodeName: "missing",
+			want:     (75 + 50) / 2,
+		},
+		{
+			name:     "packing disabled",
+			enabled:  false,
+			nodeName: "empty",
+			want:     75,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler.config.Power.PackingEnabled = tt.enabled
+			got, status := scheduler.Score(context.Background(), nil, cpuPod("1"), tt.nodeName)
+			if !status.IsSuccess() {
+				t.Fatalf("Score() status = %v", status)
+			}
+			if got != tt.want {
+				t.Errorf("Score() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeScore(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	tests := []struct {
+		name   string
+		scores []int64
+		want   []int64
+	}{
+		{
+			name:   "best node scales to the maximum",
+			scores: []int64{40, 20, 10},
+			want:   []int64{100, 50, 25},
+		},
+		{
+			name:   "all zero",
+			scores: []int64{0, 0},
+			want:   []int64{0, 0},
+		},
+	}
+
+	scheduler := newTestScheduler(&config.Config{}, 100, 0, time.Now())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := make(framework.NodeScoreList, len(tt.scores))
+			for i, s := range tt.scores {
+				scores[i] = framework.NodeScore{Name: fmt.Sprintf("node-%d", i), Score: s}
+			}
+			if status := scheduler.ScoreExtensions().NormalizeScore(context.Background(), nil, &v1.Pod{}, scores); !status.IsSuccess() {
+				t.Fatalf("NormalizeScore() status = %v", status)
+			}
+			for i, s := range scores {
+				if s.Score != tt.want[i] {
+					t.Errorf("NormalizeScore() node %d = %d, want %d", i, s.Score, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPluginArgs(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+	t.Setenv("ELECTRICITY_MAP_API_KEY", "test-key")
+
+	tests := []struct {
+		name      string
+		obj       runtime.Object
+		want      config.ScoreWeights
+		wantMode  string
+		wantLimit float64
+		wantErr   bool
+	}{
+		{
+			name: "no args",
+			obj:  nil,
+			want: config.ScoreWeights{Zone: 1, Efficiency: 1, Packing: 1, Spot: 1},
+		},
+		{
+			name: "json args override the environment",
+			obj:  &runtime.Unknown{Raw: []byte(`{"scoreWeights":{"zone":2,"packing":0.5}}`), ContentType: runtime.ContentTypeJSON},
+			want: config.ScoreWeights{Zone: 2, Efficiency: 1, Packing: 0.5, Spot: 1},
+		},
+		{
+			name: "yaml args",
+			obj:  &runtime.Unknown{Raw: []byte("scoreWeights:\n  efficiency: 3\n"), ContentType: runtime.ContentTypeYAML},
+			want: config.ScoreWeights{Zone: 1, Efficiency: 3, Packing: 1, Spot: 1},
+		},
+		{
+			name:      "profile policy",
+			obj:       &runtime.Unknown{Raw: []byte(`{"enforcementMode":"audit","carbonIntensityThreshold":100}`)},
+			want:      config.ScoreWeights{Zone: 1, Efficiency: 1, Packing: 1, Spot: 1},
+			wantMode:  config.EnforcementModeAudit,
+			wantLimit: 100,
+		},
+		{
+			name:    "invalid enforcement mode",
+			obj:     &runtime.Unknown{Raw: []byte(`{"enforcementMode":"strict"}`)},
+			wantErr: true,
+		},
+		{
+			name:    "invalid weight",
+			obj:     &runtime.Unknown{Raw: []byte(`{"scoreWeights":{"zone":0}}`)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := config.Load(tt.obj)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.Scoring.Weights != tt.want {
+				t.Errorf("Load() score weights = %+v, want %+v", cfg.Scoring.Weights, tt.want)
+			}
+			if err == nil && tt.wantMode != "" && cfg.Scheduling.EnforcementMode != tt.wantMode {
+				t.Errorf("Load() enforcement mode = %q, want %q", cfg.Scheduling.EnforcementMode, tt.wantMode)
+			}
+			if err == nil && tt.wantLimit != 0 && cfg.Scheduling.BaseCarbonIntensityThreshold != tt.wantLimit {
+				t.Errorf("Load() threshold = %v, want %v", cfg.Scheduling.BaseCarbonIntensityThreshold, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestPostFilter(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+			},
+		},
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:              "test-pod",
+		Namespace:         "default",
+		CreationTimestamp: metav1.NewTime(baseTime.Add(-90 * time.Minute)),
+	}}
+
+	t.Run("delayed pod", func(t *testing.T) {
+		recorder := events.NewFakeRecorder(10)
+		scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+		scheduler.handle = &mockHandle{recorder: recorder}
+
+		state := framework.NewCycleState()
+		if _, status := scheduler.PreFilter(context.Background(), state, pod); status.Code() != framework.Unschedulable {
+			t.Fatalf("PreFilter() status = %v, want Unschedulable", status)
+		}
+		_, status := scheduler.PostFilter(context.Background(), state, pod, framework.NodeToStatusMap{})
+		if status.Code() != framework.Unschedulable {
+			t.Fatalf("PostFilter() status = %v, want Unschedulable", status)
+		}
+		for _, want := range []string{"zone=test-region", "intensity=250.0", "threshold=200.0", "nextTransition=", "waited=1h30m0s"} {
+			if !strings.Contains(status.Message(), want) {
+				t.Errorf("PostFilter() message = %q, want it to contain %q", status.Message(), want)
+			}
+		}
+
+		var found bool
+		for len(recorder.Events) > 0 {
+			if strings.Contains(<-recorder.Events, "CarbonAwareDiagnostics") {
+				found = true
+			}
+		}
+		if !found {
+			t.Error("PostFilter() recorded no diagnostics event")
+		}
+	})
+
+	t.Run("pod rejected by other plugins", func(t *testing.T) {
+		recorder := events.NewFakeRecorder(10)
+		scheduler := newTestScheduler(&cfg.Config, 100, 0, baseTime)
+		scheduler.handle = &mockHandle{recorder: recorder}
+
+		_, status := scheduler.PostFilter(context.Background(), framework.NewCycleState(), pod, framework.NodeToStatusMap{})
+		if status.Code() != framework.Unschedulable || status.Message() != "" {
+			t.Errorf("PostFilter() status = %v, want Unschedulable without message", status)
+		}
+		if got := len(recorder.Events); got != 0 {
+			t.Errorf("recorded %d events, want 0", got)
+		}
+	})
+}
+
+func TestConcurrencySlots(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+				MaxConcurrentPods:            1,
+			},
+		},
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 150, 0, baseTime)
+	scheduler.slots = newConcurrencySlots()
+
+	first := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "first", UID: "uid-first", CreationTimestamp: metav1.NewTime(baseTime)}}
+	second := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "second", UID: "uid-second", CreationTimestamp: metav1.NewTime(baseTime)}}
+
+	state := framework.NewCycleState()
+	if _, status := scheduler.PreFilter(context.Background(), state, first); !status.IsSuccess() {
+		t.Fatalf("PreFilter(first) = %v, want Success", status)
+	}
+	scheduler.Reserve(context.Background(), state, first, "node-1")
+
+	s, ok := readAdmissionState(state)
+	if !ok || s.zone != "test-region" || s.intensity != 150 {
+		t.Errorf("admission state = %+v, want the zone and intensity of the reserved node", s)
+	}
+	if status := admit(scheduler, second); status.Code() != framework.Unschedulable {
+		t.Fatalf("admit(second) = %v, want Unschedulable while the slot is taken", status)
+	}
+
+	// Pods rejected at Permit or failing to bind return their slot
+	scheduler.Unreserve(context.Background(), state, first, "node-1")
+	if s.slot || s.zone != "" {
+		t.Errorf("admission state = %+v, want it cleared after Unreserve", s)
+	}
+	if status := admit(scheduler, second); !status.IsSuccess() {
+		t.Fatalf("admit(second) = %v, want Success after Unreserve", status)
+	}
+
+	// Finished pods return their slot
+	if status := admit(scheduler, first); status.Code() != framework.Unschedulable {
+		t.Fatalf("admit(first) = %v, want Unschedulable while the slot is taken", status)
+	}
+	finished := second.DeepCopy()
+	finished.Status.Phase = v1.PodSucceeded
+	scheduler.releaseSlot(finished)
+	if status := admit(scheduler, first); !status.IsSuccess() {
+		t.Errorf("admit(first) = %v, want Success after the running pod finished", status)
+	}
+
+	// Preemptors are admitted while the slot is taken, and take no slot
+	preemptor := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "preemptor", UID: "uid-preemptor", CreationTimestamp: metav1.NewTime(baseTime)},
+		Status:     v1.PodStatus{NominatedNodeName: "node-1"},
+	}
+	scheduler.cache.Set("test-region", &api.ElectricityData{CarbonIntensity: 250, Timestamp: baseTime})
+	if status := admit(scheduler, preemptor); !status.IsSuccess() {
+		t.Errorf("admit(preemptor) = %v, want Success for a nominated pod", status)
+	}
+	scheduler.releaseSlot(first)
+	scheduler.cache.Set("test-region", &api.ElectricityData{CarbonIntensity: 150, Timestamp: baseTime})
+	if status := admit(scheduler, second); !status.IsSuccess() {
+		t.Errorf("admit(second) = %v, want Success, the preemptor holds no slot", status)
+	}
+}
+
+func TestConservationConcurrencyLimit(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		base         int
+		conservation int
+		active       bool
+		wantLimit    int
+	}{
+		{name: "no alert keeps the base limit", base: 4, conservation: 2, wantLimit: 4},
+		{name: "alert lowers the limit", base: 4, conservation: 2, active: true, wantLimit: 2},
+		{name: "alert limits unlimited pods", conservation: 2, active: true, wantLimit: 2},
+		{name: "higher conservation limit is ignored", base: 1, conservation: 2, active: true, wantLimit: 1},
+		{name: "no conservation limit", base: 4, active: true, wantLimit: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					Scheduling: config.SchedulingConfig{MaxConcurrentPods: tt.base},
+					GridAlert: config.GridAlertConfig{
+						Enabled:           true,
+						ThresholdFactor:   1,
+						MaxConcurrentPods: tt.conservation,
+					},
+				},
+			}
+			scheduler := newTestScheduler(&cfg.Config, 150, 0, baseTime)
+			scheduler.gridAlerts = gridalert.NewPoller("http://mock-url/", time.Minute, time.Second, scheduler.clock.Now)
+			scheduler.gridAlerts.Set(gridalert.Alert{ID: "flex-alert", Active: tt.active})
+			scheduler.slots = newConcurrencySlots()
+			for i := 0; i < 2; i++ {
+				scheduler.slots.take(types.UID(fmt.Sprintf("running-%d", i)))
+			}
+
+			if got := scheduler.concurrencyLimit(); got != tt.wantLimit {
+				t.Errorf("concurrencyLimit() = %d, want %d", got, tt.wantLimit)
+			}
+			status := scheduler.checkConcurrency(&v1.Pod{})
+			if limited := status.Code() == framework.Unschedulable; limited != (tt.wantLimit > 0 && tt.wantLimit <= 2) {
+				t.Errorf("checkConcurrency() = %v with 2 running pods and a limit of %d", status, tt.wantLimit)
+			}
+		})
+	}
+}
+
+func TestRecordBoundIntensity(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+			},
+			Power: config.PowerConfig{
+				DefaultIdlePower: 100,
+				DefaultMaxPower:  400,
+			},
+		},
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:              "test-pod",
+		Namespace:         "default",
+		UID:               "uid-test",
+		CreationTimestamp: metav1.NewTime(baseTime),
+	}}
+
+	recorder := events.NewFakeRecorder(10)
+	scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+	scheduler.handle = &mockHandle{recorder: recorder}
+
+	if status := scheduler.checkCarbonIntensityConstraints(context.Background(), pod); status.Code() != framework.Unschedulable {
+		t.Fatalf("checkCarbonIntensityConstraints() = %v, want Unschedulable", status)
+	}
+	if pod.Annotations != nil {
+		t.Errorf("pod annotations = %v, want the pod left unmodified while delayed", pod.Annotations)
+	}
+
+	scheduler.cache.Set("test-region", &api.ElectricityData{CarbonIntensity: 100, Timestamp: baseTime})
+	scheduler.PostBind(context.Background(), framework.NewCycleState(), pod, "node-1")
+
+	if _, ok := scheduler.initialIntensity.Load(pod.UID); ok {
+		t.Error("expected the initial intensity to be forgotten once the pod is bound")
+	}
+	var event string
+	for len(recorder.Events) > 0 {
+		if e := <-recorder.Events; strings.Contains(e, "CarbonAwareBound") {
+			event = e
+		}
+	}
+	for _, want := range []string{"carbon intensity 100.00", "delayed at 250.00"} {
+		if !strings.Contains(event, want) {
+			t.Errorf("bound event = %q, want it to contain %q", event, want)
+		}
+	}
+
+	// Stamps aren't trusted unless configured, as submitters can set them
+	forged := pod.DeepCopy()
+	forged.UID = "uid-forged"
+	forged.Annotations = map[string]string{initialIntensityAnnotation: "900.00"}
+	scheduler.PostBind(context.Background(), framework.NewCycleState(), forged, "node-1")
+	for len(recorder.Events) > 0 {
+		if e := <-recorder.Events; strings.Contains(e, "submitted at") {
+			t.Errorf("bound event = %q, want the untrusted stamp ignored", e)
+		}
+	}
+
+	// Pods stamped at admission are compared with their submission intensity
+	scheduler.config.Observability.StampsTrusted = true
+	stamped := pod.DeepCopy()
+	stamped.UID = "uid-stamped"
+	stamped.Annotations = map[string]string{initialIntensityAnnotation: "300.00"}
+	scheduler.recordInitialIntensity(stamped, 250)
+	scheduler.PostBind(context.Background(), framework.NewCycleState(), stamped, "node-1")
+
+	if _, ok := scheduler.initialIntensity.Load(stamped.UID); ok {
+		t.Error("expected the initial intensity of the stamped pod to be forgotten once it is bound")
+	}
+	event = ""
+	for len(recorder.Events) > 0 {
+		if e := <-recorder.Events; strings.Contains(e, "CarbonAwareBound") {
+			event = e
+		}
+	}
+	if !strings.Contains(event, "submitted at 300.00") || strings.Contains(event, "delayed at") {
+		t.Errorf("bound event = %q, want it compared with the submission intensity", event)
+	}
+}
+
+func TestRackPowerBudget(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+			},
+			Power: config.PowerConfig{
+				DefaultIdlePower:   100,
+				DefaultMaxPower:    400,
+				RackBudgetsEnabled: true,
+			},
+		},
+	}
+
+	cpuPod := func(cpu string) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "pod-" + cpu, CreationTimestamp: metav1.NewTime(baseTime)},
+			Spec: v1.PodSpec{Containers: []v1.Container{{
+				Resources: v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)}},
+			}}},
+		}
+	}
+	node := func(name string, labels map[string]string, pods ...*v1.Pod) *framework.NodeInfo {
+		n := framework.NewNodeInfo(pods...)
+		n.SetNode(&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+			Status:     v1.NodeStatus{Allocatable: v1.ResourceList{v1.ResourceCPU: resource.MustParse("4")}},
+		})
+		return n
+	}
+
+	// Rack r1 draws 250W + 100W against the lower of its declared budgets, 450W
+	nodes := tf.NodeInfoLister{
+		node("busy", map[string]string{rackLabel: "r1", rackPowerBudgetLabel: "500"}, cpuPod("2")),
+		node("empty", map[string]string{rackLabel: "r1", rackPowerBudgetLabel: "450"}),
+		node("unracked", nil),
+	}
+
+	// The PDU outlets feeding the busy node measure 340W, above the 250W its pods request
+	pdu := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"PowerWatts": {"Reading": 170}}`)
+	}))
+	defer pdu.Close()
+	pduSource := config.EnergySourceConfig{
+		Default:      config.EnergySourcePDU,
+		PollInterval: time.Hour,
+		MaxAge:       5 * time.Minute,
+		PDU: config.PDUConfig{
+			OutletsAnnotation: "outlets",
+			Addresses:         map[string]string{"pdu-a": pdu.URL, "pdu-b": pdu.URL},
+			OutletPath:        "/redfish/v1/PowerEquipment/RackPDUs/1/Outlets/%s",
+		},
+	}
+	meter := energysource.New(pduSource, time.Second, func() time.Time { return baseTime })
+	meter.TrackNode(&v1.Node{ObjectMeta: metav1.ObjectMeta{
+		Name: "busy", Annotations: map[string]string{"outlets": "pdu-a/1,pdu-b/1"},
+	}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	meter.Run(ctx, stopCh)
+	waitForNodePower(t, meter, "busy")
+
+	tests := []struct {
+		name     string
+		disabled bool
+		measured bool
+		pod      *v1.Pod
+		nodeName string
+		wantCode framework.Code
+	}{
+		{
+			name:     "within budget",
+			pod:      cpuPod("1"),
+			nodeName: "empty",
+			wantCode: framework.Success,
+		},
+		{
+			name:     "exceeds budget",
+			pod:      cpuPod("4"),
+			nodeName: "empty",
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:     "node without rack",
+			pod:      cpuPod("4"),
+			nodeName: "unracked",
+			wantCode: framework.Success,
+		},
+		{
+			name:     "budgets disabled",
+			disabled: true,
+			pod:      cpuPod("4"),
+			nodeName: "empty",
+			wantCode: framework.Success,
+		},
+		{
+			name:     "measured rack power exceeds budget",
+			measured: true,
+			pod:      cpuPod("1"),
+			nodeName: "empty",
+			wantCode: framework.Unschedulable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg := cfg.Config
+			testCfg.Power.RackBudgetsEnabled = !tt.disabled
+			scheduler := newTestScheduler(&testCfg, 100, 0, baseTime)
+			scheduler.handle = &mockHandle{nodeInfos: nodes}
+			if tt.measured {
+				scheduler.energySource = meter
+			}
+
+			state := framework.NewCycleState()
+			if _, status := scheduler.PreFilter(context.Background(), state, tt.pod); !status.IsSuccess() {
+				t.Fatalf("PreFilter() = %v, want Success", status)
+			}
+			nodeInfo, _ := nodes.Get(tt.nodeName)
+			if got := scheduler.Filter(context.Background(), state, tt.pod, nodeInfo); got.Code() != tt.wantCode {
+				t.Errorf("Filter() = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestPowerCap(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+			},
+			Power: config.PowerConfig{
+				DefaultIdlePower: 100,
+				DefaultMaxPower:  400,
+			},
+			PowerCap: config.PowerCapConfig{
+				Enabled:   true,
+				Threshold: 0.9,
+			},
+		},
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 100, 0, baseTime)
+	scheduler.nodePower = promquery.NewVector(nil, "", "node", time.Minute, 5*time.Minute, scheduler.clock.Now)
+	scheduler.nodePower.Set(map[string]float64{"hot": 380, "capped": 380, "cool": 200})
+
+	tests := []struct {
+		name     string
+		node     *v1.Node
+		wantCode framework.Code
+	}{
+		{
+			name:     "close to max power",
+			node:     &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "hot"}},
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name: "power cap label",
+			node: &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "capped",
+				Labels: map[string]string{powerCapLabel: "500"}}},
+			wantCode: framework.Success,
+		},
+		{
+			name:     "well under cap",
+			node:     &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "cool"}},
+			wantCode: framework.Success,
+		},
+		{
+			name:     "no reading",
+			node:     &v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "unknown"}},
+			wantCode: framework.Success,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nodeInfo := framework.NewNodeInfo()
+			nodeInfo.SetNode(tt.node)
+			if got := scheduler.Filter(context.Background(), framework.NewCycleState(), &v1.Pod{}, nodeInfo); got.Code() != tt.wantCode {
+				t.Errorf("Filter() = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestDelayedConditionReported(t *testing.T) {
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "test-uid"}}
+	client := kubefake.NewSimpleClientset(pod)
+	scheduler := newTestScheduler(&config.Config{}, 250, 0, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	scheduler.delayStatus = newDelayReporter(client, nil)
+
+	scheduler.setDelayedCondition(pod, v1.ConditionTrue, reasonCarbonDelayed, delayConditionMessages[reasonCarbonDelayed])
+	scheduler.delayStatus.delayed(pod, "held", delayDiagnosis{}, scheduler.clock.Now())
+	if actions := client.Actions(); len(actions) != 0 {
+		t.Fatalf("actions = %v, want the condition left to the delay status worker", actions)
+	}
+
+	scheduler.delayStatus.sync(context.Background())
+	got, err := client.CoreV1().Pods("default").Get(context.Background(), "test-pod", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Status.Conditions) != 1 || got.Status.Conditions[0].Type != delayedCondition ||
+		got.Status.Conditions[0].Reason != reasonCarbonDelayed {
+		t.Errorf("conditions = %+v, want the delayed condition", got.Status.Conditions)
+	}
+	if len(scheduler.delayStatus.conditions) != 0 || len(scheduler.delayStatus.delays) != 0 {
+		t.Error("expected no pending conditions, and no delays without CarbonDelays")
+	}
+}
+
+func TestDelayedCondition(t *testing.T) {
+	earlier := metav1.NewTime(time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC))
+	now := metav1.NewTime(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	delayed := v1.PodCondition{
+		Type:               delayedCondition,
+		Status:             v1.ConditionTrue,
+		Reason:             reasonCarbonDelayed,
+		Message:            "Current carbon intensity (250.00) exceeds threshold (200.00)",
+		LastTransitionTime: earlier,
+	}
+	withCondition := func(c v1.PodCondition) *v1.Pod {
+		return &v1.Pod{Status: v1.PodStatus{Conditions: []v1.PodCondition{{Type: v1.PodScheduled}, c}}}
+	}
+
+	tests := []struct {
+		name        string
+		pod         *v1.Pod
+		status      v1.ConditionStatus
+		reason      string
+		message     string
+		wantChanged bool
+		wantTime    metav1.Time
+	}{
+		{
+			name:        "first delay",
+			pod:         &v1.Pod{},
+			status:      v1.ConditionTrue,
+			reason:      reasonCarbonDelayed,
+			message:     delayed.Message,
+			wantChanged: true,
+			wantTime:    now,
+		},
+		{
+			name:        "unchanged delay",
+			pod:         withCondition(delayed),
+			status:      v1.ConditionTrue,
+			reason:      reasonCarbonDelayed,
+			message:     delayed.Message,
+			wantChanged: false,
+			wantTime:    earlier,
+		},
+		{
+			name:        "new delay reason keeps transition time",
+			pod:         withCondition(delayed),
+			status:      v1.ConditionTrue,
+			reason:      reasonPriceDelayed,
+			message:     "Current electricity rate ($0.300/kWh) exceeds threshold ($0.200/kWh)",
+			wantChanged: true,
+			wantTime:    earlier,
+		},
+		{
+			name:        "delayed pod admitted",
+			pod:         withCondition(delayed),
+			status:      v1.ConditionFalse,
+			reason:      reasonAdmitted,
+			message:     "Bound to node-1",
+			wantChanged: true,
+			wantTime:    now,
+		},
+		{
+			name:        "pod never delayed",
+			pod:         &v1.Pod{},
+			status:      v1.ConditionFalse,
+			reason:      reasonAdmitted,
+			message:     "Bound to node-1",
+			wantChanged: false,
+			wantTime:    now,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cond, changed := delayedConditionUpdate(tt.pod, tt.status, tt.reason, tt.message, now)
+			if changed != tt.wantChanged {
+				t.Errorf("delayedConditionUpdate() changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if !cond.LastTransitionTime.Equal(&tt.wantTime) {
+				t.Errorf("delayedConditionUpdate() transition time = %v, want %v", cond.LastTransitionTime, tt.wantTime)
+			}
+		})
+	}
+
+	for message, want := range map[string]string{
+		"Current electricity rate ($0.300/kWh) exceeds threshold ($0.200/kWh)": reasonPriceDelayed,
+		"Current carbon intensity (250.00) exceeds threshold (200.00)":         reasonCarbonDelayed,
+		"Grid alert alert-1 active, scheduler in conservation mode":            reasonCarbonDelayed,
+	} {
+		if got := delayConditionReason(message); got != want {
+			t.Errorf("delayConditionReason(%q) = %q, want %q", message, got, want)
+		}
+	}
+}
+
+func TestPreBind(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold:  200,
+				AbortCarbonIntensityThreshold: 300,
+				MaxSchedulingDelay:            24 * time.Hour,
+			},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		abort       float64
+		spike       float64
+		want        framework.Code
+	}{
+		{
+			name:  "intensity rose under abort threshold",
+			abort: 300,
+			spike: 250,
+			want:  framework.Success,
+		},
+		{
+			name:  "intensity spiked above abort threshold",
+			abort: 300,
+			spike: 350,
+			want:  framework.Unschedulable,
+		},
+		{
+			name:        "pod threshold above abort threshold",
+			annotations: map[string]string{"carbon-aware-scheduler.kubernetes.io/carbon-intensity-threshold": "400"},
+			abort:       300,
+			spike:       350,
+			want:        framework.Success,
+		},
+		{
+			name:        "opted out pod",
+			annotations: map[string]string{"carbon-aware-scheduler.kubernetes.io/skip": "true"},
+			abort:       300,
+			spike:       350,
+			want:        framework.Success,
+		},
+		{
+			name:  "abort disabled",
+			abort: 0,
+			spike: 350,
+			want:  framework.Success,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testCfg := cfg.Config
+			testCfg.Scheduling.AbortCarbonIntensityThreshold = tt.abort
+			scheduler := newTestScheduler(&testCfg, 150, 0, baseTime)
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:              "test-pod",
+				Namespace:         "default",
+				UID:               "test-pod",
+				Annotations:       tt.annotations,
+				CreationTimestamp: metav1.NewTime(baseTime),
+			}}
+
+			state := framework.NewCycleState()
+			if _, status := scheduler.PreFilter(context.Background(), state, pod); !status.IsSuccess() {
+				t.Fatalf("PreFilter() status = %v, want Success", status)
+			}
+			scheduler.Reserve(context.Background(), state, pod, "node-1")
+
+			scheduler.cache.Set("test-region", &api.ElectricityData{CarbonIntensity: tt.spike, Timestamp: baseTime})
+			if got := scheduler.PreBind(context.Background(), state, pod, "node-1"); got.Code() != tt.want {
+				t.Errorf("PreBind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShareDataLayer(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &config.Config{
+		API: config.APIConfig{
+			Key:    "test-key",
+			Region: "test-region",
+		},
+		Scheduling: config.SchedulingConfig{
+			BaseCarbonIntensityThreshold: 200,
+			MaxSchedulingDelay:           24 * time.Hour,
+		},
+	}
+	owner := newTestScheduler(cfg, 150, 0, baseTime)
+
+	auditCfg := *cfg
+	auditCfg.Scheduling.EnforcementMode = config.EnforcementModeAudit
+	profile := &CarbonAwareScheduler{
+		handle:     &mockHandle{},
+		config:     &auditCfg,
+		clock:      owner.clock,
+		hysteresis: newHysteresis(),
+		stopCh:     make(chan struct{}),
+	}
+	profile.shareDataLayer(owner)
+
+	owner.nodeZones.Store("node-1", "other-zone")
+	if got := profile.nodeZone("node-1"); got != "other-zone" {
+		t.Errorf("nodeZone() = %q, want zones tracked by the owner", got)
+	}
+	if data, ok := profile.cache.Get("test-region"); !ok || data.CarbonIntensity != 150 {
+		t.Errorf("cache.Get() = %v, %v, want the owner's cached data", data, ok)
+	}
+
+	// Closing a profile sharing the data layer leaves it to its owner
+	if err := profile.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, ok := owner.cache.Get("test-region"); !ok {
+		t.Error("closing a sharing profile closed the shared cache")
+	}
+
+	// Closing the owner releases the data layer, and closing twice is safe
+	owner.stopCh = make(chan struct{})
+	sharedDataMu.Lock()
+	sharedData = owner
+	sharedDataMu.Unlock()
+	for i := 0; i < 2; i++ {
+		if err := owner.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+	sharedDataMu.Lock()
+	defer sharedDataMu.Unlock()
+	if sharedData != nil {
+		t.Error("closing the owner left it as the shared data layer")
+	}
+}
+
+func TestGangRelease(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+				GangReleaseTTL:               10 * time.Minute,
+			},
+		},
+	}
+	member := func(name, group string) *v1.Pod {
+		pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "default",
+			UID:               types.UID(name),
+			CreationTimestamp: metav1.NewTime(baseTime),
+		}}
+		if group != "" {
+			pod.Labels = map[string]string{"scheduling.x-k8s.io/pod-group": group}
+		}
+		return pod
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 150, 0, baseTime)
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	bound := member("gang-bound", "gang")
+	bound.Spec.NodeName = "node-2"
+	for _, pod := range []*v1.Pod{member("gang-0", "gang"), member("gang-1", "gang"), bound, member("other-0", "other")} {
+		indexer.Add(pod)
+	}
+	scheduler.gangPods = corelisters.NewPodLister(indexer)
+
+	// A member admitted in PreFilter releases nothing until it is placed
+	first := member("gang-0", "gang")
+	state := framework.NewCycleState()
+	activate := framework.NewPodsToActivate()
+	state.Write(framework.PodsToActivateKey, activate)
+	if _, status := scheduler.PreFilter(context.Background(), state, first); !status.IsSuccess() {
+		t.Fatalf("first member status = %v, want Success", status)
+	}
+
+	// Intensity rises above the threshold before the other members are scheduled
+	scheduler.cache.Set("test-region", &api.ElectricityData{CarbonIntensity: 250, Timestamp: baseTime})
+	if status := admit(scheduler, member("gang-1", "gang")); status.Code() != framework.Unschedulable {
+		t.Errorf("member before release status = %v, want Unschedulable", status)
+	}
+
+	scheduler.Reserve(context.Background(), state, first, "node-1")
+	if status, _ := scheduler.Permit(context.Background(), state, first, "node-1"); !status.IsSuccess() {
+		t.Fatalf("first member permit status = %v, want Success", status)
+	}
+	if _, ok := activate.Map["default/gang-1"]; !ok || len(activate.Map) != 1 {
+		t.Errorf("pods to activate = %v, want only the pending member gang-1", activate.Map)
+	}
+	if status := admit(scheduler, member("gang-1", "gang")); !status.IsSuccess() {
+		t.Errorf("released member status = %v, want Success", status)
+	}
+	if status := admit(scheduler, member("other-0", "other")); status.Code() != framework.Unschedulable {
+		t.Errorf("member of another group status = %v, want Unschedulable", status)
+	}
+	if status := admit(scheduler, member("solo", "")); status.Code() != framework.Unschedulable {
+		t.Errorf("pod without group status = %v, want Unschedulable", status)
+	}
+
+	// Opted-out members don't release their group
+	optedOut := member("opted-out", "other")
+	optedOut.Annotations = map[string]string{skipAnnotation: "true"}
+	state = framework.NewCycleState()
+	scheduler.PreFilter(context.Background(), state, optedOut)
+	scheduler.Permit(context.Background(), state, optedOut, "node-1")
+	if status := admit(scheduler, member("other-0", "other")); status.Code() != framework.Unschedulable {
+		t.Errorf("member of group with opted-out member status = %v, want Unschedulable", status)
+	}
+
+	// The release expires, and is pruned
+	scheduler.clock.(*clock.MockClock).Set(baseTime.Add(11 * time.Minute))
+	scheduler.pruneGangReleases()
+	if _, ok := scheduler.gangReleases.Load("default/gang"); ok {
+		t.Error("expired release was not pruned")
+	}
+	if status := admit(scheduler, member("gang-2", "gang")); status.Code() != framework.Unschedulable {
+		t.Errorf("member after release expired status = %v, want Unschedulable", status)
+	}
+}
+
+func TestCarbonData(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+	scheduler.nodeZones.Store("node-1", "other-zone")
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:              "test-pod",
+		Namespace:         "default",
+		Annotations:       map[string]string{"carbon-aware-scheduler.kubernetes.io/carbon-intensity-threshold": "300"},
+		CreationTimestamp: metav1.NewTime(baseTime),
+	}}
+
+	state := framework.NewCycleState()
+	scheduler.PreFilter(context.Background(), state, pod)
+
+	d, ok := ReadCarbonData(state)
+	if !ok {
+		t.Fatal("ReadCarbonData() found no carbon data")
+	}
+	if d.Zone != "test-region" || d.Threshold != 300 {
+		t.Errorf("carbon data zone = %q, threshold = %v, want test-region and 300", d.Zone, d.Threshold)
+	}
+	want := ZoneData{Intensity: 250, Threshold: 200, Timestamp: baseTime}
+	if got := d.Zones["test-region"]; got != want {
+		t.Errorf("zone data = %+v, want %+v", got, want)
+	}
+	if _, ok := d.Zones["other-zone"]; ok {
+		t.Error("published data for a zone without cached data")
+	}
+
+	if _, ok := ReadCarbonData(framework.NewCycleState()); ok {
+		t.Error("ReadCarbonData() found carbon data in an empty cycle state")
+	}
+}
+
+func TestDeferredBind(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+				DeferBindMaxDelay:            time.Hour,
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+	scheduler.stopCh = make(chan struct{})
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:              "deferred",
+		Namespace:         "default",
+		UID:               "deferred",
+		Annotations:       map[string]string{deferBindAnnotation: "true"},
+		CreationTimestamp: metav1.NewTime(baseTime),
+	}}
+	other := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:              "other",
+		Namespace:         "default",
+		UID:               "other",
+		CreationTimestamp: metav1.NewTime(baseTime),
+	}}
+
+	if _, status := scheduler.PreFilter(context.Background(), framework.NewCycleState(), other); status.Code() != framework.Unschedulable {
+		t.Errorf("PreFilter(other) = %v, want Unschedulable", status)
+	}
+
+	state := framework.NewCycleState()
+	if _, status := scheduler.PreFilter(context.Background(), state, pod); !status.IsSuccess() {
+		t.Fatalf("PreFilter() = %v, want Success for a pod deferring its bind", status)
+	}
+	scheduler.Reserve(context.Background(), state, pod, "node-1")
+	if status, _ := scheduler.Permit(context.Background(), state, pod, "node-1"); !status.IsSuccess() {
+		t.Errorf("Permit() = %v, want Success, deferred pods wait at Bind", status)
+	}
+
+	// The bind waits while the pod's constraints hold
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if status := scheduler.Bind(ctx, state, pod, "node-1"); status.IsSuccess() || status.IsSkip() {
+		t.Errorf("Bind() = %v, want an error when cancelled while deferred", status)
+	}
+
+	// Cleared constraints still wait for a concurrency slot
+	scheduler.cache.Set("test-region", &api.ElectricityData{CarbonIntensity: 150, Timestamp: baseTime})
+	scheduler.backoff.Delete(pod.UID)
+	scheduler.config.Scheduling.MaxConcurrentPods = 1
+	scheduler.slots = newConcurrencySlots()
+	scheduler.slots.take("running")
+	if status := scheduler.Bind(ctx, state, pod, "node-1"); status.IsSuccess() || status.IsSkip() {
+		t.Errorf("Bind() = %v, want to keep waiting without a free slot", status)
+	}
+
+	// Once the constraints clear the bind is left to the next bind plugin, taking a slot
+	scheduler.slots.release("running")
+	if status := scheduler.Bind(context.Background(), state, pod, "node-1"); !status.IsSkip() {
+		t.Errorf("Bind() = %v, want Skip once the constraints cleared", status)
+	}
+	if scheduler.slots.available(1) {
+		t.Error("Bind() should take a concurrency slot for the deferred pod")
+	}
+	// The slot is returned if the bind fails
+	scheduler.Unreserve(context.Background(), state, pod, "node-1")
+	if !scheduler.slots.available(1) {
+		t.Error("Unreserve() should return the slot of the deferred pod")
+	}
+
+	if status := scheduler.Bind(context.Background(), framework.NewCycleState(), other, "node-1"); !status.IsSkip() {
+		t.Errorf("Bind(other) = %v, want Skip", status)
+	}
+}
+
+func TestSpotScore(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+			},
+			Scoring: config.ScoringConfig{
+				Weights: config.ScoreWeights{Zone: 1, Efficiency: 1, Packing: 1, Spot: 1},
+			},
+		},
+	}
+
+	labeledNode := func(name string, labels map[string]string) *framework.NodeInfo {
+		n := framework.NewNodeInfo()
+		n.SetNode(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}})
+		return n
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 100, 0, baseTime)
+	scheduler.handle = &mockHandle{nodeInfos: tf.NodeInfoLister{
+		labeledNode("karpenter-spot", map[string]string{"karpenter.sh/capacity-type": "spot"}),
+		labeledNode("eks-spot", map[string]string{"eks.amazonaws.com/capacityType": "SPOT"}),
+		labeledNode("gke-preemptible", map[string]string{"cloud.google.com/gke-preemptible": "true"}),
+		labeledNode("on-demand", map[string]string{"karpenter.sh/capacity-type": "on-demand"}),
+	}}
+
+	flexible := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{flexibleAnnotation: "true"}}}
+
+	tests := []struct {
+		name     string
+		pod      *v1.Pod
+		nodeName string
+		want     int64
+	}{
+		{
+			name:     "karpenter spot node",
+			pod:      flexible,
+			nodeName: "karpenter-spot",
+			want:     (75 + 100) / 2,
+		},
+		{
+			name:     "eks spot node",
+			pod:      flexible,
+			nodeName: "eks-spot",
+			want:     (75 + 100) / 2,
+		},
+		{
+			name:     "gke preemptible node",
+			pod:      flexible,
+			nodeName: "gke-preemptible",
+			want:     (75 + 100) / 2,
+		},
+		{
+			name:     "on-demand node",
+			pod:      flexible,
+			nodeName: "on-demand",
+			want:     75 / 2,
+		},
+		{
+			name:     "pod not flexible",
+			pod:      &v1.Pod{},
+			nodeName: "karpenter-spot",
+			want:     75,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, status := scheduler.Score(context.Background(), nil, tt.pod, tt.nodeName)
+			if !status.IsSuccess() {
+				t.Fatalf("Score() status = %v", status)
+			}
+			if got != tt.want {
+				t.Errorf("Score() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCarbonPolicy(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	// A Monday
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	policies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, p := range []*v1alpha1.CarbonPolicy{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "lenient", Name: "threshold"},
+			Spec:       v1alpha1.CarbonPolicySpec{CarbonIntensityThreshold: ptr.To(300.0)},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "onboarding", Name: "audit"},
+			Spec:       v1alpha1.CarbonPolicySpec{EnforcementMode: v1alpha1.EnforcementModeAudit},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "urgent", Name: "delay"},
+			Spec:       v1alpha1.CarbonPolicySpec{MaxSchedulingDelay: &metav1.Duration{Duration: time.Hour}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "peak", Name: "weekdays"},
+			Spec: v1alpha1.CarbonPolicySpec{
+				CarbonIntensityThreshold: ptr.To(300.0),
+				PeakSchedules:            []v1alpha1.PeakSchedule{{DayOfWeek: "12345", StartTime: "09:00", EndTime: "17:00"}},
+			},
+		},
+	} {
+		if err := policies.Add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clusterPolicies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+	tests := []struct {
+		name        string
+		namespace   string
+		annotations map[string]string
+		age         time.Duration
+		wantCode    framework.Code
+		wantReason  string
+	}{
+		{
+			name:      "no policy",
+			namespace: "default",
+			wantCode:  framework.Unschedulable,
+		},
+		{
+			name:      "policy threshold",
+			namespace: "lenient",
+			wantCode:  framework.Success,
+		},
+		{
+			name:        "annotation overrides policy threshold",
+			namespace:   "lenient",
+			annotations: map[string]string{"carbon-aware-scheduler.kubernetes.io/carbon-intensity-threshold": "200"},
+			wantCode:    framework.Unschedulable,
+		},
+		{
+			name:      "policy enforcement mode",
+			namespace: "onboarding",
+			wantCode:  framework.Success,
+		},
+		{
+			name:      "policy max delay exceeded",
+			namespace: "urgent",
+			age:       2 * time.Hour,
+			wantCode:  framework.Success,
+		},
+		{
+			name:       "policy peak schedule",
+			namespace:  "peak",
+			wantCode:   framework.Unschedulable,
+			wantReason: "Peak hours of carbon policy peak/weekdays",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           24 * time.Hour,
+						EnforcementMode:              config.EnforcementModeEnforce,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+			scheduler.policies = policy.NewResolver(policies, clusterPolicies, nil)
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:         tt.namespace,
+				Annotations:       tt.annotations,
+				CreationTimestamp: metav1.NewTime(baseTime.Add(-tt.age)),
+			}}
+			_, status := scheduler.PreFilter(context.Background(), nil, pod)
+			if status.Code() != tt.wantCode {
+				t.Errorf("PreFilter() = %v, want %v", status, tt.wantCode)
+			}
+			if tt.wantReason != "" && status.Message() != tt.wantReason {
+				t.Errorf("PreFilter() message = %q, want %q", status.Message(), tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestNamespaceDefaults(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range []*v1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "lenient", Annotations: map[string]string{thresholdAnnotation: "300"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "invalid", Annotations: map[string]string{thresholdAnnotation: "high"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "urgent", Annotations: map[string]string{maxDelayAnnotation: "1h"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "opted-out", Annotations: map[string]string{skipAnnotation: "true"}}},
+	} {
+		if err := indexer.Add(ns); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		annotations map[string]string
+		age         time.Duration
+		wantCode    framework.Code
+	}{
+		{
+			name:      "no namespace defaults",
+			namespace: "default",
+			wantCode:  framework.Unschedulable,
+		},
+		{
+			name:      "namespace threshold",
+			namespace: "lenient",
+			wantCode:  framework.Success,
+		},
+		{
+			name:        "pod threshold overrides namespace",
+			namespace:   "lenient",
+			annotations: map[string]string{thresholdAnnotation: "200"},
+			wantCode:    framework.Unschedulable,
+		},
+		{
+			name:      "invalid namespace threshold ignored",
+			namespace: "invalid",
+			wantCode:  framework.Unschedulable,
+		},
+		{
+			name:      "namespace max delay exceeded",
+			namespace: "urgent",
+			age:       2 * time.Hour,
+			wantCode:  framework.Success,
+		},
+		{
+			name:      "namespace max delay not exceeded",
+			namespace: "urgent",
+			age:       30 * time.Minute,
+			wantCode:  framework.Unschedulable,
+		},
+		{
+			name:      "namespace skip",
+			namespace: "opted-out",
+			wantCode:  framework.Success,
+		},
+		{
+			name:        "pod opts back in",
+			namespace:   "opted-out",
+			annotations: map[string]string{skipAnnotation: "false"},
+			wantCode:    framework.Unschedulable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           24 * time.Hour,
+						EnforcementMode:              config.EnforcementModeEnforce,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+			scheduler.namespaceLister = corelisters.NewNamespaceLister(indexer)
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:         tt.namespace,
+				Annotations:       tt.annotations,
+				CreationTimestamp: metav1.NewTime(baseTime.Add(-tt.age)),
+			}}
+			if _, status := scheduler.PreFilter(context.Background(), nil, pod); status.Code() != tt.wantCode {
+				t.Errorf("PreFilter() = %v, want %v", status, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCarbonBudget(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	budgets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, b := range []*v1alpha1.CarbonBudget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "blocked", Name: "daily"},
+			Spec:       v1alpha1.CarbonBudgetSpec{Limit: 100, Period: v1alpha1.BudgetPeriodDay},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "audited", Name: "daily"},
+			Spec:       v1alpha1.CarbonBudgetSpec{Limit: 100, Period: v1alpha1.BudgetPeriodDay, Action: v1alpha1.BudgetActionAudit},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "demoted", Name: "daily"},
+			Spec: v1alpha1.CarbonBudgetSpec{Limit: 100, Period: v1alpha1.BudgetPeriodDay,
+				Action: v1alpha1.BudgetActionDemote, DemotedThresholdFactor: ptr.To(0.4)},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "within", Name: "weekly"},
+			Spec:       v1alpha1.CarbonBudgetSpec{Limit: 1000, Period: v1alpha1.BudgetPeriodWeek},
+		},
+	} {
+		if err := budgets.Add(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		annotations map[string]string
+		age         time.Duration
+		wantCode    framework.Code
+	}{
+		{
+			name:      "budget exhausted",
+			namespace: "blocked",
+			wantCode:  framework.Unschedulable,
+		},
+		{
+			name:      "budget exhausted past max delay",
+			namespace: "blocked",
+			age:       48 * time.Hour,
+			wantCode:  framework.Unschedulable,
+		},
+		{
+			name:        "opted out pod exempt",
+			namespace:   "blocked",
+			annotations: map[string]string{skipAnnotation: "true"},
+			wantCode:    framework.Success,
+		},
+		{
+			name:      "audit budget exhausted",
+			namespace: "audited",
+			wantCode:  framework.Success,
+		},
+		{
+			name:      "demoted under stricter threshold",
+			namespace: "demoted",
+			wantCode:  framework.Unschedulable,
+		},
+		{
+			name:      "demoted past max delay",
+			namespace: "demoted",
+			age:       48 * time.Hour,
+			wantCode:  framework.Success,
+		},
+		{
+			name:      "within budget",
+			namespace: "within",
+			wantCode:  framework.Success,
+		},
+		{
+			name:      "no budget",
+			namespace: "default",
+			wantCode:  framework.Success,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           24 * time.Hour,
+						EnforcementMode:              config.EnforcementModeEnforce,
+					},
+				},
+			}
+
+			scheduler := newTestScheduler(&cfg.Config, 100, 0, baseTime)
+			scheduler.budgets = policy.NewBudgets(budgets, nil, policy.NewLedger(), nil, policy.BudgetDefaults{})
+			for _, ns := range []string{"blocked", "audited", "demoted", "within"} {
+				scheduler.budgets.Accrue(ns, "", 150, baseTime.Add(-time.Hour))
+			}
+
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:         tt.namespace,
+				Annotations:       tt.annotations,
+				CreationTimestamp: metav1.NewTime(baseTime.Add(-tt.age)),
+			}}
+			if _, status := scheduler.PreFilter(context.Background(), nil, pod); status.Code() != tt.wantCode {
+				t.Errorf("PreFilter() = %v, want %v", status, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAccountingLabel(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+				EnforcementMode:              config.EnforcementModeEnforce,
+			},
+			Policy: config.PolicyConfig{AccountingLabel: "team"},
+		},
+	}
+
+	namespaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range []*v1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "ml-train", Labels: map[string]string{"team": "ml"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "ml-serve", Labels: map[string]string{"team": "ml"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "shared"}},
+	} {
+		if err := namespaces.Add(ns); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clusterBudgets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := clusterBudgets.Add(&v1alpha1.ClusterCarbonBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: "ml"},
+		Spec: v1alpha1.ClusterCarbonBudgetSpec{
+			CarbonBudgetSpec: v1alpha1.CarbonBudgetSpec{Limit: 100, Period: v1alpha1.BudgetPeriodDay},
+			LabelValue:       "ml",
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	budgets := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+
+	scheduler := newTestScheduler(&cfg.Config, 100, 0, baseTime)
+	scheduler.namespaceLister = corelisters.NewNamespaceLister(namespaces)
+	scheduler.budgets = policy.NewBudgets(budgets, clusterBudgets, policy.NewLedger(), nil, policy.BudgetDefaults{})
+
+	newPod := func(namespace string, labels map[string]string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace:         namespace,
+			Labels:            labels,
+			CreationTimestamp: metav1.NewTime(baseTime),
+		}}
+	}
+
+	if got := scheduler.accountingValue(newPod("ml-train", nil)); got != "ml" {
+		t.Errorf("accountingValue() from namespace = %q, want ml", got)
+	}
+	if got := scheduler.accountingValue(newPod("shared", map[string]string{"team": "ml"})); got != "ml" {
+		t.Errorf("accountingValue() from pod = %q, want ml", got)
+	}
+
+	// Emissions of the team in one namespace and a shared one exhaust its budget
+	scheduler.accrueEmissions(newPod("ml-train", nil), 60)
+	scheduler.accrueEmissions(newPod("shared", map[string]string{"team": "ml"}), 60)
+
+	tests := []struct {
+		name     string
+		pod      *v1.Pod
+		wantCode framework.Code
+	}{
+		{
+			name:     "team namespace",
+			pod:      newPod("ml-serve", nil),
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:     "team pod in shared namespace",
+			pod:      newPod("shared", map[string]string{"team": "ml"}),
+			wantCode: framework.Unschedulable,
+		},
+		{
+			name:     "other team",
+			pod:      newPod("shared", map[string]string{"team": "web"}),
+			wantCode: framework.Success,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, status := scheduler.PreFilter(context.Background(), nil, tt.pod); status.Code() != tt.wantCode {
+				t.Errorf("PreFilter() = %v, want %v", status, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCarbonSLO(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+				EnforcementMode:              config.EnforcementModeEnforce,
+			},
+		},
+	}
+
+	slos := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := slos.Add(&v1alpha1.CarbonSLO{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "batch", Name: "clean"},
+		Spec: v1alpha1.CarbonSLOSpec{
+			CarbonIntensity:     150,
+			Target:              100,
+			Period:              v1alpha1.BudgetPeriodDay,
+			ThresholdAdjustment: &v1alpha1.SLOThresholdAdjustment{},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduler := newTestScheduler(&cfg.Config, 180, 0, baseTime)
+	scheduler.slos = policy.NewSLOs(slos, policy.NewLedger(), nil)
+
+	completed := func(cpu string, intensity string) *v1.Pod {
+		start := metav1.NewTime(baseTime.Add(-time.Hour))
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "batch",
+				Annotations: map[string]string{boundIntensityAnnotation: intensity},
+			},
+			Spec: v1.PodSpec{Containers: []v1.Container{{Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)},
+			}}}},
+			Status: v1.PodStatus{StartTime: &start},
+		}
+	}
+	newPod := func(annotations map[string]string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "batch",
+			Annotations:       annotations,
+			CreationTimestamp: metav1.NewTime(baseTime),
+		}}
+	}
+
+	if _, status := scheduler.PreFilter(context.Background(), nil, newPod(nil)); !status.IsSuccess() {
+		t.Errorf("PreFilter() without SLO data = %v, want success", status)
+	}
+
+	// Half the CPU-hours clean halves the threshold, delaying pods under 180 gCO2/kWh
+	scheduler.accrueSLO(completed("2", "100"))
+	scheduler.accrueSLO(completed("2", "300"))
+	if _, status := scheduler.PreFilter(context.Background(), nil, newPod(nil)); status.Code() != framework.Unschedulable {
+		t.Errorf("PreFilter() missing SLO = %v, want unschedulable", status)
+	}
+	if _, status := scheduler.PreFilter(context.Background(), nil, newPod(map[string]string{skipAnnotation: "true"})); !status.IsSuccess() {
+		t.Errorf("PreFilter() opted out = %v, want success", status)
+	}
+}
+
+func TestPolicyPrecedence(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	namespaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range []*v1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "policed", Labels: map[string]string{"policies": "cluster"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "annotated",
+			Labels:      map[string]string{"policies": "cluster", enforcementModeLabel: config.EnforcementModeAudit},
+			Annotations: map[string]string{thresholdAnnotation: "250"},
+		}},
+	} {
+		if err := namespaces.Add(ns); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	policies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, ns := range []string{"policed", "annotated"} {
+		if err := policies.Add(&v1alpha1.CarbonPolicy{
+			ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: "partial"},
+			Spec:       v1alpha1.CarbonPolicySpec{CarbonIntensityThreshold: ptr.To(150.0)},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clusterPolicies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := clusterPolicies.Add(&v1alpha1.ClusterCarbonPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: v1alpha1.CarbonPolicySpec{
+			NamespaceSelector:        &metav1.LabelSelector{MatchLabels: map[string]string{"policies": "cluster"}},
+			CarbonIntensityThreshold: ptr.To(400.0),
+			MaxSchedulingDelay:       &metav1.Duration{Duration: 6 * time.Hour},
+			EnforcementMode:          v1alpha1.EnforcementModeEnforce,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+				EnforcementMode:              config.EnforcementModeEnforce,
+			},
+		},
+	}
+	scheduler := newTestScheduler(&cfg.Config, 100, 0, baseTime)
+	scheduler.namespaceLister = corelisters.NewNamespaceLister(namespaces)
+	scheduler.policies = policy.NewResolver(policies, clusterPolicies, corelisters.NewNamespaceLister(namespaces))
+
+	tests := []struct {
+		name          string
+		namespace     string
+		annotations   map[string]string
+		wantThreshold float64
+		wantDelay     time.Duration
+		wantMode      string
+	}{
+		{
+			name:          "plugin defaults",
+			namespace:     "default",
+			wantThreshold: 200,
+			wantDelay:     24 * time.Hour,
+			wantMode:      config.EnforcementModeEnforce,
+		},
+		{
+			name:          "namespaced policy over cluster policy, merged",
+			namespace:     "policed",
+			wantThreshold: 150,
+			wantDelay:     6 * time.Hour,
+			wantMode:      config.EnforcementModeEnforce,
+		},
+		{
+			name:          "namespace annotation and label over policies",
+			namespace:     "annotated",
+			wantThreshold: 250,
+			wantDelay:     6 * time.Hour,
+			wantMode:      config.EnforcementModeAudit,
+		},
+		{
+			name:      "pod annotations over everything",
+			namespace: "annotated",
+			annotations: map[string]string{
+				thresholdAnnotation: "100",
+				maxDelayAnnotation:  "1h",
+			},
+			wantThreshold: 100,
+			wantDelay:     time.Hour,
+			wantMode:      config.EnforcementModeAudit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Namespace:         tt.namespace,
+				Annotations:       tt.annotations,
+				CreationTimestamp: metav1.NewTime(baseTime),
+			}}
+			threshold, err := scheduler.carbonThreshold(pod)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if threshold != tt.wantThreshold {
+				t.Errorf("carbonThreshold() = %v, want %v", threshold, tt.wantThreshold)
+			}
+			if got := scheduler.maxSchedulingDelay(pod); got != tt.wantDelay {
+				t.Errorf("maxSchedulingDelay() = %v, want %v", got, tt.wantDelay)
+			}
+			if got := scheduler.enforcementMode(pod); got != tt.wantMode {
+				t.Errorf("enforcementMode() = %v, want %v", got, tt.wantMode)
+			}
+		})
+	}
+}
+
+func TestWorkloadClass(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	classes := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, class := range []*v1alpha1.WorkloadClass{
+		{ObjectMeta: metav1.ObjectMeta{Name: "flexible"}, Spec: v1alpha1.WorkloadClassSpec{
+			CarbonIntensityThreshold: ptr.To(150.0),
+			MaxSchedulingDelay:       &metav1.Duration{Duration: 48 * time.Hour},
+			AgingCurve:               v1alpha1.AgingCurveLinear,
+			AgingMaxFactor:           ptr.To(3.0),
+		}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "steady"}, Spec: v1alpha1.WorkloadClassSpec{
+			AgingCurve: v1alpha1.AgingCurveNone,
+		}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "critical"}, Spec: v1alpha1.WorkloadClassSpec{Exempt: true}},
+	} {
+		if err := classes.Add(class); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+				AgingCurve:                   config.AgingCurveLinear,
+				AgingMaxFactor:               2,
+			},
+		},
+	}
+	// Halfway through the default delay, a quarter through the flexible delay
+	scheduler := newTestScheduler(&cfg.Config, 180, 0, baseTime.Add(12*time.Hour))
+	scheduler.classes = policy.NewClasses(classes)
+
+	tests := []struct {
+		name          string
+		class         string
+		annotations   map[string]string
+		wantThreshold float64
+		wantDelay     time.Duration
+		wantOptedOut  bool
+	}{
+		{
+			name:          "no class",
+			wantThreshold: 200 * 1.5,
+			wantDelay:     24 * time.Hour,
+		},
+		{
+			name:          "unknown class falls back to defaults",
+			class:         "missing",
+			wantThreshold: 200 * 1.5,
+			wantDelay:     24 * time.Hour,
+		},
+		{
+			name:          "flexible class",
+			class:         "flexible",
+			wantThreshold: 150 * 1.5,
+			wantDelay:     48 * time.Hour,
+		},
+		{
+			name:          "flexible class overridden by annotations",
+			class:         "flexible",
+			annotations:   map[string]string{thresholdAnnotation: "100", maxDelayAnnotation: "24h"},
+			wantThreshold: 100 * 2,
+			wantDelay:     24 * time.Hour,
+		},
+		{
+			name:          "class disabling aging",
+			class:         "steady",
+			wantThreshold: 200,
+			wantDelay:     24 * time.Hour,
+		},
+		{
+			name:          "exempt class",
+			class:         "critical",
+			wantThreshold: 200 * 1.5,
+			wantDelay:     24 * time.Hour,
+			wantOptedOut:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:              "test-pod",
+				Namespace:         "default",
+				Annotations:       tt.annotations,
+				CreationTimestamp: metav1.NewTime(baseTime),
+			}}
+			if tt.class != "" {
+				pod.Labels = map[string]string{workloadClassLabel: tt.class}
+			}
+
+			threshold, err := scheduler.carbonThreshold(pod)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(threshold-tt.wantThreshold) > 1e-9 {
+				t.Errorf("carbonThreshold() = %v, want %v", threshold, tt.wantThreshold)
+			}
+			if got := scheduler.maxSchedulingDelay(pod); got != tt.wantDelay {
+				t.Errorf("maxSchedulingDelay() = %v, want %v", got, tt.wantDelay)
+			}
+			if got := scheduler.isOptedOut(pod); got != tt.wantOptedOut {
+				t.Errorf("isOptedOut() = %v, want %v", got, tt.wantOptedOut)
+			}
+		})
+	}
+}
+
+func TestCarbonExemption(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	namespaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ns := range []*v1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "finance", Labels: map[string]string{"team": "finance"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "research"}},
+	} {
+		if err := namespaces.Add(ns); err != nil {
+			t.Fatal(err)
+		}
+	}
+	exemptions := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, ex := range []*v1alpha1.CarbonExemption{
+		{ObjectMeta: metav1.ObjectMeta{Name: "quarter-end", UID: "uid-quarter-end"}, Spec: v1alpha1.CarbonExemptionSpec{
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "finance"}},
+			Start:             ptr.To(metav1.NewTime(baseTime.Add(-time.Hour))),
+			Expires:           metav1.NewTime(baseTime.Add(2 * time.Hour)),
+		}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "batch-crunch", UID: "uid-batch-crunch"}, Spec: v1alpha1.CarbonExemptionSpec{
+			PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "batch"}},
+			Start:       ptr.To(metav1.NewTime(baseTime.Add(time.Hour))),
+			Expires:     metav1.NewTime(baseTime.Add(3 * time.Hour)),
+		}},
+	} {
+		if err := exemptions.Add(ex); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+			},
+		},
+	}
+	recorder := events.NewFakeRecorder(10)
+	scheduler := newTestScheduler(&cfg.Config, 300, 0, baseTime)
+	scheduler.handle = &mockHandle{recorder: recorder}
+	scheduler.exemptions = policy.NewExemptions(exemptions, corelisters.NewNamespaceLister(namespaces))
+
+	pod := func(namespace string, labels map[string]string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:              "test-pod",
+			Namespace:         namespace,
+			Labels:            labels,
+			CreationTimestamp: metav1.NewTime(baseTime),
+		}}
+	}
+	batch := map[string]string{"app": "batch"}
+
+	tests := []struct {
+		name       string
+		offset     time.Duration
+		pod        *v1.Pod
+		wantStatus framework.Code
+		wantEvents []string
+	}{
+		{
+			name:       "namespace exempted",
+			pod:        pod("finance", nil),
+			wantStatus: framework.Success,
+			wantEvents: []string{"CarbonExemptionActive"},
+		},
+		{
+			name:       "exemption not started yet",
+			pod:        pod("research", batch),
+			wantStatus: framework.Unschedulable,
+		},
+		{
+			name:       "exemption started",
+			offset:     90 * time.Minute,
+			pod:        pod("research", batch),
+			wantStatus: framework.Success,
+			wantEvents: []string{"CarbonExemptionActive"},
+		},
+		{
+			name:       "exemption lapsed",
+			offset:     150 * time.Minute,
+			pod:        pod("finance", nil),
+			wantStatus: framework.Unschedulable,
+			wantEvents: []string{"CarbonExemptionLapsed"},
+		},
+	}
+
+	active := make(map[types.UID]bool)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler.clock.(*clock.MockClock).Set(baseTime.Add(tt.offset))
+			scheduler.checkExemptions(active)
+
+			var reasons []string
+			for len(recorder.Events) > 0 {
+				if reason := strings.Fields(<-recorder.Events)[1]; strings.HasPrefix(reason, "CarbonExemption") {
+					reasons = append(reasons, reason)
+				}
+			}
+			if fmt.Sprint(reasons) != fmt.Sprint(tt.wantEvents) {
+				t.Errorf("events = %v, want %v", reasons, tt.wantEvents)
+			}
+
+			if _, status := scheduler.PreFilter(context.Background(), nil, tt.pod); status.Code() != tt.wantStatus {
+				t.Errorf("PreFilter() = %v, want %v", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCarbonDelayStatus(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold: 200,
+				MaxSchedulingDelay:           24 * time.Hour,
+			},
+			Power: config.PowerConfig{
+				DefaultIdlePower: 100,
+				DefaultMaxPower:  400,
+			},
+		},
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:              "test-pod",
+		Namespace:         "default",
+		UID:               "test-uid",
+		CreationTimestamp: metav1.NewTime(baseTime),
+	}}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{carbonDelayResource: "CarbonDelayList"})
+	scheduler := newTestScheduler(&cfg.Config, 250, 0, baseTime)
+	scheduler.handle = &mockHandle{recorder: events.NewFakeRecorder(10)}
+	scheduler.delayStatus = newDelayReporter(&mockClientSet{}, client)
+
+	delay := func(intensity float64) {
+		t.Helper()
+		scheduler.cache.Set("test-region", &api.ElectricityData{CarbonIntensity: intensity, Timestamp: scheduler.clock.Now()})
+		scheduler.backoff.Delete(pod.UID)
+		state := framework.NewCycleState()
+		if _, status := scheduler.PreFilter(context.Background(), state, pod); status.Code() != framework.Unschedulable {
+			t.Fatalf("PreFilter() status = %v, want Unschedulable", status)
+		}
+		scheduler.PostFilter(context.Background(), state, pod, framework.NodeToStatusMap{})
+	}
+	get := func() v1alpha1.CarbonDelay {
+		t.Helper()
+		scheduler.delayStatus.sync(context.Background())
+		obj, err := client.Resource(carbonDelayResource).Namespace("default").Get(context.Background(), "test-pod", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get carbon delay: %v", err)
+		}
+		var delay v1alpha1.CarbonDelay
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &delay); err != nil {
+			t.Fatal(err)
+		}
+		return delay
+	}
+
+	delay(250)
+	got := get()
+	if len(got.OwnerReferences) != 1 || got.OwnerReferences[0].UID != pod.UID {
+		t.Errorf("owner references = %v, want the pod", got.OwnerReferences)
+	}
+	status := got.Status
+	if status.Phase != v1alpha1.DelayPhaseDelayed || status.Reason != reasonCarbonDelayed || status.Zone != "test-region" {
+		t.Errorf("status = %+v, want carbon delayed in test-region", status)
+	}
+	if status.CarbonIntensity == nil || *status.CarbonIntensity != 250 ||
+		status.CarbonIntensityThreshold == nil || *status.CarbonIntensityThreshold != 200 {
+		t.Errorf("intensity = %v and threshold = %v, want 250 and 200", status.CarbonIntensity, status.CarbonIntensityThreshold)
+	}
+	if !status.WaitingSince.Time.Equal(baseTime) || status.ProjectedRelease == nil || len(status.History) != 1 {
+		t.Errorf("status = %+v, want waiting since %v with a projected release and one reason", status, baseTime)
+	}
+
+	// Another reason is added to the history, keeping the wait start
+	scheduler.clock.(*clock.MockClock).Set(baseTime.Add(time.Hour))
+	delay(300)
+	status = get().Status
+	if len(status.History) != 2 || !status.WaitingSince.Time.Equal(baseTime) || *status.CarbonIntensity != 300 {
+		t.Errorf("status = %+v, want two reasons since %v at intensity 300", status, baseTime)
+	}
+	if !status.History[1].Time.Time.Equal(baseTime.Add(time.Hour)) || status.History[1].Message != status.Message {
+		t.Errorf("history = %+v, want the current reason last", status.History)
+	}
+
+	scheduler.PostBind(context.Background(), nil, pod, "test-node")
+	status = get().Status
+	if status.Phase != v1alpha1.DelayPhaseAdmitted || status.NodeName != "test-node" || status.AdmittedAt == nil || status.ProjectedRelease != nil {
+		t.Errorf("status = %+v, want admitted to test-node", status)
+	}
+	if _, ok := scheduler.delayStatus.delays[pod.UID]; ok {
+		t.Error("expected admitted pod to be forgotten once written")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	cfg := &testConfig{
+		Config: config.Config{
+			API: config.APIConfig{
+				Key:    "test-key",
+				Region: "test-region",
+			},
+			Scheduling: config.SchedulingConfig{
+				BaseCarbonIntensityThreshold:    200,
+				ReleaseCarbonIntensityThreshold: 180,
+				MaxSchedulingDelay:              24 * time.Hour,
+			},
+		},
+	}
+	recorder := events.NewFakeRecorder(10)
+	scheduler := newTestScheduler(&cfg.Config, 300, 0, baseTime)
+	scheduler.handle = &mockHandle{recorder: recorder}
+	handler := &evaluationHandler{owner: scheduler, token: "secret"}
+
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		auth         string
+		wantCode     int
+		wantDecision string
+		wantReason   string
+	}{
+		{
+			name:   "delayed pod",
+			method: http.MethodPost,
+			auth:   "Bearer secret",
+			body: `apiVersion: v1
+kind: Pod
+metadata:
+  name: train
+  namespace: ml
+spec:
+  containers:
+  - name: train
+    image: train:latest
+`,
+			wantCode:     http.StatusOK,
+			wantDecision: decisionDelay,
+			wantReason:   "exceeds threshold",
+		},
+		{
+			name:         "delayed pod on a node",
+			method:       http.MethodPost,
+			auth:         "Bearer secret",
+			body:         `{"metadata": {"name": "train"}, "spec": {"nodeName": "node-1"}}`,
+			wantCode:     http.StatusOK,
+			wantDecision: decisionDelay,
+			wantReason:   "exceeds threshold",
+		},
+		{
+			name:         "admitted pod",
+			method:       http.MethodPost,
+			auth:         "Bearer secret",
+			body:         `{"metadata": {"name": "train", "annotations": {"carbon-aware-scheduler.kubernetes.io/carbon-intensity-threshold": "400"}}}`,
+			wantCode:     http.StatusOK,
+			wantDecision: decisionAdmit,
+		},
+		{
+			name:         "skipped pod",
+			method:       http.MethodPost,
+			auth:         "Bearer secret",
+			body:         `{"metadata": {"name": "train", "annotations": {"carbon-aware-scheduler.kubernetes.io/skip": "true"}}}`,
+			wantCode:     http.StatusOK,
+			wantDecision: decisionAdmit,
+		},
+		{
+			name:     "invalid pod",
+			method:   http.MethodPost,
+			auth:     "Bearer secret",
+			body:     `{"metadata": [}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "wrong method",
+			method:   http.MethodGet,
+			auth:     "Bearer secret",
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:     "missing token",
+			method:   http.MethodPost,
+			body:     `{"metadata": {"name": "train"}}`,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "wrong token",
+			method:   http.MethodPost,
+			body:     `{"metadata": {"name": "train"}}`,
+			auth:     "Bearer guess",
+			wantCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tt.method, "/evaluate", strings.NewReader(tt.body))
+			if tt.auth != "" {
+				r.Header.Set("Authorization", tt.auth)
+			}
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			var got Evaluation
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Decision != tt.wantDecision || !strings.Contains(got.Reason, tt.wantReason) {
+				t.Errorf("decision = %s (%q), want %s (%q)", got.Decision, got.Reason, tt.wantDecision, tt.wantReason)
+			}
+			if got.Zone != "test-region" || got.CarbonIntensity == nil || *got.CarbonIntensity != 300 {
+				t.Errorf("evaluation = %+v, want intensity 300 in test-region", got)
+			}
+			if (got.Decision == decisionDelay) != (got.ProjectedStart != nil) {
+				t.Errorf("projected start = %v, want one for delayed pods only", got.ProjectedStart)
+			}
+		})
+	}
+
+	// Zones without cached data are not fetched
+	if got := scheduler.evaluate(context.Background(), &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:        "train",
+		Annotations: map[string]string{regionAnnotation: "nowhere"},
+	}}); got.Decision != decisionError || !strings.Contains(got.Reason, "no cached carbon intensity") {
+		t.Errorf("evaluation in an uncached zone = %s (%q), want an error without fetching", got.Decision, got.Reason)
+	}
+	if _, ok := scheduler.cache.Get("nowhere"); ok {
+		t.Error("evaluation cached data for the zone of a submitted pod")
+	}
+
+	// Nothing is recorded about evaluated pods
+	if len(recorder.Events) > 0 {
+		t.Errorf("expected no events, got %q", <-recorder.Events)
+	}
+	scheduler.backoff.Range(func(key, _ interface{}) bool {
+		t.Errorf("expected no backoff, got one for %v", key)
+		return true
+	})
+	if len(scheduler.hysteresis.blocked) > 0 {
+		t.Errorf("expected no hysteresis latches, got %v", scheduler.hysteresis.blocked)
+	}
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		name := family.GetName()
+		if (strings.HasSuffix(name, "_carbon_intensity") || strings.HasSuffix(name, "_node_cpu_usage_cores")) && len(family.GetMetric()) > 0 {
+			t.Errorf("expected no %s metrics, got %v", name, family.GetMetric())
+		}
+	}
+}
+
+func TestQuotaBorrowing(t *testing.T) {
+	cleanup := setupTest(t)
+	defer cleanup()
+
+	baseTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	quotas := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := quotas.Add(&schedv1alpha1.ElasticQuota{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "quota"},
+		Spec: schedv1alpha1.ElasticQuotaSpec{
+			Min: v1.ResourceList{v1.ResourceCPU: resource.MustParse("2")},
+			Max: v1.ResourceList{v1.ResourceCPU: resource.MustParse("8")},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	pod := func(name, namespace, cpu, nodeName string, phase v1.PodPhase) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				UID:               types.UID(name),
+				CreationTimestamp: metav1.NewTime(baseTime),
+			},
+			Spec: v1.PodSpec{
+				NodeName: nodeName,
+				Containers: []v1.Container{{Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)},
+				}}},
+			},
+			Status: v1.PodStatus{Phase: phase},
+		}
+	}
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, p := range []*v1.Pod{
+		pod("running", "team-a", "1500m", "node-1", v1.PodRunning),
+		pod("completed", "team-a", "4", "node-1", v1.PodSucceeded),
+		pod("pending", "team-a", "4", "", v1.PodPending),
+		pod("other", "team-b", "4", "node-1", v1.PodRunning),
+	} {
+		if err := pods.Add(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		intensity  float64
+		pod        *v1.Pod
+		wantStatus framework.Code
+	}{
+		{
+			name:       "within min",
+			intensity:  180,
+			pod:        pod("new", "team-a", "500m", "", v1.PodPending),
+			wantStatus: framework.Success,
+		},
+		{
+			name:       "borrowing above limit",
+			intensity:  180,
+			pod:        pod("new", "team-a", "1", "", v1.PodPending),
+			wantStatus: framework.Unschedulable,
+		},
+		{
+			name:       "borrowing below limit",
+			intensity:  120,
+			pod:        pod("new", "team-a", "1", "", v1.PodPending),
+			wantStatus: framework.Success,
+		},
+		{
+			name:       "namespace without quota",
+			intensity:  180,
+			pod:        pod("new", "team-b", "1", "", v1.PodPending),
+			wantStatus: framework.Success,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &testConfig{
+				Config: config.Config{
+					API: config.APIConfig{
+						Key:    "test-key",
+						Region: "test-region",
+					},
+					Scheduling: config.SchedulingConfig{
+						BaseCarbonIntensityThreshold: 200,
+						MaxSchedulingDelay:           24 * time.Hour,
+					},
+					Policy: config.PolicyConfig{QuotaBorrowingMaxIntensity: 150},
+				},
+			}
+			scheduler := newTestScheduler(&cfg.Config, tt.intensity, 0, baseTime)
+			scheduler.quotas = policy.NewQuotas(quotas)
+			scheduler.podLister = corelisters.NewPodLister(pods)
+
+			_, status := scheduler.PreFilter(context.Background(), framework.NewCycleState(), tt.pod)
+			if status.Code() != tt.wantStatus {
+				t.Errorf("status = %v (%s), want %v", status.Code(), status.Message(), tt.wantStatus)
+			}
+			if tt.wantStatus == framework.Unschedulable && !strings.Contains(status.Message(), "borrowing of cpu paused") {
+				t.Errorf("unexpected message: %s", status.Message())
+			}
+		})
+	}
+}
+
+func TestOwnerExempt(t *testing.T) {
+	owned := func(apiVersion, kind string, annotations map[string]string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-pod",
+			Namespace:   "default",
+			Annotations: annotations,
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: apiVersion, Kind: kind, Name: "owner", Controller: ptr.To(true)},
+			},
+		}}
+	}
+
+	tests := []struct {
+		name               string
+		pod                *v1.Pod
+		exemptStatefulSets bool
+		want               bool
+	}{
+		{
+			name: "daemonset pod",
+			pod:  owned("apps/v1", "DaemonSet", nil),
+			want: true,
+		},
+		{
+			name: "daemonset pod opted in",
+			pod:  owned("apps/v1", "DaemonSet", map[string]string{skipAnnotation: "false"}),
+			want: false,
+		},
+		{
+			name: "static pod",
+			pod:  owned("v1", "Node", nil),
+			want: true,
+		},
+		{
+			name: "mirror pod",
+			pod: &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{v1.MirrorPodAnnotationKey: "hash"},
+			}},
+			want: true,
+		},
+		{
+			name: "statefulset pod",
+			pod:  owned("apps/v1", "StatefulSet", nil),
+			want: false,
+		},
+		{
+			name:               "statefulset pod exempted",
+			pod:                owned("apps/v1", "StatefulSet", nil),
+			exemptStatefulSets: true,
+			want:               true,
+		},
+		{
+			name: "job pod",
+			pod:  owned("batch/v1", "Job", nil),
+			want: false,
+		},
+		{
+			name: "daemonset of another group",
+			pod:  owned("example.com/v1", "DaemonSet", nil),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := &CarbonAwareScheduler{config: &config.Config{
+				Scheduling: config.SchedulingConfig{ExemptStatefulSets: tt.exemptStatefulSets},
+			}}
+			if got := scheduler.ownerExempt(tt.pod); got != tt.want {
+				t.Errorf("ownerExempt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPodWorkload(t *testing.T) {
+	jobs := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	jobs.Add(&batchv1.Job{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "default",
+		Name:      "nightly-28000000",
+		OwnerReferences: []metav1.OwnerReference{
+			{Kind: "CronJob", Name: "nightly", Controller: ptr.To(true)},
+		},
+	}})
+
+	owned := func(kind, name string, labels map[string]string) *v1.Pod {
+		return &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name + "-x7k2p",
+			Labels:    labels,
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: kind, Name: name, Controller: ptr.To(true)},
+			},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		pod      *v1.Pod
+		wantKind string
+		wantName string
+	}{
+		{
+			name:     "deployment pod",
+			pod:      owned("ReplicaSet", "web-5d8f7c9b6", map[string]string{"pod-template-hash": "5d8f7c9b6"}),
+			wantKind: "Deployment",
+			wantName: "web",
+		},
+		{
+			name:     "standalone replicaset pod",
+			pod:      owned("ReplicaSet", "web", nil),
+			wantKind: "ReplicaSet",
+			wantName: "web",
+		},
+		{
+			name:     "cronjob pod",
+			pod:      owned("Job", "nightly-28000000", nil),
+			wantKind: "CronJob",
+			wantName: "nightly",
+		},
+		{
+			name:     "job pod",
+			pod:      owned("Job", "backfill", nil),
+			wantKind: "Job",
+			wantName: "backfill",
+		},
+		{
+			name:     "statefulset pod",
+			pod:      owned("StatefulSet", "db", nil),
+			wantKind: "StatefulSet",
+			wantName: "db",
+		},
+		{
+			name:     "bare pod",
+			pod:      &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "debug"}},
+			wantKind: "Pod",
+			wantName: "",
+		},
+	}
+
+	scheduler := &CarbonAwareScheduler{
+		config:    &config.Config{},
+		dataLayer: &dataLayer{jobLister: batchlisters.NewJobLister(jobs)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if kind, name := scheduler.podWorkload(tt.pod); kind != tt.wantKind || name != tt.wantName {
+				t.Errorf("podWorkload() = %s/%s, want %s/%s", kind, name, tt.wantKind, tt.wantName)
+			}
+		})
+	}
+
+	// Pods are only named in debug mode
+	pod := tests[0].pod
+	if got := scheduler.workloadLabels(pod); got[3] != "" {
+		t.Errorf("workloadLabels() = %v, want no pod name", got)
+	}
+	scheduler.config.Observability.PodMetricsEnabled = true
+	if got := scheduler.workloadLabels(pod); got[3] != pod.Name {
+		t.Errorf("workloadLabels() in debug mode = %v, want pod %s", got, pod.Name)
+	}
+	bare := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "debug"}}
+	if got := scheduler.workloadLabels(bare); !slices.Equal(got, []string{"default", "Pod", "", "debug"}) {
+		t.Errorf("workloadLabels() of a bare pod in debug mode = %v, want it named by the pod label only", got)
+	}
+}
+
+func TestEnforcedInclusion(t *testing.T) {
+	policies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := policies.Add(&v1alpha1.CarbonPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "regulated", Name: "mandatory"},
+		Spec:       v1alpha1.CarbonPolicySpec{EnforcedInclusion: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	clusterPolicies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	if err := clusterPolicies.Add(&v1alpha1.ClusterCarbonPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "audit"},
+		Spec: v1alpha1.CarbonPolicySpec{
+			PodSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "none"}},
+			EnforcementMode: v1alpha1.EnforcementModeAudit,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	namespaces := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, name := range []string{"regulated", "default"} {
+		if err := namespaces.Add(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Annotations: map[string]string{skipAnnotation: "true"},
+		}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	scheduler := &CarbonAwareScheduler{
+		config:          &config.Config{},
+		clock:           clock.NewMockClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)),
+		namespaceLister: corelisters.NewNamespaceLister(namespaces),
+		dataLayer: &dataLayer{
+			policies: policy.NewResolver(policies, clusterPolicies, corelisters.NewNamespaceLister(namespaces)),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		annotations map[string]string
+		owner       string
+		want        bool
+	}{
+		{
+			name:      "namespace skip ignored",
+			namespace: "regulated",
+			want:      false,
+		},
+		{
+			name:        "pod skip ignored",
+			namespace:   "regulated",
+			annotations: map[string]string{skipAnnotation: "true"},
+			want:        false,
+		},
+		{
+			name:        "off mode ignored",
+			namespace:   "regulated",
+			annotations: map[string]string{strictnessAnnotation: strictnessOff},
+			want:        false,
+		},
+		{
+			name:      "daemonset pod still exempt",
+			namespace: "regulated",
+			owner:     "DaemonSet",
+			want:      true,
+		},
+		{
+			name:      "namespace skip elsewhere",
+			namespace: "default",
+			want:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+				Name:        "test-pod",
+				Namespace:   tt.namespace,
+				Annotations: tt.annotations,
+			}}
+			if tt.owner != "" {
+				pod.OwnerReferences = []metav1.OwnerReference{
+					{APIVersion: "apps/v1", Kind: tt.owner, Name: "owner", Controller: ptr.To(true)},
+				}
+			}
+			if got := scheduler.isOptedOut(pod); got != tt.want {
+				t.Errorf("isOptedOut() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Pod overrides of gating are ignored too
+	scheduler.config.Scheduling.MaxSchedulingDelay = 24 * time.Hour
+	scheduler.config.Scheduling.EnforcementMode = config.EnforcementModeEnforce
+	plain := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "regulated"}}
+	wantThreshold, err := scheduler.carbonThreshold(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := scheduler.clock.Now()
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "test-pod",
+		Namespace: "regulated",
+		Annotations: map[string]string{
+			releaseAnnotation:           "true",
+			thresholdAnnotation:         "10000",
+			maxDelayAnnotation:          "1s",
+			deadlineAnnotation:          now.Add(time.Minute).Format(time.RFC3339),
+			estimatedDurationAnnotation: "1s",
+			policy.PolicyAnnotation:     "audit",
+		},
+	}}
+	if scheduler.isReleased(pod) {
+		t.Error("isReleased() = true, want the release annotation ignored")
+	}
+	if got := scheduler.enforcementMode(pod); got != config.EnforcementModeEnforce {
+		t.Errorf("enforcementMode() = %s, want the audit mode of the named policy ignored", got)
+	}
+	elsewhere := pod.DeepCopy()
+	elsewhere.Namespace = "default"
+	if got := scheduler.enforcementMode(elsewhere); got != config.EnforcementModeAudit {
+		t.Errorf("enforcementMode() = %s, want the named policy applied outside enforced inclusion", got)
+	}
+	if got, err := scheduler.carbonThreshold(pod); err != nil || got != wantThreshold {
+		t.Errorf("carbonThreshold() = %v, %v, want %v", got, err, wantThreshold)
+	}
+	if got := scheduler.maxSchedulingDelay(pod); got != 24*time.Hour {
+		t.Errorf("maxSchedulingDelay() = %v, want the configured delay", got)
+	}
+	if _, ok := scheduler.latestStart(pod); ok {
+		t.Error("latestStart() found a deadline, want the deadline annotation ignored")
+	}
+	if _, ok := scheduler.estimatedDuration(pod); ok {
+		t.Error("estimatedDuration() found a duration, want the annotation ignored")
+	}
+}
+
+func TestRestrictedOptOut(t *testing.T) {
+	policies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	if err := policies.Add(&v1alpha1.CarbonPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "team-a", Name: "restricted"},
+		Spec:       v1alpha1.CarbonPolicySpec{SkipRestriction: &v1alpha1.SkipRestriction{Groups: []string{"ops"}}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	scheduler := &CarbonAwareScheduler{
+		config: &config.Config{},
+		clock:  clock.NewMockClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)),
+		dataLayer: &dataLayer{
+			policies: policy.NewResolver(policies, cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}), nil),
+		},
+	}
+
+	tests := []struct {
+		name        string
+		namespace   string
+		annotations map[string]string
+		want        bool
+	}{
+		{
+			name:        "skip without approval",
+			namespace:   "team-a",
+			annotations: map[string]string{skipAnnotation: "true"},
+			want:        false,
+		},
+		{
+			name:        "price skip without approval",
+			namespace:   "team-a",
+			annotations: map[string]string{"price-aware-scheduler.kubernetes.io/skip": "true"},
+			want:        false,
+		},
+		{
+			name:        "off mode without approval",
+			namespace:   "team-a",
+			annotations: map[string]string{strictnessAnnotation: strictnessOff},
+			want:        false,
+		},
+		{
+			name:        "approved skip",
+			namespace:   "team-a",
+			annotations: map[string]string{skipAnnotation: "true", optOutAllowedAnnotation: "true"},
+			want:        true,
+		},
+		{
+			name:        "unrestricted namespace",
+			namespace:   "team-b",
+			annotations: map[string]string{skipAnnotation: "true"},
+			want:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: tt.namespace, Annotations: tt.annotations}}
+			if got := scheduler.isOptedOut(pod); got != tt.want {
+				t.Errorf("isOptedOut() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPolicyState(t *testing.T) {
+	policies := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	threshold := &v1alpha1.CarbonPolicy{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "threshold"},
+		Spec:       v1alpha1.CarbonPolicySpec{CarbonIntensityThreshold: ptr.To(300.0)},
+	}
+	if err := policies.Add(threshold); err != nil {
+		t.Fatal(err)
+	}
+	scheduler := &CarbonAwareScheduler{
+		config: &config.Config{},
+		dataLayer: &dataLayer{
+			policies: policy.NewResolver(policies, cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}), nil),
+		},
+	}
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "test-pod", Namespace: "default", UID: "uid"}}
+
+	state := framework.NewCycleState()
+	scheduler.writePolicyState(state, pod)
+	if _, err := state.Read(policyStateKey); err != nil {
+		t.Fatalf("policy not written to the cycle state: %v", err)
+	}
+
+	// The policy resolved for the cycle is read until the pod's next cycle
+	if err := policies.Delete(threshold); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := scheduler.policyThreshold(pod); !ok || got != 300 {
+		t.Errorf("policyThreshold() = %v, %v, want the threshold resolved for the cycle", got, ok)
+	}
+	scheduler.writePolicyState(framework.NewCycleState(), pod)
+	if _, ok := scheduler.policyThreshold(pod); ok {
+		t.Error("policyThreshold() found a threshold, want the policy resolved again in the next cycle")
+	}
+}
@@ -0,0 +1,120 @@
+package computegardener
+
+import (
+	"context"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	"k8s.io/kubernetes/pkg/scheduler/framework/plugins/helper"
+)
+
+// zoneScoreStateKey is the CycleState key the zone scores of nodes are stored under
+const zoneScoreStateKey framework.StateKey = Name + "/zone-score"
+
+// zoneScoreState holds the zone score of each candidate node of a scheduling cycle
+type zoneScoreState struct {
+	scores map[string]int64
+}
+
+// Clone implements framework.StateData. The state is never modified once written.
+func (s *zoneScoreState) Clone() framework.StateData {
+	return s
+}
+
+// PreScore implements the PreScore interface. It fetches the intensity of each zone
+// of the candidate nodes once per scheduling cycle, so that scoring nodes is a map
+// lookup without locking or API calls in the per-node path.
+func (cs *CarbonAwareScheduler) PreScore(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodes []*framework.NodeInfo) *framework.Status {
+	zoneScores := make(map[string]int64)
+	s := &zoneScoreState{scores: make(map[string]int64, len(nodes))}
+	for _, nodeInfo := range nodes {
+		if nodeInfo.Node() == nil {
+			continue
+		}
+		name := nodeInfo.Node().Name
+		zone := cs.nodeZone(name)
+		score, ok := zoneScores[zone]
+		if !ok {
+			score = cs.zoneScore(ctx, zone)
+			zoneScores[zone] = score
+		}
+		s.scores[name] = score
+	}
+	state.Write(zoneScoreStateKey, s)
+	return nil
+}
+
+// Score implements the Score interface. Nodes are scored by the carbon intensity
+// of their grid zone, so that in clusters spanning regions pods drift toward the
+// greener zones. Intensity is normalized against the base threshold like in the
+// weighted decision mode: 0 scores highest, twice the threshold or more scores 0.
+// Pods can opt into also preferring energy-efficient nodes, carbon-flexible pods
+// prefer spot nodes, and packing scoring prefers nodes where the pod adds the least
+// power. Applicable scores are combined by their configured weights.
+func (cs *CarbonAwareScheduler) Score(ctx context.Context, state *framework.CycleState, pod *v1.Pod, nodeName string) (int64, *framework.Status) {
+	weights := cs.config.Scoring.Weights
+	total := weights.Zone * float64(cs.nodeZoneScore(ctx, state, nodeName))
+	sum := weights.Zone
+
+	if prefersEfficientNodes(pod) {
+		total += weights.Efficiency * float64(cs.efficiencyScore(nodeName))
+		sum += weights.Efficiency
+	}
+	if cs.config.Power.PackingEnabled {
+		total += weights.Packing * float64(cs.packingScore(pod, nodeName))
+		sum += weights.Packing
+	}
+	if isFlexible(pod) {
+		total += weights.Spot * float64(cs.spotScore(nodeName))
+		sum += weights.Spot
+	}
+	return int64(total / sum), nil
+}
+
+// nodeZoneScore returns the zone score of a node computed in PreScore, or computes
+// it if PreScore didn't run
+func (cs *CarbonAwareScheduler) nodeZoneScore(ctx context.Context, state *framework.CycleState, nodeName string) int64 {
+	if state != nil {
+		if data, err := state.Read(zoneScoreStateKey); err == nil {
+			if score, ok := data.(*zoneScoreState).scores[nodeName]; ok {
+				return score
+			}
+		}
+	}
+	return cs.zoneScore(ctx, cs.nodeZone(nodeName))
+}
+
+// zoneScore scores a zone by its carbon intensity
+func (cs *CarbonAwareScheduler) zoneScore(ctx context.Context, zone string) int64 {
+	data, err := cs.getZoneCarbonIntensityData(ctx, zone)
+	if err != nil {
+		// Missing data for one zone shouldn't fail scheduling, score it neutrally
+		klog.V(2).InfoS("Failed to get carbon intensity for scoring", "zone", zone, "err", err)
+		return framework.MaxNodeScore / 2
+	}
+
+	intensity := cs.effectiveIntensity(zone, data)
+	return int64((1 - normalize(intensity, cs.baseThreshold(zone))) * float64(framework.MaxNodeScore))
+}
+
+// ScoreExtensions returns the plugin, which normalizes its scores
+func (cs *CarbonAwareScheduler) ScoreExtensions() framework.ScoreExtensions {
+	return cs
+}
+
+// NormalizeScore scales scores so the best node scores the maximum. Without it,
+// a cluster whose zones are all moderately dirty would contribute little to the
+// choice between nodes, whatever weight the plugin is given in the profile.
+func (cs *CarbonAwareScheduler) NormalizeScore(ctx context.Context, state *framework.CycleState, pod *v1.Pod, scores framework.NodeScoreList) *framework.Status {
+	return helper.DefaultNormalizeScore(framework.MaxNodeScore, false, scores)
+}
+
+// nodeZone returns the grid zone of a node, or the configured region if the node
+// carries no region label known to the zone mapper
+func (cs *CarbonAwareScheduler) nodeZone(nodeName string) string {
+	if zone, ok := cs.nodeZones.Load(nodeName); ok {
+		return zone.(string)
+	}
+	return cs.config.API.Region
+}
@@ -0,0 +1,31 @@
+package computegardener
+
+import (
+	"slices"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// isSidecar reports whether a container of a pod is a sidecar whose usage is left
+// out of the pod's energy: a configured sidecar, such as a service mesh proxy, or a
+// native sidecar, an init container that keeps running alongside the pod
+func (cs *CarbonAwareScheduler) isSidecar(pod *v1.Pod, containerName string) bool {
+	if !cs.config.Power.ExcludeSidecars {
+		return false
+	}
+	return slices.Contains(cs.config.Power.SidecarContainers, containerName) ||
+		slices.Contains(nativeSidecars(pod), containerName)
+}
+
+// nativeSidecars returns the names of the init containers of a pod that keep
+// running alongside its containers. Other init containers run to completion before
+// the pod starts, and their usage counts toward the pod's energy.
+func nativeSidecars(pod *v1.Pod) []string {
+	var names []string
+	for _, c := range pod.Spec.InitContainers {
+		if c.RestartPolicy != nil && *c.RestartPolicy == v1.ContainerRestartPolicyAlways {
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,68 @@
+package computegardener
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/api/v1/resource"
+)
+
+// sloFactor returns the factor the carbon intensity threshold of a pod is adjusted by
+// to keep the CarbonSLOs selecting it on track, or 1
+func (cs *CarbonAwareScheduler) sloFactor(pod *v1.Pod) float64 {
+	if cs.slos == nil || cs.isOptedOut(pod) || cs.isReleased(pod) {
+		return 1
+	}
+	factor, slo := cs.slos.ThresholdFactor(pod, cs.clock.Now())
+	if slo != "" {
+		klog.V(4).InfoS("Adjusting threshold for carbon SLO", "pod", klog.KObj(pod), "slo", slo, "thresholdFactor", factor)
+	}
+	return factor
+}
+
+// accrueSLO attributes the CPU-hours of a completed pod to the CarbonSLOs selecting
+// it. A pod counts as executed at the intensity it was bound at, or else the current
+// intensity of its node's zone.
+func (cs *CarbonAwareScheduler) accrueSLO(pod *v1.Pod) {
+	if cs.slos == nil || pod.Status.StartTime == nil {
+		return
+	}
+	requests := resource.PodRequests(pod, resource.PodResourcesOptions{})
+	cpu := requests.Cpu().AsApproximateFloat64()
+	hours := cs.clock.Since(pod.Status.StartTime.Time).Hours()
+	if cpu <= 0 || hours <= 0 {
+		return
+	}
+
+	intensity, err := strconv.ParseFloat(pod.Annotations[boundIntensityAnnotation], 64)
+	if err != nil {
+		zone := cs.nodeZone(pod.Spec.NodeName)
+		data, ok := cs.cache.Get(zone)
+		if !ok {
+			klog.V(2).InfoS("No carbon intensity to attribute pod to carbon SLOs", "pod", klog.KObj(pod))
+			return
+		}
+		intensity = cs.effectiveIntensity(zone, data)
+	}
+	cs.slos.Accrue(pod, cpu*hours, intensity, cs.clock.Now())
+}
+
+// sloWorker reports the attainment of carbon SLOs in their status
+func (cs *CarbonAwareScheduler) sloWorker(ctx context.Context) {
+	ticker := time.NewTicker(cs.config.Policy.SLOSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cs.stopCh:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			cs.slos.SyncStatus(ctx, cs.clock.Now())
+		}
+	}
+}
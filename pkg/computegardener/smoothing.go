@@ -0,0 +1,61 @@
+package computegardener
+
+import (
+	"sync"
+	"time"
+)
+
+// smoother keeps the last N carbon intensity samples per zone and computes an
+// exponentially weighted moving average over them, so a single noisy reading does
+// not flip scheduling decisions
+type smoother struct {
+	window int
+	alpha  float64
+
+	mutex   sync.Mutex
+	samples map[string][]float64
+	latest  map[string]time.Time
+}
+
+func newSmoother(window int, alpha float64) *smoother {
+	return &smoother{
+		window:  window,
+		alpha:   alpha,
+		samples: make(map[string][]float64),
+		latest:  make(map[string]time.Time),
+	}
+}
+
+// add records a sample for a zone, ignoring samples not newer than the last one
+func (s *smoother) add(zone string, timestamp time.Time, value float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if last, ok := s.latest[zone]; ok && !timestamp.After(last) {
+		return
+	}
+	s.latest[zone] = timestamp
+
+	samples := append(s.samples[zone], value)
+	if len(samples) > s.window {
+		samples = samples[len(samples)-s.window:]
+	}
+	s.samples[zone] = samples
+}
+
+// value returns the smoothed intensity for a zone, or fallback if no samples exist
+func (s *smoother) value(zone string, fallback float64) float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	samples := s.samples[zone]
+	if len(samples) == 0 {
+		return fallback
+	}
+
+	ewma := samples[0]
+	for _, v := range samples[1:] {
+		ewma = s.alpha*v + (1-s.alpha)*ewma
+	}
+	return ewma
+}
@@ -0,0 +1,47 @@
+package computegardener
+
+import (
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// flexibleAnnotation marks a pod as carbon-flexible: interruptible work that can run
+// whenever and wherever green capacity is available
+const flexibleAnnotation = "carbon-aware-scheduler.kubernetes.io/flexible"
+
+// spotLabels are the well-known node labels marking spot or preemptible capacity,
+// with the value they carry on such nodes
+var spotLabels = map[string]string{
+	"node.kubernetes.io/lifecycle":          "spot",
+	"karpenter.sh/capacity-type":            "spot",
+	"eks.amazonaws.com/capacityType":        "spot",
+	"cloud.google.com/gke-spot":             "true",
+	"cloud.google.com/gke-preemptible":      "true",
+	"kubernetes.azure.com/scalesetpriority": "spot",
+}
+
+// isFlexible reports whether a pod is marked as carbon-flexible
+func isFlexible(pod *v1.Pod) bool {
+	return pod.Annotations[flexibleAnnotation] == "true"
+}
+
+// isSpotNode reports whether a node is spot or preemptible capacity
+func isSpotNode(node *v1.Node) bool {
+	for label, value := range spotLabels {
+		if strings.EqualFold(node.Labels[label], value) {
+			return true
+		}
+	}
+	return false
+}
+
+// spotScore scores spot and preemptible nodes the maximum and other nodes 0
+func (cs *CarbonAwareScheduler) spotScore(nodeName string) int64 {
+	nodeInfo, err := cs.handle.SnapshotSharedLister().NodeInfos().Get(nodeName)
+	if err != nil || nodeInfo.Node() == nil || !isSpotNode(nodeInfo.Node()) {
+		return 0
+	}
+	return framework.MaxNodeScore
+}
@@ -0,0 +1,70 @@
+package computegardener
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// strictnessAnnotation selects how hard carbon-aware gating tries for a pod
+const strictnessAnnotation = "carbon-aware-scheduler.kubernetes.io/strictness"
+
+// Strictness classes
+const (
+	// strictnessStrict waits up to the scheduling deadline for the cleanest window
+	strictnessStrict = "strict"
+	// strictnessBestEffort only delays when the forecast shows the threshold being
+	// met within the best-effort horizon
+	strictnessBestEffort = "best-effort"
+	// strictnessOff disables carbon and price gating
+	strictnessOff = "off"
+)
+
+func podStrictness(pod *v1.Pod) string {
+	return pod.Annotations[strictnessAnnotation]
+}
+
+// hasBestEffortWindow reports whether the forecast shows intensity at or below the
+// threshold within the best-effort horizon
+func (cs *CarbonAwareScheduler) hasBestEffortWindow(ctx context.Context, pod *v1.Pod, zone string, threshold float64) bool {
+	now := cs.clock.Now()
+	until := now.Add(cs.config.Scheduling.BestEffortHorizon)
+	if deadline := cs.schedulingDeadline(pod); deadline.Before(until) {
+		until = deadline
+	}
+
+	points, err := cs.getForecast(ctx, zone, until.Sub(now))
+	if err != nil || len(points) < 2 {
+		return false
+	}
+
+	// Allow points equal to the threshold, which don't block scheduling
+	_, ok := nextLowerWindow(points, now, until, math.Nextafter(threshold, math.Inf(1)))
+	return ok
+}
+
+// checkStrict holds a strict pod that is under its threshold while the forecast
+// shows a cleaner window before its scheduling deadline
+func (cs *CarbonAwareScheduler) checkStrict(ctx context.Context, pod *v1.Pod, zone string, intensity float64) *framework.Status {
+	now := cs.clock.Now()
+	deadline := cs.schedulingDeadline(pod)
+	if !deadline.After(now) {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	points, err := cs.getForecast(ctx, zone, deadline.Sub(now))
+	if err != nil || len(points) < 2 {
+		return framework.NewStatus(framework.Success, "")
+	}
+
+	if p, ok := nextLowerWindow(points, now, deadline, intensity); ok {
+		cs.countAttempt("strict_waiting")
+		return framework.NewStatus(framework.Unschedulable,
+			fmt.Sprintf("Cleaner window forecast at %s (%.2f)", p.Timestamp.Format(time.RFC3339), p.CarbonIntensity))
+	}
+	return framework.NewStatus(framework.Success, "")
+}
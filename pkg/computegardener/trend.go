@@ -0,0 +1,70 @@
+package computegardener
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/history"
+)
+
+// isRising reports whether intensity in a zone is expected to keep rising over the
+// trend horizon. The forecast is used when available, otherwise the gradient of
+// recorded samples over the past horizon.
+func (cs *CarbonAwareScheduler) isRising(ctx context.Context, zone string, current float64) bool {
+	horizon := cs.config.Scheduling.TrendHorizon
+	now := cs.clock.Now()
+
+	points, err := cs.getForecast(ctx, zone, horizon)
+	if err == nil && len(points) >= 2 {
+		return forecastRising(points, now, current)
+	}
+
+	if cs.history == nil {
+		return false
+	}
+	return historyGradient(cs.history.Samples(zone, now.Add(-horizon))) > 0
+}
+
+// forecastRising reports whether every forecast point after now is at least current,
+// ending above it
+func forecastRising(points []api.Point, now time.Time, current float64) bool {
+	last := current
+	future := false
+	for _, p := range points {
+		if !p.Timestamp.After(now) {
+			continue
+		}
+		if p.CarbonIntensity < current {
+			return false
+		}
+		last = p.CarbonIntensity
+		future = true
+	}
+	return future && last > current
+}
+
+// historyGradient returns the least-squares slope of samples in gCO2/kWh per hour,
+// or 0 if there are too few samples
+func historyGradient(samples []history.Sample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+
+	origin := samples[0].Timestamp
+	var sumX, sumY, sumXY, sumXX float64
+	for _, s := range samples {
+		x := s.Timestamp.Sub(origin).Hours()
+		sumX += x
+		sumY += s.CarbonIntensity
+		sumXY += x * s.CarbonIntensity
+		sumXX += x * x
+	}
+
+	n := float64(len(samples))
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denom
+}
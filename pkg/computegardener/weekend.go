@@ -0,0 +1,20 @@
+package computegardener
+
+import (
+	"time"
+)
+
+// isWeekend reports whether t falls on a Saturday or Sunday
+func isWeekend(t time.Time) bool {
+	day := t.Weekday()
+	return day == time.Saturday || day == time.Sunday
+}
+
+// scheduleThreshold returns the configured base threshold for the day of the week,
+// the weekend threshold on weekends if one is set
+func (cs *CarbonAwareScheduler) scheduleThreshold() float64 {
+	if cs.config.Scheduling.WeekendCarbonIntensityThreshold > 0 && isWeekend(cs.clock.Now()) {
+		return cs.config.Scheduling.WeekendCarbonIntensityThreshold
+	}
+	return cs.config.Scheduling.BaseCarbonIntensityThreshold
+}
@@ -0,0 +1,94 @@
+package computegardener
+
+import (
+	"context"
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+)
+
+// checkWeightedScore admits a pod when the weighted combination of normalized carbon
+// intensity, electricity price and cluster load is at or below the cut-off. Carbon
+// and price are normalized so that their thresholds score 0.5, load is the fraction
+// of allocatable CPU requested across the cluster.
+func (cs *CarbonAwareScheduler) checkWeightedScore(ctx context.Context, pod *v1.Pod) *framework.Status {
+	weights := cs.config.Scheduling.Weights
+	var score, totalWeight float64
+
+	if weights.Carbon > 0 {
+		zone := cs.podZone(pod)
+		data, err := cs.getZoneCarbonIntensityData(ctx, zone)
+		if err != nil {
+			cs.countAttempt("error")
+			return framework.NewStatus(framework.Error, fmt.Sprintf("failed to get carbon intensity data: %v", err))
+		}
+		cs.metrics().carbonIntensity.WithLabelValues(zone).Set(data.CarbonIntensity)
+
+		threshold, err := cs.carbonThreshold(pod)
+		if err != nil {
+			return framework.NewStatus(framework.Error, err.Error())
+		}
+		score += weights.Carbon * normalize(cs.effectiveIntensity(zone, data), threshold)
+		totalWeight += weights.Carbon
+	}
+
+	if weights.Price > 0 && cs.config.Pricing.Enabled && cs.pricingImpl != nil {
+		threshold, err := cs.priceThreshold(pod)
+		if err != nil {
+			return framework.NewStatus(framework.Error, err.Error())
+		}
+		score += weights.Price * normalize(cs.pricingImpl.GetCurrentRate(cs.clock.Now()), threshold)
+		totalWeight += weights.Price
+	}
+
+	if weights.Load > 0 {
+		load, err := cs.clusterLoad()
+		if err != nil {
+			klog.V(2).InfoS("Failed to compute cluster load, ignoring load term", "err", err)
+		} else {
+			score += weights.Load * load
+			totalWeight += weights.Load
+		}
+	}
+
+	if totalWeight == 0 {
+		return framework.NewStatus(framework.Success, "")
+	}
+	score /= totalWeight
+	cs.metrics().weightedScore.Set(score)
+
+	if score > cs.config.Scheduling.WeightedCutoff {
+		cs.countAttempt("weighted_score_exceeded")
+		return framework.NewStatus(framework.Unschedulable,
+			fmt.Sprintf("Weighted score (%.2f) exceeds cut-off (%.2f)", score, cs.config.Scheduling.WeightedCutoff))
+	}
+	return framework.NewStatus(framework.Success, "")
+}
+
+// normalize maps value to [0, 1] with threshold at 0.5
+func normalize(value, threshold float64) float64 {
+	if threshold <= 0 {
+		return 1
+	}
+	return min(max(value/(2*threshold), 0), 1)
+}
+
+// clusterLoad returns the fraction of allocatable CPU requested across the cluster
+func (cs *CarbonAwareScheduler) clusterLoad() (float64, error) {
+	nodes, err := cs.handle.SnapshotSharedLister().NodeInfos().List()
+	if err != nil {
+		return 0, err
+	}
+
+	var requested, allocatable int64
+	for _, n := range nodes {
+		requested += n.Requested.MilliCPU
+		allocatable += n.Allocatable.MilliCPU
+	}
+	if allocatable == 0 {
+		return 0, nil
+	}
+	return min(float64(requested)/float64(allocatable), 1), nil
+}
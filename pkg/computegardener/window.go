@@ -0,0 +1,277 @@
+package computegardener
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/api"
+)
+
+// nextLowerWindow returns the first forecast point after now and no later than the
+// deadline whose intensity is below current
+func nextLowerWindow(points []api.Point, now, deadline time.Time, current float64) (api.Point, bool) {
+	for _, p := range points {
+		if !p.Timestamp.After(now) {
+			continue
+		}
+		if p.Timestamp.After(deadline) {
+			break
+		}
+		if p.CarbonIntensity < current {
+			return p, true
+		}
+	}
+	return api.Point{}, false
+}
+
+// meanIntensity returns the time-weighted mean intensity of a run of the given
+// duration starting at start. Each forecast point holds until the next one, and
+// current holds until the first point after now.
+func meanIntensity(points []api.Point, now time.Time, current float64, start time.Time, duration time.Duration) float64 {
+	end := start.Add(duration)
+	value := current
+	var sum float64
+	t := start
+	for _, p := range points {
+		if !p.Timestamp.After(now) {
+			continue
+		}
+		if p.Timestamp.After(t) {
+			if !p.Timestamp.Before(end) {
+				break
+			}
+			sum += value * p.Timestamp.Sub(t).Seconds()
+			t = p.Timestamp
+		}
+		value = p.CarbonIntensity
+	}
+	sum += value * end.Sub(t).Seconds()
+	return sum / duration.Seconds()
+}
+
+// hasLowerRun reports whether a run of the given duration starting at a forecast
+// point no later than the deadline has lower mean intensity than starting now
+func hasLowerRun(points []api.Point, now, deadline time.Time, current float64, duration time.Duration) bool {
+	if duration <= 0 {
+		_, ok := nextLowerWindow(points, now, deadline, current)
+		return ok
+	}
+
+	base := meanIntensity(points, now, current, now, duration)
+	for _, p := range points {
+		if !p.Timestamp.After(now) {
+			continue
+		}
+		if p.Timestamp.After(deadline) {
+			break
+		}
+		if meanIntensity(points, now, current, p.Timestamp, duration) < base {
+			return true
+		}
+	}
+	return false
+}
+
+// submittedAt returns when a pod was submitted: the later of its creation time and
+// the time stamped by the admission webhook. The stamp can be set by the submitter if
+// the webhook isn't running, so it can only ever postpone a pod's deadline. It is zero
+// for pods without either.
+func submittedAt(pod *v1.Pod) time.Time {
+	submitted := pod.CreationTimestamp.Time
+	if val, ok := pod.Annotations[submittedAtAnnotation]; ok {
+		t, err := time.Parse(time.RFC3339, val)
+		if err != nil {
+			klog.V(2).InfoS("Ignoring invalid submitted-at annotation", "pod", klog.KObj(pod), "value", val)
+		} else if t.After(submitted) {
+			submitted = t
+		}
+	}
+	return submitted
+}
+
+// schedulingDeadline returns the latest time a pod can be held until, which is the
+// earlier of its maximum scheduling delay and its latest viable start
+func (cs *CarbonAwareScheduler) schedulingDeadline(pod *v1.Pod) time.Time {
+	deadline := submittedAt(pod).Add(cs.maxSchedulingDelay(pod))
+	if latest, ok := cs.latestStart(pod); ok && latest.Before(deadline) {
+		return latest
+	}
+	return deadline
+}
+
+// latestStart returns the latest time a pod can start and still finish by the
+// time in its deadline annotation
+func (cs *CarbonAwareScheduler) latestStart(pod *v1.Pod) (time.Time, bool) {
+	val, ok := cs.podOverride(pod, deadlineAnnotation)
+	if !ok {
+		return time.Time{}, false
+	}
+	deadline, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		klog.V(2).InfoS("Ignoring invalid deadline annotation", "pod", klog.KObj(pod), "value", val)
+		return time.Time{}, false
+	}
+	duration, _ := cs.estimatedDuration(pod)
+	return deadline.Add(-duration), true
+}
+
+// estimatedDuration returns the estimated-duration annotation of a pod, falling back
+// to the learned duration of its Job template
+func (cs *CarbonAwareScheduler) estimatedDuration(pod *v1.Pod) (time.Duration, bool) {
+	val, ok := cs.podOverride(pod, estimatedDurationAnnotation)
+	if !ok {
+		return cs.learnedDuration(pod)
+	}
+	duration, err := time.ParseDuration(val)
+	if err != nil || duration < 0 {
+		klog.V(2).InfoS("Ignoring invalid estimated duration annotation", "pod", klog.KObj(pod), "value", val)
+		return 0, false
+	}
+	return duration, true
+}
+
+// maxSchedulingDelay returns the max-delay annotation of a pod, or the configured
+// maximum scheduling delay if the annotation is not set or invalid
+func (cs *CarbonAwareScheduler) maxSchedulingDelay(pod *v1.Pod) time.Duration {
+	defaultDelay, ok := cs.classMaxDelay(pod)
+	if !ok {
+		defaultDelay, ok = cs.namespaceMaxDelay(pod)
+	}
+	if !ok {
+		defaultDelay, ok = cs.policyMaxDelay(pod)
+	}
+	if !ok {
+		defaultDelay = cs.config.Scheduling.MaxSchedulingDelay
+	}
+	val, ok := cs.podOverride(pod, maxDelayAnnotation)
+	if !ok {
+		return defaultDelay
+	}
+	delay, err := time.ParseDuration(val)
+	if err != nil || delay < 0 {
+		klog.V(2).InfoS("Ignoring invalid max delay annotation", "pod", klog.KObj(pod), "value", val)
+		return defaultDelay
+	}
+	return delay
+}
+
+// hasBetterWindow reports whether the forecast shows intensity dropping below the
+// current value before the pod's scheduling deadline. For pods with an estimated
+// duration, runs are compared by their mean intensity. If the forecast carries no
+// information beyond the current value, it assumes a better window may exist so
+// that gating behaves as it does without forecasts.
+func (cs *CarbonAwareScheduler) hasBetterWindow(ctx context.Context, pod *v1.Pod, zone string, current float64) bool {
+	now := cs.clock.Now()
+	deadline := cs.schedulingDeadline(pod)
+	if !deadline.After(now) {
+		return false
+	}
+
+	duration, _ := cs.estimatedDuration(pod)
+	points, err := cs.getForecast(ctx, zone, deadline.Sub(now)+duration)
+	if err != nil || len(points) < 2 {
+		return true
+	}
+
+	return hasLowerRun(points, now, deadline, current, duration)
+}
+
+// releaseAnnotation releases a held pod from gating when set to "true"
+const releaseAnnotation = "carbon-aware-scheduler.kubernetes.io/release"
+
+// maxDelayAnnotation overrides the maximum scheduling delay for a pod
+const maxDelayAnnotation = "carbon-aware-scheduler.kubernetes.io/max-delay"
+
+// deadlineAnnotation is the RFC3339 time a pod must have finished running by
+const deadlineAnnotation = "carbon-aware-scheduler.kubernetes.io/deadline"
+
+// estimatedDurationAnnotation is the expected run time of a pod
+const estimatedDurationAnnotation = "carbon-aware-scheduler.kubernetes.io/estimated-duration"
+
+// projectedStartAnnotation records when a delayed pod is expected to be released
+const projectedStartAnnotation = "carbon-aware-scheduler.kubernetes.io/projected-start"
+
+// projectionStep is the resolution used when scanning pricing schedules
+const projectionStep = 15 * time.Minute
+
+// projectedPriceStart returns the next off-peak transition for a pod delayed on
+// electricity price, or its scheduling deadline if none comes first
+func (cs *CarbonAwareScheduler) projectedPriceStart(pod *v1.Pod) time.Time {
+	now := cs.clock.Now()
+	deadline := cs.schedulingDeadline(pod)
+
+	threshold, err := cs.priceThreshold(pod)
+	if err != nil || cs.pricingImpl == nil {
+		return deadline
+	}
+
+	for t := now.Truncate(projectionStep).Add(projectionStep); t.Before(deadline); t = t.Add(projectionStep) {
+		if cs.pricingImpl.GetCurrentRate(t) <= threshold {
+			return t
+		}
+	}
+	return deadline
+}
+
+// projectedCarbonStart returns the first forecast point at which intensity drops
+// to the pod's threshold, or its scheduling deadline if none comes first
+func (cs *CarbonAwareScheduler) projectedCarbonStart(ctx context.Context, pod *v1.Pod) time.Time {
+	now := cs.clock.Now()
+	deadline := cs.schedulingDeadline(pod)
+	if !deadline.After(now) {
+		return deadline
+	}
+
+	threshold, err := cs.carbonThreshold(pod)
+	if err != nil {
+		return deadline
+	}
+	if cs.config.Scheduling.ReleaseCarbonIntensityThreshold > 0 {
+		threshold = cs.releaseThreshold(threshold)
+	}
+
+	points, err := cs.getForecast(ctx, cs.podZone(pod), deadline.Sub(now))
+	if err != nil {
+		return deadline
+	}
+
+	// Allow points equal to the threshold, which don't block scheduling
+	if p, ok := nextLowerWindow(points, now, deadline, math.Nextafter(threshold, math.Inf(1))); ok {
+		return p.Timestamp
+	}
+	return deadline
+}
+
+// recordProjectedStart annotates a delayed pod with its projected start time and
+// emits an Event explaining the delay. Both are skipped if the projection hasn't
+// changed since the last attempt.
+func (cs *CarbonAwareScheduler) recordProjectedStart(pod *v1.Pod, start time.Time, status *framework.Status) {
+	value := start.UTC().Format(time.RFC3339)
+	if cs.dryRun || pod.Annotations[projectedStartAnnotation] == value {
+		return
+	}
+
+	cs.handle.EventRecorder().Eventf(pod, nil, v1.EventTypeNormal, "CarbonAwareDelay", "Scheduling",
+		"%s; will retry around %s", status.Message(), start.Local().Format("15:04 MST"))
+
+	// Patch asynchronously to keep API calls out of the scheduling cycle
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:%q}}}`, projectedStartAnnotation, value)
+	client := cs.handle.ClientSet()
+	cs.patches.Add(1)
+	go func() {
+		defer cs.patches.Done()
+		_, err := client.CoreV1().Pods(pod.Namespace).Patch(context.Background(), pod.Name,
+			types.MergePatchType, []byte(patch), metav1.PatchOptions{})
+		if err != nil {
+			klog.ErrorS(err, "Failed to annotate projected start time", "pod", klog.KObj(pod))
+		}
+	}()
+}
@@ -0,0 +1,62 @@
+package computegardener
+
+import (
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+
+	"sigs.k8s.io/scheduler-plugins/apis/computegardener/v1alpha1"
+	"sigs.k8s.io/scheduler-plugins/pkg/computegardener/config"
+)
+
+// workloadClassLabel names the WorkloadClass whose settings apply to a pod
+const workloadClassLabel = "carbon-aware-scheduler.kubernetes.io/workload-class"
+
+// workloadClass returns the WorkloadClass a pod references, if it exists
+func (cs *CarbonAwareScheduler) workloadClass(pod *v1.Pod) (*v1alpha1.WorkloadClass, bool) {
+	return cs.classes.Get(pod.Labels[workloadClassLabel])
+}
+
+// classThreshold returns the carbon intensity threshold set by the class of a pod
+func (cs *CarbonAwareScheduler) classThreshold(pod *v1.Pod) (float64, bool) {
+	class, ok := cs.workloadClass(pod)
+	if !ok || class.Spec.CarbonIntensityThreshold == nil {
+		return 0, false
+	}
+	return *class.Spec.CarbonIntensityThreshold, true
+}
+
+// classMaxDelay returns the maximum scheduling delay set by the class of a pod
+func (cs *CarbonAwareScheduler) classMaxDelay(pod *v1.Pod) (time.Duration, bool) {
+	class, ok := cs.workloadClass(pod)
+	if !ok || class.Spec.MaxSchedulingDelay == nil {
+		return 0, false
+	}
+	return class.Spec.MaxSchedulingDelay.Duration, true
+}
+
+// agingSettings returns the aging curve and maximum factor of a pod, from its class
+// or else the configuration
+func (cs *CarbonAwareScheduler) agingSettings(pod *v1.Pod) (string, float64) {
+	curve, maxFactor := cs.config.Scheduling.AgingCurve, cs.config.Scheduling.AgingMaxFactor
+	class, ok := cs.workloadClass(pod)
+	if !ok {
+		return curve, maxFactor
+	}
+	switch class.Spec.AgingCurve {
+	case v1alpha1.AgingCurveNone:
+		curve = config.AgingCurveNone
+	case v1alpha1.AgingCurveLinear, v1alpha1.AgingCurveQuadratic:
+		curve = class.Spec.AgingCurve
+	}
+	if class.Spec.AgingMaxFactor != nil {
+		maxFactor = *class.Spec.AgingMaxFactor
+	}
+	return curve, maxFactor
+}
+
+// classExempt reports whether the class of a pod exempts it from carbon-aware scheduling
+func (cs *CarbonAwareScheduler) classExempt(pod *v1.Pod) bool {
+	class, ok := cs.workloadClass(pod)
+	return ok && class.Spec.Exempt
+}
@@ -0,0 +1,102 @@
+package zones
+
+import (
+	v1 "k8s.io/api/core/v1"
+)
+
+// Zone identifies a grid zone in the naming scheme of each supported data provider
+type Zone struct {
+	ElectricityMaps string // Electricity Maps zone key, e.g. "US-CAL-CISO"
+	WattTime        string // WattTime region abbreviation, empty if unknown
+}
+
+// cloudRegions maps well-known cloud provider regions to the grid zone their
+// data centers draw from. Mappings are best-effort and can be overridden in config.
+var cloudRegions = map[string]Zone{
+	// AWS
+	"us-east-1":      {ElectricityMaps: "US-MIDA-PJM", WattTime: "PJM_DC"},
+	"us-east-2":      {ElectricityMaps: "US-MIDA-PJM"},
+	"us-west-1":      {ElectricityMaps: "US-CAL-CISO", WattTime: "CAISO_NORTH"},
+	"us-west-2":      {ElectricityMaps: "US-NW-BPAT", WattTime: "BPA"},
+	"ca-central-1":   {ElectricityMaps: "CA-QC"},
+	"sa-east-1":      {ElectricityMaps: "BR-CS"},
+	"eu-west-1":      {ElectricityMaps: "IE", WattTime: "IE"},
+	"eu-west-2":      {ElectricityMaps: "GB", WattTime: "UK"},
+	"eu-west-3":      {ElectricityMaps: "FR", WattTime: "FR"},
+	"eu-central-1":   {ElectricityMaps: "DE", WattTime: "DE"},
+	"eu-north-1":     {ElectricityMaps: "SE-SE3"},
+	"ap-south-1":     {ElectricityMaps: "IN-WE"},
+	"ap-northeast-1": {ElectricityMaps: "JP-TK"},
+	"ap-southeast-2": {ElectricityMaps: "AU-NSW"},
+
+	// GCP
+	"us-central1":             {ElectricityMaps: "US-MIDW-MISO"},
+	"us-east1":                {ElectricityMaps: "US-CAR-SCEG"},
+	"us-east4":                {ElectricityMaps: "US-MIDA-PJM", WattTime: "PJM_DC"},
+	"us-west1":                {ElectricityMaps: "US-NW-BPAT", WattTime: "BPA"},
+	"us-west2":                {ElectricityMaps: "US-CAL-LDWP"},
+	"northamerica-northeast1": {ElectricityMaps: "CA-QC"},
+	"europe-west1":            {ElectricityMaps: "BE"},
+	"europe-west2":            {ElectricityMaps: "GB", WattTime: "UK"},
+	"europe-west3":            {ElectricityMaps: "DE", WattTime: "DE"},
+	"europe-west4":            {ElectricityMaps: "NL", WattTime: "NL"},
+	"europe-north1":           {ElectricityMaps: "FI"},
+	"asia-northeast1":         {ElectricityMaps: "JP-TK"},
+	"australia-southeast1":    {ElectricityMaps: "AU-NSW"},
+
+	// Azure
+	"eastus":             {ElectricityMaps: "US-MIDA-PJM", WattTime: "PJM_DC"},
+	"eastus2":            {ElectricityMaps: "US-MIDA-PJM", WattTime: "PJM_DC"},
+	"centralus":          {ElectricityMaps: "US-MIDW-MISO"},
+	"southcentralus":     {ElectricityMaps: "US-TEX-ERCO"},
+	"westus":             {ElectricityMaps: "US-CAL-CISO", WattTime: "CAISO_NORTH"},
+	"westus2":            {ElectricityMaps: "US-NW-GCPD"},
+	"canadacentral":      {ElectricityMaps: "CA-ON", WattTime: "IESO_NORTH"},
+	"northeurope":        {ElectricityMaps: "IE", WattTime: "IE"},
+	"westeurope":         {ElectricityMaps: "NL", WattTime: "NL"},
+	"uksouth":            {ElectricityMaps: "GB", WattTime: "UK"},
+	"francecentral":      {ElectricityMaps: "FR", WattTime: "FR"},
+	"germanywestcentral": {ElectricityMaps: "DE", WattTime: "DE"},
+	"swedencentral":      {ElectricityMaps: "SE-SE3"},
+	"australiaeast":      {ElectricityMaps: "AU-NSW"},
+	"japaneast":          {ElectricityMaps: "JP-TK"},
+}
+
+// Lookup returns the built-in grid zone mapping for a cloud region
+func Lookup(region string) (Zone, bool) {
+	zone, ok := cloudRegions[region]
+	return zone, ok
+}
+
+// Mapper resolves cloud regions to Electricity Maps zones, applying
+// operator-supplied overrides before the built-in table
+type Mapper struct {
+	overrides map[string]string
+}
+
+// NewMapper creates a new Mapper with the given region-to-zone overrides
+func NewMapper(overrides map[string]string) *Mapper {
+	return &Mapper{overrides: overrides}
+}
+
+// ZoneForRegion returns the grid zone for a cloud region
+func (m *Mapper) ZoneForRegion(region string) (string, bool) {
+	if region == "" {
+		return "", false
+	}
+	if zone, ok := m.overrides[region]; ok {
+		return zone, true
+	}
+	if zone, ok := cloudRegions[region]; ok && zone.ElectricityMaps != "" {
+		return zone.ElectricityMaps, true
+	}
+	return "", false
+}
+
+// ZoneForNode returns the grid zone for a node based on its topology region label
+func (m *Mapper) ZoneForNode(node *v1.Node) (string, bool) {
+	if node == nil {
+		return "", false
+	}
+	return m.ZoneForRegion(node.Labels[v1.LabelTopologyRegion])
+}